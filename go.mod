@@ -22,4 +22,5 @@ require (
 	golang.org/x/tools v0.0.0-20200828161849-5deb26317202
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
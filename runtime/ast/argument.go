@@ -21,6 +21,8 @@ package ast
 import (
 	"encoding/json"
 	"strings"
+
+	"github.com/turbolent/prettier"
 )
 
 type Argument struct {
@@ -48,10 +50,25 @@ func (a *Argument) String() string {
 		builder.WriteString(a.Label)
 		builder.WriteString(": ")
 	}
-	builder.WriteString(a.Expression.String())
+	builder.WriteString(expressionStringOrPlaceholder(a.Expression))
 	return builder.String()
 }
 
+func (a *Argument) Doc() prettier.Doc {
+	return a.doc(docContext{})
+}
+
+func (a *Argument) doc(context docContext) prettier.Doc {
+	argumentDoc := expressionDoc(a.Expression, context)
+	if a.Label == "" {
+		return argumentDoc
+	}
+	return prettier.Concat{
+		prettier.Text(a.Label + ": "),
+		argumentDoc,
+	}
+}
+
 func (a *Argument) MarshalJSON() ([]byte, error) {
 	type Alias Argument
 	return json.Marshal(&struct {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/turbolent/prettier"
 )
 
 func TestArgument_MarshalJSON(t *testing.T) {
@@ -108,3 +109,89 @@ func TestArgument_MarshalJSON(t *testing.T) {
 		)
 	})
 }
+
+func TestArgument_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("without label", func(t *testing.T) {
+
+		t.Parallel()
+
+		argument := &Argument{
+			Expression: newTestIdentifierExpression("x"),
+		}
+
+		assert.Equal(t,
+			prettier.Text("x"),
+			argument.Doc(),
+		)
+	})
+
+	t.Run("with label", func(t *testing.T) {
+
+		t.Parallel()
+
+		argument := &Argument{
+			Label:      "label",
+			Expression: newTestIdentifierExpression("x"),
+		}
+
+		assert.Equal(t,
+			prettier.Concat{
+				prettier.Text("label: "),
+				prettier.Text("x"),
+			},
+			argument.Doc(),
+		)
+	})
+}
+
+func TestArguments_String(t *testing.T) {
+
+	t.Parallel()
+
+	arguments := Arguments{
+		{
+			Label:      "from",
+			Expression: newTestIdentifierExpression("a"),
+		},
+		{
+			Expression: newTestIdentifierExpression("b"),
+		},
+		{
+			Label: "to",
+			Expression: &BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression("c"),
+				Right:     newTestIdentifierExpression("d"),
+			},
+		},
+		{
+			Expression: newTestIdentifierExpression("e"),
+		},
+	}
+
+	assert.Equal(t,
+		"(from: a, b, to: c + d, e)",
+		arguments.String(),
+	)
+
+	// The string representation of the arguments must be consistent
+	// with the string representation of an invocation
+
+	invocation := &InvocationExpression{
+		InvokedExpression: newTestIdentifierExpression("f"),
+		Arguments:         arguments,
+	}
+
+	assert.Equal(t,
+		"f(from: a, b, to: c + d, e)",
+		invocation.String(),
+	)
+
+	assert.Equal(t,
+		"f(from: a, b, to: c + d, e)",
+		testDocString(invocation.Doc()),
+	)
+}
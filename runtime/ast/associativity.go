@@ -0,0 +1,46 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/json"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=Associativity
+
+// Associativity defines how operations of the same precedence are grouped
+// in the absence of parentheses
+type Associativity uint
+
+const (
+	// AssociativityNone is the associativity of operations which are not binary
+	AssociativityNone Associativity = iota
+	// AssociativityLeft groups operations from the left, i.e. `a - b - c` is `(a - b) - c`
+	AssociativityLeft
+	// AssociativityRight groups operations from the right, i.e. `a ?? b ?? c` is `a ?? (b ?? c)`
+	AssociativityRight
+)
+
+func AssociativityCount() int {
+	return len(_Associativity_index) - 1
+}
+
+func (a Associativity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
@@ -0,0 +1,138 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+)
+
+func cloneExpression(expression Expression) Expression {
+	if expression == nil {
+		return nil
+	}
+	return expression.Clone()
+}
+
+func cloneExpressions(expressions []Expression) []Expression {
+	if expressions == nil {
+		return nil
+	}
+	result := make([]Expression, len(expressions))
+	for i, expression := range expressions {
+		result[i] = cloneExpression(expression)
+	}
+	return result
+}
+
+func cloneBigInt(value *big.Int) *big.Int {
+	if value == nil {
+		return nil
+	}
+	return new(big.Int).Set(value)
+}
+
+func clonePosition(position *Position) *Position {
+	if position == nil {
+		return nil
+	}
+	result := *position
+	return &result
+}
+
+func cloneType(ty Type) Type {
+	if ty == nil {
+		return nil
+	}
+	return ty.Clone()
+}
+
+func cloneTypeAnnotation(typeAnnotation *TypeAnnotation) *TypeAnnotation {
+	if typeAnnotation == nil {
+		return nil
+	}
+	return &TypeAnnotation{
+		IsResource: typeAnnotation.IsResource,
+		Type:       cloneType(typeAnnotation.Type),
+		StartPos:   typeAnnotation.StartPos,
+	}
+}
+
+func cloneTypeAnnotations(typeAnnotations []*TypeAnnotation) []*TypeAnnotation {
+	if typeAnnotations == nil {
+		return nil
+	}
+	result := make([]*TypeAnnotation, len(typeAnnotations))
+	for i, typeAnnotation := range typeAnnotations {
+		result[i] = cloneTypeAnnotation(typeAnnotation)
+	}
+	return result
+}
+
+func (a *Argument) Clone() *Argument {
+	if a == nil {
+		return nil
+	}
+	return &Argument{
+		Label:                a.Label,
+		LabelStartPos:        clonePosition(a.LabelStartPos),
+		LabelEndPos:          clonePosition(a.LabelEndPos),
+		TrailingSeparatorPos: a.TrailingSeparatorPos,
+		Expression:           cloneExpression(a.Expression),
+	}
+}
+
+func (args Arguments) Clone() Arguments {
+	if args == nil {
+		return nil
+	}
+	result := make(Arguments, len(args))
+	for i, argument := range args {
+		result[i] = argument.Clone()
+	}
+	return result
+}
+
+func (p *Parameter) Clone() *Parameter {
+	if p == nil {
+		return nil
+	}
+	return &Parameter{
+		Label:          p.Label,
+		Identifier:     p.Identifier,
+		TypeAnnotation: cloneTypeAnnotation(p.TypeAnnotation),
+		Range:          p.Range,
+	}
+}
+
+func (l *ParameterList) Clone() *ParameterList {
+	if l == nil {
+		return nil
+	}
+	var parameters []*Parameter
+	if l.Parameters != nil {
+		parameters = make([]*Parameter, len(l.Parameters))
+		for i, parameter := range l.Parameters {
+			parameters[i] = parameter.Clone()
+		}
+	}
+	return &ParameterList{
+		Parameters: parameters,
+		Range:      l.Range,
+	}
+}
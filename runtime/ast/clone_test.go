@@ -0,0 +1,264 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpression_Clone(t *testing.T) {
+
+	t.Parallel()
+
+	newExpression := func() *InvocationExpression {
+		return &InvocationExpression{
+			InvokedExpression: &MemberExpression{
+				Expression: newTestIdentifierExpression("a"),
+				Identifier: Identifier{
+					Identifier: "b",
+				},
+			},
+			Arguments: []*Argument{
+				{
+					Label: "x",
+					Expression: &ArrayExpression{
+						Values: []Expression{
+							&IntegerExpression{
+								PositiveLiteral: "1",
+								Value:           big.NewInt(1),
+								Base:            10,
+							},
+							&FixedPointExpression{
+								PositiveLiteral: "1.5",
+								UnsignedInteger: big.NewInt(1),
+								Fractional:      big.NewInt(5),
+								Scale:           1,
+							},
+						},
+					},
+				},
+				{
+					Expression: &DictionaryExpression{
+						Entries: []DictionaryEntry{
+							{
+								Key:   &StringExpression{Value: "k"},
+								Value: newTestIdentifierExpression("v"),
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	original := newExpression()
+
+	clone, ok := original.Clone().(*InvocationExpression)
+	require.True(t, ok)
+
+	assert.Equal(t, original, clone)
+	assert.True(t, EqualExpressionsLiterally(original, clone))
+
+	// Mutate the clone
+
+	clone.InvokedExpression.(*MemberExpression).Identifier.Identifier = "c"
+	clone.Arguments[0].Label = "y"
+
+	array := clone.Arguments[0].Expression.(*ArrayExpression)
+	array.Values[0].(*IntegerExpression).Value.SetInt64(2)
+	array.Values[1].(*FixedPointExpression).Fractional.SetInt64(7)
+	array.Values = append(array.Values, &NilExpression{})
+
+	dictionary := clone.Arguments[1].Expression.(*DictionaryExpression)
+	dictionary.Entries[0].Key = &StringExpression{Value: "other"}
+
+	clone.Arguments = append(clone.Arguments, &Argument{
+		Expression: &BoolExpression{Value: true},
+	})
+
+	// The original must be untouched
+
+	assert.Equal(t, newExpression(), original)
+}
+
+func TestExpression_Clone_Nested(t *testing.T) {
+
+	t.Parallel()
+
+	original := &ConditionalExpression{
+		Test: &UnaryExpression{
+			Operation:  OperationNegate,
+			Expression: newTestIdentifierExpression("a"),
+		},
+		Then: &CastingExpression{
+			Operation:  OperationForceCast,
+			Expression: newTestIdentifierExpression("b"),
+			TypeAnnotation: &TypeAnnotation{
+				IsResource: true,
+				Type: &NominalType{
+					Identifier: Identifier{
+						Identifier: "R",
+					},
+				},
+			},
+		},
+		Else: &IndexExpression{
+			TargetExpression: &ForceExpression{
+				Expression: newTestIdentifierExpression("c"),
+			},
+			IndexingExpression: &BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression("d"),
+				Right:     newTestIdentifierExpression("e"),
+			},
+		},
+	}
+
+	clone := original.Clone().(*ConditionalExpression)
+	require.Equal(t, original, clone)
+
+	clone.Test.(*UnaryExpression).Operation = OperationMinus
+	clone.Then.(*CastingExpression).TypeAnnotation.IsResource = false
+	clone.Else.(*IndexExpression).IndexingExpression.(*BinaryExpression).Left = newTestIdentifierExpression("f")
+
+	assert.Equal(t, OperationNegate, original.Test.(*UnaryExpression).Operation)
+	assert.True(t, original.Then.(*CastingExpression).TypeAnnotation.IsResource)
+	assert.Equal(t,
+		"d",
+		original.Else.(*IndexExpression).IndexingExpression.(*BinaryExpression).Left.String(),
+	)
+}
+
+func TestType_Clone(t *testing.T) {
+
+	t.Parallel()
+
+	newNominalType := func(identifier string, nestedIdentifiers ...string) *NominalType {
+		nominalType := &NominalType{
+			Identifier: Identifier{
+				Identifier: identifier,
+			},
+		}
+		for _, nestedIdentifier := range nestedIdentifiers {
+			nominalType.NestedIdentifiers = append(
+				nominalType.NestedIdentifiers,
+				Identifier{
+					Identifier: nestedIdentifier,
+				},
+			)
+		}
+		return nominalType
+	}
+
+	original := &FunctionType{
+		ParameterTypeAnnotations: []*TypeAnnotation{
+			{
+				IsResource: true,
+				Type: &RestrictedType{
+					Type: newNominalType("R"),
+					Restrictions: []*NominalType{
+						newNominalType("I", "J"),
+					},
+				},
+			},
+			{
+				Type: &ConstantSizedType{
+					Type: &OptionalType{
+						Type: newNominalType("Int"),
+					},
+					Size: &IntegerExpression{
+						PositiveLiteral: "2",
+						Value:           big.NewInt(2),
+						Base:            10,
+					},
+				},
+			},
+		},
+		ReturnTypeAnnotation: &TypeAnnotation{
+			Type: &InstantiationType{
+				Type: newNominalType("Capability"),
+				TypeArguments: []*TypeAnnotation{
+					{
+						Type: &ReferenceType{
+							Authorized: true,
+							Type: &DictionaryType{
+								KeyType: newNominalType("String"),
+								ValueType: &VariableSizedType{
+									Type: newNominalType("Int"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	clone := original.Clone().(*FunctionType)
+	require.Equal(t, original, clone)
+
+	restrictedType := clone.ParameterTypeAnnotations[0].Type.(*RestrictedType)
+	restrictedType.Restrictions[0].NestedIdentifiers[0].Identifier = "K"
+	restrictedType.Restrictions = append(restrictedType.Restrictions, newNominalType("L"))
+
+	constantSizedType := clone.ParameterTypeAnnotations[1].Type.(*ConstantSizedType)
+	constantSizedType.Size.Value.SetInt64(3)
+	constantSizedType.Type.(*OptionalType).Type.(*NominalType).Identifier.Identifier = "UInt"
+
+	referenceType := clone.ReturnTypeAnnotation.Type.(*InstantiationType).TypeArguments[0].Type.(*ReferenceType)
+	referenceType.Authorized = false
+	referenceType.Type.(*DictionaryType).ValueType.(*VariableSizedType).Type = newNominalType("Bool")
+
+	assert.Equal(t,
+		"((@R{I.J}, [Int?; 2]): Capability<auth &{String: [Int]}>)",
+		original.String(),
+	)
+	assert.Equal(t,
+		"((@R{I.K, L}, [UInt?; 2]): Capability<&{String: [Bool]}>)",
+		clone.String(),
+	)
+	assert.Equal(t, int64(2), original.ParameterTypeAnnotations[1].Type.(*ConstantSizedType).Size.Value.Int64())
+}
+
+func TestExpression_Clone_Types(t *testing.T) {
+
+	t.Parallel()
+
+	original := &ReferenceExpression{
+		Expression: newTestIdentifierExpression("x"),
+		Type: &ReferenceType{
+			Type: &NominalType{
+				Identifier: Identifier{
+					Identifier: "R",
+				},
+			},
+		},
+	}
+
+	clone := original.Clone().(*ReferenceExpression)
+	require.Equal(t, original, clone)
+
+	clone.Type.(*ReferenceType).Authorized = true
+
+	assert.False(t, original.Type.(*ReferenceType).Authorized)
+}
@@ -0,0 +1,178 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/turbolent/prettier"
+)
+
+// Comment is a line comment, e.g. `// ...`, or a block comment, e.g. `/* ... */`.
+//
+// Comments are not part of the AST produced by the parser,
+// but can be represented as elements, e.g. in a flat list of comments,
+// or attached to elements in a TriviaMap.
+type Comment struct {
+	// Text is the content of the comment, without the delimiters
+	Text  string
+	Block bool
+	Range
+}
+
+var _ Element = &Comment{}
+
+func (*Comment) Accept(Visitor) Repr {
+	// NO-OP
+	return nil
+}
+
+func (*Comment) Walk(_ func(Element)) {
+	// NO-OP
+}
+
+// String returns the source code of the comment.
+//
+// The text of a line comment cannot contain line breaks,
+// so a line comment with a multi-line text is written as multiple line comments.
+//
+// Block comments may be nested, so the text of a block comment may contain
+// balanced block comment delimiters. Unbalanced delimiters in the text
+// would end the comment early, or not at all, so they are balanced,
+// see safeBlockCommentText.
+func (c *Comment) String() string {
+	if c.Block {
+		return blockCommentStart + safeBlockCommentText(c.Text) + blockCommentEnd
+	}
+	return strings.Join(c.lines(), "\n")
+}
+
+const lineCommentStart = "//"
+const blockCommentStart = "/*"
+const blockCommentEnd = "*/"
+
+// lines returns the line comments for the text of a line comment.
+func (c *Comment) lines() []string {
+	lines := strings.Split(normalizeLineBreaks(c.Text), "\n")
+	for i, line := range lines {
+		lines[i] = lineCommentStart + line
+	}
+	return lines
+}
+
+func normalizeLineBreaks(text string) string {
+	if !strings.ContainsRune(text, '\r') {
+		return text
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// safeBlockCommentText returns the given text of a block comment,
+// balanced so that it can be enclosed in block comment delimiters:
+// For each unbalanced closing delimiter an opening delimiter is prepended,
+// and for each unbalanced opening delimiter a closing delimiter is appended.
+//
+// Delimiters in the text cannot just be separated, e.g. `*/` to `* /`,
+// as the separated characters might form new delimiters with the surrounding characters.
+func safeBlockCommentText(text string) string {
+
+	// Count the unbalanced delimiters.
+	// Scan the text like the lexer does: delimiters do not overlap
+
+	unbalancedEnds := 0
+	unbalancedStarts := 0
+
+	for i := 0; i < len(text)-1; {
+		switch text[i : i+2] {
+		case blockCommentStart:
+			unbalancedStarts++
+			i += 2
+
+		case blockCommentEnd:
+			if unbalancedStarts > 0 {
+				unbalancedStarts--
+			} else {
+				unbalancedEnds++
+			}
+			i += 2
+
+		default:
+			i++
+		}
+	}
+
+	// A trailing slash would form an opening delimiter with a following closing delimiter
+	trailingSlash := strings.HasSuffix(text, "/")
+
+	if unbalancedEnds == 0 && unbalancedStarts == 0 && !trailingSlash {
+		return text
+	}
+
+	var builder strings.Builder
+
+	for i := 0; i < unbalancedEnds; i++ {
+		builder.WriteString(blockCommentStart)
+	}
+
+	builder.WriteString(text)
+
+	if trailingSlash {
+		builder.WriteByte(' ')
+	}
+
+	for i := 0; i < unbalancedStarts; i++ {
+		builder.WriteString(blockCommentEnd)
+	}
+
+	return builder.String()
+}
+
+// Doc returns the document of the comment, see String.
+//
+// NOTE: The text of a multi-line block comment is emitted verbatim,
+// i.e. it is not re-indented.
+func (c *Comment) Doc() prettier.Doc {
+	if c.Block {
+		return prettier.Text(c.String())
+	}
+
+	lines := c.lines()
+	if len(lines) == 1 {
+		return prettier.Text(lines[0])
+	}
+
+	lineDocs := make([]prettier.Doc, len(lines))
+	for i, line := range lines {
+		lineDocs[i] = prettier.Text(line)
+	}
+	return prettier.Join(prettier.HardLine{}, lineDocs...)
+}
+
+func (c *Comment) MarshalJSON() ([]byte, error) {
+	type Alias Comment
+	return json.Marshal(&struct {
+		Type string
+		*Alias
+	}{
+		Type:  "Comment",
+		Alias: (*Alias)(c),
+	})
+}
@@ -0,0 +1,204 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/turbolent/prettier"
+)
+
+func TestComment_String(t *testing.T) {
+
+	t.Parallel()
+
+	type testCase struct {
+		comment  *Comment
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"line": {
+			comment:  &Comment{Text: " test"},
+			expected: "// test",
+		},
+		"line, empty": {
+			comment:  &Comment{},
+			expected: "//",
+		},
+		"line, multiple lines": {
+			comment:  &Comment{Text: " first\n second\r\n third"},
+			expected: "// first\n// second\n// third",
+		},
+		"block": {
+			comment:  &Comment{Text: " test ", Block: true},
+			expected: "/* test */",
+		},
+		"block, empty": {
+			comment:  &Comment{Block: true},
+			expected: "/**/",
+		},
+		"block, multiple lines": {
+			comment: &Comment{
+				Text:  "\n * first\n * second\n ",
+				Block: true,
+			},
+			expected: "/*\n * first\n * second\n */",
+		},
+		"block, nested": {
+			comment:  &Comment{Text: " a /* b */ c ", Block: true},
+			expected: "/* a /* b */ c */",
+		},
+		"block, unbalanced end": {
+			comment:  &Comment{Text: " a */ b ", Block: true},
+			expected: "/*/* a */ b */",
+		},
+		"block, unbalanced start": {
+			comment:  &Comment{Text: " a /* b ", Block: true},
+			expected: "/* a /* b */*/",
+		},
+		"block, unbalanced end and start": {
+			comment:  &Comment{Text: "*/ /*", Block: true},
+			expected: "/*/**/ /**/*/",
+		},
+		"block, overlapping": {
+			comment:  &Comment{Text: "/*/", Block: true},
+			expected: "/*/*/ */*/",
+		},
+		"block, trailing slash": {
+			comment:  &Comment{Text: " a /", Block: true},
+			expected: "/* a / */",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t, testCase.expected, testCase.comment.String())
+		})
+	}
+}
+
+func TestComment_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("line", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			prettier.Text("// test"),
+			(&Comment{Text: " test"}).Doc(),
+		)
+	})
+
+	t.Run("line, multiple lines", func(t *testing.T) {
+
+		t.Parallel()
+
+		comment := &Comment{Text: " first\n second"}
+
+		assert.Equal(t,
+			prettier.Concat{
+				prettier.Text("// first"),
+				prettier.HardLine{},
+				prettier.Text("// second"),
+			},
+			comment.Doc(),
+		)
+	})
+
+	t.Run("block", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			prettier.Text("/* test */"),
+			(&Comment{Text: " test ", Block: true}).Doc(),
+		)
+	})
+
+	t.Run("block, multiple lines", func(t *testing.T) {
+
+		t.Parallel()
+
+		comment := &Comment{
+			Text:  "\n    first\n    second\n",
+			Block: true,
+		}
+
+		assert.Equal(t,
+			"/*\n    first\n    second\n*/",
+			testDocString(comment.Doc()),
+		)
+	})
+}
+
+func TestComment_MarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	comment := &Comment{
+		Text:  " test ",
+		Block: true,
+		Range: Range{
+			StartPos: Position{Offset: 1, Line: 2, Column: 3},
+			EndPos:   Position{Offset: 4, Line: 5, Column: 6},
+		},
+	}
+
+	actual, err := json.Marshal(comment)
+	require.NoError(t, err)
+
+	assert.JSONEq(t,
+		`
+        {
+            "Type": "Comment",
+            "Text": " test ",
+            "Block": true,
+            "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+            "EndPos": {"Offset": 4, "Line": 5, "Column": 6}
+        }
+        `,
+		string(actual),
+	)
+}
+
+func TestComment_Walk(t *testing.T) {
+
+	t.Parallel()
+
+	comment := &Comment{Text: " test"}
+
+	var children []Element
+	comment.Walk(func(element Element) {
+		children = append(children, element)
+	})
+
+	assert.Empty(t, children)
+	assert.Nil(t, comment.Accept(nil))
+}
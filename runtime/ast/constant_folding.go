@@ -0,0 +1,172 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+)
+
+// FoldConstants returns the given expression with all unary and binary expressions
+// whose operands are constant replaced by a literal of their result.
+//
+// Arithmetic operations (`+`, `-`, `*`, `/`, `%`) and negation are folded
+// if all operands are integer literals, and the logical operations (`&&`, `||`, `!`)
+// are folded if all operands are boolean literals.
+// Division and remainder by zero are not folded.
+//
+// The folded literals have the range of the expression they replace.
+// Like MapExpression, the given expression is not mutated.
+func FoldConstants(expression Expression) Expression {
+	return MapExpression(expression, foldConstantExpression)
+}
+
+func foldConstantExpression(expression Expression) Expression {
+	switch expression := expression.(type) {
+	case *UnaryExpression:
+		folded := foldConstantUnaryExpression(expression)
+		if folded == nil {
+			return expression
+		}
+		return folded
+
+	case *BinaryExpression:
+		folded := foldConstantBinaryExpression(expression)
+		if folded == nil {
+			return expression
+		}
+		return folded
+
+	default:
+		return expression
+	}
+}
+
+// foldConstantUnaryExpression returns the literal of the result of the given unary expression,
+// or nil if the expression cannot be folded.
+func foldConstantUnaryExpression(expression *UnaryExpression) Expression {
+	switch operand := expression.Expression.(type) {
+	case *IntegerExpression:
+		if operand.Value == nil || expression.Operation != OperationMinus {
+			return nil
+		}
+
+		value := new(big.Int).Neg(operand.Value)
+		return newFoldedIntegerExpression(value, expression)
+
+	case *BoolExpression:
+		if expression.Operation != OperationNegate {
+			return nil
+		}
+
+		return newFoldedBoolExpression(!operand.Value, expression)
+
+	default:
+		return nil
+	}
+}
+
+// foldConstantBinaryExpression returns the literal of the result of the given binary expression,
+// or nil if the expression cannot be folded.
+func foldConstantBinaryExpression(expression *BinaryExpression) Expression {
+	switch left := expression.Left.(type) {
+	case *IntegerExpression:
+		right, ok := expression.Right.(*IntegerExpression)
+		if !ok || left.Value == nil || right.Value == nil {
+			return nil
+		}
+
+		value := foldIntegerOperation(expression.Operation, left.Value, right.Value)
+		if value == nil {
+			return nil
+		}
+
+		return newFoldedIntegerExpression(value, expression)
+
+	case *BoolExpression:
+		right, ok := expression.Right.(*BoolExpression)
+		if !ok {
+			return nil
+		}
+
+		var value bool
+		switch expression.Operation {
+		case OperationAnd:
+			value = left.Value && right.Value
+		case OperationOr:
+			value = left.Value || right.Value
+		default:
+			return nil
+		}
+
+		return newFoldedBoolExpression(value, expression)
+
+	default:
+		return nil
+	}
+}
+
+// foldIntegerOperation returns the result of the given arithmetic operation,
+// or nil if the operation is not an arithmetic operation, or if it divides by zero.
+//
+// Like integer division and remainder in Cadence,
+// the quotient is truncated towards zero,
+// and the remainder has the sign of the dividend.
+func foldIntegerOperation(operation Operation, left, right *big.Int) *big.Int {
+	switch operation {
+	case OperationPlus:
+		return new(big.Int).Add(left, right)
+
+	case OperationMinus:
+		return new(big.Int).Sub(left, right)
+
+	case OperationMul:
+		return new(big.Int).Mul(left, right)
+
+	case OperationDiv:
+		if right.Sign() == 0 {
+			return nil
+		}
+		return new(big.Int).Quo(left, right)
+
+	case OperationMod:
+		if right.Sign() == 0 {
+			return nil
+		}
+		return new(big.Int).Rem(left, right)
+
+	default:
+		return nil
+	}
+}
+
+func newFoldedIntegerExpression(value *big.Int, original Expression) *IntegerExpression {
+	return &IntegerExpression{
+		PositiveLiteral: new(big.Int).Abs(value).String(),
+		Value:           value,
+		Base:            10,
+		Range:           NewRangeFromPositioned(original),
+	}
+}
+
+func newFoldedBoolExpression(value bool, original Expression) *BoolExpression {
+	return &BoolExpression{
+		Value: value,
+		Range: NewRangeFromPositioned(original),
+	}
+}
@@ -0,0 +1,222 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldConstants(t *testing.T) {
+
+	t.Parallel()
+
+	newInteger := func(value int64, offset int) *IntegerExpression {
+		return NewIntegerExpression(
+			big.NewInt(value),
+			Position{Offset: offset, Line: 1, Column: offset},
+		)
+	}
+
+	newBool := func(value bool, offset int) *BoolExpression {
+		length := len("false")
+		if value {
+			length = len("true")
+		}
+		return &BoolExpression{
+			Value: value,
+			Range: Range{
+				StartPos: Position{Offset: offset, Line: 1, Column: offset},
+				EndPos:   Position{Offset: offset + length - 1, Line: 1, Column: offset + length - 1},
+			},
+		}
+	}
+
+	t.Run("arithmetic", func(t *testing.T) {
+
+		t.Parallel()
+
+		// 2 * 3 + 4
+
+		expression := &BinaryExpression{
+			Operation: OperationPlus,
+			Left: &BinaryExpression{
+				Operation: OperationMul,
+				Left:      newInteger(2, 0),
+				Right:     newInteger(3, 4),
+			},
+			Right: newInteger(4, 8),
+		}
+
+		assert.Equal(t,
+			&IntegerExpression{
+				PositiveLiteral: "10",
+				Value:           big.NewInt(10),
+				Base:            10,
+				Range: Range{
+					StartPos: Position{Offset: 0, Line: 1, Column: 0},
+					EndPos:   Position{Offset: 8, Line: 1, Column: 8},
+				},
+			},
+			FoldConstants(expression),
+		)
+
+		// The original expression is not mutated
+
+		assert.Equal(t, "((2 * 3) + 4)", expression.CanonicalString())
+	})
+
+	t.Run("negation", func(t *testing.T) {
+
+		t.Parallel()
+
+		// -(7 % -4)
+
+		expression := &UnaryExpression{
+			Operation: OperationMinus,
+			Expression: &BinaryExpression{
+				Operation: OperationMod,
+				Left:      newInteger(7, 2),
+				Right:     newInteger(-4, 6),
+			},
+			StartPos: Position{Offset: 0, Line: 1, Column: 0},
+		}
+
+		folded := FoldConstants(expression)
+
+		assert.Equal(t, "-3", folded.String())
+		assert.Equal(t,
+			NewRangeFromPositioned(expression),
+			NewRangeFromPositioned(folded),
+		)
+	})
+
+	t.Run("division by zero", func(t *testing.T) {
+
+		t.Parallel()
+
+		// 1 / 0
+
+		expression := &BinaryExpression{
+			Operation: OperationDiv,
+			Left:      newInteger(1, 0),
+			Right:     newInteger(0, 4),
+		}
+
+		assert.Same(t, expression, FoldConstants(expression))
+	})
+
+	t.Run("remainder by zero", func(t *testing.T) {
+
+		t.Parallel()
+
+		// 1 + (2 % (1 - 1))
+
+		expression := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      newInteger(1, 0),
+			Right: &BinaryExpression{
+				Operation: OperationMod,
+				Left:      newInteger(2, 5),
+				Right: &BinaryExpression{
+					Operation: OperationMinus,
+					Left:      newInteger(1, 10),
+					Right:     newInteger(1, 14),
+				},
+			},
+		}
+
+		assert.Equal(t, "(1 + (2 % 0))", FoldConstants(expression).CanonicalString())
+	})
+
+	t.Run("boolean", func(t *testing.T) {
+
+		t.Parallel()
+
+		// !(true && false) || false
+
+		expression := &BinaryExpression{
+			Operation: OperationOr,
+			Left: &UnaryExpression{
+				Operation: OperationNegate,
+				Expression: &BinaryExpression{
+					Operation: OperationAnd,
+					Left:      newBool(true, 2),
+					Right:     newBool(false, 10),
+				},
+				StartPos: Position{Offset: 0, Line: 1, Column: 0},
+			},
+			Right: newBool(false, 20),
+		}
+
+		assert.Equal(t,
+			&BoolExpression{
+				Value: true,
+				Range: Range{
+					StartPos: Position{Offset: 0, Line: 1, Column: 0},
+					EndPos:   Position{Offset: 24, Line: 1, Column: 24},
+				},
+			},
+			FoldConstants(expression),
+		)
+	})
+
+	t.Run("non-constant operand", func(t *testing.T) {
+
+		t.Parallel()
+
+		// x * (2 + 3)
+
+		expression := &BinaryExpression{
+			Operation: OperationMul,
+			Left:      newTestIdentifierExpression("x"),
+			Right: &BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newInteger(2, 5),
+				Right:     newInteger(3, 9),
+			},
+		}
+
+		assert.Equal(t, "(x * 5)", FoldConstants(expression).CanonicalString())
+	})
+
+	t.Run("mixed operands", func(t *testing.T) {
+
+		t.Parallel()
+
+		// 1 + true, 1 == 1
+
+		for _, expression := range []*BinaryExpression{
+			{
+				Operation: OperationPlus,
+				Left:      newInteger(1, 0),
+				Right:     newBool(true, 4),
+			},
+			{
+				Operation: OperationEqual,
+				Left:      newInteger(1, 0),
+				Right:     newInteger(1, 5),
+			},
+		} {
+			assert.Same(t, expression, FoldConstants(expression))
+		}
+	})
+}
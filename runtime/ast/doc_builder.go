@@ -0,0 +1,302 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"github.com/turbolent/prettier"
+)
+
+// DocBuilder builds the documents of expressions, i.e. it decides how expressions are pretty-printed.
+// It has one method per kind of expression.
+//
+// The documents of expressions are built by BuildDoc and Format by dispatching to the builder,
+// including the documents of all subexpressions, so a builder may customize the formatting
+// of some kinds of expressions only, e.g. of invocations, by embedding DefaultDocBuilder
+// and only overriding the methods for those.
+//
+// The documents of subexpressions should be built using DocBuilderContext.ExpressionDoc,
+// so they are also built by the builder.
+type DocBuilder interface {
+	VisitBoolExpression(context DocBuilderContext, expression *BoolExpression) prettier.Doc
+	VisitNilExpression(context DocBuilderContext, expression *NilExpression) prettier.Doc
+	VisitVoidExpression(context DocBuilderContext, expression *VoidExpression) prettier.Doc
+	VisitIntegerExpression(context DocBuilderContext, expression *IntegerExpression) prettier.Doc
+	VisitFixedPointExpression(context DocBuilderContext, expression *FixedPointExpression) prettier.Doc
+	VisitArrayExpression(context DocBuilderContext, expression *ArrayExpression) prettier.Doc
+	VisitDictionaryExpression(context DocBuilderContext, expression *DictionaryExpression) prettier.Doc
+	VisitIdentifierExpression(context DocBuilderContext, expression *IdentifierExpression) prettier.Doc
+	VisitInvocationExpression(context DocBuilderContext, expression *InvocationExpression) prettier.Doc
+	VisitMemberExpression(context DocBuilderContext, expression *MemberExpression) prettier.Doc
+	VisitIndexExpression(context DocBuilderContext, expression *IndexExpression) prettier.Doc
+	VisitConditionalExpression(context DocBuilderContext, expression *ConditionalExpression) prettier.Doc
+	VisitUnaryExpression(context DocBuilderContext, expression *UnaryExpression) prettier.Doc
+	VisitBinaryExpression(context DocBuilderContext, expression *BinaryExpression) prettier.Doc
+	VisitFunctionExpression(context DocBuilderContext, expression *FunctionExpression) prettier.Doc
+	VisitStringExpression(context DocBuilderContext, expression *StringExpression) prettier.Doc
+	VisitStringTemplateExpression(context DocBuilderContext, expression *StringTemplateExpression) prettier.Doc
+	VisitCastingExpression(context DocBuilderContext, expression *CastingExpression) prettier.Doc
+	VisitCreateExpression(context DocBuilderContext, expression *CreateExpression) prettier.Doc
+	VisitDestroyExpression(context DocBuilderContext, expression *DestroyExpression) prettier.Doc
+	VisitAttachmentExpression(context DocBuilderContext, expression *AttachmentExpression) prettier.Doc
+	VisitReferenceExpression(context DocBuilderContext, expression *ReferenceExpression) prettier.Doc
+	VisitForceExpression(context DocBuilderContext, expression *ForceExpression) prettier.Doc
+	VisitPathExpression(context DocBuilderContext, expression *PathExpression) prettier.Doc
+}
+
+// DocBuilderContext is passed to the methods of a DocBuilder.
+// It carries the builder and the formatting options.
+type DocBuilderContext struct {
+	context docContext
+}
+
+// ExpressionDoc returns the document for the given subexpression,
+// including the comments attached to it, built by the builder.
+func (c DocBuilderContext) ExpressionDoc(expression Expression) prettier.Doc {
+	return expressionDoc(expression, c.context)
+}
+
+// SubexpressionDoc returns the document for the given subexpression, built by the builder,
+// and parenthesized if the subexpression binds weaker than the given precedence,
+// one of the Precedence* levels.
+func (c DocBuilderContext) SubexpressionDoc(expression Expression, precedence int) prettier.Doc {
+	return subexpressionDoc(expression, precedence, c.context)
+}
+
+// BuildDoc returns the document for the given expression, built by the given builder.
+func BuildDoc(builder DocBuilder, expression Expression) prettier.Doc {
+	return expressionDoc(expression, docContext{builder: builder})
+}
+
+// buildExpressionDoc returns the document for the given expression in the given context,
+// built by the given builder
+func buildExpressionDoc(builder DocBuilder, expression Expression, context docContext) prettier.Doc {
+	return expression.AcceptExp(docBuilderVisitor{
+		builder: builder,
+		context: DocBuilderContext{context: context},
+	}).(prettier.Doc)
+}
+
+// docBuilderVisitor dispatches the expressions it visits to the methods of a DocBuilder
+type docBuilderVisitor struct {
+	builder DocBuilder
+	context DocBuilderContext
+}
+
+var _ ExpressionVisitor = docBuilderVisitor{}
+
+func (v docBuilderVisitor) VisitBoolExpression(expression *BoolExpression) Repr {
+	return v.builder.VisitBoolExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitNilExpression(expression *NilExpression) Repr {
+	return v.builder.VisitNilExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitVoidExpression(expression *VoidExpression) Repr {
+	return v.builder.VisitVoidExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitIntegerExpression(expression *IntegerExpression) Repr {
+	return v.builder.VisitIntegerExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitFixedPointExpression(expression *FixedPointExpression) Repr {
+	return v.builder.VisitFixedPointExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitArrayExpression(expression *ArrayExpression) Repr {
+	return v.builder.VisitArrayExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitDictionaryExpression(expression *DictionaryExpression) Repr {
+	return v.builder.VisitDictionaryExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitIdentifierExpression(expression *IdentifierExpression) Repr {
+	return v.builder.VisitIdentifierExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitInvocationExpression(expression *InvocationExpression) Repr {
+	return v.builder.VisitInvocationExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitMemberExpression(expression *MemberExpression) Repr {
+	return v.builder.VisitMemberExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitIndexExpression(expression *IndexExpression) Repr {
+	return v.builder.VisitIndexExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitConditionalExpression(expression *ConditionalExpression) Repr {
+	return v.builder.VisitConditionalExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitUnaryExpression(expression *UnaryExpression) Repr {
+	return v.builder.VisitUnaryExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitBinaryExpression(expression *BinaryExpression) Repr {
+	return v.builder.VisitBinaryExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitFunctionExpression(expression *FunctionExpression) Repr {
+	return v.builder.VisitFunctionExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitStringExpression(expression *StringExpression) Repr {
+	return v.builder.VisitStringExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitStringTemplateExpression(expression *StringTemplateExpression) Repr {
+	return v.builder.VisitStringTemplateExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitCastingExpression(expression *CastingExpression) Repr {
+	return v.builder.VisitCastingExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitCreateExpression(expression *CreateExpression) Repr {
+	return v.builder.VisitCreateExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitDestroyExpression(expression *DestroyExpression) Repr {
+	return v.builder.VisitDestroyExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitAttachmentExpression(expression *AttachmentExpression) Repr {
+	return v.builder.VisitAttachmentExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitReferenceExpression(expression *ReferenceExpression) Repr {
+	return v.builder.VisitReferenceExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitForceExpression(expression *ForceExpression) Repr {
+	return v.builder.VisitForceExpression(v.context, expression)
+}
+
+func (v docBuilderVisitor) VisitPathExpression(expression *PathExpression) Repr {
+	return v.builder.VisitPathExpression(v.context, expression)
+}
+
+// DefaultDocBuilder is the DocBuilder which builds the documents of expressions
+// like their Doc methods do, but builds the documents of subexpressions
+// using the builder of the context.
+//
+// It can be embedded into a builder which only customizes the formatting of some kinds of expressions.
+type DefaultDocBuilder struct{}
+
+var _ DocBuilder = DefaultDocBuilder{}
+
+func (DefaultDocBuilder) VisitBoolExpression(context DocBuilderContext, expression *BoolExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitNilExpression(context DocBuilderContext, expression *NilExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitVoidExpression(context DocBuilderContext, expression *VoidExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitIntegerExpression(context DocBuilderContext, expression *IntegerExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitFixedPointExpression(context DocBuilderContext, expression *FixedPointExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitArrayExpression(context DocBuilderContext, expression *ArrayExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitDictionaryExpression(context DocBuilderContext, expression *DictionaryExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitIdentifierExpression(context DocBuilderContext, expression *IdentifierExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitInvocationExpression(context DocBuilderContext, expression *InvocationExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitMemberExpression(context DocBuilderContext, expression *MemberExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitIndexExpression(context DocBuilderContext, expression *IndexExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitConditionalExpression(context DocBuilderContext, expression *ConditionalExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitUnaryExpression(context DocBuilderContext, expression *UnaryExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitBinaryExpression(context DocBuilderContext, expression *BinaryExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitFunctionExpression(context DocBuilderContext, expression *FunctionExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitStringExpression(context DocBuilderContext, expression *StringExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitStringTemplateExpression(context DocBuilderContext, expression *StringTemplateExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitCastingExpression(context DocBuilderContext, expression *CastingExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitCreateExpression(context DocBuilderContext, expression *CreateExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitDestroyExpression(context DocBuilderContext, expression *DestroyExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitAttachmentExpression(context DocBuilderContext, expression *AttachmentExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitReferenceExpression(context DocBuilderContext, expression *ReferenceExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitForceExpression(context DocBuilderContext, expression *ForceExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
+
+func (DefaultDocBuilder) VisitPathExpression(context DocBuilderContext, expression *PathExpression) prettier.Doc {
+	return builtinExpressionDoc(expression, context.context)
+}
@@ -0,0 +1,234 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbolent/prettier"
+)
+
+// compactArrayDocBuilder is a DocBuilder which formats array literals
+// without spaces after the separators, and never breaks them
+type compactArrayDocBuilder struct {
+	DefaultDocBuilder
+}
+
+func (compactArrayDocBuilder) VisitArrayExpression(
+	context DocBuilderContext,
+	expression *ArrayExpression,
+) prettier.Doc {
+	doc := prettier.Concat{
+		prettier.Text("["),
+	}
+	for i, value := range expression.Values {
+		if i > 0 {
+			doc = append(doc, prettier.Text(","))
+		}
+		doc = append(doc, context.ExpressionDoc(value))
+	}
+	doc = append(doc, prettier.Text("]"))
+	return doc
+}
+
+// countingInvocationDocBuilder is a DocBuilder which counts
+// the invocations it builds documents for
+type countingInvocationDocBuilder struct {
+	DefaultDocBuilder
+	invocations *int
+}
+
+func (b countingInvocationDocBuilder) VisitInvocationExpression(
+	context DocBuilderContext,
+	expression *InvocationExpression,
+) prettier.Doc {
+	*b.invocations++
+	return b.DefaultDocBuilder.VisitInvocationExpression(context, expression)
+}
+
+func TestDocBuilder(t *testing.T) {
+
+	t.Parallel()
+
+	newInteger := func(value int64) *IntegerExpression {
+		return NewIntegerExpression(big.NewInt(value), Position{})
+	}
+
+	// [f(values: [1, 2 + 3]), -[4]]
+
+	expr := &ArrayExpression{
+		Values: []Expression{
+			&InvocationExpression{
+				InvokedExpression: newTestIdentifierExpression("f"),
+				Arguments: Arguments{
+					{
+						Label: "values",
+						Expression: &ArrayExpression{
+							Values: []Expression{
+								newInteger(1),
+								&BinaryExpression{
+									Operation: OperationPlus,
+									Left:      newInteger(2),
+									Right:     newInteger(3),
+								},
+							},
+						},
+					},
+				},
+			},
+			&UnaryExpression{
+				Operation: OperationMinus,
+				Expression: &ArrayExpression{
+					Values: []Expression{
+						newInteger(4),
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("default", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			testDocString(expr.Doc()),
+			testDocString(BuildDoc(DefaultDocBuilder{}, expr)),
+		)
+
+		assert.Equal(t,
+			Format(expr, FormatOptions{MaxLineWidth: 10}),
+			Format(expr, FormatOptions{
+				MaxLineWidth: 10,
+				DocBuilder:   DefaultDocBuilder{},
+			}),
+		)
+
+		formatExpr := newTestFormatExpression()
+
+		assert.Equal(t,
+			Format(formatExpr, FormatOptions{MaxLineWidth: 40}),
+			Format(formatExpr, FormatOptions{
+				MaxLineWidth: 40,
+				DocBuilder:   DefaultDocBuilder{},
+			}),
+		)
+	})
+
+	t.Run("custom arrays", func(t *testing.T) {
+
+		t.Parallel()
+
+		const expected = "[f(values: [1,2 + 3]),-[4]]"
+
+		assert.Equal(t,
+			expected,
+			testDocString(BuildDoc(compactArrayDocBuilder{}, expr)),
+		)
+
+		// Nested arrays are formatted by the builder,
+		// even when the enclosing expressions are formatted by the default builder
+
+		assert.Equal(t,
+			expected,
+			Format(expr, FormatOptions{
+				DocBuilder: compactArrayDocBuilder{},
+			}),
+		)
+	})
+
+	t.Run("options", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &ArrayExpression{
+			Values: []Expression{
+				newInteger(1_000_000),
+			},
+		}
+
+		assert.Equal(t,
+			"[1_000_000]",
+			Format(expr, FormatOptions{
+				DigitGrouping: 3,
+				DocBuilder:    compactArrayDocBuilder{},
+			}),
+		)
+	})
+	t.Run("member chain", func(t *testing.T) {
+
+		t.Parallel()
+
+		// a.b(x).c(y).d
+
+		newInvocation := func(invokedExpression Expression, argument string) *InvocationExpression {
+			return &InvocationExpression{
+				InvokedExpression: invokedExpression,
+				Arguments: Arguments{
+					{Expression: newTestIdentifierExpression(argument)},
+				},
+			}
+		}
+
+		expr := &MemberExpression{
+			Expression: newInvocation(
+				&MemberExpression{
+					Expression: newInvocation(
+						&MemberExpression{
+							Expression: newTestIdentifierExpression("a"),
+							Identifier: Identifier{Identifier: "b"},
+						},
+						"x",
+					),
+					Identifier: Identifier{Identifier: "c"},
+				},
+				"y",
+			),
+			Identifier: Identifier{Identifier: "d"},
+		}
+
+		// The invocations in the chain are built by the builder
+
+		var invocations int
+		builder := countingInvocationDocBuilder{
+			invocations: &invocations,
+		}
+
+		assert.Equal(t,
+			"a.b(x).c(y).d",
+			Format(expr, FormatOptions{DocBuilder: builder}),
+		)
+		assert.Equal(t, 2, invocations)
+
+		// The default builder builds the same documents as the Doc methods,
+		// including the layout of the chain
+
+		for _, maxLineWidth := range []int{80, 5} {
+			assert.Equal(t,
+				Format(expr, FormatOptions{MaxLineWidth: maxLineWidth}),
+				Format(expr, FormatOptions{
+					MaxLineWidth: maxLineWidth,
+					DocBuilder:   DefaultDocBuilder{},
+				}),
+			)
+		}
+	})
+}
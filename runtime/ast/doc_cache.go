@@ -0,0 +1,94 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"sync/atomic"
+
+	"github.com/turbolent/prettier"
+)
+
+// docCache memoizes the document of an array or dictionary expression,
+// i.e. the result of its Doc function. It is safe for concurrent use.
+//
+// The cached document is only reused if the children of the expression
+// are still the ones it was generated from, and the cached documents
+// of nested array and dictionary expressions are still valid,
+// so replacing children in place invalidates it.
+//
+// Other in-place mutations of the children, e.g. replacing the operand
+// of a nested binary expression, are not detected, see ResetDocCache.
+type docCache struct {
+	// entry is the *docCacheEntry, if any
+	entry atomic.Value
+}
+
+type docCacheEntry struct {
+	children []Expression
+	doc      prettier.Doc
+}
+
+// get returns the cached document, if it was generated from the given children
+func (c *docCache) get(children func(f func(Expression))) prettier.Doc {
+	entry, _ := c.entry.Load().(*docCacheEntry)
+	if entry == nil || !entry.valid(children) {
+		return nil
+	}
+	return entry.doc
+}
+
+// set caches the given document, generated from the given children
+func (c *docCache) set(children func(f func(Expression)), doc prettier.Doc) {
+	entry := &docCacheEntry{doc: doc}
+	children(func(child Expression) {
+		entry.children = append(entry.children, child)
+	})
+	c.entry.Store(entry)
+}
+
+// reset discards the cached document, if any
+func (c *docCache) reset() {
+	c.entry.Store((*docCacheEntry)(nil))
+}
+
+func (e *docCacheEntry) valid(children func(f func(Expression))) bool {
+	valid := true
+	index := 0
+
+	children(func(child Expression) {
+		if !valid {
+			return
+		}
+
+		if index >= len(e.children) || e.children[index] != child {
+			valid = false
+			return
+		}
+		index++
+
+		switch child := child.(type) {
+		case *ArrayExpression:
+			valid = child.docCache.get(child.walkDocChildren) != nil
+		case *DictionaryExpression:
+			valid = child.docCache.get(child.walkDocChildren) != nil
+		}
+	})
+
+	return valid && index == len(e.children)
+}
@@ -25,17 +25,29 @@ import (
 	"strings"
 
 	"github.com/turbolent/prettier"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/errors"
 )
 
 const NilConstant = "nil"
 
 type Expression interface {
 	Element
+	// String returns the string representation of the expression.
+	// Like the document returned by Doc, it only contains the parentheses
+	// which are needed to preserve the structure of the expression, e.g. `(a + b) * c`
 	fmt.Stringer
+	// CanonicalString returns the canonical string representation of the expression,
+	// in which all operator expressions are parenthesized, e.g. `((a + b) * c)`.
+	// Unlike String, it is unambiguous without knowledge of the operator precedences
+	CanonicalString() string
 	IfStatementTest
 	isExpression()
 	AcceptExp(ExpressionVisitor) Repr
 	Doc() prettier.Doc
+	CheckEqual(other Expression, checker ExpressionEqualityChecker) error
+	Clone() Expression
 }
 
 // BoolExpression
@@ -61,6 +73,15 @@ func (e *BoolExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitBoolExpression(e)
 }
 
+func (e *BoolExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckBoolExpressionEquality(e, other)
+}
+
+func (e *BoolExpression) Clone() Expression {
+	clone := *e
+	return &clone
+}
+
 func (e *BoolExpression) String() string {
 	if e.Value {
 		return "true"
@@ -68,6 +89,10 @@ func (e *BoolExpression) String() string {
 	return "false"
 }
 
+func (e *BoolExpression) CanonicalString() string {
+	return e.String()
+}
+
 var boolExpressionTrueDoc prettier.Doc = prettier.Text("true")
 var boolExpressionFalseDoc prettier.Doc = prettier.Text("false")
 
@@ -112,10 +137,23 @@ func (e *NilExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitNilExpression(e)
 }
 
+func (e *NilExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckNilExpressionEquality(e, other)
+}
+
+func (e *NilExpression) Clone() Expression {
+	clone := *e
+	return &clone
+}
+
 func (e *NilExpression) String() string {
 	return NilConstant
 }
 
+func (e *NilExpression) CanonicalString() string {
+	return e.String()
+}
+
 var nilExpressionDoc prettier.Doc = prettier.Text("nil")
 
 func (*NilExpression) Doc() prettier.Doc {
@@ -143,6 +181,74 @@ func (e *NilExpression) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// VoidExpression
+
+const VoidConstant = "()"
+
+type VoidExpression struct {
+	Pos Position `json:"-"`
+}
+
+func (*VoidExpression) isExpression() {}
+
+func (*VoidExpression) isIfStatementTest() {}
+
+func (e *VoidExpression) Accept(visitor Visitor) Repr {
+	return e.AcceptExp(visitor)
+}
+
+func (*VoidExpression) Walk(_ func(Element)) {
+	// NO-OP
+}
+
+func (e *VoidExpression) AcceptExp(visitor ExpressionVisitor) Repr {
+	return visitor.VisitVoidExpression(e)
+}
+
+func (e *VoidExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckVoidExpressionEquality(e, other)
+}
+
+func (e *VoidExpression) Clone() Expression {
+	clone := *e
+	return &clone
+}
+
+func (e *VoidExpression) String() string {
+	return VoidConstant
+}
+
+func (e *VoidExpression) CanonicalString() string {
+	return e.String()
+}
+
+var voidExpressionDoc prettier.Doc = prettier.Text(VoidConstant)
+
+func (*VoidExpression) Doc() prettier.Doc {
+	return voidExpressionDoc
+}
+
+func (e *VoidExpression) StartPosition() Position {
+	return e.Pos
+}
+
+func (e *VoidExpression) EndPosition() Position {
+	return e.Pos.Shifted(len(VoidConstant) - 1)
+}
+
+func (e *VoidExpression) MarshalJSON() ([]byte, error) {
+	type Alias VoidExpression
+	return json.Marshal(&struct {
+		Type string
+		Range
+		*Alias
+	}{
+		Type:  "VoidExpression",
+		Range: NewRangeFromPositioned(e),
+		Alias: (*Alias)(e),
+	})
+}
+
 // StringExpression
 
 type StringExpression struct {
@@ -166,10 +272,23 @@ func (e *StringExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitStringExpression(e)
 }
 
+func (e *StringExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckStringExpressionEquality(e, other)
+}
+
+func (e *StringExpression) Clone() Expression {
+	clone := *e
+	return &clone
+}
+
 func (e *StringExpression) String() string {
 	return QuoteString(e.Value)
 }
 
+func (e *StringExpression) CanonicalString() string {
+	return e.String()
+}
+
 func (e *StringExpression) Doc() prettier.Doc {
 	return prettier.Text(QuoteString(e.Value))
 }
@@ -185,6 +304,99 @@ func (e *StringExpression) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// StringTemplateExpression
+//
+// Segments are the literal parts of the string template,
+// and Values are the expressions interpolated between them,
+// i.e. there is one more segment than there are values.
+
+type StringTemplateExpression struct {
+	Values   []Expression
+	Segments []string
+	Range
+}
+
+func (*StringTemplateExpression) isExpression() {}
+
+func (*StringTemplateExpression) isIfStatementTest() {}
+
+func (e *StringTemplateExpression) Accept(visitor Visitor) Repr {
+	return e.AcceptExp(visitor)
+}
+
+func (e *StringTemplateExpression) Walk(walkChild func(Element)) {
+	walkExpressions(walkChild, e.Values)
+}
+
+func (e *StringTemplateExpression) AcceptExp(visitor ExpressionVisitor) Repr {
+	return visitor.VisitStringTemplateExpression(e)
+}
+
+func (e *StringTemplateExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckStringTemplateExpressionEquality(e, other)
+}
+
+func (e *StringTemplateExpression) Clone() Expression {
+	var segments []string
+	if e.Segments != nil {
+		segments = make([]string, len(e.Segments))
+		copy(segments, e.Segments)
+	}
+	return &StringTemplateExpression{
+		Values:   cloneExpressions(e.Values),
+		Segments: segments,
+		Range:    e.Range,
+	}
+}
+
+func (e *StringTemplateExpression) String() string {
+	return writtenExpressionString(e)
+}
+
+func (e *StringTemplateExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
+}
+
+func (e *StringTemplateExpression) Doc() prettier.Doc {
+	return e.doc(docContext{})
+}
+
+func (e *StringTemplateExpression) doc(context docContext) prettier.Doc {
+	var builder strings.Builder
+	builder.WriteByte('"')
+
+	doc := make(prettier.Concat, 0, len(e.Values)*2+1)
+
+	for i, segment := range e.Segments {
+		writeEscapedString(&builder, segment)
+		if i < len(e.Values) {
+			builder.WriteString(`\(`)
+			doc = append(
+				doc,
+				prettier.Text(builder.String()),
+				// A string literal cannot span multiple lines
+				expressionDoc(e.Values[i], context).Flatten(),
+			)
+			builder.Reset()
+			builder.WriteByte(')')
+		}
+	}
+
+	builder.WriteByte('"')
+	return append(doc, prettier.Text(builder.String()))
+}
+
+func (e *StringTemplateExpression) MarshalJSON() ([]byte, error) {
+	type Alias StringTemplateExpression
+	return json.Marshal(&struct {
+		Type string
+		*Alias
+	}{
+		Type:  "StringTemplateExpression",
+		Alias: (*Alias)(e),
+	})
+}
+
 // IntegerExpression
 
 type IntegerExpression struct {
@@ -194,6 +406,36 @@ type IntegerExpression struct {
 	Range
 }
 
+// NewIntegerExpression returns a decimal integer literal expression
+// for the given value, which starts at the given position.
+// The value is copied, and the literal is unsigned,
+// i.e. the sign is only determined by the value.
+func NewIntegerExpression(value *big.Int, pos Position) *IntegerExpression {
+	value = new(big.Int).Set(value)
+	positiveLiteral := new(big.Int).Abs(value).String()
+
+	length := len(positiveLiteral)
+	if value.Sign() < 0 {
+		length++
+	}
+
+	expression := &IntegerExpression{
+		PositiveLiteral: positiveLiteral,
+		Value:           value,
+		Base:            10,
+		Range: Range{
+			StartPos: pos,
+			EndPos:   pos.Shifted(length - 1),
+		},
+	}
+
+	if expression.Validate() != nil {
+		panic(errors.NewUnreachableError())
+	}
+
+	return expression
+}
+
 func (*IntegerExpression) isExpression() {}
 
 func (*IntegerExpression) isIfStatementTest() {}
@@ -210,17 +452,217 @@ func (e *IntegerExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitIntegerExpression(e)
 }
 
+func (e *IntegerExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckIntegerExpressionEquality(e, other)
+}
+
+func (e *IntegerExpression) Clone() Expression {
+	clone := *e
+	clone.Value = cloneBigInt(e.Value)
+	return &clone
+}
+
 func (e *IntegerExpression) String() string {
-	literal := e.PositiveLiteral
-	if e.Value.Sign() < 0 {
+	literal := e.positiveLiteral()
+	if e.IsNegative() {
 		literal = "-" + literal
 	}
 	return literal
 }
 
-func (e *IntegerExpression) Doc() prettier.Doc {
-	literal := e.PositiveLiteral
+func (e *IntegerExpression) CanonicalString() string {
+	return e.String()
+}
+
+// IsNegative returns true if the value of the expression is negative.
+// The sign is determined by the value, not by the literal, which is unsigned.
+func (e *IntegerExpression) IsNegative() bool {
+	return e.Value != nil && e.Value.Sign() < 0
+}
+
+// Normalize removes a stray sign from the literal of the expression,
+// e.g. `-5` becomes `5`, as the literal is unsigned
+// and the sign is determined by the value of the expression.
+func (e *IntegerExpression) Normalize() {
+	e.PositiveLiteral = unsignedIntegerLiteral(e.PositiveLiteral)
+}
+
+// unsignedIntegerLiteral returns the given integer literal without leading signs
+func unsignedIntegerLiteral(literal string) string {
+	return strings.TrimLeft(literal, "+-")
+}
+
+// positiveLiteral returns the literal of the expression, which is rendered by String and Doc.
+//
+// A stray sign in the literal is removed, see Normalize,
+// so the rendered literal never has two signs, e.g. `--5`.
+// If the literal lacks the prefix of the base of the expression, e.g. `ff` in base 16,
+// the prefix is added, so the result is a valid literal in the base, e.g. `0xff`.
+// If the literal is empty, the value is formatted in the base.
+// If the value is missing too, the placeholder for missing children is returned.
+func (e *IntegerExpression) positiveLiteral() string {
+	literal := unsignedIntegerLiteral(e.PositiveLiteral)
+
+	if literal == "" && e.Value == nil {
+		return missingPlaceholder
+	}
+
+	prefix, ok := integerLiteralPrefixes[e.Base]
+	if !ok {
+		return literal
+	}
+
+	if literal == "" && e.Value != nil {
+		return prefix + new(big.Int).Abs(e.Value).Text(e.Base)
+	}
+
+	if !strings.HasPrefix(literal, prefix) {
+		return prefix + literal
+	}
+
+	return literal
+}
+
+// RenderWithBase returns the literal of the value of the expression in the base of the expression,
+// with the prefix of the base, e.g. `0xff`, and a leading minus sign if the value is negative.
+// Decimal literals are rendered if the base is not a valid base.
+//
+// If grouping is positive, the digits are grouped from the right into groups of the given size,
+// separated by underscores, e.g. `0b1010_1010` for grouping 4.
+func (e *IntegerExpression) RenderWithBase(grouping int) string {
+	if e.Value == nil {
+		return e.String()
+	}
+
+	base := e.Base
+	prefix, ok := integerLiteralPrefixes[base]
+	if !ok {
+		base = 10
+	}
+
+	digits := groupDigits(new(big.Int).Abs(e.Value).Text(base), grouping)
+
+	var builder strings.Builder
 	if e.Value.Sign() < 0 {
+		builder.WriteByte('-')
+	}
+	builder.WriteString(prefix)
+	builder.WriteString(digits)
+	return builder.String()
+}
+
+// groupDigits separates the given digits from the right into groups of the given size,
+// using underscores. The digits must not contain underscores.
+// If the group size is not positive, the digits are returned as-is.
+func groupDigits(digits string, size int) string {
+	if size <= 0 || len(digits) <= size {
+		return digits
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(digits) + (len(digits)-1)/size)
+
+	// The first group may be shorter than the others
+	first := len(digits) % size
+	if first == 0 {
+		first = size
+	}
+	builder.WriteString(digits[:first])
+
+	for i := first; i < len(digits); i += size {
+		builder.WriteByte('_')
+		builder.WriteString(digits[i : i+size])
+	}
+
+	return builder.String()
+}
+
+// integerLiteralPrefixes are the prefixes of integer literals, by base
+var integerLiteralPrefixes = map[int]string{
+	2:  "0b",
+	8:  "0o",
+	10: "",
+	16: "0x",
+}
+
+// Validate returns an error if the literal of the expression
+// is not a valid literal in the base of the expression,
+// or if the value of the literal is not the (absolute) value of the expression.
+func (e *IntegerExpression) Validate() error {
+	if e.Value == nil {
+		return fmt.Errorf("integer literal `%s` has no value", e.PositiveLiteral)
+	}
+
+	prefix, ok := integerLiteralPrefixes[e.Base]
+	if !ok {
+		return fmt.Errorf("integer literal `%s` has invalid base %d", e.PositiveLiteral, e.Base)
+	}
+
+	if !strings.HasPrefix(e.PositiveLiteral, prefix) {
+		return fmt.Errorf(
+			"integer literal `%s` is not a valid base %d literal: missing prefix `%s`",
+			e.PositiveLiteral,
+			e.Base,
+			prefix,
+		)
+	}
+
+	digits := strings.ReplaceAll(e.PositiveLiteral[len(prefix):], "_", "")
+
+	// NOTE: big.Int.SetString accepts a sign, but literals are unsigned
+	var literalValue *big.Int
+	if !strings.HasPrefix(digits, "+") && !strings.HasPrefix(digits, "-") {
+		literalValue, _ = new(big.Int).SetString(digits, e.Base)
+	}
+	if literalValue == nil {
+		return fmt.Errorf(
+			"integer literal `%s` is not a valid base %d literal",
+			e.PositiveLiteral,
+			e.Base,
+		)
+	}
+
+	if literalValue.CmpAbs(e.Value) != 0 {
+		return fmt.Errorf(
+			"integer literal `%s` in base %d has value %s, but expression has value %s",
+			e.PositiveLiteral,
+			e.Base,
+			literalValue,
+			e.Value,
+		)
+	}
+
+	return nil
+}
+
+func (e *IntegerExpression) Doc() prettier.Doc {
+	literal := e.positiveLiteral()
+	if e.IsNegative() {
+		literal = "-" + literal
+	}
+	return prettier.Text(literal)
+}
+
+func (e *IntegerExpression) doc(context docContext) prettier.Doc {
+	if context.digitGrouping <= 0 && !context.stripSeparators {
+		return e.Doc()
+	}
+
+	literal := e.positiveLiteral()
+	if literal == missingPlaceholder {
+		return e.Doc()
+	}
+
+	prefix := integerLiteralPrefixes[e.Base]
+	if !strings.HasPrefix(literal, prefix) {
+		prefix = ""
+	}
+
+	digits := strings.ReplaceAll(literal[len(prefix):], "_", "")
+	digits = groupDigits(digits, context.digitGrouping)
+
+	literal = prefix + digits
+	if e.IsNegative() {
 		literal = "-" + literal
 	}
 	return prettier.Text(literal)
@@ -250,6 +692,44 @@ type FixedPointExpression struct {
 	Range
 }
 
+// NewFixedPointExpression returns a fixed-point literal expression
+// for the given sign, integer part, fractional part, and scale,
+// which starts at the given position.
+// The scale is the number of digits of the fractional part, including leading zeros,
+// e.g. the fractional part 5 and the scale 2 represent `.05`.
+// The integer and fractional parts are copied.
+func NewFixedPointExpression(
+	negative bool,
+	unsignedInteger *big.Int,
+	fractional *big.Int,
+	scale uint,
+	pos Position,
+) *FixedPointExpression {
+	unsignedInteger = new(big.Int).Set(unsignedInteger)
+	fractional = new(big.Int).Set(fractional)
+
+	var builder strings.Builder
+	writeFixedPointLiteral(&builder, unsignedInteger, fractional, scale)
+	positiveLiteral := builder.String()
+
+	length := len(positiveLiteral)
+	if negative {
+		length++
+	}
+
+	return &FixedPointExpression{
+		PositiveLiteral: positiveLiteral,
+		Negative:        negative,
+		UnsignedInteger: unsignedInteger,
+		Fractional:      fractional,
+		Scale:           scale,
+		Range: Range{
+			StartPos: pos,
+			EndPos:   pos.Shifted(length - 1),
+		},
+	}
+}
+
 func (*FixedPointExpression) isExpression() {}
 
 func (*FixedPointExpression) isIfStatementTest() {}
@@ -266,6 +746,17 @@ func (e *FixedPointExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitFixedPointExpression(e)
 }
 
+func (e *FixedPointExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckFixedPointExpressionEquality(e, other)
+}
+
+func (e *FixedPointExpression) Clone() Expression {
+	clone := *e
+	clone.UnsignedInteger = cloneBigInt(e.UnsignedInteger)
+	clone.Fractional = cloneBigInt(e.Fractional)
+	return &clone
+}
+
 func (e *FixedPointExpression) String() string {
 	literal := e.PositiveLiteral
 	if literal != "" {
@@ -279,22 +770,45 @@ func (e *FixedPointExpression) String() string {
 	if e.Negative {
 		builder.WriteRune('-')
 	}
-	builder.WriteString(e.UnsignedInteger.String())
+	writeFixedPointLiteral(&builder, e.UnsignedInteger, e.Fractional, e.Scale)
+	return builder.String()
+}
+
+func (e *FixedPointExpression) CanonicalString() string {
+	return e.String()
+}
+
+// writeFixedPointLiteral writes the decimal literal of the given unsigned fixed-point number,
+// i.e. the integer part, followed by a dot, followed by the fractional part,
+// padded with leading zeros to the given scale.
+// Missing parts are written as the placeholder for missing children
+func writeFixedPointLiteral(builder *strings.Builder, unsignedInteger, fractional *big.Int, scale uint) {
+	if unsignedInteger == nil {
+		builder.WriteString(missingPlaceholder)
+	} else {
+		builder.WriteString(unsignedInteger.String())
+	}
 	builder.WriteRune('.')
-	fractional := e.Fractional.String()
-	for i := uint(0); i < (e.Scale - uint(len(fractional))); i++ {
-		builder.WriteRune('0')
+	if fractional == nil {
+		builder.WriteString(missingPlaceholder)
+		return
 	}
-	builder.WriteString(fractional)
-	return builder.String()
+	fractionalString := fractional.String()
+	fractionalLength := uint(len(fractionalString))
+	// NOTE: guard against underflow: the fractional part
+	// might have more digits than the scale
+	if fractionalLength < scale {
+		for i := uint(0); i < scale-fractionalLength; i++ {
+			builder.WriteRune('0')
+		}
+	}
+	builder.WriteString(fractionalString)
 }
 
 func (e *FixedPointExpression) Doc() prettier.Doc {
-	literal := e.PositiveLiteral
-	if e.Negative {
-		literal = "-" + literal
-	}
-	return prettier.Text(literal)
+	// NOTE: String uses the literal, if any,
+	// and otherwise reconstructs it from the value
+	return prettier.Text(e.String())
 }
 
 func (e *FixedPointExpression) MarshalJSON() ([]byte, error) {
@@ -317,6 +831,7 @@ func (e *FixedPointExpression) MarshalJSON() ([]byte, error) {
 type ArrayExpression struct {
 	Values []Expression
 	Range
+	docCache docCache
 }
 
 func (*ArrayExpression) isExpression() {}
@@ -335,17 +850,27 @@ func (e *ArrayExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitArrayExpression(e)
 }
 
+func (e *ArrayExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckArrayExpressionEquality(e, other)
+}
+
+func (e *ArrayExpression) Clone() Expression {
+	return &ArrayExpression{
+		Values: cloneExpressions(e.Values),
+		Range:  e.Range,
+	}
+}
+
 func (e *ArrayExpression) String() string {
-	var builder strings.Builder
-	builder.WriteString("[")
+	valueStrings := make([]string, len(e.Values))
 	for i, value := range e.Values {
-		if i > 0 {
-			builder.WriteString(", ")
-		}
-		builder.WriteString(value.String())
+		valueStrings[i] = expressionStringOrPlaceholder(value)
 	}
-	builder.WriteString("]")
-	return builder.String()
+	return joinStrings("[", valueStrings, ", ", "]")
+}
+
+func (e *ArrayExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 var arrayExpressionSeparatorDoc prettier.Doc = prettier.Concat{
@@ -353,18 +878,50 @@ var arrayExpressionSeparatorDoc prettier.Doc = prettier.Concat{
 	prettier.Line{},
 }
 
+// Doc returns the document of the array expression.
+//
+// The result is cached. The cache is invalidated when the elements are replaced,
+// but if an element is mutated in place, ResetDocCache must be called.
 func (e *ArrayExpression) Doc() prettier.Doc {
+	if doc := e.docCache.get(e.walkDocChildren); doc != nil {
+		return doc
+	}
+	doc := e.doc(docContext{})
+	e.docCache.set(e.walkDocChildren, doc)
+	return doc
+}
+
+// ResetDocCache discards the cached result of Doc.
+//
+// The caches of nested array and dictionary expressions are not reset.
+func (e *ArrayExpression) ResetDocCache() {
+	e.docCache.reset()
+}
+
+func (e *ArrayExpression) walkDocChildren(f func(Expression)) {
+	for _, value := range e.Values {
+		f(value)
+	}
+}
+
+func (e *ArrayExpression) doc(context docContext) prettier.Doc {
 	if len(e.Values) == 0 {
 		return prettier.Text("[]")
 	}
 
 	elementDocs := make([]prettier.Doc, len(e.Values))
+	elements := make([]Element, len(e.Values))
 	for i, value := range e.Values {
-		elementDocs[i] = value.Doc()
+		elementDocs[i] = expressionDoc(value, listElementContext(value, context))
+		elements[i] = value
 	}
-	return prettier.WrapBrackets(
-		prettier.Join(arrayExpressionSeparatorDoc, elementDocs...),
-		prettier.SoftLine{},
+	return listDoc(
+		"[",
+		elementDocs,
+		elements,
+		context.trivia.hasLineComments(elements...),
+		"]",
+		context,
 	)
 }
 
@@ -379,11 +936,76 @@ func (e *ArrayExpression) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// ElementRange returns the range of the element at the given index,
+// and false if the array has no such element
+func (e *ArrayExpression) ElementRange(i int) (Range, bool) {
+	if i < 0 || i >= len(e.Values) {
+		return Range{}, false
+	}
+
+	value := e.Values[i]
+	if isNilExpression(value) {
+		return Range{}, false
+	}
+
+	return NewRangeFromPositioned(value), true
+}
+
+// IsHomogeneousLiteral returns true if the array is not empty,
+// and all elements are literals of the same kind, e.g. all are integer literals.
+//
+// The kind is returned as the type discriminator of the JSON encoding of the elements,
+// e.g. "IntegerExpression".
+func (e *ArrayExpression) IsHomogeneousLiteral() (kind string, ok bool) {
+	if len(e.Values) == 0 {
+		return "", false
+	}
+
+	for i, value := range e.Values {
+		if !isLiteralExpression(value) {
+			return "", false
+		}
+
+		valueKind := nodeKind(value)
+		if i == 0 {
+			kind = valueKind
+		} else if valueKind != kind {
+			return "", false
+		}
+	}
+
+	return kind, true
+}
+
+// isLiteralExpression returns true if the given expression is a literal,
+// e.g. a boolean, string, or integer literal
+func isLiteralExpression(expression Expression) bool {
+	if isNilExpression(expression) {
+		return false
+	}
+
+	switch expression.(type) {
+	case *BoolExpression,
+		*NilExpression,
+		*VoidExpression,
+		*StringExpression,
+		*IntegerExpression,
+		*FixedPointExpression,
+		*PathExpression:
+
+		return true
+
+	default:
+		return false
+	}
+}
+
 // DictionaryExpression
 
 type DictionaryExpression struct {
 	Entries []DictionaryEntry
 	Range
+	docCache docCache
 }
 
 func (*DictionaryExpression) isExpression() {}
@@ -405,39 +1027,164 @@ func (e *DictionaryExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitDictionaryExpression(e)
 }
 
+func (e *DictionaryExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckDictionaryExpressionEquality(e, other)
+}
+
+func (e *DictionaryExpression) Clone() Expression {
+	var entries []DictionaryEntry
+	if e.Entries != nil {
+		entries = make([]DictionaryEntry, len(e.Entries))
+		for i, entry := range e.Entries {
+			entries[i] = DictionaryEntry{
+				Key:   cloneExpression(entry.Key),
+				Value: cloneExpression(entry.Value),
+			}
+		}
+	}
+	return &DictionaryExpression{
+		Entries: entries,
+		Range:   e.Range,
+	}
+}
+
 func (e *DictionaryExpression) String() string {
-	var builder strings.Builder
-	builder.WriteString("{")
+	entryStrings := make([]string, len(e.Entries))
 	for i, entry := range e.Entries {
-		if i > 0 {
-			builder.WriteString(", ")
-		}
-		builder.WriteString(entry.Key.String())
-		builder.WriteString(": ")
-		builder.WriteString(entry.Value.String())
+		entryStrings[i] = expressionStringOrPlaceholder(entry.Key) +
+			": " +
+			expressionStringOrPlaceholder(entry.Value)
 	}
-	builder.WriteString("}")
-	return builder.String()
+	return joinStrings("{", entryStrings, ", ", "}")
 }
 
-var dictionaryExpressionSeparatorDoc prettier.Doc = prettier.Concat{
-	prettier.Text(","),
-	prettier.Line{},
+func (e *DictionaryExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
+// Doc returns the document of the dictionary expression.
+//
+// The result is cached. The cache is invalidated when the entries are replaced,
+// but if a key or value is mutated in place, ResetDocCache must be called.
 func (e *DictionaryExpression) Doc() prettier.Doc {
+	if doc := e.docCache.get(e.walkDocChildren); doc != nil {
+		return doc
+	}
+	doc := e.doc(docContext{})
+	e.docCache.set(e.walkDocChildren, doc)
+	return doc
+}
+
+// ResetDocCache discards the cached result of Doc.
+//
+// The caches of nested array and dictionary expressions are not reset.
+func (e *DictionaryExpression) ResetDocCache() {
+	e.docCache.reset()
+}
+
+func (e *DictionaryExpression) walkDocChildren(f func(Expression)) {
+	for _, entry := range e.Entries {
+		f(entry.Key)
+		f(entry.Value)
+	}
+}
+
+// EntryByKey returns the first entry whose key is equal to the given key,
+// and true if there is such an entry, or false otherwise.
+//
+// Keys are compared using the given equality function.
+// If it is nil, EqualExpressions is used,
+// i.e. keys are compared structurally and literals are compared by value.
+func (e *DictionaryExpression) EntryByKey(key Expression, eq func(a, b Expression) bool) (*DictionaryEntry, bool) {
+	if eq == nil {
+		eq = EqualExpressions
+	}
+
+	for i := range e.Entries {
+		entry := &e.Entries[i]
+		if eq(entry.Key, key) {
+			return entry, true
+		}
+	}
+
+	return nil, false
+}
+
+// DeduplicateEntries removes all entries whose key is equal to the key of a preceding entry,
+// and returns the removed entries. Keys are compared using EqualExpressions.
+//
+// The entries are updated in place.
+func (e *DictionaryExpression) DeduplicateEntries() (removed []DictionaryEntry) {
+	// Keys with the same hash are candidates for equality
+	keysByHash := make(map[uint64][]Expression, len(e.Entries))
+
+	entries := e.Entries[:0]
+
+	for _, entry := range e.Entries {
+		hash := HashExpression(entry.Key)
+
+		duplicate := false
+		for _, key := range keysByHash[hash] {
+			if EqualExpressions(key, entry.Key) {
+				duplicate = true
+				break
+			}
+		}
+
+		if duplicate {
+			removed = append(removed, entry)
+			continue
+		}
+
+		keysByHash[hash] = append(keysByHash[hash], entry.Key)
+		entries = append(entries, entry)
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	// Clear the remaining entries, so the removed keys and values can be garbage collected
+
+	for i := len(entries); i < len(e.Entries); i++ {
+		e.Entries[i] = DictionaryEntry{}
+	}
+
+	e.Entries = entries
+
+	return removed
+}
+
+func (e *DictionaryExpression) doc(context docContext) prettier.Doc {
 	if len(e.Entries) == 0 {
 		return prettier.Text("{}")
 	}
 
 	entryDocs := make([]prettier.Doc, len(e.Entries))
+	if context.alignDictionaryValues {
+		e.alignedEntryDocs(entryDocs, context)
+	} else {
+		for i, entry := range e.Entries {
+			entryDocs[i] = entry.doc(listElementContext(entry.Value, context))
+		}
+	}
+
+	elements := make([]Element, len(e.Entries))
+	broken := false
 	for i, entry := range e.Entries {
-		entryDocs[i] = entry.Doc()
+		elements[i] = entry.Value
+		if context.trivia.hasLineComments(entry.Key, entry.Value) {
+			broken = true
+		}
 	}
 
-	return prettier.WrapBraces(
-		prettier.Join(dictionaryExpressionSeparatorDoc, entryDocs...),
-		prettier.SoftLine{},
+	return listDoc(
+		"{",
+		entryDocs,
+		elements,
+		broken,
+		"}",
+		context,
 	)
 }
 
@@ -457,6 +1204,22 @@ type DictionaryEntry struct {
 	Value Expression
 }
 
+var _ HasPosition = DictionaryEntry{}
+
+// StartPosition returns the start position of the key.
+// If the entry is incomplete, i.e. the key is nil,
+// the start position of the value is returned.
+func (e DictionaryEntry) StartPosition() Position {
+	return firstStartPosition(e.Key, e.Value)
+}
+
+// EndPosition returns the end position of the value.
+// If the entry is incomplete, i.e. the value is nil,
+// the end position of the key is returned.
+func (e DictionaryEntry) EndPosition() Position {
+	return lastEndPosition(e.Key, e.Value)
+}
+
 func (e DictionaryEntry) MarshalJSON() ([]byte, error) {
 	type Alias DictionaryEntry
 	return json.Marshal(&struct {
@@ -474,8 +1237,12 @@ var dictionaryKeyValueSeparatorDoc prettier.Doc = prettier.Concat{
 }
 
 func (e DictionaryEntry) Doc() prettier.Doc {
-	keyDoc := e.Key.Doc()
-	valueDoc := e.Value.Doc()
+	return e.doc(docContext{})
+}
+
+func (e DictionaryEntry) doc(context docContext) prettier.Doc {
+	keyDoc := expressionDoc(e.Key, context)
+	valueDoc := expressionDoc(e.Value, context)
 
 	return prettier.Group{
 		Doc: prettier.Concat{
@@ -486,6 +1253,47 @@ func (e DictionaryEntry) Doc() prettier.Doc {
 	}
 }
 
+// alignedEntryDocs sets the documents for the entries of the dictionary,
+// with the keys padded to the width of the widest key,
+// if the dictionary is broken across multiple lines, see resolveDictionaryAlignment
+func (e *DictionaryExpression) alignedEntryDocs(entryDocs []prettier.Doc, context docContext) {
+	keyDocs := make([]prettier.Doc, len(e.Entries))
+	keyWidths := make([]int, len(e.Entries))
+	maxKeyWidth := 0
+
+	for i, entry := range e.Entries {
+		keyDoc := expressionDoc(entry.Key, context)
+		keyDocs[i] = keyDoc
+
+		keyWidth := flatDocWidth(keyDoc)
+		keyWidths[i] = keyWidth
+		if keyWidth > maxKeyWidth {
+			maxKeyWidth = keyWidth
+		}
+	}
+
+	for i, entry := range e.Entries {
+		keyDoc := keyDocs[i]
+
+		padding := maxKeyWidth - keyWidths[i]
+		if padding > 0 {
+			keyDoc = prettier.Concat{
+				prettier.Text(dictionaryEntryStartPlaceholder),
+				keyDoc,
+				prettier.Text(strings.Repeat(dictionaryKeyPaddingPlaceholder, padding)),
+			}
+		}
+
+		entryDocs[i] = prettier.Group{
+			Doc: prettier.Concat{
+				keyDoc,
+				dictionaryKeyValueSeparatorDoc,
+				expressionDoc(entry.Value, listElementContext(entry.Value, context)),
+			},
+		}
+	}
+}
+
 // IdentifierExpression
 
 type IdentifierExpression struct {
@@ -508,10 +1316,23 @@ func (e *IdentifierExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitIdentifierExpression(e)
 }
 
+func (e *IdentifierExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckIdentifierExpressionEquality(e, other)
+}
+
+func (e *IdentifierExpression) Clone() Expression {
+	clone := *e
+	return &clone
+}
+
 func (e *IdentifierExpression) String() string {
 	return e.Identifier.Identifier
 }
 
+func (e *IdentifierExpression) CanonicalString() string {
+	return e.String()
+}
+
 func (e *IdentifierExpression) Doc() prettier.Doc {
 	return prettier.Text(e.Identifier.Identifier)
 }
@@ -542,16 +1363,15 @@ func (e *IdentifierExpression) EndPosition() Position {
 type Arguments []*Argument
 
 func (args Arguments) String() string {
-	var builder strings.Builder
-	builder.WriteRune('(')
+	argumentStrings := make([]string, len(args))
 	for i, argument := range args {
-		if i > 0 {
-			builder.WriteString(", ")
+		if argument == nil {
+			argumentStrings[i] = missingPlaceholder
+			continue
 		}
-		builder.WriteString(argument.String())
+		argumentStrings[i] = argument.String()
 	}
-	builder.WriteRune(')')
-	return builder.String()
+	return joinStrings("(", argumentStrings, ", ", ")")
 }
 
 // InvocationExpression
@@ -564,6 +1384,47 @@ type InvocationExpression struct {
 	EndPos            Position `json:"-"`
 }
 
+// NewInvocationExpression returns an invocation of the given expression
+// with the given type arguments and arguments.
+//
+// The positions of the argument list are computed from the children,
+// assuming the invocation is written like `f<T, U>(a, b)`:
+// The argument list starts immediately after the invoked expression
+// or the type argument list, and each argument is immediately followed
+// by its separator, i.e. a comma or the closing parenthesis.
+//
+// The trailing separator positions of the given arguments are updated.
+func NewInvocationExpression(
+	invokedExpression Expression,
+	typeArguments []*TypeAnnotation,
+	arguments []*Argument,
+) *InvocationExpression {
+
+	var argumentsStartPos Position
+	if len(typeArguments) > 0 {
+		// skip the closing `>` of the type argument list
+		lastTypeArgument := typeArguments[len(typeArguments)-1]
+		argumentsStartPos = lastTypeArgument.EndPosition().Shifted(2)
+	} else {
+		argumentsStartPos = invokedExpression.EndPosition().Shifted(1)
+	}
+
+	endPos := argumentsStartPos.Shifted(1)
+
+	for _, argument := range arguments {
+		argument.TrailingSeparatorPos = argument.EndPosition().Shifted(1)
+		endPos = argument.TrailingSeparatorPos
+	}
+
+	return &InvocationExpression{
+		InvokedExpression: invokedExpression,
+		TypeArguments:     typeArguments,
+		Arguments:         arguments,
+		ArgumentsStartPos: argumentsStartPos,
+		EndPos:            endPos,
+	}
+}
+
 func (*InvocationExpression) isExpression() {}
 
 func (*InvocationExpression) isIfStatementTest() {}
@@ -583,36 +1444,52 @@ func (e *InvocationExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitInvocationExpression(e)
 }
 
-func (e *InvocationExpression) String() string {
-	var builder strings.Builder
-	builder.WriteString(e.InvokedExpression.String())
-	if len(e.TypeArguments) > 0 {
-		builder.WriteRune('<')
-		for i, ty := range e.TypeArguments {
-			if i > 0 {
-				builder.WriteString(", ")
-			}
-			builder.WriteString(ty.String())
-		}
-		builder.WriteRune('>')
+func (e *InvocationExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckInvocationExpressionEquality(e, other)
+}
+
+func (e *InvocationExpression) Clone() Expression {
+	return &InvocationExpression{
+		InvokedExpression: cloneExpression(e.InvokedExpression),
+		TypeArguments:     cloneTypeAnnotations(e.TypeArguments),
+		Arguments:         e.Arguments.Clone(),
+		ArgumentsStartPos: e.ArgumentsStartPos,
+		EndPos:            e.EndPos,
 	}
-	builder.WriteString(e.Arguments.String())
-	return builder.String()
+}
+
+func (e *InvocationExpression) String() string {
+	return writtenExpressionString(e)
+}
+
+func (e *InvocationExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 func (e *InvocationExpression) Doc() prettier.Doc {
+	return e.doc(docContext{})
+}
 
-	result := prettier.Concat{
-		// TODO: potentially parenthesize
-		e.InvokedExpression.Doc(),
-	}
+func (e *InvocationExpression) doc(context docContext) prettier.Doc {
+	return append(
+		prettier.Concat{
+			subexpressionDoc(e.InvokedExpression, PrecedenceUnaryPostfix, context),
+		},
+		e.argumentsDocs(context)...,
+	)
+}
+
+// argumentsDocs returns the documents for the type argument list, if any,
+// and the argument list of the invocation.
+func (e *InvocationExpression) argumentsDocs(context docContext) []prettier.Doc {
+
+	var result []prettier.Doc
 
 	if len(e.TypeArguments) > 0 {
 		typeArgumentDocs := make([]prettier.Doc, len(e.TypeArguments))
-		// TODO: type arguments
-		//for i, typeArgument := range e.TypeArguments {
-		//	typeArgumentDocs[i] = typeArgument.Doc()
-		//}
+		for i, typeArgument := range e.TypeArguments {
+			typeArgumentDocs[i] = typeArgument.Doc()
+		}
 
 		result = append(result,
 			prettier.Wrap(
@@ -629,19 +1506,18 @@ func (e *InvocationExpression) Doc() prettier.Doc {
 		argumentsDoc = prettier.Text("()")
 	} else {
 		argumentDocs := make([]prettier.Doc, len(e.Arguments))
+		elements := make([]Element, len(e.Arguments))
 		for i, argument := range e.Arguments {
-			argumentDoc := argument.Expression.Doc()
-			if argument.Label != "" {
-				argumentDoc = prettier.Concat{
-					prettier.Text(argument.Label + ": "),
-					argumentDoc,
-				}
-			}
-			argumentDocs[i] = argumentDoc
+			argumentDocs[i] = argument.doc(listElementContext(argument.Expression, context))
+			elements[i] = argument.Expression
 		}
-		argumentsDoc = prettier.WrapParentheses(
-			prettier.Join(arrayExpressionSeparatorDoc, argumentDocs...),
-			prettier.SoftLine{},
+		argumentsDoc = listDoc(
+			"(",
+			argumentDocs,
+			elements,
+			context.trivia.hasLineComments(elements...),
+			")",
+			context,
 		)
 	}
 
@@ -658,6 +1534,44 @@ func (e *InvocationExpression) EndPosition() Position {
 	return e.EndPos
 }
 
+// ArgumentCount returns the number of arguments of the invocation
+func (e *InvocationExpression) ArgumentCount() int {
+	return len(e.Arguments)
+}
+
+// TypeArgumentCount returns the number of type arguments of the invocation
+func (e *InvocationExpression) TypeArgumentCount() int {
+	return len(e.TypeArguments)
+}
+
+// ArgumentByLabel returns the first argument with the given label,
+// and false if the invocation has no such argument.
+// Unlabeled arguments are never returned
+func (e *InvocationExpression) ArgumentByLabel(label string) (*Argument, bool) {
+	if label == "" {
+		return nil, false
+	}
+
+	for _, argument := range e.Arguments {
+		if argument != nil && argument.Label == label {
+			return argument, true
+		}
+	}
+
+	return nil, false
+}
+
+// PositionalArgument returns the argument at the given position,
+// whether it is labeled or not, and false if the invocation has no such argument
+func (e *InvocationExpression) PositionalArgument(i int) (*Argument, bool) {
+	if i < 0 || i >= len(e.Arguments) {
+		return nil, false
+	}
+
+	argument := e.Arguments[i]
+	return argument, argument != nil
+}
+
 func (e *InvocationExpression) MarshalJSON() ([]byte, error) {
 	type Alias InvocationExpression
 	return json.Marshal(&struct {
@@ -679,6 +1593,57 @@ type AccessExpression interface {
 	AccessedExpression() Expression
 }
 
+// HasOptionalAccess returns true if any link of the access chain
+// of the given expression is an optional member access, e.g. `a?.b.c`.
+//
+// The chain is walked from the given expression towards its base,
+// through the accessed expressions of member and index expressions,
+// and through the invoked expressions of invocations, e.g. `a?.b().c`.
+func HasOptionalAccess(expression Expression) bool {
+	for {
+		switch current := expression.(type) {
+		case *MemberExpression:
+			if current.Optional {
+				return true
+			}
+			expression = current.Expression
+
+		case AccessExpression:
+			expression = current.AccessedExpression()
+
+		case *InvocationExpression:
+			expression = current.InvokedExpression
+
+		default:
+			return false
+		}
+	}
+}
+
+// RootExpression returns the base of the access and call chain of the given expression,
+// e.g. the identifier expression `a` for `a.b()[0]!.c`.
+//
+// The chain is walked through the accessed expressions of member and index expressions,
+// the invoked expressions of invocations, and the operands of force expressions.
+// The first expression which is none of these is returned.
+func RootExpression(expression Expression) Expression {
+	for {
+		switch current := expression.(type) {
+		case AccessExpression:
+			expression = current.AccessedExpression()
+
+		case *InvocationExpression:
+			expression = current.InvokedExpression
+
+		case *ForceExpression:
+			expression = current.Expression
+
+		default:
+			return expression
+		}
+	}
+}
+
 // MemberExpression
 
 type MemberExpression struct {
@@ -712,42 +1677,129 @@ func (e *MemberExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitMemberExpression(e)
 }
 
+func (e *MemberExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckMemberExpressionEquality(e, other)
+}
+
+func (e *MemberExpression) Clone() Expression {
+	clone := *e
+	clone.Expression = cloneExpression(e.Expression)
+	return &clone
+}
+
 func (e *MemberExpression) String() string {
-	optional := ""
-	if e.Optional {
-		optional = "?"
-	}
-	return fmt.Sprintf(
-		"%s%s.%s",
-		e.Expression, optional, e.Identifier,
-	)
+	return writtenExpressionString(e)
+}
+
+func (e *MemberExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 var memberExpressionSeparatorDoc prettier.Doc = prettier.Text(".")
 var memberExpressionOptionalSeparatorDoc prettier.Doc = prettier.Text("?.")
 
 func (e *MemberExpression) Doc() prettier.Doc {
-	var separatorDoc prettier.Doc
+	return e.doc(docContext{})
+}
+
+func (e *MemberExpression) separatorDoc() prettier.Doc {
 	if e.Optional {
-		separatorDoc = memberExpressionOptionalSeparatorDoc
-	} else {
-		separatorDoc = memberExpressionSeparatorDoc
+		return memberExpressionOptionalSeparatorDoc
+	}
+	return memberExpressionSeparatorDoc
+}
+
+// doc returns the document for the chain of member accesses ending in this member access,
+// e.g. `a.b.c().d`: The accesses are grouped as a unit, i.e. the chain is either on one line,
+// or broken before each access.
+//
+// Invocations of members are part of the chain, so fluent call chains are grouped, too.
+// A single member access, e.g. `a.b`, is never broken.
+func (e *MemberExpression) doc(context docContext) prettier.Doc {
+
+	// Collect the documents for the accesses of the chain, in reverse order,
+	// and determine the expression the chain starts with
+
+	var reversedAccessDocs []prettier.Concat
+	var invocationDocs []prettier.Doc
+
+	var current Expression = e
+
+chain:
+	for {
+		switch expression := current.(type) {
+		case *MemberExpression:
+			accessDoc := prettier.Concat{
+				expression.separatorDoc(),
+				prettier.Text(expression.Identifier.Identifier),
+			}
+			accessDoc = append(accessDoc, invocationDocs...)
+			invocationDocs = nil
+
+			reversedAccessDocs = append(reversedAccessDocs, accessDoc)
+			current = expression.Expression
+
+		case *InvocationExpression:
+			// The invoked member is part of the chain, and so are the arguments,
+			// so the invocation may only be inlined if the invoked member can be inlined, too.
+			// Otherwise, the arguments would be lost
+			invokedExpression, ok := expression.InvokedExpression.(*MemberExpression)
+			if !ok || !isInlinableChainLink(invokedExpression, context) {
+				break chain
+			}
+
+			invocationDocs = expression.argumentsDocs(context)
+			current = invokedExpression
+			continue
+
+		default:
+			break chain
+		}
+
+		if !isInlinableChainLink(current, context) {
+			break
+		}
+	}
+
+	baseDoc := memberTargetDoc(current, context)
+
+	if len(reversedAccessDocs) == 1 {
+		return append(
+			prettier.Concat{baseDoc},
+			reversedAccessDocs[0]...,
+		)
+	}
+
+	accessDocs := make(prettier.Concat, 0, len(reversedAccessDocs)*2)
+	for i := len(reversedAccessDocs) - 1; i >= 0; i-- {
+		accessDocs = append(accessDocs,
+			prettier.SoftLine{},
+			reversedAccessDocs[i],
+		)
 	}
+
 	return prettier.Concat{
-		// TODO: potentially parenthesize
-		e.Expression.Doc(),
+		baseDoc,
 		prettier.Group{
 			Doc: prettier.Indent{
-				Doc: prettier.Concat{
-					prettier.SoftLine{},
-					separatorDoc,
-					prettier.Text(e.Identifier.Identifier),
-				},
+				Doc: accessDocs,
 			},
 		},
 	}
 }
 
+// isInlinableChainLink returns true if the document of the given inner expression of a member chain
+// may be inlined into the document of the chain.
+//
+// The comments attached to the expression are emitted by its own document,
+// and a custom builder may build a different document for it,
+// so in these cases the chain has to start with the expression's own document.
+// The default builder builds the same documents as the Doc methods.
+func isInlinableChainLink(expression Expression, context docContext) bool {
+	return (context.builder == nil || context.builder == DocBuilder(DefaultDocBuilder{})) &&
+		context.trivia[expression] == nil
+}
+
 func (e *MemberExpression) StartPosition() Position {
 	return e.Expression.StartPosition()
 }
@@ -803,20 +1855,36 @@ func (e *IndexExpression) Walk(walkChild func(Element)) {
 func (e *IndexExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitIndexExpression(e)
 }
+
+func (e *IndexExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckIndexExpressionEquality(e, other)
+}
+
+func (e *IndexExpression) Clone() Expression {
+	return &IndexExpression{
+		TargetExpression:   cloneExpression(e.TargetExpression),
+		IndexingExpression: cloneExpression(e.IndexingExpression),
+		Range:              e.Range,
+	}
+}
+
 func (e *IndexExpression) String() string {
-	return fmt.Sprintf(
-		"%s[%s]",
-		e.TargetExpression,
-		e.IndexingExpression,
-	)
+	return writtenExpressionString(e)
+}
+
+func (e *IndexExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 func (e *IndexExpression) Doc() prettier.Doc {
+	return e.doc(docContext{})
+}
+
+func (e *IndexExpression) doc(context docContext) prettier.Doc {
 	return prettier.Concat{
-		// TODO: potentially parenthesize
-		e.TargetExpression.Doc(),
+		subexpressionDoc(e.TargetExpression, PrecedenceUnaryPostfix, context),
 		prettier.WrapBrackets(
-			e.IndexingExpression.Doc(),
+			expressionDoc(e.IndexingExpression, context),
 			prettier.SoftLine{},
 		),
 	}
@@ -860,11 +1928,25 @@ func (e *ConditionalExpression) Walk(walkChild func(Element)) {
 func (e *ConditionalExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitConditionalExpression(e)
 }
+
+func (e *ConditionalExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckConditionalExpressionEquality(e, other)
+}
+
+func (e *ConditionalExpression) Clone() Expression {
+	return &ConditionalExpression{
+		Test: cloneExpression(e.Test),
+		Then: cloneExpression(e.Then),
+		Else: cloneExpression(e.Else),
+	}
+}
+
 func (e *ConditionalExpression) String() string {
-	return fmt.Sprintf(
-		"(%s ? %s : %s)",
-		e.Test, e.Then, e.Else,
-	)
+	return writtenExpressionString(e)
+}
+
+func (e *ConditionalExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 var conditionalExpressionTestSeparatorDoc prettier.Doc = prettier.Concat{
@@ -877,14 +1959,16 @@ var conditionalExpressionBranchSeparatorDoc prettier.Doc = prettier.Concat{
 }
 
 func (e *ConditionalExpression) Doc() prettier.Doc {
-	// TODO: potentially parenthesize
-	testDoc := e.Test.Doc()
-
-	// TODO: potentially parenthesize
-	thenDoc := e.Then.Doc()
+	return e.doc(docContext{})
+}
 
-	// TODO: potentially parenthesize
-	elseDoc := e.Else.Doc()
+func (e *ConditionalExpression) doc(context docContext) prettier.Doc {
+	// The conditional operator is right-associative:
+	// The test must bind tighter than the conditional operator,
+	// but the branches may contain conditional expressions without parentheses
+	testDoc := subexpressionDoc(e.Test, PrecedenceTernary+1, context)
+	thenDoc := subexpressionDoc(e.Then, PrecedenceTernary, context)
+	elseDoc := subexpressionDoc(e.Else, PrecedenceTernary, context)
 
 	return prettier.Group{
 		Doc: prettier.Concat{
@@ -905,12 +1989,18 @@ func (e *ConditionalExpression) Doc() prettier.Doc {
 	}
 }
 
+// StartPosition returns the start position of the test.
+// If the expression is incomplete, i.e. the test is nil,
+// the start position of the first non-nil branch is returned.
 func (e *ConditionalExpression) StartPosition() Position {
-	return e.Test.StartPosition()
+	return firstStartPosition(e.Test, e.Then, e.Else)
 }
 
+// EndPosition returns the end position of the else branch.
+// If the expression is incomplete, i.e. the else branch is nil,
+// the end position of the last non-nil child is returned.
 func (e *ConditionalExpression) EndPosition() Position {
-	return e.Else.EndPosition()
+	return lastEndPosition(e.Test, e.Then, e.Else)
 }
 
 func (e *ConditionalExpression) MarshalJSON() ([]byte, error) {
@@ -928,6 +2018,10 @@ func (e *ConditionalExpression) MarshalJSON() ([]byte, error) {
 
 // UnaryExpression
 
+// UnaryExpression is a prefix operation, e.g. a negation `!a` or a minus `-a`.
+//
+// Moves of resources, e.g. `<-r`, are also unary expressions, with the operation OperationMove,
+// so they do not need a separate expression node.
 type UnaryExpression struct {
 	Operation  Operation
 	Expression Expression
@@ -950,19 +2044,32 @@ func (e *UnaryExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitUnaryExpression(e)
 }
 
+func (e *UnaryExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckUnaryExpressionEquality(e, other)
+}
+
+func (e *UnaryExpression) Clone() Expression {
+	clone := *e
+	clone.Expression = cloneExpression(e.Expression)
+	return &clone
+}
+
 func (e *UnaryExpression) String() string {
-	return fmt.Sprintf(
-		"%s%s",
-		e.Operation.Symbol(),
-		e.Expression,
-	)
+	return writtenExpressionString(e)
+}
+
+func (e *UnaryExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 func (e *UnaryExpression) Doc() prettier.Doc {
+	return e.doc(docContext{})
+}
+
+func (e *UnaryExpression) doc(context docContext) prettier.Doc {
 	return prettier.Concat{
 		prettier.Text(e.Operation.Symbol()),
-		// TODO: potentially parenthesize
-		e.Expression.Doc(),
+		unaryOperandDoc(e.Expression, context),
 	}
 }
 
@@ -1012,19 +2119,33 @@ func (e *BinaryExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitBinaryExpression(e)
 }
 
+func (e *BinaryExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckBinaryExpressionEquality(e, other)
+}
+
+func (e *BinaryExpression) Clone() Expression {
+	return &BinaryExpression{
+		Operation: e.Operation,
+		Left:      cloneExpression(e.Left),
+		Right:     cloneExpression(e.Right),
+	}
+}
+
 func (e *BinaryExpression) String() string {
-	return fmt.Sprintf(
-		"(%s %s %s)",
-		e.Left, e.Operation.Symbol(), e.Right,
-	)
+	return writtenExpressionString(e)
+}
+
+func (e *BinaryExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 func (e *BinaryExpression) Doc() prettier.Doc {
-	// TODO: potentially parenthesize
-	leftDoc := e.Left.Doc()
+	return e.doc(docContext{})
+}
 
-	// TODO: potentially parenthesize
-	rightDoc := e.Right.Doc()
+func (e *BinaryExpression) doc(context docContext) prettier.Doc {
+	leftDoc := binaryOperandDoc(e.Operation, e.Left, true, context)
+	rightDoc := binaryOperandDoc(e.Operation, e.Right, false, context)
 
 	return prettier.Group{
 		Doc: prettier.Concat{
@@ -1041,12 +2162,18 @@ func (e *BinaryExpression) Doc() prettier.Doc {
 	}
 }
 
+// StartPosition returns the start position of the left operand.
+// If the expression is incomplete, i.e. the left operand is nil,
+// the start position of the right operand is returned.
 func (e *BinaryExpression) StartPosition() Position {
-	return e.Left.StartPosition()
+	return firstStartPosition(e.Left, e.Right)
 }
 
+// EndPosition returns the end position of the right operand.
+// If the expression is incomplete, i.e. the right operand is nil,
+// the end position of the left operand is returned.
 func (e *BinaryExpression) EndPosition() Position {
-	return e.Right.EndPosition()
+	return lastEndPosition(e.Left, e.Right)
 }
 
 func (e *BinaryExpression) MarshalJSON() ([]byte, error) {
@@ -1080,34 +2207,67 @@ func (e *FunctionExpression) Accept(visitor Visitor) Repr {
 }
 
 func (e *FunctionExpression) Walk(walkChild func(Element)) {
-	// TODO: walk parameters
-	// TODO: walk return type
 	walkChild(e.FunctionBlock)
 }
 
+// WalkTypes walks the type annotations of the parameters in declaration order,
+// followed by the return type annotation, if any.
+// The function block is walked by Walk, i.e. after the types.
+func (e *FunctionExpression) WalkTypes(walkType func(Type)) {
+	if e.ParameterList != nil {
+		for _, parameter := range e.ParameterList.Parameters {
+			walkType(parameter.TypeAnnotation.Type)
+		}
+	}
+
+	if e.ReturnTypeAnnotation != nil &&
+		!IsEmptyType(e.ReturnTypeAnnotation.Type) {
+
+		walkType(e.ReturnTypeAnnotation.Type)
+	}
+}
+
 func (e *FunctionExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitFunctionExpression(e)
 }
 
+func (e *FunctionExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckFunctionExpressionEquality(e, other)
+}
+
+// Clone returns a deep copy of the function expression.
+// NOTE: the function block is shared, as statements cannot be cloned
+func (e *FunctionExpression) Clone() Expression {
+	return &FunctionExpression{
+		ParameterList:        e.ParameterList.Clone(),
+		ReturnTypeAnnotation: cloneTypeAnnotation(e.ReturnTypeAnnotation),
+		FunctionBlock:        e.FunctionBlock,
+		StartPos:             e.StartPos,
+	}
+}
+
 func (e *FunctionExpression) String() string {
 	// TODO:
 	return "func ..."
 }
 
-var functionExpressionFunKeywordDoc prettier.Doc = prettier.Text("fun ")
-var functionExpressionParameterSeparatorDoc prettier.Doc = prettier.Concat{
-	prettier.Text(","),
-	prettier.Line{},
+func (e *FunctionExpression) CanonicalString() string {
+	return e.String()
 }
 
+var functionExpressionFunKeywordDoc prettier.Doc = prettier.Text("fun ")
 var typeSeparatorDoc prettier.Doc = prettier.Text(": ")
 var functionExpressionBlockStartDoc prettier.Doc = prettier.Text(" {")
 var functionExpressionBlockEndDoc prettier.Doc = prettier.Text("}")
 var functionExpressionEmptyBlockDoc prettier.Doc = prettier.Text(" {}")
 
 func (e *FunctionExpression) Doc() prettier.Doc {
+	return e.doc(docContext{})
+}
+
+func (e *FunctionExpression) doc(context docContext) prettier.Doc {
 
-	signatureDoc := e.parametersDoc()
+	signatureDoc := e.parametersDoc(context)
 
 	if e.ReturnTypeAnnotation != nil &&
 		!IsEmptyType(e.ReturnTypeAnnotation.Type) {
@@ -1115,7 +2275,7 @@ func (e *FunctionExpression) Doc() prettier.Doc {
 		signatureDoc = prettier.Concat{
 			signatureDoc,
 			typeSeparatorDoc,
-			// TODO: type
+			e.ReturnTypeAnnotation.Doc(),
 		}
 	}
 
@@ -1133,7 +2293,7 @@ func (e *FunctionExpression) Doc() prettier.Doc {
 	// TODO: pre-conditions
 	// TODO: post-conditions
 
-	statementsDoc := e.statementsDoc()
+	statementsDoc := e.statementsDoc(context)
 
 	return append(doc,
 		functionExpressionBlockStartDoc,
@@ -1145,7 +2305,7 @@ func (e *FunctionExpression) Doc() prettier.Doc {
 	)
 }
 
-func (e *FunctionExpression) parametersDoc() prettier.Doc {
+func (e *FunctionExpression) parametersDoc(context docContext) prettier.Doc {
 
 	if e.ParameterList == nil ||
 		len(e.ParameterList.Parameters) == 0 {
@@ -1168,37 +2328,37 @@ func (e *FunctionExpression) parametersDoc() prettier.Doc {
 		parameterDoc = append(parameterDoc,
 			prettier.Text(parameter.Identifier.Identifier),
 			typeSeparatorDoc,
+			parameter.TypeAnnotation.Doc(),
 		)
 
-		// TODO: type
-
 		parameterDocs = append(parameterDocs, parameterDoc)
 	}
 
-	return prettier.WrapParentheses(
-		prettier.Join(
-			functionExpressionParameterSeparatorDoc,
-			parameterDocs...,
-		),
-		prettier.SoftLine{},
+	return listDoc(
+		"(",
+		parameterDocs,
+		nil,
+		false,
+		")",
+		context,
 	)
 }
 
-func (e *FunctionExpression) statementsDoc() prettier.Doc {
+func (e *FunctionExpression) statementsDoc(context docContext) prettier.Doc {
 	var statementsDoc prettier.Concat
 
 	statements := e.FunctionBlock.Block.Statements
 
 	for _, statement := range statements {
 		// TODO: replace once Statement implements Doc
-		hasDoc, ok := statement.(interface{ Doc() prettier.Doc })
+		statementDoc, ok := elementDoc(statement, context)
 		if !ok {
 			continue
 		}
 
 		statementsDoc = append(statementsDoc,
 			prettier.HardLine{},
-			hasDoc.Doc(),
+			statementDoc,
 		)
 	}
 
@@ -1244,23 +2404,43 @@ func (e *CastingExpression) Accept(visitor Visitor) Repr {
 }
 func (e *CastingExpression) Walk(walkChild func(Element)) {
 	walkChild(e.Expression)
-	// TODO: also walk type
+}
+
+func (e *CastingExpression) WalkTypes(walkType func(Type)) {
+	walkType(e.TypeAnnotation.Type)
 }
 
 func (e *CastingExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitCastingExpression(e)
 }
 
+func (e *CastingExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckCastingExpressionEquality(e, other)
+}
+
+// Clone returns a deep copy of the casting expression.
+// NOTE: the parent variable declaration is shared
+func (e *CastingExpression) Clone() Expression {
+	clone := *e
+	clone.Expression = cloneExpression(e.Expression)
+	clone.TypeAnnotation = cloneTypeAnnotation(e.TypeAnnotation)
+	return &clone
+}
+
 func (e *CastingExpression) String() string {
-	return fmt.Sprintf(
-		"(%s %s %s)",
-		e.Expression, e.Operation.Symbol(), e.TypeAnnotation,
-	)
+	return writtenExpressionString(e)
+}
+
+func (e *CastingExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 func (e *CastingExpression) Doc() prettier.Doc {
-	// TODO: potentially parenthesize
-	doc := e.Expression.Doc()
+	return e.doc(docContext{})
+}
+
+func (e *CastingExpression) doc(context docContext) prettier.Doc {
+	doc := subexpressionDoc(e.Expression, e.Operation.Precedence(), context)
 
 	return prettier.Group{
 		Doc: prettier.Concat{
@@ -1270,17 +2450,50 @@ func (e *CastingExpression) Doc() prettier.Doc {
 			prettier.Line{},
 			prettier.Text(e.Operation.Symbol()),
 			prettier.Space,
-			// TODO: type
+			e.TypeAnnotation.Doc(),
 		},
 	}
 }
 
+// StartPosition returns the start position of the casted expression.
+// If the expression is incomplete, i.e. the casted expression is nil,
+// the start position of the type annotation is returned.
 func (e *CastingExpression) StartPosition() Position {
-	return e.Expression.StartPosition()
+	if e.Expression != nil {
+		return e.Expression.StartPosition()
+	}
+	if e.TypeAnnotation != nil {
+		return e.TypeAnnotation.StartPosition()
+	}
+	return Position{}
 }
 
+// EndPosition returns the end position of the type annotation.
+// If the expression is incomplete, i.e. the type annotation or its type is nil,
+// the end position of the casted expression is returned.
 func (e *CastingExpression) EndPosition() Position {
-	return e.TypeAnnotation.EndPosition()
+	if e.TypeAnnotation != nil && e.TypeAnnotation.Type != nil {
+		return e.TypeAnnotation.EndPosition()
+	}
+	if e.Expression != nil {
+		return e.Expression.EndPosition()
+	}
+	return Position{}
+}
+
+// IsStatic returns true if the cast is a static cast, i.e. `as`
+func (e *CastingExpression) IsStatic() bool {
+	return e.Operation == OperationCast
+}
+
+// IsFailable returns true if the cast is a failable cast, i.e. `as?`
+func (e *CastingExpression) IsFailable() bool {
+	return e.Operation == OperationFailableCast
+}
+
+// IsForced returns true if the cast is a force cast, i.e. `as!`
+func (e *CastingExpression) IsForced() bool {
+	return e.Operation == OperationForceCast
 }
 
 func (e *CastingExpression) MarshalJSON() ([]byte, error) {
@@ -1319,18 +2532,36 @@ func (e *CreateExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitCreateExpression(e)
 }
 
+func (e *CreateExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckCreateExpressionEquality(e, other)
+}
+
+func (e *CreateExpression) Clone() Expression {
+	clone := *e
+	if e.InvocationExpression != nil {
+		clone.InvocationExpression = e.InvocationExpression.Clone().(*InvocationExpression)
+	}
+	return &clone
+}
+
 func (e *CreateExpression) String() string {
-	return fmt.Sprintf(
-		"(create %s)",
-		e.InvocationExpression,
-	)
+	return writtenExpressionString(e)
+}
+
+func (e *CreateExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 func (e *CreateExpression) Doc() prettier.Doc {
+	return e.doc(docContext{})
+}
+
+func (e *CreateExpression) doc(context docContext) prettier.Doc {
 	return prettier.Concat{
 		prettier.Text("create "),
-		// TODO: potentially parenthesize
-		e.InvocationExpression.Doc(),
+		// The invocation binds tighter than the keyword, so it is never parenthesized,
+		// but guard against invocations of expressions which were constructed programmatically
+		subexpressionDoc(e.InvocationExpression, PrecedenceUnaryPrefix, context),
 	}
 }
 
@@ -1378,20 +2609,37 @@ func (e *DestroyExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitDestroyExpression(e)
 }
 
+func (e *DestroyExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckDestroyExpressionEquality(e, other)
+}
+
+func (e *DestroyExpression) Clone() Expression {
+	clone := *e
+	clone.Expression = cloneExpression(e.Expression)
+	return &clone
+}
+
 func (e *DestroyExpression) String() string {
-	return fmt.Sprintf(
-		"(destroy %s)",
-		e.Expression,
-	)
+	return writtenExpressionString(e)
+}
+
+func (e *DestroyExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 const destroyExpressionKeywordDoc = prettier.Text("destroy ")
 
 func (e *DestroyExpression) Doc() prettier.Doc {
+	return e.doc(docContext{})
+}
+
+func (e *DestroyExpression) doc(context docContext) prettier.Doc {
 	return prettier.Concat{
 		destroyExpressionKeywordDoc,
-		// TODO: potentially parenthesize
-		e.Expression.Doc(),
+		// The parser parses the operand greedily, so e.g. `destroy a ?? b` destroys `a ?? b`,
+		// but operands which bind weaker than the keyword are parenthesized for clarity,
+		// i.e. `destroy (a ?? b)`
+		subexpressionDoc(e.Expression, PrecedenceUnaryPrefix, context),
 	}
 }
 
@@ -1416,6 +2664,91 @@ func (e *DestroyExpression) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// AttachmentExpression
+
+type AttachmentExpression struct {
+	Base       Expression
+	Attachment *InvocationExpression
+	StartPos   Position `json:"-"`
+}
+
+func (*AttachmentExpression) isExpression() {}
+
+func (*AttachmentExpression) isIfStatementTest() {}
+
+func (e *AttachmentExpression) Accept(visitor Visitor) Repr {
+	return e.AcceptExp(visitor)
+}
+
+func (e *AttachmentExpression) Walk(walkChild func(Element)) {
+	walkChild(e.Attachment)
+	walkChild(e.Base)
+}
+
+func (e *AttachmentExpression) AcceptExp(visitor ExpressionVisitor) Repr {
+	return visitor.VisitAttachmentExpression(e)
+}
+
+func (e *AttachmentExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckAttachmentExpressionEquality(e, other)
+}
+
+func (e *AttachmentExpression) Clone() Expression {
+	clone := *e
+	clone.Base = cloneExpression(e.Base)
+	if e.Attachment != nil {
+		clone.Attachment = e.Attachment.Clone().(*InvocationExpression)
+	}
+	return &clone
+}
+
+func (e *AttachmentExpression) String() string {
+	return writtenExpressionString(e)
+}
+
+func (e *AttachmentExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
+}
+
+var attachmentExpressionKeywordDoc prettier.Doc = prettier.Text("attach ")
+var attachmentExpressionBaseSeparatorDoc prettier.Doc = prettier.Text(" to ")
+
+func (e *AttachmentExpression) Doc() prettier.Doc {
+	return e.doc(docContext{})
+}
+
+func (e *AttachmentExpression) doc(context docContext) prettier.Doc {
+	return prettier.Concat{
+		attachmentExpressionKeywordDoc,
+		expressionDoc(e.Attachment, context),
+		attachmentExpressionBaseSeparatorDoc,
+		// The base extends as far to the right as possible,
+		// so it never needs to be parenthesized
+		subexpressionDoc(e.Base, PrecedenceTernary, context),
+	}
+}
+
+func (e *AttachmentExpression) StartPosition() Position {
+	return e.StartPos
+}
+
+func (e *AttachmentExpression) EndPosition() Position {
+	return e.Base.EndPosition()
+}
+
+func (e *AttachmentExpression) MarshalJSON() ([]byte, error) {
+	type Alias AttachmentExpression
+	return json.Marshal(&struct {
+		Type string
+		Range
+		*Alias
+	}{
+		Type:  "AttachmentExpression",
+		Range: NewRangeFromPositioned(e),
+		Alias: (*Alias)(e),
+	})
+}
+
 // ReferenceExpression
 
 type ReferenceExpression struct {
@@ -1434,27 +2767,47 @@ func (e *ReferenceExpression) Accept(visitor Visitor) Repr {
 
 func (e *ReferenceExpression) Walk(walkChild func(Element)) {
 	walkChild(e.Expression)
-	// TODO: walk type
+}
+
+func (e *ReferenceExpression) WalkTypes(walkType func(Type)) {
+	walkType(e.Type)
 }
 
 func (e *ReferenceExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitReferenceExpression(e)
 }
 
+func (e *ReferenceExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckReferenceExpressionEquality(e, other)
+}
+
+func (e *ReferenceExpression) Clone() Expression {
+	clone := *e
+	clone.Expression = cloneExpression(e.Expression)
+	clone.Type = cloneType(e.Type)
+	return &clone
+}
+
 func (e *ReferenceExpression) String() string {
-	return fmt.Sprintf(
-		"(&%s as %s)",
-		e.Expression,
-		e.Type,
-	)
+	return writtenExpressionString(e)
+}
+
+func (e *ReferenceExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 var referenceExpressionRefOperatorDoc prettier.Doc = prettier.Text("&")
 var referenceExpressionAsOperatorDoc prettier.Doc = prettier.Text("as")
 
 func (e *ReferenceExpression) Doc() prettier.Doc {
-	// TODO: potentially parenthesize
-	doc := e.Expression.Doc()
+	return e.doc(docContext{})
+}
+
+func (e *ReferenceExpression) doc(context docContext) prettier.Doc {
+	// The parser parses the operand and the type as a casting expression,
+	// so the operand must bind at least as tight as a casting expression,
+	// e.g. `&(a ?? b) as &T`
+	doc := subexpressionDoc(e.Expression, PrecedenceCasting, context)
 
 	return prettier.Group{
 		Doc: prettier.Concat{
@@ -1465,7 +2818,7 @@ func (e *ReferenceExpression) Doc() prettier.Doc {
 			prettier.Line{},
 			referenceExpressionAsOperatorDoc,
 			prettier.Space,
-			// TODO: type
+			e.Type.Doc(),
 		},
 	}
 }
@@ -1478,6 +2831,19 @@ func (e *ReferenceExpression) EndPosition() Position {
 	return e.Type.EndPosition()
 }
 
+// BorrowType returns the type the referenced value is borrowed as,
+// e.g. `&T` for the reference expression `&x as &T`
+func (e *ReferenceExpression) BorrowType() Type {
+	return e.Type
+}
+
+// IsAuthorized returns true if the borrow type is an authorized reference type,
+// e.g. `auth &T`
+func (e *ReferenceExpression) IsAuthorized() bool {
+	referenceType, ok := e.Type.(*ReferenceType)
+	return ok && referenceType.Authorized
+}
+
 func (e *ReferenceExpression) MarshalJSON() ([]byte, error) {
 	type Alias ReferenceExpression
 	return json.Marshal(&struct {
@@ -1514,16 +2880,33 @@ func (e *ForceExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitForceExpression(e)
 }
 
+func (e *ForceExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckForceExpressionEquality(e, other)
+}
+
+func (e *ForceExpression) Clone() Expression {
+	clone := *e
+	clone.Expression = cloneExpression(e.Expression)
+	return &clone
+}
+
 func (e *ForceExpression) String() string {
-	return fmt.Sprintf("%s!", e.Expression)
+	return writtenExpressionString(e)
+}
+
+func (e *ForceExpression) CanonicalString() string {
+	return writtenCanonicalExpressionString(e)
 }
 
 const forceExpressionOperatorDoc = prettier.Text("!")
 
 func (e *ForceExpression) Doc() prettier.Doc {
+	return e.doc(docContext{})
+}
+
+func (e *ForceExpression) doc(context docContext) prettier.Doc {
 	return prettier.Concat{
-		// TODO: potentially parenthesize
-		e.Expression.Doc(),
+		subexpressionDoc(e.Expression, PrecedenceUnaryPostfix, context),
 		forceExpressionOperatorDoc,
 	}
 }
@@ -1573,10 +2956,23 @@ func (e *PathExpression) AcceptExp(visitor ExpressionVisitor) Repr {
 	return visitor.VisitPathExpression(e)
 }
 
+func (e *PathExpression) CheckEqual(other Expression, checker ExpressionEqualityChecker) error {
+	return checker.CheckPathExpressionEquality(e, other)
+}
+
+func (e *PathExpression) Clone() Expression {
+	clone := *e
+	return &clone
+}
+
 func (e *PathExpression) String() string {
 	return fmt.Sprintf("/%s/%s", e.Domain, e.Identifier)
 }
 
+func (e *PathExpression) CanonicalString() string {
+	return e.String()
+}
+
 func (e *PathExpression) Doc() prettier.Doc {
 	return prettier.Text(e.String())
 }
@@ -1589,6 +2985,21 @@ func (e *PathExpression) EndPosition() Position {
 	return e.Identifier.EndPosition()
 }
 
+// DomainKind returns the domain of the path,
+// and false if the domain is not one of the valid domains
+// `storage`, `private`, or `public`.
+func (e *PathExpression) DomainKind() (common.PathDomain, bool) {
+	domain, ok := common.AllPathDomainsByIdentifier[e.Domain.Identifier]
+	return domain, ok
+}
+
+// IsValidDomain returns true if the domain of the path is valid,
+// i.e. one of `storage`, `private`, or `public`.
+func (e *PathExpression) IsValidDomain() bool {
+	_, ok := e.DomainKind()
+	return ok
+}
+
 func (e *PathExpression) MarshalJSON() ([]byte, error) {
 	type Alias PathExpression
 	return json.Marshal(&struct {
@@ -1601,3 +3012,30 @@ func (e *PathExpression) MarshalJSON() ([]byte, error) {
 		Alias: (*Alias)(e),
 	})
 }
+
+type ExpressionEqualityChecker interface {
+	CheckBoolExpressionEquality(*BoolExpression, Expression) error
+	CheckNilExpressionEquality(*NilExpression, Expression) error
+	CheckVoidExpressionEquality(*VoidExpression, Expression) error
+	CheckStringExpressionEquality(*StringExpression, Expression) error
+	CheckStringTemplateExpressionEquality(*StringTemplateExpression, Expression) error
+	CheckIntegerExpressionEquality(*IntegerExpression, Expression) error
+	CheckFixedPointExpressionEquality(*FixedPointExpression, Expression) error
+	CheckArrayExpressionEquality(*ArrayExpression, Expression) error
+	CheckDictionaryExpressionEquality(*DictionaryExpression, Expression) error
+	CheckIdentifierExpressionEquality(*IdentifierExpression, Expression) error
+	CheckInvocationExpressionEquality(*InvocationExpression, Expression) error
+	CheckMemberExpressionEquality(*MemberExpression, Expression) error
+	CheckIndexExpressionEquality(*IndexExpression, Expression) error
+	CheckConditionalExpressionEquality(*ConditionalExpression, Expression) error
+	CheckUnaryExpressionEquality(*UnaryExpression, Expression) error
+	CheckBinaryExpressionEquality(*BinaryExpression, Expression) error
+	CheckFunctionExpressionEquality(*FunctionExpression, Expression) error
+	CheckCastingExpressionEquality(*CastingExpression, Expression) error
+	CheckCreateExpressionEquality(*CreateExpression, Expression) error
+	CheckDestroyExpressionEquality(*DestroyExpression, Expression) error
+	CheckAttachmentExpressionEquality(*AttachmentExpression, Expression) error
+	CheckReferenceExpressionEquality(*ReferenceExpression, Expression) error
+	CheckForceExpressionEquality(*ForceExpression, Expression) error
+	CheckPathExpressionEquality(*PathExpression, Expression) error
+}
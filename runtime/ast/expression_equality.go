@@ -0,0 +1,537 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// ExpressionMismatchError is returned by the DefaultExpressionEqualityChecker
+// for the first pair of expressions which are not equal
+type ExpressionMismatchError struct {
+	Expected Expression
+	Found    Expression
+}
+
+func (e *ExpressionMismatchError) Error() string {
+	return fmt.Sprintf(
+		"expression mismatch. expected `%s`, found `%s`",
+		expressionString(e.Expected),
+		expressionString(e.Found),
+	)
+}
+
+func expressionString(expression Expression) string {
+	if expression == nil {
+		return "<nil>"
+	}
+	return expression.String()
+}
+
+// TypeAnnotationMismatchError is returned by the DefaultExpressionEqualityChecker
+// for the first pair of type annotations which are not equal
+type TypeAnnotationMismatchError struct {
+	Expected *TypeAnnotation
+	Found    *TypeAnnotation
+}
+
+func (e *TypeAnnotationMismatchError) Error() string {
+	return fmt.Sprintf(
+		"type annotation mismatch. expected `%s`, found `%s`",
+		e.Expected,
+		e.Found,
+	)
+}
+
+// DefaultExpressionEqualityChecker is an ExpressionEqualityChecker
+// which compares expressions structurally, ignoring positions.
+//
+// By default, literals are compared by value, e.g. the integer literals `0x0A` and `10` are equal.
+// If RequireIdenticalLiterals is set, the spelling of literals must also be identical.
+//
+// The bodies of function expressions are not compared structurally,
+// as statements cannot be checked for equality:
+// Function expressions are only equal if their function blocks are the same,
+// or are both empty.
+type DefaultExpressionEqualityChecker struct {
+	// TypeEqualityChecker is used to check the equality of types in expressions,
+	// e.g. the target type of casting expressions.
+	// If nil, the DefaultTypeEqualityChecker is used.
+	TypeEqualityChecker TypeEqualityChecker
+	// RequireIdenticalLiterals requires numeric literals to be spelled identically,
+	// e.g. the integer literals `0x0A` and `10` are not equal
+	RequireIdenticalLiterals bool
+}
+
+// EqualExpressions returns true if the given expressions are structurally equal,
+// ignoring positions. Numeric literals are compared by value.
+func EqualExpressions(a, b Expression) bool {
+	return DefaultExpressionEqualityChecker{}.checkEqual(a, b) == nil
+}
+
+// EqualExpressionsLiterally returns true if the given expressions are structurally equal,
+// ignoring positions. Numeric literals must be spelled identically,
+// which is useful to test formatters.
+func EqualExpressionsLiterally(a, b Expression) bool {
+	checker := DefaultExpressionEqualityChecker{
+		RequireIdenticalLiterals: true,
+	}
+	return checker.checkEqual(a, b) == nil
+}
+
+// EqualTypes returns true if the given types are structurally equal,
+// ignoring positions.
+func EqualTypes(a, b Type) bool {
+	return checkTypeEqual(a, b, DefaultTypeEqualityChecker{}) == nil
+}
+
+var _ ExpressionEqualityChecker = DefaultExpressionEqualityChecker{}
+
+func (c DefaultExpressionEqualityChecker) checkEqual(expected Expression, found Expression) error {
+	if expected == nil || found == nil {
+		if expected == nil && found == nil {
+			return nil
+		}
+		return &ExpressionMismatchError{
+			Expected: expected,
+			Found:    found,
+		}
+	}
+	return expected.CheckEqual(found, c)
+}
+
+func (c DefaultExpressionEqualityChecker) checkTypeEqual(expected Type, found Type) error {
+	typeEqualityChecker := c.TypeEqualityChecker
+	if typeEqualityChecker == nil {
+		typeEqualityChecker = DefaultTypeEqualityChecker{}
+	}
+	return checkTypeEqual(expected, found, typeEqualityChecker)
+}
+
+func (c DefaultExpressionEqualityChecker) checkTypeAnnotationEqual(expected *TypeAnnotation, found *TypeAnnotation) error {
+	if expected == nil || found == nil {
+		if expected == nil && found == nil {
+			return nil
+		}
+		return &TypeAnnotationMismatchError{
+			Expected: expected,
+			Found:    found,
+		}
+	}
+
+	if expected.IsResource != found.IsResource ||
+		c.checkTypeEqual(expected.Type, found.Type) != nil {
+
+		return &TypeAnnotationMismatchError{
+			Expected: expected,
+			Found:    found,
+		}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckBoolExpressionEquality(expected *BoolExpression, found Expression) error {
+	foundBoolExpression, ok := found.(*BoolExpression)
+	if !ok || expected.Value != foundBoolExpression.Value {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckNilExpressionEquality(expected *NilExpression, found Expression) error {
+	_, ok := found.(*NilExpression)
+	if !ok {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckVoidExpressionEquality(expected *VoidExpression, found Expression) error {
+	_, ok := found.(*VoidExpression)
+	if !ok {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckStringExpressionEquality(expected *StringExpression, found Expression) error {
+	foundStringExpression, ok := found.(*StringExpression)
+	if !ok || expected.Value != foundStringExpression.Value {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckStringTemplateExpressionEquality(expected *StringTemplateExpression, found Expression) error {
+	foundStringTemplateExpression, ok := found.(*StringTemplateExpression)
+	if !ok ||
+		len(expected.Values) != len(foundStringTemplateExpression.Values) ||
+		len(expected.Segments) != len(foundStringTemplateExpression.Segments) {
+
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	for i, expectedSegment := range expected.Segments {
+		if expectedSegment != foundStringTemplateExpression.Segments[i] {
+			return &ExpressionMismatchError{Expected: expected, Found: found}
+		}
+	}
+
+	for i, expectedValue := range expected.Values {
+		err := c.checkEqual(expectedValue, foundStringTemplateExpression.Values[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckIntegerExpressionEquality(expected *IntegerExpression, found Expression) error {
+	foundIntegerExpression, ok := found.(*IntegerExpression)
+	if !ok || !equalBigInts(expected.Value, foundIntegerExpression.Value) {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	if c.RequireIdenticalLiterals &&
+		(expected.PositiveLiteral != foundIntegerExpression.PositiveLiteral ||
+			expected.Base != foundIntegerExpression.Base) {
+
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckFixedPointExpressionEquality(expected *FixedPointExpression, found Expression) error {
+	foundFixedPointExpression, ok := found.(*FixedPointExpression)
+	if !ok ||
+		expected.Negative != foundFixedPointExpression.Negative ||
+		expected.Scale != foundFixedPointExpression.Scale ||
+		!equalBigInts(expected.UnsignedInteger, foundFixedPointExpression.UnsignedInteger) ||
+		!equalBigInts(expected.Fractional, foundFixedPointExpression.Fractional) {
+
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	if c.RequireIdenticalLiterals &&
+		expected.PositiveLiteral != foundFixedPointExpression.PositiveLiteral {
+
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckArrayExpressionEquality(expected *ArrayExpression, found Expression) error {
+	foundArrayExpression, ok := found.(*ArrayExpression)
+	if !ok || len(expected.Values) != len(foundArrayExpression.Values) {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	for i, expectedValue := range expected.Values {
+		err := c.checkEqual(expectedValue, foundArrayExpression.Values[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckDictionaryExpressionEquality(expected *DictionaryExpression, found Expression) error {
+	foundDictionaryExpression, ok := found.(*DictionaryExpression)
+	if !ok || len(expected.Entries) != len(foundDictionaryExpression.Entries) {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	for i, expectedEntry := range expected.Entries {
+		foundEntry := foundDictionaryExpression.Entries[i]
+
+		err := c.checkEqual(expectedEntry.Key, foundEntry.Key)
+		if err != nil {
+			return err
+		}
+
+		err = c.checkEqual(expectedEntry.Value, foundEntry.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckIdentifierExpressionEquality(expected *IdentifierExpression, found Expression) error {
+	foundIdentifierExpression, ok := found.(*IdentifierExpression)
+	if !ok || expected.Identifier.Identifier != foundIdentifierExpression.Identifier.Identifier {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckInvocationExpressionEquality(expected *InvocationExpression, found Expression) error {
+	foundInvocationExpression, ok := found.(*InvocationExpression)
+	if !ok ||
+		len(expected.TypeArguments) != len(foundInvocationExpression.TypeArguments) ||
+		len(expected.Arguments) != len(foundInvocationExpression.Arguments) {
+
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	err := c.checkEqual(expected.InvokedExpression, foundInvocationExpression.InvokedExpression)
+	if err != nil {
+		return err
+	}
+
+	for i, expectedTypeArgument := range expected.TypeArguments {
+		err = c.checkTypeAnnotationEqual(expectedTypeArgument, foundInvocationExpression.TypeArguments[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, expectedArgument := range expected.Arguments {
+		foundArgument := foundInvocationExpression.Arguments[i]
+
+		if expectedArgument.Label != foundArgument.Label {
+			return &ExpressionMismatchError{Expected: expected, Found: found}
+		}
+
+		err = c.checkEqual(expectedArgument.Expression, foundArgument.Expression)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckMemberExpressionEquality(expected *MemberExpression, found Expression) error {
+	foundMemberExpression, ok := found.(*MemberExpression)
+	if !ok ||
+		expected.Optional != foundMemberExpression.Optional ||
+		expected.Identifier.Identifier != foundMemberExpression.Identifier.Identifier {
+
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return c.checkEqual(expected.Expression, foundMemberExpression.Expression)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckIndexExpressionEquality(expected *IndexExpression, found Expression) error {
+	foundIndexExpression, ok := found.(*IndexExpression)
+	if !ok {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	err := c.checkEqual(expected.TargetExpression, foundIndexExpression.TargetExpression)
+	if err != nil {
+		return err
+	}
+
+	return c.checkEqual(expected.IndexingExpression, foundIndexExpression.IndexingExpression)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckConditionalExpressionEquality(expected *ConditionalExpression, found Expression) error {
+	foundConditionalExpression, ok := found.(*ConditionalExpression)
+	if !ok {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	err := c.checkEqual(expected.Test, foundConditionalExpression.Test)
+	if err != nil {
+		return err
+	}
+
+	err = c.checkEqual(expected.Then, foundConditionalExpression.Then)
+	if err != nil {
+		return err
+	}
+
+	return c.checkEqual(expected.Else, foundConditionalExpression.Else)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckUnaryExpressionEquality(expected *UnaryExpression, found Expression) error {
+	foundUnaryExpression, ok := found.(*UnaryExpression)
+	if !ok || expected.Operation != foundUnaryExpression.Operation {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return c.checkEqual(expected.Expression, foundUnaryExpression.Expression)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckBinaryExpressionEquality(expected *BinaryExpression, found Expression) error {
+	foundBinaryExpression, ok := found.(*BinaryExpression)
+	if !ok || expected.Operation != foundBinaryExpression.Operation {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	err := c.checkEqual(expected.Left, foundBinaryExpression.Left)
+	if err != nil {
+		return err
+	}
+
+	return c.checkEqual(expected.Right, foundBinaryExpression.Right)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckFunctionExpressionEquality(expected *FunctionExpression, found Expression) error {
+	foundFunctionExpression, ok := found.(*FunctionExpression)
+	if !ok {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	var expectedParameters, foundParameters []*Parameter
+	if expected.ParameterList != nil {
+		expectedParameters = expected.ParameterList.Parameters
+	}
+	if foundFunctionExpression.ParameterList != nil {
+		foundParameters = foundFunctionExpression.ParameterList.Parameters
+	}
+
+	if len(expectedParameters) != len(foundParameters) {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	for i, expectedParameter := range expectedParameters {
+		foundParameter := foundParameters[i]
+
+		if expectedParameter.Label != foundParameter.Label ||
+			expectedParameter.Identifier.Identifier != foundParameter.Identifier.Identifier {
+
+			return &ExpressionMismatchError{Expected: expected, Found: found}
+		}
+
+		err := c.checkTypeAnnotationEqual(expectedParameter.TypeAnnotation, foundParameter.TypeAnnotation)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := c.checkTypeAnnotationEqual(expected.ReturnTypeAnnotation, foundFunctionExpression.ReturnTypeAnnotation)
+	if err != nil {
+		return err
+	}
+
+	if expected.FunctionBlock != foundFunctionExpression.FunctionBlock &&
+		!(expected.FunctionBlock.IsEmpty() && foundFunctionExpression.FunctionBlock.IsEmpty()) {
+
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return nil
+}
+
+func (c DefaultExpressionEqualityChecker) CheckCastingExpressionEquality(expected *CastingExpression, found Expression) error {
+	foundCastingExpression, ok := found.(*CastingExpression)
+	if !ok || expected.Operation != foundCastingExpression.Operation {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	err := c.checkEqual(expected.Expression, foundCastingExpression.Expression)
+	if err != nil {
+		return err
+	}
+
+	return c.checkTypeAnnotationEqual(expected.TypeAnnotation, foundCastingExpression.TypeAnnotation)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckCreateExpressionEquality(expected *CreateExpression, found Expression) error {
+	foundCreateExpression, ok := found.(*CreateExpression)
+	if !ok {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return c.checkEqual(expected.InvocationExpression, foundCreateExpression.InvocationExpression)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckDestroyExpressionEquality(expected *DestroyExpression, found Expression) error {
+	foundDestroyExpression, ok := found.(*DestroyExpression)
+	if !ok {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return c.checkEqual(expected.Expression, foundDestroyExpression.Expression)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckAttachmentExpressionEquality(expected *AttachmentExpression, found Expression) error {
+	foundAttachmentExpression, ok := found.(*AttachmentExpression)
+	if !ok {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	err := c.checkEqual(expected.Attachment, foundAttachmentExpression.Attachment)
+	if err != nil {
+		return err
+	}
+
+	return c.checkEqual(expected.Base, foundAttachmentExpression.Base)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckReferenceExpressionEquality(expected *ReferenceExpression, found Expression) error {
+	foundReferenceExpression, ok := found.(*ReferenceExpression)
+	if !ok {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	err := c.checkEqual(expected.Expression, foundReferenceExpression.Expression)
+	if err != nil {
+		return err
+	}
+
+	return c.checkTypeEqual(expected.Type, foundReferenceExpression.Type)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckForceExpressionEquality(expected *ForceExpression, found Expression) error {
+	foundForceExpression, ok := found.(*ForceExpression)
+	if !ok {
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return c.checkEqual(expected.Expression, foundForceExpression.Expression)
+}
+
+func (c DefaultExpressionEqualityChecker) CheckPathExpressionEquality(expected *PathExpression, found Expression) error {
+	foundPathExpression, ok := found.(*PathExpression)
+	if !ok ||
+		expected.Domain.Identifier != foundPathExpression.Domain.Identifier ||
+		expected.Identifier.Identifier != foundPathExpression.Identifier.Identifier {
+
+		return &ExpressionMismatchError{Expected: expected, Found: found}
+	}
+
+	return nil
+}
+
+// equalBigInts returns true if the given integers are equal.
+// The integers may be nil, e.g. for incomplete literals constructed programmatically:
+// A nil integer is only equal to another nil integer.
+func equalBigInts(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Cmp(b) == 0
+}
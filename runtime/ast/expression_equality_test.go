@@ -0,0 +1,353 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultExpressionEqualityChecker(t *testing.T) {
+
+	t.Parallel()
+
+	checker := DefaultExpressionEqualityChecker{}
+
+	newExpression := func(offset int, value int64) Expression {
+		return &BinaryExpression{
+			Operation: OperationPlus,
+			Left: &IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "a",
+					Pos:        Position{Offset: offset, Line: 1, Column: offset},
+				},
+			},
+			Right: &InvocationExpression{
+				InvokedExpression: &MemberExpression{
+					Expression: &IdentifierExpression{
+						Identifier: Identifier{
+							Identifier: "b",
+							Pos:        Position{Offset: offset + 4, Line: 1, Column: offset + 4},
+						},
+					},
+					Identifier: Identifier{
+						Identifier: "c",
+					},
+				},
+				Arguments: []*Argument{
+					{
+						Label: "x",
+						Expression: &IntegerExpression{
+							PositiveLiteral: big.NewInt(value).String(),
+							Value:           big.NewInt(value),
+							Base:            10,
+							Range: Range{
+								StartPos: Position{Offset: offset + 10, Line: 1, Column: offset + 10},
+								EndPos:   Position{Offset: offset + 11, Line: 1, Column: offset + 11},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("equal, different positions", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := newExpression(0, 42).CheckEqual(newExpression(10, 42), checker)
+		require.NoError(t, err)
+	})
+
+	t.Run("different integer values", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := newExpression(0, 42).CheckEqual(newExpression(0, 43), checker)
+		require.Error(t, err)
+
+		var mismatchErr *ExpressionMismatchError
+		require.ErrorAs(t, err, &mismatchErr)
+
+		assert.Equal(t, "42", mismatchErr.Expected.String())
+		assert.Equal(t, "43", mismatchErr.Found.String())
+		assert.Equal(t, "expression mismatch. expected `42`, found `43`", err.Error())
+	})
+
+	t.Run("same integer value, different literal", func(t *testing.T) {
+
+		t.Parallel()
+
+		expected := &IntegerExpression{
+			PositiveLiteral: "0x0A",
+			Value:           big.NewInt(10),
+			Base:            16,
+		}
+
+		found := &IntegerExpression{
+			PositiveLiteral: "10",
+			Value:           big.NewInt(10),
+			Base:            10,
+		}
+
+		require.NoError(t, expected.CheckEqual(found, checker))
+	})
+
+	t.Run("different kinds", func(t *testing.T) {
+
+		t.Parallel()
+
+		expected := &NilExpression{}
+		found := &BoolExpression{Value: false}
+
+		err := expected.CheckEqual(found, checker)
+		require.Error(t, err)
+		assert.Equal(t, "expression mismatch. expected `nil`, found `false`", err.Error())
+	})
+
+	t.Run("different operations", func(t *testing.T) {
+
+		t.Parallel()
+
+		expected := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      newTestIdentifierExpression("a"),
+			Right:     newTestIdentifierExpression("b"),
+		}
+		found := &BinaryExpression{
+			Operation: OperationMinus,
+			Left:      newTestIdentifierExpression("a"),
+			Right:     newTestIdentifierExpression("b"),
+		}
+
+		err := expected.CheckEqual(found, checker)
+		require.Error(t, err)
+		assert.Equal(t, "expression mismatch. expected `a + b`, found `a - b`", err.Error())
+	})
+
+	t.Run("fixed-point values", func(t *testing.T) {
+
+		t.Parallel()
+
+		newFixedPoint := func(fractional int64) *FixedPointExpression {
+			return &FixedPointExpression{
+				PositiveLiteral: "",
+				UnsignedInteger: big.NewInt(1),
+				Fractional:      big.NewInt(fractional),
+				Scale:           2,
+			}
+		}
+
+		require.NoError(t, newFixedPoint(5).CheckEqual(newFixedPoint(5), checker))
+		require.Error(t, newFixedPoint(5).CheckEqual(newFixedPoint(6), checker))
+	})
+
+	t.Run("casting types", func(t *testing.T) {
+
+		t.Parallel()
+
+		newCasting := func(isResource bool, typeName string) *CastingExpression {
+			return &CastingExpression{
+				Operation:  OperationForceCast,
+				Expression: newTestIdentifierExpression("x"),
+				TypeAnnotation: &TypeAnnotation{
+					IsResource: isResource,
+					Type: &NominalType{
+						Identifier: Identifier{
+							Identifier: typeName,
+						},
+					},
+				},
+			}
+		}
+
+		require.NoError(t, newCasting(true, "R").CheckEqual(newCasting(true, "R"), checker))
+
+		err := newCasting(true, "R").CheckEqual(newCasting(false, "R"), checker)
+		require.Error(t, err)
+		assert.IsType(t, &TypeAnnotationMismatchError{}, err)
+
+		err = newCasting(true, "R").CheckEqual(newCasting(true, "S"), checker)
+		require.Error(t, err)
+		assert.IsType(t, &TypeAnnotationMismatchError{}, err)
+	})
+
+	t.Run("array and dictionary", func(t *testing.T) {
+
+		t.Parallel()
+
+		newArray := func(values ...string) *ArrayExpression {
+			array := &ArrayExpression{}
+			for _, value := range values {
+				array.Values = append(array.Values, &StringExpression{Value: value})
+			}
+			return array
+		}
+
+		require.NoError(t, newArray("a", "b").CheckEqual(newArray("a", "b"), checker))
+		require.Error(t, newArray("a", "b").CheckEqual(newArray("a"), checker))
+		require.Error(t, newArray("a", "b").CheckEqual(newArray("a", "c"), checker))
+
+		newDictionary := func(key, value string) *DictionaryExpression {
+			return &DictionaryExpression{
+				Entries: []DictionaryEntry{
+					{
+						Key:   &StringExpression{Value: key},
+						Value: newTestIdentifierExpression(value),
+					},
+				},
+			}
+		}
+
+		require.NoError(t, newDictionary("a", "b").CheckEqual(newDictionary("a", "b"), checker))
+		require.Error(t, newDictionary("a", "b").CheckEqual(newDictionary("a", "c"), checker))
+		require.Error(t, newDictionary("a", "b").CheckEqual(newDictionary("c", "b"), checker))
+	})
+}
+
+func TestEqualExpressions(t *testing.T) {
+
+	t.Parallel()
+
+	decimal := &IntegerExpression{
+		PositiveLiteral: "10",
+		Value:           big.NewInt(10),
+		Base:            10,
+		Range: Range{
+			StartPos: Position{Offset: 1, Line: 1, Column: 1},
+			EndPos:   Position{Offset: 2, Line: 1, Column: 2},
+		},
+	}
+
+	otherDecimal := &IntegerExpression{
+		PositiveLiteral: "10",
+		Value:           big.NewInt(10),
+		Base:            10,
+		Range: Range{
+			StartPos: Position{Offset: 5, Line: 2, Column: 3},
+			EndPos:   Position{Offset: 6, Line: 2, Column: 4},
+		},
+	}
+
+	hexadecimal := &IntegerExpression{
+		PositiveLiteral: "0x0A",
+		Value:           big.NewInt(10),
+		Base:            16,
+	}
+
+	t.Run("semantic", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.True(t, EqualExpressions(decimal, otherDecimal))
+		assert.True(t, EqualExpressions(decimal, hexadecimal))
+		assert.False(t, EqualExpressions(decimal, newTestIdentifierExpression("a")))
+		assert.False(t, EqualExpressions(decimal, nil))
+		assert.True(t, EqualExpressions(nil, nil))
+	})
+
+	t.Run("literally", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.True(t, EqualExpressionsLiterally(decimal, otherDecimal))
+		assert.False(t, EqualExpressionsLiterally(decimal, hexadecimal))
+	})
+
+	t.Run("parenthesization does not matter", func(t *testing.T) {
+
+		t.Parallel()
+
+		// `(a + b) + c` and `a + b + c` are the same tree
+
+		newExpression := func() Expression {
+			return &BinaryExpression{
+				Operation: OperationPlus,
+				Left: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      newTestIdentifierExpression("a"),
+					Right:     newTestIdentifierExpression("b"),
+				},
+				Right: newTestIdentifierExpression("c"),
+			}
+		}
+
+		assert.True(t, EqualExpressions(newExpression(), newExpression()))
+	})
+
+	t.Run("nil values", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.True(t, EqualExpressions(&IntegerExpression{}, &IntegerExpression{}))
+		assert.False(t, EqualExpressions(&IntegerExpression{}, decimal))
+		assert.False(t, EqualExpressions(decimal, &IntegerExpression{}))
+
+		fixedPoint := &FixedPointExpression{
+			UnsignedInteger: big.NewInt(1),
+			Fractional:      big.NewInt(5),
+			Scale:           1,
+		}
+
+		assert.True(t, EqualExpressions(&FixedPointExpression{}, &FixedPointExpression{}))
+		assert.False(t, EqualExpressions(&FixedPointExpression{}, fixedPoint))
+		assert.False(t, EqualExpressions(fixedPoint, &FixedPointExpression{}))
+	})
+}
+
+func TestEqualTypes(t *testing.T) {
+
+	t.Parallel()
+
+	newOptionalType := func(identifier string, offset int) Type {
+		return &OptionalType{
+			Type: &NominalType{
+				Identifier: Identifier{
+					Identifier: identifier,
+					Pos:        Position{Offset: offset, Line: 1, Column: offset},
+				},
+			},
+			EndPos: Position{Offset: offset + 2, Line: 1, Column: offset + 2},
+		}
+	}
+
+	assert.True(t, EqualTypes(newOptionalType("Int", 0), newOptionalType("Int", 10)))
+	assert.False(t, EqualTypes(newOptionalType("Int", 0), newOptionalType("String", 0)))
+	assert.False(t, EqualTypes(newOptionalType("Int", 0), nil))
+
+	newConstantSizedType := func(size *big.Int) Type {
+		return &ConstantSizedType{
+			Type: &NominalType{
+				Identifier: Identifier{Identifier: "Int"},
+			},
+			Size: &IntegerExpression{
+				Value: size,
+				Base:  10,
+			},
+		}
+	}
+
+	assert.True(t, EqualTypes(newConstantSizedType(nil), newConstantSizedType(nil)))
+	assert.False(t, EqualTypes(newConstantSizedType(nil), newConstantSizedType(big.NewInt(2))))
+	assert.False(t, EqualTypes(newConstantSizedType(big.NewInt(2)), newConstantSizedType(nil)))
+}
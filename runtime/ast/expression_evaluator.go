@@ -0,0 +1,251 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"github.com/onflow/cadence/runtime/errors"
+)
+
+// ExpressionEvaluator is like ExpressionVisitor,
+// but its Visit methods return a result of type T instead of Repr,
+// so callers do not need to assert the type of the result.
+//
+// Expressions are dispatched to an evaluator using EvalExpression.
+// Every ExpressionVisitor is an ExpressionEvaluator[Repr].
+//
+// NOTE: only available when building with Go 1.21 or later, as it uses type parameters:
+// The module declares an older language version, and only since Go 1.21,
+// the build constraint of a file also upgrades the language version of the file.
+type ExpressionEvaluator[T any] interface {
+	VisitBoolExpression(*BoolExpression) T
+	VisitNilExpression(*NilExpression) T
+	VisitVoidExpression(*VoidExpression) T
+	VisitIntegerExpression(*IntegerExpression) T
+	VisitFixedPointExpression(*FixedPointExpression) T
+	VisitArrayExpression(*ArrayExpression) T
+	VisitDictionaryExpression(*DictionaryExpression) T
+	VisitIdentifierExpression(*IdentifierExpression) T
+	VisitInvocationExpression(*InvocationExpression) T
+	VisitMemberExpression(*MemberExpression) T
+	VisitIndexExpression(*IndexExpression) T
+	VisitConditionalExpression(*ConditionalExpression) T
+	VisitUnaryExpression(*UnaryExpression) T
+	VisitBinaryExpression(*BinaryExpression) T
+	VisitFunctionExpression(*FunctionExpression) T
+	VisitStringExpression(*StringExpression) T
+	VisitStringTemplateExpression(*StringTemplateExpression) T
+	VisitCastingExpression(*CastingExpression) T
+	VisitCreateExpression(*CreateExpression) T
+	VisitDestroyExpression(*DestroyExpression) T
+	VisitAttachmentExpression(*AttachmentExpression) T
+	VisitReferenceExpression(*ReferenceExpression) T
+	VisitForceExpression(*ForceExpression) T
+	VisitPathExpression(*PathExpression) T
+}
+
+var _ ExpressionEvaluator[Repr] = BaseExpressionVisitor{}
+
+// EvalExpression calls the Visit method of the given evaluator
+// which corresponds to the kind of the given expression, and returns its result.
+//
+// It is the typed equivalent of Expression.AcceptExp.
+func EvalExpression[T any](expression Expression, evaluator ExpressionEvaluator[T]) T {
+	switch expression := expression.(type) {
+	case *BoolExpression:
+		return evaluator.VisitBoolExpression(expression)
+
+	case *NilExpression:
+		return evaluator.VisitNilExpression(expression)
+
+	case *VoidExpression:
+		return evaluator.VisitVoidExpression(expression)
+
+	case *IntegerExpression:
+		return evaluator.VisitIntegerExpression(expression)
+
+	case *FixedPointExpression:
+		return evaluator.VisitFixedPointExpression(expression)
+
+	case *ArrayExpression:
+		return evaluator.VisitArrayExpression(expression)
+
+	case *DictionaryExpression:
+		return evaluator.VisitDictionaryExpression(expression)
+
+	case *IdentifierExpression:
+		return evaluator.VisitIdentifierExpression(expression)
+
+	case *InvocationExpression:
+		return evaluator.VisitInvocationExpression(expression)
+
+	case *MemberExpression:
+		return evaluator.VisitMemberExpression(expression)
+
+	case *IndexExpression:
+		return evaluator.VisitIndexExpression(expression)
+
+	case *ConditionalExpression:
+		return evaluator.VisitConditionalExpression(expression)
+
+	case *UnaryExpression:
+		return evaluator.VisitUnaryExpression(expression)
+
+	case *BinaryExpression:
+		return evaluator.VisitBinaryExpression(expression)
+
+	case *FunctionExpression:
+		return evaluator.VisitFunctionExpression(expression)
+
+	case *StringExpression:
+		return evaluator.VisitStringExpression(expression)
+
+	case *StringTemplateExpression:
+		return evaluator.VisitStringTemplateExpression(expression)
+
+	case *CastingExpression:
+		return evaluator.VisitCastingExpression(expression)
+
+	case *CreateExpression:
+		return evaluator.VisitCreateExpression(expression)
+
+	case *DestroyExpression:
+		return evaluator.VisitDestroyExpression(expression)
+
+	case *AttachmentExpression:
+		return evaluator.VisitAttachmentExpression(expression)
+
+	case *ReferenceExpression:
+		return evaluator.VisitReferenceExpression(expression)
+
+	case *ForceExpression:
+		return evaluator.VisitForceExpression(expression)
+
+	case *PathExpression:
+		return evaluator.VisitPathExpression(expression)
+
+	default:
+		panic(errors.NewUnreachableError())
+	}
+}
+
+// BaseExpressionEvaluator is an ExpressionEvaluator which returns the zero value of T for all expressions.
+// It can be embedded into an evaluator which only needs to handle some kinds of expressions:
+// Only the Visit methods for those need to be implemented.
+type BaseExpressionEvaluator[T any] struct{}
+
+var _ ExpressionEvaluator[int] = BaseExpressionEvaluator[int]{}
+
+func (BaseExpressionEvaluator[T]) VisitBoolExpression(_ *BoolExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitNilExpression(_ *NilExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitVoidExpression(_ *VoidExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitIntegerExpression(_ *IntegerExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitFixedPointExpression(_ *FixedPointExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitArrayExpression(_ *ArrayExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitDictionaryExpression(_ *DictionaryExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitIdentifierExpression(_ *IdentifierExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitInvocationExpression(_ *InvocationExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitMemberExpression(_ *MemberExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitIndexExpression(_ *IndexExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitConditionalExpression(_ *ConditionalExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitUnaryExpression(_ *UnaryExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitBinaryExpression(_ *BinaryExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitFunctionExpression(_ *FunctionExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitStringExpression(_ *StringExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitStringTemplateExpression(_ *StringTemplateExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitCastingExpression(_ *CastingExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitCreateExpression(_ *CreateExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitDestroyExpression(_ *DestroyExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitAttachmentExpression(_ *AttachmentExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitReferenceExpression(_ *ReferenceExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitForceExpression(_ *ForceExpression) (result T) {
+	return
+}
+
+func (BaseExpressionEvaluator[T]) VisitPathExpression(_ *PathExpression) (result T) {
+	return
+}
@@ -0,0 +1,143 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testIntegerEvaluator evaluates integer expressions.
+// The result is nil if the expression is not a constant integer expression
+type testIntegerEvaluator struct {
+	BaseExpressionEvaluator[*big.Int]
+}
+
+var _ ExpressionEvaluator[*big.Int] = testIntegerEvaluator{}
+
+func (testIntegerEvaluator) VisitIntegerExpression(expression *IntegerExpression) *big.Int {
+	return expression.Value
+}
+
+func (e testIntegerEvaluator) VisitUnaryExpression(expression *UnaryExpression) *big.Int {
+	value := EvalExpression[*big.Int](expression.Expression, e)
+	if value == nil || expression.Operation != OperationMinus {
+		return nil
+	}
+	return new(big.Int).Neg(value)
+}
+
+func (e testIntegerEvaluator) VisitBinaryExpression(expression *BinaryExpression) *big.Int {
+	left := EvalExpression[*big.Int](expression.Left, e)
+	right := EvalExpression[*big.Int](expression.Right, e)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	switch expression.Operation {
+	case OperationPlus:
+		return new(big.Int).Add(left, right)
+	case OperationMinus:
+		return new(big.Int).Sub(left, right)
+	case OperationMul:
+		return new(big.Int).Mul(left, right)
+	default:
+		return nil
+	}
+}
+
+func (e testIntegerEvaluator) VisitConditionalExpression(expression *ConditionalExpression) *big.Int {
+	test, ok := expression.Test.(*BoolExpression)
+	if !ok {
+		return nil
+	}
+	if test.Value {
+		return EvalExpression[*big.Int](expression.Then, e)
+	}
+	return EvalExpression[*big.Int](expression.Else, e)
+}
+
+func TestEvalExpression(t *testing.T) {
+
+	t.Parallel()
+
+	newInteger := func(value int64) *IntegerExpression {
+		return NewIntegerExpression(big.NewInt(value), Position{})
+	}
+
+	t.Run("integer", func(t *testing.T) {
+
+		t.Parallel()
+
+		// true ? -(2 * 3) + 4 : 0
+
+		expression := &ConditionalExpression{
+			Test: &BoolExpression{Value: true},
+			Then: &BinaryExpression{
+				Operation: OperationPlus,
+				Left: &UnaryExpression{
+					Operation: OperationMinus,
+					Expression: &BinaryExpression{
+						Operation: OperationMul,
+						Left:      newInteger(2),
+						Right:     newInteger(3),
+					},
+				},
+				Right: newInteger(4),
+			},
+			Else: newInteger(0),
+		}
+
+		result := EvalExpression[*big.Int](expression, testIntegerEvaluator{})
+		require.NotNil(t, result)
+		assert.Equal(t, big.NewInt(-2), result)
+	})
+
+	t.Run("unhandled", func(t *testing.T) {
+
+		t.Parallel()
+
+		// 1 + x
+
+		expression := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      newInteger(1),
+			Right:     newTestIdentifierExpression("x"),
+		}
+
+		assert.Nil(t, EvalExpression[*big.Int](expression, testIntegerEvaluator{}))
+	})
+
+	t.Run("expression visitor", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Every expression visitor is an evaluator with result type Repr
+
+		var visitor ExpressionVisitor = BaseExpressionVisitor{}
+
+		assert.Nil(t, EvalExpression[Repr](newInteger(1), visitor))
+	})
+}
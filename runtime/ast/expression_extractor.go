@@ -32,6 +32,10 @@ type NilExtractor interface {
 	ExtractNil(extractor *ExpressionExtractor, expression *NilExpression) ExpressionExtraction
 }
 
+type VoidExtractor interface {
+	ExtractVoid(extractor *ExpressionExtractor, expression *VoidExpression) ExpressionExtraction
+}
+
 type IntExtractor interface {
 	ExtractInteger(extractor *ExpressionExtractor, expression *IntegerExpression) ExpressionExtraction
 }
@@ -44,6 +48,10 @@ type StringExtractor interface {
 	ExtractString(extractor *ExpressionExtractor, expression *StringExpression) ExpressionExtraction
 }
 
+type StringTemplateExtractor interface {
+	ExtractStringTemplate(extractor *ExpressionExtractor, expression *StringTemplateExpression) ExpressionExtraction
+}
+
 type ArrayExtractor interface {
 	ExtractArray(extractor *ExpressionExtractor, expression *ArrayExpression) ExpressionExtraction
 }
@@ -96,6 +104,10 @@ type DestroyExtractor interface {
 	ExtractDestroy(extractor *ExpressionExtractor, expression *DestroyExpression) ExpressionExtraction
 }
 
+type AttachmentExtractor interface {
+	ExtractAttachment(extractor *ExpressionExtractor, expression *AttachmentExpression) ExpressionExtraction
+}
+
 type ReferenceExtractor interface {
 	ExtractReference(extractor *ExpressionExtractor, expression *ReferenceExpression) ExpressionExtraction
 }
@@ -109,28 +121,31 @@ type PathExtractor interface {
 }
 
 type ExpressionExtractor struct {
-	nextIdentifier       int
-	BoolExtractor        BoolExtractor
-	NilExtractor         NilExtractor
-	IntExtractor         IntExtractor
-	FixedPointExtractor  FixedPointExtractor
-	StringExtractor      StringExtractor
-	ArrayExtractor       ArrayExtractor
-	DictionaryExtractor  DictionaryExtractor
-	IdentifierExtractor  IdentifierExtractor
-	InvocationExtractor  InvocationExtractor
-	MemberExtractor      MemberExtractor
-	IndexExtractor       IndexExtractor
-	ConditionalExtractor ConditionalExtractor
-	UnaryExtractor       UnaryExtractor
-	BinaryExtractor      BinaryExtractor
-	FunctionExtractor    FunctionExtractor
-	CastingExtractor     CastingExtractor
-	CreateExtractor      CreateExtractor
-	DestroyExtractor     DestroyExtractor
-	ReferenceExtractor   ReferenceExtractor
-	ForceExtractor       ForceExtractor
-	PathExtractor        PathExtractor
+	nextIdentifier          int
+	BoolExtractor           BoolExtractor
+	NilExtractor            NilExtractor
+	VoidExtractor           VoidExtractor
+	IntExtractor            IntExtractor
+	FixedPointExtractor     FixedPointExtractor
+	StringExtractor         StringExtractor
+	StringTemplateExtractor StringTemplateExtractor
+	ArrayExtractor          ArrayExtractor
+	DictionaryExtractor     DictionaryExtractor
+	IdentifierExtractor     IdentifierExtractor
+	InvocationExtractor     InvocationExtractor
+	MemberExtractor         MemberExtractor
+	IndexExtractor          IndexExtractor
+	ConditionalExtractor    ConditionalExtractor
+	UnaryExtractor          UnaryExtractor
+	BinaryExtractor         BinaryExtractor
+	FunctionExtractor       FunctionExtractor
+	CastingExtractor        CastingExtractor
+	CreateExtractor         CreateExtractor
+	DestroyExtractor        DestroyExtractor
+	AttachmentExtractor     AttachmentExtractor
+	ReferenceExtractor      ReferenceExtractor
+	ForceExtractor          ForceExtractor
+	PathExtractor           PathExtractor
 }
 
 func (extractor *ExpressionExtractor) Extract(expression Expression) ExpressionExtraction {
@@ -205,6 +220,27 @@ func (extractor *ExpressionExtractor) ExtractNil(expression *NilExpression) Expr
 	}
 }
 
+func (extractor *ExpressionExtractor) VisitVoidExpression(expression *VoidExpression) Repr {
+
+	// delegate to child extractor, if any,
+	// or call default implementation
+
+	if extractor.VoidExtractor != nil {
+		return extractor.VoidExtractor.ExtractVoid(extractor, expression)
+	}
+	return extractor.ExtractVoid(expression)
+}
+
+func (extractor *ExpressionExtractor) ExtractVoid(expression *VoidExpression) ExpressionExtraction {
+
+	// nothing to rewrite, return as-is
+
+	return ExpressionExtraction{
+		RewrittenExpression:  expression,
+		ExtractedExpressions: nil,
+	}
+}
+
 func (extractor *ExpressionExtractor) VisitIntegerExpression(expression *IntegerExpression) Repr {
 
 	// delegate to child extractor, if any,
@@ -268,6 +304,35 @@ func (extractor *ExpressionExtractor) ExtractString(expression *StringExpression
 	}
 }
 
+func (extractor *ExpressionExtractor) VisitStringTemplateExpression(expression *StringTemplateExpression) Repr {
+
+	// delegate to child extractor, if any,
+	// or call default implementation
+
+	if extractor.StringTemplateExtractor != nil {
+		return extractor.StringTemplateExtractor.ExtractStringTemplate(extractor, expression)
+	}
+	return extractor.ExtractStringTemplate(expression)
+}
+
+func (extractor *ExpressionExtractor) ExtractStringTemplate(expression *StringTemplateExpression) ExpressionExtraction {
+
+	// copy the expression
+	newExpression := *expression
+
+	// rewrite all interpolated value expressions
+
+	rewrittenExpressions, extractedExpressions :=
+		extractor.VisitExpressions(expression.Values)
+
+	newExpression.Values = rewrittenExpressions
+
+	return ExpressionExtraction{
+		RewrittenExpression:  &newExpression,
+		ExtractedExpressions: extractedExpressions,
+	}
+}
+
 func (extractor *ExpressionExtractor) VisitArrayExpression(expression *ArrayExpression) Repr {
 
 	// delegate to child extractor, if any,
@@ -705,6 +770,42 @@ func (extractor *ExpressionExtractor) ExtractDestroy(expression *DestroyExpressi
 	}
 }
 
+func (extractor *ExpressionExtractor) VisitAttachmentExpression(expression *AttachmentExpression) Repr {
+	// delegate to child extractor, if any,
+	// or call default implementation
+
+	if extractor.AttachmentExtractor != nil {
+		return extractor.AttachmentExtractor.ExtractAttachment(extractor, expression)
+	}
+	return extractor.ExtractAttachment(expression)
+}
+
+func (extractor *ExpressionExtractor) ExtractAttachment(expression *AttachmentExpression) ExpressionExtraction {
+
+	// copy the expression
+	newExpression := *expression
+
+	// rewrite the attachment invocation and the base.
+	// NOTE: the invocation is only rewritten in place if it stays an invocation
+
+	var extractedExpressions []ExtractedExpression
+
+	attachmentResult := extractor.Extract(newExpression.Attachment)
+	if attachment, ok := attachmentResult.RewrittenExpression.(*InvocationExpression); ok {
+		newExpression.Attachment = attachment
+		extractedExpressions = append(extractedExpressions, attachmentResult.ExtractedExpressions...)
+	}
+
+	baseResult := extractor.Extract(newExpression.Base)
+	newExpression.Base = baseResult.RewrittenExpression
+	extractedExpressions = append(extractedExpressions, baseResult.ExtractedExpressions...)
+
+	return ExpressionExtraction{
+		RewrittenExpression:  &newExpression,
+		ExtractedExpressions: extractedExpressions,
+	}
+}
+
 func (extractor *ExpressionExtractor) VisitReferenceExpression(expression *ReferenceExpression) Repr {
 	// delegate to child extractor, if any,
 	// or call default implementation
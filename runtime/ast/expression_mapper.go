@@ -0,0 +1,635 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+// MapExpression rewrites the given expression bottom-up:
+// The children of each expression are mapped first,
+// then f is applied to the expression with the mapped children.
+//
+// An expression is only copied if at least one of its children changed,
+// all other expressions are returned as-is. The given expression is not mutated.
+//
+// The bodies of function expressions are mapped as well.
+// The invocations of create and attachment expressions and of emit statements
+// are not passed to f, as they must remain invocations, but their children are.
+func MapExpression(expression Expression, f func(Expression) Expression) Expression {
+	mapper := expressionMapper{
+		mapFunc: f,
+	}
+	return mapper.mapExpression(expression)
+}
+
+type expressionMapper struct {
+	mapFunc func(Expression) Expression
+	// functionBodyMapper, if set, returns the mapper for the body
+	// of the given function expression, or nil if the body should not be mapped
+	functionBodyMapper func(*FunctionExpression) *expressionMapper
+	// scopeMapper, if set, returns the mapper for the scope of the given local declarations,
+	// e.g. the statements following a variable declaration in a block,
+	// or nil if the scope should not be mapped
+	scopeMapper func(declared []Identifier) *expressionMapper
+}
+
+// scoped returns the mapper for the scope of the given local declarations,
+// or nil if the scope should not be mapped
+func (m expressionMapper) scoped(declared ...Identifier) *expressionMapper {
+	if m.scopeMapper == nil {
+		return &m
+	}
+	return m.scopeMapper(declared)
+}
+
+func (m expressionMapper) mapExpression(expression Expression) Expression {
+	if expression == nil {
+		return nil
+	}
+	return m.mapFunc(m.mapChildren(expression))
+}
+
+func (m expressionMapper) mapExpressions(expressions []Expression) ([]Expression, bool) {
+	var result []Expression
+	for i, expression := range expressions {
+		mapped := m.mapExpression(expression)
+		if result == nil {
+			if mapped == expression {
+				continue
+			}
+			result = make([]Expression, len(expressions))
+			copy(result, expressions[:i])
+		}
+		result[i] = mapped
+	}
+	if result == nil {
+		return expressions, false
+	}
+	return result, true
+}
+
+// mapChildren returns the given expression with mapped children.
+// It returns the expression itself if none of the children changed,
+// and a copy otherwise.
+func (m expressionMapper) mapChildren(expression Expression) Expression {
+	switch expression := expression.(type) {
+	case *StringTemplateExpression:
+		values, changed := m.mapExpressions(expression.Values)
+		if !changed {
+			return expression
+		}
+		result := *expression
+		result.Values = values
+		return &result
+
+	case *ArrayExpression:
+		values, changed := m.mapExpressions(expression.Values)
+		if !changed {
+			return expression
+		}
+		result := *expression
+		result.Values = values
+		return &result
+
+	case *DictionaryExpression:
+		entries, changed := m.mapDictionaryEntries(expression.Entries)
+		if !changed {
+			return expression
+		}
+		result := *expression
+		result.Entries = entries
+		return &result
+
+	case *InvocationExpression:
+		return m.mapInvocationChildren(expression)
+
+	case *MemberExpression:
+		target := m.mapExpression(expression.Expression)
+		if target == expression.Expression {
+			return expression
+		}
+		result := *expression
+		result.Expression = target
+		return &result
+
+	case *IndexExpression:
+		target := m.mapExpression(expression.TargetExpression)
+		index := m.mapExpression(expression.IndexingExpression)
+		if target == expression.TargetExpression &&
+			index == expression.IndexingExpression {
+
+			return expression
+		}
+		result := *expression
+		result.TargetExpression = target
+		result.IndexingExpression = index
+		return &result
+
+	case *ConditionalExpression:
+		test := m.mapExpression(expression.Test)
+		then := m.mapExpression(expression.Then)
+		els := m.mapExpression(expression.Else)
+		if test == expression.Test &&
+			then == expression.Then &&
+			els == expression.Else {
+
+			return expression
+		}
+		result := *expression
+		result.Test = test
+		result.Then = then
+		result.Else = els
+		return &result
+
+	case *UnaryExpression:
+		operand := m.mapExpression(expression.Expression)
+		if operand == expression.Expression {
+			return expression
+		}
+		result := *expression
+		result.Expression = operand
+		return &result
+
+	case *BinaryExpression:
+		left := m.mapExpression(expression.Left)
+		right := m.mapExpression(expression.Right)
+		if left == expression.Left &&
+			right == expression.Right {
+
+			return expression
+		}
+		result := *expression
+		result.Left = left
+		result.Right = right
+		return &result
+
+	case *FunctionExpression:
+		bodyMapper := &m
+		if m.functionBodyMapper != nil {
+			bodyMapper = m.functionBodyMapper(expression)
+			if bodyMapper == nil {
+				return expression
+			}
+		}
+		functionBlock := bodyMapper.mapFunctionBlock(expression.FunctionBlock)
+		if functionBlock == expression.FunctionBlock {
+			return expression
+		}
+		result := *expression
+		result.FunctionBlock = functionBlock
+		return &result
+
+	case *CastingExpression:
+		operand := m.mapExpression(expression.Expression)
+		if operand == expression.Expression {
+			return expression
+		}
+		result := *expression
+		result.Expression = operand
+		return &result
+
+	case *CreateExpression:
+		invocation := m.mapInvocationChildren(expression.InvocationExpression)
+		if invocation == expression.InvocationExpression {
+			return expression
+		}
+		result := *expression
+		result.InvocationExpression = invocation
+		return &result
+
+	case *DestroyExpression:
+		operand := m.mapExpression(expression.Expression)
+		if operand == expression.Expression {
+			return expression
+		}
+		result := *expression
+		result.Expression = operand
+		return &result
+
+	case *AttachmentExpression:
+		attachment := m.mapInvocationChildren(expression.Attachment)
+		base := m.mapExpression(expression.Base)
+		if attachment == expression.Attachment &&
+			base == expression.Base {
+
+			return expression
+		}
+		result := *expression
+		result.Attachment = attachment
+		result.Base = base
+		return &result
+
+	case *ReferenceExpression:
+		operand := m.mapExpression(expression.Expression)
+		if operand == expression.Expression {
+			return expression
+		}
+		result := *expression
+		result.Expression = operand
+		return &result
+
+	case *ForceExpression:
+		operand := m.mapExpression(expression.Expression)
+		if operand == expression.Expression {
+			return expression
+		}
+		result := *expression
+		result.Expression = operand
+		return &result
+
+	default:
+		// The expression has no child expressions
+		return expression
+	}
+}
+
+func (m expressionMapper) mapDictionaryEntries(entries []DictionaryEntry) ([]DictionaryEntry, bool) {
+	var result []DictionaryEntry
+	for i, entry := range entries {
+		key := m.mapExpression(entry.Key)
+		value := m.mapExpression(entry.Value)
+		if result == nil {
+			if key == entry.Key && value == entry.Value {
+				continue
+			}
+			result = make([]DictionaryEntry, len(entries))
+			copy(result, entries[:i])
+		}
+		result[i] = DictionaryEntry{
+			Key:   key,
+			Value: value,
+		}
+	}
+	if result == nil {
+		return entries, false
+	}
+	return result, true
+}
+
+func (m expressionMapper) mapInvocationChildren(invocation *InvocationExpression) *InvocationExpression {
+	if invocation == nil {
+		return nil
+	}
+
+	invoked := m.mapExpression(invocation.InvokedExpression)
+	arguments, argumentsChanged := m.mapArguments(invocation.Arguments)
+	if invoked == invocation.InvokedExpression && !argumentsChanged {
+		return invocation
+	}
+
+	result := *invocation
+	result.InvokedExpression = invoked
+	result.Arguments = arguments
+	return &result
+}
+
+func (m expressionMapper) mapArguments(arguments Arguments) (Arguments, bool) {
+	var result Arguments
+	for i, argument := range arguments {
+		mapped := m.mapExpression(argument.Expression)
+		if result == nil {
+			if mapped == argument.Expression {
+				continue
+			}
+			result = make(Arguments, len(arguments))
+			copy(result, arguments[:i])
+		}
+		if mapped == argument.Expression {
+			result[i] = argument
+			continue
+		}
+		newArgument := *argument
+		newArgument.Expression = mapped
+		result[i] = &newArgument
+	}
+	if result == nil {
+		return arguments, false
+	}
+	return result, true
+}
+
+func (m expressionMapper) mapFunctionBlock(functionBlock *FunctionBlock) *FunctionBlock {
+	if functionBlock == nil {
+		return nil
+	}
+
+	block := m.mapBlock(functionBlock.Block)
+	preConditions := m.mapConditions(functionBlock.PreConditions)
+	postConditions := m.mapConditions(functionBlock.PostConditions)
+	if block == functionBlock.Block &&
+		preConditions == functionBlock.PreConditions &&
+		postConditions == functionBlock.PostConditions {
+
+		return functionBlock
+	}
+
+	return &FunctionBlock{
+		Block:          block,
+		PreConditions:  preConditions,
+		PostConditions: postConditions,
+	}
+}
+
+func (m expressionMapper) mapConditions(conditions *Conditions) *Conditions {
+	if conditions == nil {
+		return nil
+	}
+
+	var result Conditions
+	for i, condition := range *conditions {
+		test := m.mapExpression(condition.Test)
+		message := m.mapExpression(condition.Message)
+		if result == nil {
+			if test == condition.Test && message == condition.Message {
+				continue
+			}
+			result = make(Conditions, len(*conditions))
+			copy(result, (*conditions)[:i])
+		}
+		if test == condition.Test && message == condition.Message {
+			result[i] = condition
+			continue
+		}
+		result[i] = &Condition{
+			Kind:    condition.Kind,
+			Test:    test,
+			Message: message,
+		}
+	}
+	if result == nil {
+		return conditions
+	}
+	return &result
+}
+
+func (m expressionMapper) mapBlock(block *Block) *Block {
+	if block == nil {
+		return nil
+	}
+
+	statements, changed := m.mapStatements(block.Statements)
+	if !changed {
+		return block
+	}
+
+	result := *block
+	result.Statements = statements
+	return &result
+}
+
+// mapScopedBlock maps the given block, which is in the scope of the given local declarations,
+// e.g. the block of a for-statement
+func (m expressionMapper) mapScopedBlock(block *Block, declared ...Identifier) *Block {
+	mapper := m.scoped(declared...)
+	if mapper == nil {
+		return block
+	}
+	return mapper.mapBlock(block)
+}
+
+func (m expressionMapper) mapStatements(statements []Statement) ([]Statement, bool) {
+	var result []Statement
+	mapper := &m
+	for i, statement := range statements {
+		mapped := statement
+		if mapper != nil {
+			mapped = mapper.mapStatement(statement)
+
+			// The following statements are in the scope of local declarations
+
+			switch statement := statement.(type) {
+			case *VariableDeclaration:
+				mapper = mapper.scoped(statement.Identifier)
+			case *FunctionDeclaration:
+				mapper = mapper.scoped(statement.Identifier)
+			}
+		}
+		if result == nil {
+			if mapped == statement {
+				continue
+			}
+			result = make([]Statement, len(statements))
+			copy(result, statements[:i])
+		}
+		result[i] = mapped
+	}
+	if result == nil {
+		return statements, false
+	}
+	return result, true
+}
+
+func (m expressionMapper) mapStatement(statement Statement) Statement {
+	switch statement := statement.(type) {
+	case *ReturnStatement:
+		expression := m.mapExpression(statement.Expression)
+		if expression == statement.Expression {
+			return statement
+		}
+		result := *statement
+		result.Expression = expression
+		return &result
+
+	case *IfStatement:
+		return m.mapIfStatement(statement)
+
+	case *WhileStatement:
+		test := m.mapExpression(statement.Test)
+		block := m.mapBlock(statement.Block)
+		if test == statement.Test && block == statement.Block {
+			return statement
+		}
+		result := *statement
+		result.Test = test
+		result.Block = block
+		return &result
+
+	case *ForStatement:
+		value := m.mapExpression(statement.Value)
+		declared := []Identifier{statement.Identifier}
+		if statement.Index != nil {
+			declared = append(declared, *statement.Index)
+		}
+		block := m.mapScopedBlock(statement.Block, declared...)
+		if value == statement.Value && block == statement.Block {
+			return statement
+		}
+		result := *statement
+		result.Value = value
+		result.Block = block
+		return &result
+
+	case *EmitStatement:
+		invocation := m.mapInvocationChildren(statement.InvocationExpression)
+		if invocation == statement.InvocationExpression {
+			return statement
+		}
+		result := *statement
+		result.InvocationExpression = invocation
+		return &result
+
+	case *AssignmentStatement:
+		target := m.mapExpression(statement.Target)
+		value := m.mapExpression(statement.Value)
+		if target == statement.Target && value == statement.Value {
+			return statement
+		}
+		result := *statement
+		result.Target = target
+		result.Value = value
+		return &result
+
+	case *SwapStatement:
+		left := m.mapExpression(statement.Left)
+		right := m.mapExpression(statement.Right)
+		if left == statement.Left && right == statement.Right {
+			return statement
+		}
+		result := *statement
+		result.Left = left
+		result.Right = right
+		return &result
+
+	case *ExpressionStatement:
+		expression := m.mapExpression(statement.Expression)
+		if expression == statement.Expression {
+			return statement
+		}
+		result := *statement
+		result.Expression = expression
+		return &result
+
+	case *SwitchStatement:
+		return m.mapSwitchStatement(statement)
+
+	case *VariableDeclaration:
+		return m.mapVariableDeclaration(statement)
+
+	default:
+		// The statement has no child expressions,
+		// or it is a nested declaration, which is not mapped
+		return statement
+	}
+}
+
+func (m expressionMapper) mapIfStatement(statement *IfStatement) *IfStatement {
+	var test IfStatementTest
+	var declaration *VariableDeclaration
+
+	switch originalTest := statement.Test.(type) {
+	case Expression:
+		test = m.mapExpression(originalTest)
+	case *VariableDeclaration:
+		declaration = m.mapVariableDeclaration(originalTest)
+		test = declaration
+	default:
+		test = originalTest
+	}
+
+	then := statement.Then
+	if declaration != nil {
+		// The declaration of an if-let statement is only in scope of the then-branch
+		then = m.mapScopedBlock(then, declaration.Identifier)
+	} else {
+		then = m.mapBlock(then)
+	}
+	els := m.mapBlock(statement.Else)
+	if test == statement.Test &&
+		then == statement.Then &&
+		els == statement.Else {
+
+		return statement
+	}
+
+	result := *statement
+	result.Test = test
+	result.Then = then
+	result.Else = els
+
+	// The declaration of the test refers back to the if-statement
+
+	if declaration != nil {
+		if declaration == statement.Test {
+			newDeclaration := *declaration
+			declaration = &newDeclaration
+			result.Test = declaration
+		}
+		declaration.ParentIfStatement = &result
+	}
+
+	return &result
+}
+
+func (m expressionMapper) mapSwitchStatement(statement *SwitchStatement) *SwitchStatement {
+	expression := m.mapExpression(statement.Expression)
+
+	var cases []*SwitchCase
+	for i, switchCase := range statement.Cases {
+		caseExpression := m.mapExpression(switchCase.Expression)
+		statements, statementsChanged := m.mapStatements(switchCase.Statements)
+		if cases == nil {
+			if caseExpression == switchCase.Expression && !statementsChanged {
+				continue
+			}
+			cases = make([]*SwitchCase, len(statement.Cases))
+			copy(cases, statement.Cases[:i])
+		}
+		if caseExpression == switchCase.Expression && !statementsChanged {
+			cases[i] = switchCase
+			continue
+		}
+		newSwitchCase := *switchCase
+		newSwitchCase.Expression = caseExpression
+		newSwitchCase.Statements = statements
+		cases[i] = &newSwitchCase
+	}
+
+	if expression == statement.Expression && cases == nil {
+		return statement
+	}
+
+	result := *statement
+	result.Expression = expression
+	if cases != nil {
+		result.Cases = cases
+	}
+	return &result
+}
+
+func (m expressionMapper) mapVariableDeclaration(declaration *VariableDeclaration) *VariableDeclaration {
+	value := m.mapExpression(declaration.Value)
+	secondValue := m.mapExpression(declaration.SecondValue)
+	if value == declaration.Value && secondValue == declaration.SecondValue {
+		return declaration
+	}
+
+	result := *declaration
+	result.Value = value
+	result.SecondValue = secondValue
+
+	// A casting expression which is the value of the declaration
+	// refers back to the declaration
+
+	if castingExpression, ok := value.(*CastingExpression); ok &&
+		castingExpression.ParentVariableDeclaration == declaration {
+
+		newCastingExpression := *castingExpression
+		newCastingExpression.ParentVariableDeclaration = &result
+		result.Value = &newCastingExpression
+	}
+
+	return &result
+}
@@ -0,0 +1,199 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapExpression(t *testing.T) {
+
+	t.Parallel()
+
+	replaceX := func(expression Expression) Expression {
+		identifierExpression, ok := expression.(*IdentifierExpression)
+		if !ok || identifierExpression.Identifier.Identifier != "x" {
+			return expression
+		}
+		return &MemberExpression{
+			Expression: newTestIdentifierExpression("self"),
+			Identifier: Identifier{
+				Identifier: "x",
+			},
+		}
+	}
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		// f(a: [x, y], b: {x: -x})[x ?? 1] as? T
+
+		unchanged := newTestIdentifierExpression("y")
+
+		original := &CastingExpression{
+			Operation: OperationFailableCast,
+			Expression: &IndexExpression{
+				TargetExpression: &InvocationExpression{
+					InvokedExpression: newTestIdentifierExpression("f"),
+					Arguments: Arguments{
+						{
+							Label: "a",
+							Expression: &ArrayExpression{
+								Values: []Expression{
+									newTestIdentifierExpression("x"),
+									unchanged,
+								},
+							},
+						},
+						{
+							Label: "b",
+							Expression: &DictionaryExpression{
+								Entries: []DictionaryEntry{
+									{
+										Key: newTestIdentifierExpression("x"),
+										Value: &UnaryExpression{
+											Operation:  OperationMinus,
+											Expression: newTestIdentifierExpression("x"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				IndexingExpression: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      newTestIdentifierExpression("x"),
+					Right: &IntegerExpression{
+						PositiveLiteral: "1",
+						Value:           big.NewInt(1),
+						Base:            10,
+					},
+				},
+			},
+			TypeAnnotation: &TypeAnnotation{
+				Type: &NominalType{
+					Identifier: Identifier{
+						Identifier: "T",
+					},
+				},
+			},
+		}
+
+		const originalString = "f(a: [x, y], b: {x: -x})[x ?? 1] as? T"
+		require.Equal(t, originalString, original.String())
+
+		result := MapExpression(original, replaceX)
+
+		assert.Equal(t,
+			"f(a: [self.x, y], b: {self.x: -self.x})[self.x ?? 1] as? T",
+			result.String(),
+		)
+
+		// The original expression is not mutated
+
+		assert.Equal(t, originalString, original.String())
+
+		// Unchanged children are shared
+
+		resultArray := result.(*CastingExpression).
+			Expression.(*IndexExpression).
+			TargetExpression.(*InvocationExpression).
+			Arguments[0].Expression.(*ArrayExpression)
+
+		assert.Same(t, unchanged, resultArray.Values[1])
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+
+		t.Parallel()
+
+		original := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      newTestIdentifierExpression("a"),
+			Right: &MemberExpression{
+				Expression: newTestIdentifierExpression("b"),
+				Identifier: Identifier{
+					Identifier: "x",
+				},
+			},
+		}
+
+		assert.Same(t, original, MapExpression(original, replaceX))
+	})
+
+	t.Run("function body", func(t *testing.T) {
+
+		t.Parallel()
+
+		original := &FunctionExpression{
+			ParameterList: &ParameterList{},
+			FunctionBlock: &FunctionBlock{
+				Block: &Block{
+					Statements: []Statement{
+						&ReturnStatement{
+							Expression: newTestIdentifierExpression("x"),
+						},
+					},
+				},
+			},
+		}
+
+		result := MapExpression(original, replaceX).(*FunctionExpression)
+
+		assert.Equal(t,
+			"self.x",
+			result.FunctionBlock.Block.Statements[0].(*ReturnStatement).Expression.String(),
+		)
+		assert.Equal(t,
+			"x",
+			original.FunctionBlock.Block.Statements[0].(*ReturnStatement).Expression.String(),
+		)
+	})
+
+	t.Run("bottom-up", func(t *testing.T) {
+
+		t.Parallel()
+
+		original := &UnaryExpression{
+			Operation: OperationMinus,
+			Expression: &UnaryExpression{
+				Operation:  OperationMinus,
+				Expression: newTestIdentifierExpression("a"),
+			},
+		}
+
+		var visited []string
+
+		MapExpression(original, func(expression Expression) Expression {
+			visited = append(visited, expression.String())
+			return expression
+		})
+
+		assert.Equal(t,
+			[]string{"a", "-a", "-(-a)"},
+			visited,
+		)
+	})
+}
@@ -21,13 +21,32 @@ package ast
 import (
 	"encoding/json"
 	"math/big"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/turbolent/prettier"
+
+	"github.com/onflow/cadence/runtime/common"
 )
 
+func newTestIdentifierExpression(identifier string) *IdentifierExpression {
+	return &IdentifierExpression{
+		Identifier: Identifier{
+			Identifier: identifier,
+		},
+	}
+}
+
+func testDocString(doc prettier.Doc) string {
+	var builder strings.Builder
+	prettier.Prettier(&builder, doc, 80, "    ")
+	return builder.String()
+}
+
 func TestBoolExpression_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -104,6 +123,39 @@ func TestNilExpression_Doc(t *testing.T) {
 	)
 }
 
+func TestVoidExpression_MarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	expr := &VoidExpression{
+		Pos: Position{Offset: 1, Line: 2, Column: 3},
+	}
+
+	actual, err := json.Marshal(expr)
+	require.NoError(t, err)
+
+	assert.JSONEq(t,
+		`
+        {
+            "Type": "VoidExpression",
+            "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+            "EndPos": {"Offset": 2, "Line": 2, "Column": 4}
+        }
+        `,
+		string(actual),
+	)
+}
+
+func TestVoidExpression_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	assert.Equal(t,
+		prettier.Text("()"),
+		(&VoidExpression{}).Doc(),
+	)
+}
+
 func TestStringExpression_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -142,6 +194,126 @@ func TestStringExpression_Doc(t *testing.T) {
 	)
 }
 
+func TestStringTemplateExpression_MarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	expr := &StringTemplateExpression{
+		Values: []Expression{
+			&IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "name",
+					Pos:        Position{Offset: 1, Line: 2, Column: 3},
+				},
+			},
+		},
+		Segments: []string{"Hello, ", "!"},
+		Range: Range{
+			StartPos: Position{Offset: 4, Line: 5, Column: 6},
+			EndPos:   Position{Offset: 7, Line: 8, Column: 9},
+		},
+	}
+
+	actual, err := json.Marshal(expr)
+	require.NoError(t, err)
+
+	assert.JSONEq(t,
+		`
+        {
+            "Type": "StringTemplateExpression",
+            "Values": [
+                {
+                    "Type": "IdentifierExpression",
+                    "Identifier": {
+                        "Identifier": "name",
+                        "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+                        "EndPos": {"Offset": 4, "Line": 2, "Column": 6}
+                    },
+                    "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+                    "EndPos": {"Offset": 4, "Line": 2, "Column": 6}
+                }
+            ],
+            "Segments": ["Hello, ", "!"],
+            "StartPos": {"Offset": 4, "Line": 5, "Column": 6},
+            "EndPos": {"Offset": 7, "Line": 8, "Column": 9}
+        }
+        `,
+		string(actual),
+	)
+}
+
+func TestStringTemplateExpression_String(t *testing.T) {
+
+	t.Parallel()
+
+	expr := &StringTemplateExpression{
+		Values: []Expression{
+			newTestIdentifierExpression("a"),
+			&BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression("b"),
+				Right:     newTestIdentifierExpression("c"),
+			},
+		},
+		Segments: []string{"\"", " and\n", ""},
+	}
+
+	assert.Equal(t,
+		`"\"\(a) and\n\(b + c)"`,
+		expr.String(),
+	)
+}
+
+func TestStringTemplateExpression_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("no values", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &StringTemplateExpression{
+			Segments: []string{"test\t"},
+		}
+
+		assert.Equal(t,
+			prettier.Concat{
+				prettier.Text(`"test\t"`),
+			},
+			expr.Doc(),
+		)
+	})
+
+	t.Run("values", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &StringTemplateExpression{
+			Values: []Expression{
+				newTestIdentifierExpression("a"),
+				&ArrayExpression{
+					Values: []Expression{
+						newTestIdentifierExpression("b"),
+						newTestIdentifierExpression("c"),
+					},
+				},
+			},
+			Segments: []string{"x\"", "y", "z"},
+		}
+
+		const expected = `"x\"\(a)y\([b, c])z"`
+
+		assert.Equal(t, expected, testDocString(expr.Doc()))
+
+		// interpolated values must not be broken across lines,
+		// even if they do not fit
+
+		var builder strings.Builder
+		prettier.Prettier(&builder, expr.Doc(), 1, "    ")
+		assert.Equal(t, expected, builder.String())
+	})
+}
+
 func TestIntegerExpression_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -257,6 +429,344 @@ func TestIntegerExpression_Doc(t *testing.T) {
 			expr.Doc(),
 		)
 	})
+
+	t.Run("missing prefix", func(t *testing.T) {
+
+		t.Parallel()
+
+		type testCase struct {
+			literal  string
+			base     int
+			value    int64
+			expected string
+		}
+
+		testCases := map[string]testCase{
+			"binary":  {"10_1010", 2, 42, "0b10_1010"},
+			"octal":   {"52", 8, 42, "0o52"},
+			"decimal": {"42", 10, 42, "42"},
+			"hex":     {"ff", 16, -255, "-0xff"},
+			"empty":   {"", 16, 255, "0xff"},
+		}
+
+		for name, testCase := range testCases {
+			testCase := testCase
+
+			t.Run(name, func(t *testing.T) {
+
+				t.Parallel()
+
+				expr := &IntegerExpression{
+					PositiveLiteral: testCase.literal,
+					Value:           big.NewInt(testCase.value),
+					Base:            testCase.base,
+				}
+
+				assert.Equal(t,
+					prettier.Text(testCase.expected),
+					expr.Doc(),
+				)
+				assert.Equal(t, testCase.expected, expr.String())
+			})
+		}
+	})
+}
+
+func TestIntegerExpression_RenderWithBase(t *testing.T) {
+
+	t.Parallel()
+
+	type testCase struct {
+		base     int
+		value    string
+		grouping int
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"binary":                   {2, "42", 0, "0b101010"},
+		"binary, grouped":          {2, "170", 4, "0b1010_1010"},
+		"binary, grouped, partial": {2, "42", 4, "0b10_1010"},
+		"octal":                    {8, "42", 0, "0o52"},
+		"octal, grouped":           {8, "16777215", 3, "0o77_777_777"},
+		"decimal":                  {10, "1000000", 0, "1000000"},
+		"decimal, grouped":         {10, "1000000", 3, "1_000_000"},
+		"decimal, grouped, long":   {10, "123456789012345678901234567890", 3, "123_456_789_012_345_678_901_234_567_890"},
+		"decimal, negative":        {10, "-1234567", 3, "-1_234_567"},
+		"decimal, short":           {10, "100", 3, "100"},
+		"hex":                      {16, "255", 0, "0xff"},
+		"hex, grouped":             {16, "3735928559", 4, "0xdead_beef"},
+		"hex, negative":            {16, "-3735928559", 2, "-0xde_ad_be_ef"},
+		"zero":                     {16, "0", 4, "0x0"},
+		"invalid base":             {0, "1000", 3, "1_000"},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			value, ok := new(big.Int).SetString(testCase.value, 10)
+			require.True(t, ok)
+
+			expr := &IntegerExpression{
+				// NOTE: the literal is ignored
+				PositiveLiteral: "1",
+				Value:           value,
+				Base:            testCase.base,
+			}
+
+			assert.Equal(t,
+				testCase.expected,
+				expr.RenderWithBase(testCase.grouping),
+			)
+		})
+	}
+}
+
+func TestIntegerExpression_Validate(t *testing.T) {
+
+	t.Parallel()
+
+	type testCase struct {
+		literal string
+		base    int
+		value   *big.Int
+		err     string
+	}
+
+	testCases := []testCase{
+		{literal: "42", base: 10, value: big.NewInt(42)},
+		{literal: "42", base: 10, value: big.NewInt(-42)},
+		{literal: "1_000", base: 10, value: big.NewInt(1000)},
+		{literal: "0b101", base: 2, value: big.NewInt(5)},
+		{literal: "0o17", base: 8, value: big.NewInt(15)},
+		{literal: "0x2A", base: 16, value: big.NewInt(42)},
+		{literal: "0xff_ff", base: 16, value: big.NewInt(0xffff)},
+		{
+			literal: "10",
+			base:    16,
+			value:   big.NewInt(10),
+			err:     "integer literal `10` is not a valid base 16 literal: missing prefix `0x`",
+		},
+		{
+			literal: "0x10",
+			base:    16,
+			value:   big.NewInt(10),
+			err:     "integer literal `0x10` in base 16 has value 16, but expression has value 10",
+		},
+		{
+			literal: "0b102",
+			base:    2,
+			value:   big.NewInt(5),
+			err:     "integer literal `0b102` is not a valid base 2 literal",
+		},
+		{
+			literal: "0x",
+			base:    16,
+			value:   big.NewInt(0),
+			err:     "integer literal `0x` is not a valid base 16 literal",
+		},
+		{
+			literal: "-1",
+			base:    10,
+			value:   big.NewInt(-1),
+			err:     "integer literal `-1` is not a valid base 10 literal",
+		},
+		{
+			literal: "1",
+			base:    3,
+			value:   big.NewInt(1),
+			err:     "integer literal `1` has invalid base 3",
+		},
+		{
+			literal: "1",
+			base:    10,
+			err:     "integer literal `1` has no value",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.literal, func(t *testing.T) {
+
+			t.Parallel()
+
+			err := (&IntegerExpression{
+				PositiveLiteral: testCase.literal,
+				Value:           testCase.value,
+				Base:            testCase.base,
+			}).Validate()
+
+			if testCase.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestNewIntegerExpression(t *testing.T) {
+
+	t.Parallel()
+
+	pos := Position{Offset: 4, Line: 2, Column: 3}
+
+	t.Run("positive", func(t *testing.T) {
+
+		t.Parallel()
+
+		value := big.NewInt(42)
+		expression := NewIntegerExpression(value, pos)
+
+		assert.Equal(t,
+			&IntegerExpression{
+				PositiveLiteral: "42",
+				Value:           big.NewInt(42),
+				Base:            10,
+				Range: Range{
+					StartPos: Position{Offset: 4, Line: 2, Column: 3},
+					EndPos:   Position{Offset: 5, Line: 2, Column: 4},
+				},
+			},
+			expression,
+		)
+		assert.Equal(t, "42", expression.String())
+		assert.Equal(t, "42", testDocString(expression.Doc()))
+
+		// The value is copied
+
+		value.SetInt64(1)
+		assert.Equal(t, "42", expression.Value.String())
+	})
+
+	t.Run("negative", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := NewIntegerExpression(big.NewInt(-42), pos)
+
+		assert.Equal(t,
+			&IntegerExpression{
+				PositiveLiteral: "42",
+				Value:           big.NewInt(-42),
+				Base:            10,
+				Range: Range{
+					StartPos: Position{Offset: 4, Line: 2, Column: 3},
+					EndPos:   Position{Offset: 6, Line: 2, Column: 5},
+				},
+			},
+			expression,
+		)
+		assert.Equal(t, "-42", expression.String())
+		assert.Equal(t, "-42", testDocString(expression.Doc()))
+	})
+
+	t.Run("zero", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := NewIntegerExpression(big.NewInt(0), pos)
+
+		assert.Equal(t, "0", expression.String())
+		assert.Equal(t, "0", testDocString(expression.Doc()))
+		assert.Equal(t, pos, expression.EndPos)
+	})
+}
+
+func TestIntegerExpression_StraySign(t *testing.T) {
+
+	t.Parallel()
+
+	type testCase struct {
+		literal  string
+		value    int64
+		base     int
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"negative value, negative literal": {
+			literal:  "-5",
+			value:    -5,
+			base:     10,
+			expected: "-5",
+		},
+		"positive value, negative literal": {
+			literal:  "-5",
+			value:    5,
+			base:     10,
+			expected: "5",
+		},
+		"negative value, positive literal": {
+			literal:  "+5",
+			value:    -5,
+			base:     10,
+			expected: "-5",
+		},
+		"negative value, negative hexadecimal literal": {
+			literal:  "-0xff",
+			value:    -255,
+			base:     16,
+			expected: "-0xff",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			expression := &IntegerExpression{
+				PositiveLiteral: testCase.literal,
+				Value:           big.NewInt(testCase.value),
+				Base:            testCase.base,
+			}
+
+			assert.Equal(t, testCase.value < 0, expression.IsNegative())
+
+			assert.Equal(t, testCase.expected, expression.String())
+			assert.Equal(t, testCase.expected, testDocString(expression.Doc()))
+			assert.Equal(t,
+				testCase.expected,
+				Format(expression, FormatOptions{StripSeparators: true}),
+			)
+
+			unary := &UnaryExpression{
+				Operation:  OperationMinus,
+				Expression: expression,
+			}
+			if testCase.value < 0 {
+				assert.Equal(t, "-("+testCase.expected+")", unary.String())
+			} else {
+				assert.Equal(t, "-"+testCase.expected, unary.String())
+			}
+
+			expression.Normalize()
+
+			assert.NoError(t, expression.Validate())
+			assert.Equal(t, testCase.expected, expression.String())
+		})
+	}
+
+	t.Run("no value", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := &IntegerExpression{
+			PositiveLiteral: "-5",
+			Base:            10,
+		}
+
+		assert.False(t, expression.IsNegative())
+		assert.Equal(t, "5", expression.String())
+	})
 }
 
 func TestFixedPointExpression_MarshalJSON(t *testing.T) {
@@ -331,16 +841,136 @@ func TestFixedPointExpression_Doc(t *testing.T) {
 			expr.Doc(),
 		)
 	})
+
+	t.Run("without literal", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &FixedPointExpression{
+			UnsignedInteger: big.NewInt(12),
+			Fractional:      big.NewInt(34),
+			Scale:           2,
+		}
+
+		assert.Equal(t,
+			prettier.Text(`12.34`),
+			expr.Doc(),
+		)
+	})
+
+	t.Run("negative, without literal", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &FixedPointExpression{
+			Negative:        true,
+			UnsignedInteger: big.NewInt(12),
+			Fractional:      big.NewInt(34),
+			Scale:           4,
+		}
+
+		assert.Equal(t,
+			prettier.Text(`-12.0034`),
+			expr.Doc(),
+		)
+	})
 }
 
-func TestArrayExpression_MarshalJSON(t *testing.T) {
+func TestFixedPointExpression_String(t *testing.T) {
 
 	t.Parallel()
 
-	expr := &ArrayExpression{
-		Values: []Expression{
-			&BoolExpression{
-				Value: true,
+	t.Run("padded", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &FixedPointExpression{
+			UnsignedInteger: big.NewInt(1),
+			Fractional:      big.NewInt(5),
+			Scale:           3,
+		}
+
+		assert.Equal(t, "1.005", expr.String())
+	})
+
+	t.Run("negative", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &FixedPointExpression{
+			Negative:        true,
+			UnsignedInteger: big.NewInt(1),
+			Fractional:      big.NewInt(5),
+			Scale:           1,
+		}
+
+		assert.Equal(t, "-1.5", expr.String())
+	})
+
+	t.Run("scale smaller than fractional digits", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &FixedPointExpression{
+			UnsignedInteger: big.NewInt(1),
+			Fractional:      big.NewInt(2345),
+			Scale:           2,
+		}
+
+		assert.Equal(t, "1.2345", expr.String())
+	})
+}
+
+func TestNewFixedPointExpression(t *testing.T) {
+
+	t.Parallel()
+
+	pos := Position{Offset: 4, Line: 2, Column: 3}
+
+	t.Run("positive", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := NewFixedPointExpression(false, big.NewInt(12), big.NewInt(5), 3, pos)
+
+		assert.Equal(t,
+			&FixedPointExpression{
+				PositiveLiteral: "12.005",
+				Negative:        false,
+				UnsignedInteger: big.NewInt(12),
+				Fractional:      big.NewInt(5),
+				Scale:           3,
+				Range: Range{
+					StartPos: Position{Offset: 4, Line: 2, Column: 3},
+					EndPos:   Position{Offset: 9, Line: 2, Column: 8},
+				},
+			},
+			expression,
+		)
+		assert.Equal(t, "12.005", expression.String())
+		assert.Equal(t, "12.005", testDocString(expression.Doc()))
+	})
+
+	t.Run("negative", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := NewFixedPointExpression(true, big.NewInt(0), big.NewInt(5), 1, pos)
+
+		assert.Equal(t, "-0.5", expression.String())
+		assert.Equal(t, "-0.5", testDocString(expression.Doc()))
+		assert.Equal(t, Position{Offset: 7, Line: 2, Column: 6}, expression.EndPos)
+	})
+}
+
+func TestArrayExpression_MarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	expr := &ArrayExpression{
+		Values: []Expression{
+			&BoolExpression{
+				Value: true,
 				Range: Range{
 					StartPos: Position{Offset: 1, Line: 2, Column: 3},
 					EndPos:   Position{Offset: 4, Line: 5, Column: 6},
@@ -573,173 +1203,897 @@ func TestDictionaryExpression_Doc(t *testing.T) {
 
 }
 
-func TestIdentifierExpression_MarshalJSON(t *testing.T) {
+func TestArrayExpression_Doc_Cache(t *testing.T) {
 
 	t.Parallel()
 
-	expr := &IdentifierExpression{
-		Identifier: Identifier{
-			Identifier: "foobar",
-			Pos:        Position{Offset: 1, Line: 2, Column: 3},
-		},
-	}
+	t.Run("replaced elements", func(t *testing.T) {
 
-	actual, err := json.Marshal(expr)
-	require.NoError(t, err)
+		t.Parallel()
 
-	assert.JSONEq(t,
-		`
-        {
-            "Type": "IdentifierExpression",
-            "Identifier": {
-                "Identifier": "foobar",
-                "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
-                "EndPos": {"Offset": 6, "Line": 2, "Column": 8}
-            },
-            "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
-            "EndPos": {"Offset": 6, "Line": 2, "Column": 8}
-        }
-        `,
-		string(actual),
-	)
-}
+		expr := &ArrayExpression{
+			Values: []Expression{
+				newTestIdentifierExpression("a"),
+			},
+		}
 
-func TestIdentifierExpression_Doc(t *testing.T) {
+		assert.Equal(t, "[a]", testDocString(expr.Doc()))
 
-	t.Parallel()
+		// Replacing the elements invalidates the cache
 
-	assert.Equal(t,
-		prettier.Text("test"),
-		(&IdentifierExpression{
-			Identifier: Identifier{
-				Identifier: "test",
+		expr.Values = append(expr.Values, newTestIdentifierExpression("b"))
+
+		assert.Equal(t, "[a, b]", testDocString(expr.Doc()))
+
+		expr.Values[0] = newTestIdentifierExpression("c")
+
+		assert.Equal(t, "[c, b]", testDocString(expr.Doc()))
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		inner := &ArrayExpression{
+			Values: []Expression{
+				newTestIdentifierExpression("a"),
 			},
-		}).Doc(),
-	)
+		}
+
+		expr := &ArrayExpression{
+			Values: []Expression{inner},
+		}
+
+		assert.Equal(t, "[[a]]", testDocString(expr.Doc()))
+
+		// Replacing the elements of a nested array invalidates the cache
+
+		inner.Values[0] = newTestIdentifierExpression("b")
+
+		assert.Equal(t, "[[b]]", testDocString(expr.Doc()))
+	})
+
+	t.Run("mutated element", func(t *testing.T) {
+
+		t.Parallel()
+
+		binary := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      newTestIdentifierExpression("a"),
+			Right:     newTestIdentifierExpression("b"),
+		}
+
+		expr := &ArrayExpression{
+			Values: []Expression{binary},
+		}
+
+		assert.Equal(t, "[a + b]", testDocString(expr.Doc()))
+
+		// Mutating an element in place does not invalidate the cache
+
+		binary.Right = newTestIdentifierExpression("c")
+
+		assert.Equal(t, "[a + b]", testDocString(expr.Doc()))
+
+		expr.ResetDocCache()
+
+		assert.Equal(t, "[a + c]", testDocString(expr.Doc()))
+	})
 }
 
-func TestPathExpression_MarshalJSON(t *testing.T) {
+func TestDictionaryExpression_Doc_Cache(t *testing.T) {
 
 	t.Parallel()
 
-	expr := &PathExpression{
-		StartPos: Position{Offset: 1, Line: 2, Column: 3},
-		Domain: Identifier{
-			Identifier: "storage",
-			Pos:        Position{Offset: 4, Line: 5, Column: 6},
-		},
-		Identifier: Identifier{
-			Identifier: "foobar",
-			Pos:        Position{Offset: 7, Line: 8, Column: 9},
+	expr := &DictionaryExpression{
+		Entries: []DictionaryEntry{
+			{
+				Key:   newTestIdentifierExpression("a"),
+				Value: newTestIdentifierExpression("b"),
+			},
 		},
 	}
 
-	actual, err := json.Marshal(expr)
-	require.NoError(t, err)
+	assert.Equal(t, "{a: b}", testDocString(expr.Doc()))
 
-	assert.JSONEq(t,
-		`
-        {
-            "Type": "PathExpression",
-            "Domain": {
-                "Identifier": "storage",
-                "StartPos": {"Offset": 4, "Line": 5, "Column": 6},
-                "EndPos": {"Offset": 10, "Line": 5, "Column": 12}
-            },
-            "Identifier": {
-                "Identifier": "foobar",
-                "StartPos": {"Offset": 7, "Line": 8, "Column": 9},
-                "EndPos": {"Offset": 12, "Line": 8, "Column": 14}
-            },
-            "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
-            "EndPos": {"Offset": 12, "Line": 8, "Column": 14}
-        }
-        `,
-		string(actual),
-	)
+	// Replacing the entries invalidates the cache
+
+	expr.Entries[0].Value = newTestIdentifierExpression("c")
+
+	assert.Equal(t, "{a: c}", testDocString(expr.Doc()))
+
+	expr.Entries = append(expr.Entries, DictionaryEntry{
+		Key:   newTestIdentifierExpression("d"),
+		Value: newTestIdentifierExpression("e"),
+	})
+
+	assert.Equal(t, "{a: c, d: e}", testDocString(expr.Doc()))
+
+	// Removing duplicate entries invalidates the cache
+
+	expr.Entries = append(expr.Entries, DictionaryEntry{
+		Key:   newTestIdentifierExpression("a"),
+		Value: newTestIdentifierExpression("f"),
+	})
+
+	assert.Equal(t, "{a: c, d: e, a: f}", testDocString(expr.Doc()))
+
+	expr.DeduplicateEntries()
+
+	assert.Equal(t, "{a: c, d: e}", testDocString(expr.Doc()))
 }
 
-func TestPathExpression_Doc(t *testing.T) {
+func TestMapExpression_DocCache(t *testing.T) {
 
 	t.Parallel()
 
-	expr := &PathExpression{
-		Domain: Identifier{
-			Identifier: "storage",
-		},
-		Identifier: Identifier{
-			Identifier: "test",
+	expr := &ArrayExpression{
+		Values: []Expression{
+			newTestIdentifierExpression("a"),
 		},
 	}
 
-	assert.Equal(t,
-		prettier.Text("/storage/test"),
-		expr.Doc(),
-	)
+	assert.Equal(t, "[a]", testDocString(expr.Doc()))
+
+	result := MapExpression(expr, func(expression Expression) Expression {
+		if _, ok := expression.(*IdentifierExpression); ok {
+			return newTestIdentifierExpression("b")
+		}
+		return expression
+	})
+
+	assert.Equal(t, "[b]", testDocString(result.Doc()))
+	assert.Equal(t, "[a]", testDocString(expr.Doc()))
 }
 
-func TestMemberExpression_MarshalJSON(t *testing.T) {
+func TestArrayExpression_Doc_Concurrent(t *testing.T) {
 
 	t.Parallel()
 
-	expr := &MemberExpression{
-		Expression: &BoolExpression{
-			Value: true,
-			Range: Range{
-				StartPos: Position{Offset: 1, Line: 2, Column: 3},
-				EndPos:   Position{Offset: 4, Line: 5, Column: 6},
+	// Documents of a shared expression can be generated concurrently,
+	// the cache is safe for concurrent use
+
+	expr := &ArrayExpression{
+		Values: []Expression{
+			newTestIdentifierExpression("a"),
+			&DictionaryExpression{
+				Entries: []DictionaryEntry{
+					{
+						Key:   newTestIdentifierExpression("b"),
+						Value: newTestIdentifierExpression("c"),
+					},
+				},
 			},
 		},
-		Optional:  true,
-		AccessPos: Position{Offset: 7, Line: 8, Column: 9},
-		Identifier: Identifier{
-			Identifier: "foobar",
-			Pos:        Position{Offset: 10, Line: 11, Column: 12},
-		},
 	}
 
-	actual, err := json.Marshal(expr)
-	require.NoError(t, err)
+	const concurrency = 8
 
-	assert.JSONEq(t,
-		`
-        {
-            "Type": "MemberExpression",
-            "Expression": {
-                "Type": "BoolExpression",
-                "Value": true,
-                "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
-                "EndPos": {"Offset": 4, "Line": 5, "Column": 6}
-            },
-            "Optional": true,
-            "AccessPos": {"Offset": 7, "Line": 8, "Column": 9},
-            "Identifier": {
-                "Identifier": "foobar",
-                "StartPos": {"Offset": 10, "Line": 11, "Column": 12},
-                "EndPos": {"Offset": 15, "Line": 11, "Column": 17}
-            },
-            "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
-            "EndPos": {"Offset": 15, "Line": 11, "Column": 17}
-        }
-        `,
-		string(actual),
-	)
+	var wg sync.WaitGroup
+	results := make([]string, concurrency)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = testDocString(expr.Doc())
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, result := range results {
+		assert.Equal(t, "[a, {b: c}]", result)
+	}
 }
 
-func TestMemberExpression_Doc(t *testing.T) {
+func BenchmarkArrayExpression_Doc(b *testing.B) {
 
-	t.Parallel()
+	values := make([]Expression, 10_000)
+	for i := range values {
+		values[i] = &IntegerExpression{
+			PositiveLiteral: strconv.Itoa(i),
+			Value:           big.NewInt(int64(i)),
+			Base:            10,
+		}
+	}
 
-	t.Run("non-optional", func(t *testing.T) {
+	expr := &ArrayExpression{
+		Values: values,
+	}
 
-		t.Parallel()
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
 
-		expr := &MemberExpression{
-			Expression: &IdentifierExpression{
+		for i := 0; i < b.N; i++ {
+			expr.ResetDocCache()
+			expr.Doc()
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			expr.Doc()
+		}
+	})
+}
+
+func TestArrayExpression_ElementRange(t *testing.T) {
+
+	t.Parallel()
+
+	// [a, true]
+
+	expr := &ArrayExpression{
+		Values: []Expression{
+			&IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "a",
+					Pos:        Position{Offset: 1, Line: 1, Column: 1},
+				},
+			},
+			&BoolExpression{
+				Value: true,
+				Range: Range{
+					StartPos: Position{Offset: 4, Line: 1, Column: 4},
+					EndPos:   Position{Offset: 7, Line: 1, Column: 7},
+				},
+			},
+		},
+		Range: Range{
+			StartPos: Position{Offset: 0, Line: 1, Column: 0},
+			EndPos:   Position{Offset: 8, Line: 1, Column: 8},
+		},
+	}
+
+	elementRange, ok := expr.ElementRange(0)
+	assert.True(t, ok)
+	assert.Equal(t,
+		Range{
+			StartPos: Position{Offset: 1, Line: 1, Column: 1},
+			EndPos:   Position{Offset: 1, Line: 1, Column: 1},
+		},
+		elementRange,
+	)
+
+	elementRange, ok = expr.ElementRange(1)
+	assert.True(t, ok)
+	assert.Equal(t,
+		Range{
+			StartPos: Position{Offset: 4, Line: 1, Column: 4},
+			EndPos:   Position{Offset: 7, Line: 1, Column: 7},
+		},
+		elementRange,
+	)
+
+	for _, index := range []int{-1, 2} {
+		_, ok = expr.ElementRange(index)
+		assert.False(t, ok)
+	}
+
+	// Missing elements have no range
+
+	_, ok = (&ArrayExpression{Values: []Expression{nil}}).ElementRange(0)
+	assert.False(t, ok)
+}
+
+func TestArrayExpression_IsHomogeneousLiteral(t *testing.T) {
+
+	t.Parallel()
+
+	newIntegerExpression := func(value int64) *IntegerExpression {
+		return &IntegerExpression{
+			PositiveLiteral: strconv.FormatInt(value, 10),
+			Value:           big.NewInt(value),
+			Base:            10,
+		}
+	}
+
+	type testCase struct {
+		values []Expression
+		kind   string
+		ok     bool
+	}
+
+	testCases := map[string]testCase{
+		"integers": {
+			values: []Expression{
+				newIntegerExpression(1),
+				newIntegerExpression(2),
+				newIntegerExpression(255),
+			},
+			kind: "IntegerExpression",
+			ok:   true,
+		},
+		"strings": {
+			values: []Expression{
+				&StringExpression{Value: "a"},
+				&StringExpression{Value: "b"},
+			},
+			kind: "StringExpression",
+			ok:   true,
+		},
+		"single": {
+			values: []Expression{
+				&BoolExpression{Value: true},
+			},
+			kind: "BoolExpression",
+			ok:   true,
+		},
+		"mixed literals": {
+			values: []Expression{
+				newIntegerExpression(1),
+				&StringExpression{Value: "b"},
+			},
+		},
+		"non-literal": {
+			values: []Expression{
+				newIntegerExpression(1),
+				newTestIdentifierExpression("x"),
+			},
+		},
+		"nested array": {
+			values: []Expression{
+				&ArrayExpression{},
+				&ArrayExpression{},
+			},
+		},
+		"missing": {
+			values: []Expression{
+				newIntegerExpression(1),
+				nil,
+			},
+		},
+		"empty": {},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			expr := &ArrayExpression{
+				Values: testCase.values,
+			}
+
+			kind, ok := expr.IsHomogeneousLiteral()
+			assert.Equal(t, testCase.ok, ok)
+			assert.Equal(t, testCase.kind, kind)
+		})
+	}
+}
+
+func TestArrayExpression_String(t *testing.T) {
+
+	t.Parallel()
+
+	assert.Equal(t, "[]", (&ArrayExpression{}).String())
+
+	assert.Equal(t,
+		"[a, bc]",
+		(&ArrayExpression{
+			Values: []Expression{
+				newTestIdentifierExpression("a"),
+				newTestIdentifierExpression("bc"),
+			},
+		}).String(),
+	)
+}
+
+func TestDictionaryExpression_String(t *testing.T) {
+
+	t.Parallel()
+
+	assert.Equal(t, "{}", (&DictionaryExpression{}).String())
+
+	assert.Equal(t,
+		"{a: b, cd: ef}",
+		(&DictionaryExpression{
+			Entries: []DictionaryEntry{
+				{
+					Key:   newTestIdentifierExpression("a"),
+					Value: newTestIdentifierExpression("b"),
+				},
+				{
+					Key:   newTestIdentifierExpression("cd"),
+					Value: newTestIdentifierExpression("ef"),
+				},
+			},
+		}).String(),
+	)
+}
+
+func TestDictionaryEntry_Positions(t *testing.T) {
+
+	t.Parallel()
+
+	// {"a": 1, b: true}
+
+	dictionary := &DictionaryExpression{
+		Entries: []DictionaryEntry{
+			{
+				Key: &StringExpression{
+					Value: "a",
+					Range: Range{
+						StartPos: Position{Offset: 1, Line: 1, Column: 1},
+						EndPos:   Position{Offset: 3, Line: 1, Column: 3},
+					},
+				},
+				Value: &IntegerExpression{
+					PositiveLiteral: "1",
+					Value:           big.NewInt(1),
+					Base:            10,
+					Range: Range{
+						StartPos: Position{Offset: 6, Line: 1, Column: 6},
+						EndPos:   Position{Offset: 6, Line: 1, Column: 6},
+					},
+				},
+			},
+			{
+				Key: &IdentifierExpression{
+					Identifier: Identifier{
+						Identifier: "b",
+						Pos:        Position{Offset: 9, Line: 1, Column: 9},
+					},
+				},
+				Value: &BoolExpression{
+					Value: true,
+					Range: Range{
+						StartPos: Position{Offset: 12, Line: 1, Column: 12},
+						EndPos:   Position{Offset: 15, Line: 1, Column: 15},
+					},
+				},
+			},
+		},
+		Range: Range{
+			StartPos: Position{Offset: 0, Line: 1, Column: 0},
+			EndPos:   Position{Offset: 16, Line: 1, Column: 16},
+		},
+	}
+
+	assert.Equal(t,
+		[]Range{
+			{
+				StartPos: Position{Offset: 1, Line: 1, Column: 1},
+				EndPos:   Position{Offset: 6, Line: 1, Column: 6},
+			},
+			{
+				StartPos: Position{Offset: 9, Line: 1, Column: 9},
+				EndPos:   Position{Offset: 15, Line: 1, Column: 15},
+			},
+		},
+		[]Range{
+			NewRangeFromPositioned(dictionary.Entries[0]),
+			NewRangeFromPositioned(dictionary.Entries[1]),
+		},
+	)
+
+	t.Run("incomplete", func(t *testing.T) {
+
+		t.Parallel()
+
+		key := dictionary.Entries[0].Key
+		value := dictionary.Entries[1].Value
+
+		assert.Equal(t,
+			NewRangeFromPositioned(key),
+			NewRangeFromPositioned(DictionaryEntry{Key: key}),
+		)
+
+		assert.Equal(t,
+			NewRangeFromPositioned(value),
+			NewRangeFromPositioned(DictionaryEntry{Value: value}),
+		)
+
+		assert.Equal(t,
+			Range{},
+			NewRangeFromPositioned(DictionaryEntry{}),
+		)
+	})
+}
+
+func TestDictionaryExpression_EntryByKey(t *testing.T) {
+
+	t.Parallel()
+
+	// {"a": 1, 0x2: 2, "a": 3}
+
+	expression := &DictionaryExpression{
+		Entries: []DictionaryEntry{
+			{
+				Key:   &StringExpression{Value: "a"},
+				Value: NewIntegerExpression(big.NewInt(1), Position{}),
+			},
+			{
+				Key: &IntegerExpression{
+					PositiveLiteral: "0x2",
+					Value:           big.NewInt(2),
+					Base:            16,
+				},
+				Value: NewIntegerExpression(big.NewInt(2), Position{}),
+			},
+			{
+				Key:   &StringExpression{Value: "a"},
+				Value: NewIntegerExpression(big.NewInt(3), Position{}),
+			},
+		},
+	}
+
+	t.Run("string key", func(t *testing.T) {
+
+		t.Parallel()
+
+		entry, ok := expression.EntryByKey(&StringExpression{Value: "a"}, nil)
+		require.True(t, ok)
+		assert.Same(t, &expression.Entries[0], entry)
+	})
+
+	t.Run("integer key, compared by value", func(t *testing.T) {
+
+		t.Parallel()
+
+		entry, ok := expression.EntryByKey(NewIntegerExpression(big.NewInt(2), Position{}), nil)
+		require.True(t, ok)
+		assert.Same(t, &expression.Entries[1], entry)
+	})
+
+	t.Run("custom equality", func(t *testing.T) {
+
+		t.Parallel()
+
+		entry, ok := expression.EntryByKey(
+			NewIntegerExpression(big.NewInt(2), Position{}),
+			EqualExpressionsLiterally,
+		)
+		assert.False(t, ok)
+		assert.Nil(t, entry)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+
+		t.Parallel()
+
+		entry, ok := expression.EntryByKey(&StringExpression{Value: "b"}, nil)
+		assert.False(t, ok)
+		assert.Nil(t, entry)
+	})
+}
+
+func TestDictionaryExpression_DeduplicateEntries(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("duplicates", func(t *testing.T) {
+
+		t.Parallel()
+
+		newInteger := func(value int64) *IntegerExpression {
+			return NewIntegerExpression(big.NewInt(value), Position{})
+		}
+
+		// {"a": 1, 2: 2, "b": 3, "a": 4, 0x2: 5, "b": 6, 3: 7}
+
+		expression := &DictionaryExpression{
+			Entries: []DictionaryEntry{
+				{Key: &StringExpression{Value: "a"}, Value: newInteger(1)},
+				{Key: newInteger(2), Value: newInteger(2)},
+				{Key: &StringExpression{Value: "b"}, Value: newInteger(3)},
+				{Key: &StringExpression{Value: "a"}, Value: newInteger(4)},
+				{
+					Key: &IntegerExpression{
+						PositiveLiteral: "0x2",
+						Value:           big.NewInt(2),
+						Base:            16,
+					},
+					Value: newInteger(5),
+				},
+				{Key: &StringExpression{Value: "b"}, Value: newInteger(6)},
+				{Key: newInteger(3), Value: newInteger(7)},
+			},
+		}
+
+		// Populate the document cache
+
+		assert.Equal(t,
+			`{"a": 1, 2: 2, "b": 3, "a": 4, 0x2: 5, "b": 6, 3: 7}`,
+			testDocString(expression.Doc()),
+		)
+
+		removed := expression.DeduplicateEntries()
+
+		assert.Equal(t,
+			`{"a": 4, 0x2: 5, "b": 6}`,
+			(&DictionaryExpression{Entries: removed}).String(),
+		)
+
+		assert.Equal(t,
+			`{"a": 1, 2: 2, "b": 3, 3: 7}`,
+			expression.String(),
+		)
+
+		assert.Equal(t,
+			`{"a": 1, 2: 2, "b": 3, 3: 7}`,
+			testDocString(expression.Doc()),
+		)
+	})
+
+	t.Run("no duplicates", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := &DictionaryExpression{
+			Entries: []DictionaryEntry{
+				{
+					Key:   &StringExpression{Value: "a"},
+					Value: &StringExpression{Value: "a"},
+				},
+				{
+					Key:   &StringExpression{Value: "b"},
+					Value: &StringExpression{Value: "a"},
+				},
+			},
+		}
+
+		assert.Nil(t, expression.DeduplicateEntries())
+		assert.Len(t, expression.Entries, 2)
+	})
+}
+
+func BenchmarkArrayExpression_String(b *testing.B) {
+
+	values := make([]Expression, 5_000)
+	for i := range values {
+		values[i] = &IntegerExpression{
+			PositiveLiteral: strconv.Itoa(i),
+			Value:           big.NewInt(int64(i)),
+			Base:            10,
+		}
+	}
+
+	expr := &ArrayExpression{
+		Values: values,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = expr.String()
+	}
+}
+
+func TestIdentifierExpression_MarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	expr := &IdentifierExpression{
+		Identifier: Identifier{
+			Identifier: "foobar",
+			Pos:        Position{Offset: 1, Line: 2, Column: 3},
+		},
+	}
+
+	actual, err := json.Marshal(expr)
+	require.NoError(t, err)
+
+	assert.JSONEq(t,
+		`
+        {
+            "Type": "IdentifierExpression",
+            "Identifier": {
+                "Identifier": "foobar",
+                "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+                "EndPos": {"Offset": 6, "Line": 2, "Column": 8}
+            },
+            "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+            "EndPos": {"Offset": 6, "Line": 2, "Column": 8}
+        }
+        `,
+		string(actual),
+	)
+}
+
+func TestIdentifierExpression_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	assert.Equal(t,
+		prettier.Text("test"),
+		(&IdentifierExpression{
+			Identifier: Identifier{
+				Identifier: "test",
+			},
+		}).Doc(),
+	)
+}
+
+func TestPathExpression_MarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	expr := &PathExpression{
+		StartPos: Position{Offset: 1, Line: 2, Column: 3},
+		Domain: Identifier{
+			Identifier: "storage",
+			Pos:        Position{Offset: 4, Line: 5, Column: 6},
+		},
+		Identifier: Identifier{
+			Identifier: "foobar",
+			Pos:        Position{Offset: 7, Line: 8, Column: 9},
+		},
+	}
+
+	actual, err := json.Marshal(expr)
+	require.NoError(t, err)
+
+	assert.JSONEq(t,
+		`
+        {
+            "Type": "PathExpression",
+            "Domain": {
+                "Identifier": "storage",
+                "StartPos": {"Offset": 4, "Line": 5, "Column": 6},
+                "EndPos": {"Offset": 10, "Line": 5, "Column": 12}
+            },
+            "Identifier": {
+                "Identifier": "foobar",
+                "StartPos": {"Offset": 7, "Line": 8, "Column": 9},
+                "EndPos": {"Offset": 12, "Line": 8, "Column": 14}
+            },
+            "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+            "EndPos": {"Offset": 12, "Line": 8, "Column": 14}
+        }
+        `,
+		string(actual),
+	)
+}
+
+func TestPathExpression_DomainKind(t *testing.T) {
+
+	t.Parallel()
+
+	newPath := func(domain string) *PathExpression {
+		return &PathExpression{
+			Domain:     Identifier{Identifier: domain},
+			Identifier: Identifier{Identifier: "foo"},
+		}
+	}
+
+	for _, domain := range common.AllPathDomains {
+
+		path := newPath(domain.Identifier())
+
+		kind, ok := path.DomainKind()
+		assert.True(t, ok)
+		assert.Equal(t, domain, kind)
+		assert.True(t, path.IsValidDomain())
+	}
+
+	assert.Equal(t, "PathDomainStorage", common.PathDomainStorage.String())
+
+	path := newPath("invalid")
+
+	kind, ok := path.DomainKind()
+	assert.False(t, ok)
+	assert.Equal(t, common.PathDomainUnknown, kind)
+	assert.False(t, path.IsValidDomain())
+
+	// Domains are case-sensitive
+
+	assert.False(t, newPath("Storage").IsValidDomain())
+}
+
+func TestPathExpression_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	expr := &PathExpression{
+		Domain: Identifier{
+			Identifier: "storage",
+		},
+		Identifier: Identifier{
+			Identifier: "test",
+		},
+	}
+
+	assert.Equal(t,
+		prettier.Text("/storage/test"),
+		expr.Doc(),
+	)
+}
+
+func TestMemberExpression_MarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	expr := &MemberExpression{
+		Expression: &BoolExpression{
+			Value: true,
+			Range: Range{
+				StartPos: Position{Offset: 1, Line: 2, Column: 3},
+				EndPos:   Position{Offset: 4, Line: 5, Column: 6},
+			},
+		},
+		Optional:  true,
+		AccessPos: Position{Offset: 7, Line: 8, Column: 9},
+		Identifier: Identifier{
+			Identifier: "foobar",
+			Pos:        Position{Offset: 10, Line: 11, Column: 12},
+		},
+	}
+
+	actual, err := json.Marshal(expr)
+	require.NoError(t, err)
+
+	assert.JSONEq(t,
+		`
+        {
+            "Type": "MemberExpression",
+            "Expression": {
+                "Type": "BoolExpression",
+                "Value": true,
+                "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+                "EndPos": {"Offset": 4, "Line": 5, "Column": 6}
+            },
+            "Optional": true,
+            "AccessPos": {"Offset": 7, "Line": 8, "Column": 9},
+            "Identifier": {
+                "Identifier": "foobar",
+                "StartPos": {"Offset": 10, "Line": 11, "Column": 12},
+                "EndPos": {"Offset": 15, "Line": 11, "Column": 17}
+            },
+            "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+            "EndPos": {"Offset": 15, "Line": 11, "Column": 17}
+        }
+        `,
+		string(actual),
+	)
+}
+
+func TestMemberExpression_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("non-optional", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &MemberExpression{
+			Expression: &IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "foo",
+				},
+			},
+			Identifier: Identifier{
+				Identifier: "bar",
+			},
+		}
+
+		assert.Equal(t,
+			prettier.Concat{
+				prettier.Text("foo"),
+				prettier.Text("."),
+				prettier.Text("bar"),
+			},
+			expr.Doc(),
+		)
+	})
+
+	t.Run("optional", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &MemberExpression{
+			Expression: &IdentifierExpression{
 				Identifier: Identifier{
 					Identifier: "foo",
 				},
 			},
+			Optional: true,
 			Identifier: Identifier{
 				Identifier: "bar",
 			},
@@ -748,51 +2102,377 @@ func TestMemberExpression_Doc(t *testing.T) {
 		assert.Equal(t,
 			prettier.Concat{
 				prettier.Text("foo"),
+				prettier.Text("?."),
+				prettier.Text("bar"),
+			},
+			expr.Doc(),
+		)
+	})
+}
+
+func TestMemberExpression_Doc_Chain(t *testing.T) {
+
+	t.Parallel()
+
+	newMember := func(expression Expression, identifier string) *MemberExpression {
+		return &MemberExpression{
+			Expression: expression,
+			Identifier: Identifier{
+				Identifier: identifier,
+			},
+		}
+	}
+
+	newInvocation := func(invokedExpression Expression, arguments ...Expression) *InvocationExpression {
+		invocation := &InvocationExpression{
+			InvokedExpression: invokedExpression,
+		}
+		for _, argument := range arguments {
+			invocation.Arguments = append(
+				invocation.Arguments,
+				&Argument{Expression: argument},
+			)
+		}
+		return invocation
+	}
+
+	t.Run("structure", func(t *testing.T) {
+
+		t.Parallel()
+
+		// a.b?.c
+
+		expr := &MemberExpression{
+			Expression: newMember(newTestIdentifierExpression("a"), "b"),
+			Optional:   true,
+			Identifier: Identifier{
+				Identifier: "c",
+			},
+		}
+
+		assert.Equal(t,
+			prettier.Concat{
+				prettier.Text("a"),
 				prettier.Group{
 					Doc: prettier.Indent{
 						Doc: prettier.Concat{
 							prettier.SoftLine{},
-							prettier.Text("."),
-							prettier.Text("bar"),
+							prettier.Concat{
+								prettier.Text("."),
+								prettier.Text("b"),
+							},
+							prettier.SoftLine{},
+							prettier.Concat{
+								prettier.Text("?."),
+								prettier.Text("c"),
+							},
+						},
+					},
+				},
+			},
+			expr.Doc(),
+		)
+	})
+
+	// account.storage.borrow(path).withdraw(amount).deposit(vault).result
+
+	var expr Expression = newTestIdentifierExpression("account")
+	expr = newMember(expr, "storage")
+	expr = newInvocation(newMember(expr, "borrow"), newTestIdentifierExpression("path"))
+	expr = newInvocation(newMember(expr, "withdraw"), newTestIdentifierExpression("amount"))
+	expr = newInvocation(newMember(expr, "deposit"), newTestIdentifierExpression("vault"))
+	expr = newMember(expr, "result")
+
+	t.Run("wide", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			"account.storage.borrow(path).withdraw(amount).deposit(vault).result",
+			Format(expr, FormatOptions{MaxLineWidth: 120}),
+		)
+	})
+
+	t.Run("narrow", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			`account
+    .storage
+    .borrow(path)
+    .withdraw(amount)
+    .deposit(vault)
+    .result`,
+			Format(expr, FormatOptions{MaxLineWidth: 40}),
+		)
+	})
+
+	t.Run("invoked", func(t *testing.T) {
+
+		t.Parallel()
+
+		invocation := newInvocation(newMember(expr, "unwrap"))
+
+		assert.Equal(t,
+			`account
+    .storage
+    .borrow(path)
+    .withdraw(amount)
+    .deposit(vault)
+    .result
+    .unwrap()`,
+			Format(invocation, FormatOptions{MaxLineWidth: 40}),
+		)
+	})
+
+	t.Run("single access", func(t *testing.T) {
+
+		t.Parallel()
+
+		member := newMember(
+			newTestIdentifierExpression("someVeryLongIdentifier"),
+			"someVeryLongMember",
+		)
+
+		assert.Equal(t,
+			"someVeryLongIdentifier.someVeryLongMember",
+			Format(member, FormatOptions{MaxLineWidth: 10}),
+		)
+	})
+}
+
+func TestMemberExpression_Doc_Parentheses(t *testing.T) {
+
+	t.Parallel()
+
+	a := newTestIdentifierExpression("a")
+	b := newTestIdentifierExpression("b")
+
+	type testCase struct {
+		expr     Expression
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"nil-coalescing": {
+			expr: &MemberExpression{
+				Expression: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      a,
+					Right:     b,
+				},
+				Identifier: Identifier{
+					Identifier: "foo",
+				},
+			},
+			expected: "(a ?? b).foo",
+		},
+		"optional chaining on force": {
+			expr: &MemberExpression{
+				Expression: &ForceExpression{
+					Expression: a,
+				},
+				Optional: true,
+				Identifier: Identifier{
+					Identifier: "b",
+				},
+			},
+			expected: "a!?.b",
+		},
+		"optional chaining on unary": {
+			expr: &MemberExpression{
+				Expression: &UnaryExpression{
+					Operation:  OperationMove,
+					Expression: a,
+				},
+				Optional: true,
+				Identifier: Identifier{
+					Identifier: "b",
+				},
+			},
+			expected: "(<-a)?.b",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				testDocString(testCase.expr.Doc()),
+			)
+		})
+	}
+}
+
+func TestHasOptionalAccess(t *testing.T) {
+
+	t.Parallel()
+
+	member := func(expression Expression, optional bool, name string) *MemberExpression {
+		return &MemberExpression{
+			Expression: expression,
+			Optional:   optional,
+			Identifier: Identifier{
+				Identifier: name,
+			},
+		}
+	}
+
+	index := func(expression Expression) *IndexExpression {
+		return &IndexExpression{
+			TargetExpression: expression,
+			IndexingExpression: &IntegerExpression{
+				PositiveLiteral: "0",
+				Value:           big.NewInt(0),
+				Base:            10,
+			},
+		}
+	}
+
+	a := newTestIdentifierExpression("a")
+
+	type testCase struct {
+		expression Expression
+		expected   bool
+	}
+
+	testCases := map[string]testCase{
+		"a": {
+			expression: a,
+			expected:   false,
+		},
+		"a.b.c.d": {
+			expression: member(member(member(a, false, "b"), false, "c"), false, "d"),
+			expected:   false,
+		},
+		"a?.b.c?.d": {
+			expression: member(member(member(a, true, "b"), false, "c"), true, "d"),
+			expected:   true,
+		},
+		"a?.b.c.d": {
+			expression: member(member(member(a, true, "b"), false, "c"), false, "d"),
+			expected:   true,
+		},
+		"a.b[0].c": {
+			expression: member(index(member(a, false, "b")), false, "c"),
+			expected:   false,
+		},
+		"a?.b[0].c": {
+			expression: member(index(member(a, true, "b")), false, "c"),
+			expected:   true,
+		},
+		"a?.b()[0]": {
+			expression: index(
+				&InvocationExpression{
+					InvokedExpression: member(a, true, "b"),
+				},
+			),
+			expected: true,
+		},
+		"(a?.b ?? c).d": {
+			expression: member(
+				&BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      member(a, true, "b"),
+					Right:     newTestIdentifierExpression("c"),
+				},
+				false,
+				"d",
+			),
+			expected: false,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				HasOptionalAccess(testCase.expression),
+			)
+		})
+	}
+}
+
+func TestRootExpression(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("chain", func(t *testing.T) {
+
+		t.Parallel()
+
+		// a.b()[0]!.c
+
+		a := newTestIdentifierExpression("a")
+
+		expression := &MemberExpression{
+			Expression: &ForceExpression{
+				Expression: &IndexExpression{
+					TargetExpression: &InvocationExpression{
+						InvokedExpression: &MemberExpression{
+							Expression: a,
+							Identifier: Identifier{
+								Identifier: "b",
+							},
 						},
 					},
+					IndexingExpression: &IntegerExpression{
+						PositiveLiteral: "0",
+						Value:           big.NewInt(0),
+						Base:            10,
+					},
 				},
 			},
-			expr.Doc(),
-		)
+			Identifier: Identifier{
+				Identifier: "c",
+			},
+		}
+
+		assert.Same(t, a, RootExpression(expression))
 	})
 
-	t.Run("optional", func(t *testing.T) {
+	t.Run("non-chain", func(t *testing.T) {
 
 		t.Parallel()
 
-		expr := &MemberExpression{
-			Expression: &IdentifierExpression{
+		a := newTestIdentifierExpression("a")
+
+		assert.Same(t, a, RootExpression(a))
+	})
+
+	t.Run("stops at other expressions", func(t *testing.T) {
+
+		t.Parallel()
+
+		// (x ?? y).z()
+
+		binary := &BinaryExpression{
+			Operation: OperationNilCoalesce,
+			Left:      newTestIdentifierExpression("x"),
+			Right:     newTestIdentifierExpression("y"),
+		}
+
+		expression := &InvocationExpression{
+			InvokedExpression: &MemberExpression{
+				Expression: binary,
 				Identifier: Identifier{
-					Identifier: "foo",
+					Identifier: "z",
 				},
 			},
-			Optional: true,
-			Identifier: Identifier{
-				Identifier: "bar",
-			},
 		}
 
-		assert.Equal(t,
-			prettier.Concat{
-				prettier.Text("foo"),
-				prettier.Group{
-					Doc: prettier.Indent{
-						Doc: prettier.Concat{
-							prettier.SoftLine{},
-							prettier.Text("?."),
-							prettier.Text("bar"),
-						},
-					},
-				},
-			},
-			expr.Doc(),
-		)
+		assert.Same(t, binary, RootExpression(expression))
 	})
 }
 
@@ -881,6 +2561,85 @@ func TestIndexExpression_Doc(t *testing.T) {
 	)
 }
 
+func TestIndexExpression_Doc_Parentheses(t *testing.T) {
+
+	t.Parallel()
+
+	x := newTestIdentifierExpression("x")
+
+	newIndex := func(i int64) *IntegerExpression {
+		return &IntegerExpression{
+			PositiveLiteral: big.NewInt(i).String(),
+			Value:           big.NewInt(i),
+			Base:            10,
+		}
+	}
+
+	cast := &CastingExpression{
+		Operation:  OperationCast,
+		Expression: x,
+		TypeAnnotation: &TypeAnnotation{
+			Type: &VariableSizedType{
+				Type: &VariableSizedType{
+					Type: &NominalType{
+						Identifier: Identifier{
+							Identifier: "Int",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	type testCase struct {
+		expr     Expression
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"casting": {
+			expr: &IndexExpression{
+				TargetExpression:   cast,
+				IndexingExpression: newIndex(0),
+			},
+			expected: "(x as [[Int]])[0]",
+		},
+		"chained indexing on casting": {
+			expr: &IndexExpression{
+				TargetExpression: &IndexExpression{
+					TargetExpression:   cast,
+					IndexingExpression: newIndex(0),
+				},
+				IndexingExpression: newIndex(1),
+			},
+			expected: "(x as [[Int]])[0][1]",
+		},
+		"force": {
+			expr: &IndexExpression{
+				TargetExpression: &ForceExpression{
+					Expression: x,
+				},
+				IndexingExpression: newIndex(0),
+			},
+			expected: "x![0]",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				testDocString(testCase.expr.Doc()),
+			)
+		})
+	}
+}
+
 func TestUnaryExpression_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -906,7 +2665,7 @@ func TestUnaryExpression_MarshalJSON(t *testing.T) {
 		`
         {
             "Type": "UnaryExpression",
-            "Operation": "OperationNegate",
+            "Operation": "Negate",
             "Expression": {
                 "Type": "IntegerExpression",
                 "PositiveLiteral": "42",
@@ -945,6 +2704,272 @@ func TestUnaryExpression_Doc(t *testing.T) {
 	)
 }
 
+func TestUnaryExpression_Doc_Parentheses(t *testing.T) {
+
+	t.Parallel()
+
+	a := newTestIdentifierExpression("a")
+	b := newTestIdentifierExpression("b")
+
+	type testCase struct {
+		expr     Expression
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"binary": {
+			expr: &UnaryExpression{
+				Operation: OperationMinus,
+				Expression: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      a,
+					Right:     b,
+				},
+			},
+			expected: "-(a + b)",
+		},
+		"casting": {
+			expr: &UnaryExpression{
+				Operation: OperationNegate,
+				Expression: &CastingExpression{
+					Operation:  OperationForceCast,
+					Expression: a,
+					TypeAnnotation: &TypeAnnotation{
+						Type: &NominalType{
+							Identifier: Identifier{
+								Identifier: "Bool",
+							},
+						},
+					},
+				},
+			},
+			expected: "!(a as! Bool)",
+		},
+		"conditional": {
+			expr: &UnaryExpression{
+				Operation: OperationNegate,
+				Expression: &ConditionalExpression{
+					Test: a,
+					Then: b,
+					Else: a,
+				},
+			},
+			expected: "!(a ? b : a)",
+		},
+		"member": {
+			expr: &UnaryExpression{
+				Operation: OperationNegate,
+				Expression: &MemberExpression{
+					Expression: a,
+					Identifier: Identifier{
+						Identifier: "b",
+					},
+				},
+			},
+			expected: "!a.b",
+		},
+		"double negation": {
+			expr: &UnaryExpression{
+				Operation: OperationMinus,
+				Expression: &UnaryExpression{
+					Operation:  OperationMinus,
+					Expression: a,
+				},
+			},
+			expected: "-(-a)",
+		},
+		"double logical negation": {
+			expr: &UnaryExpression{
+				Operation: OperationNegate,
+				Expression: &UnaryExpression{
+					Operation:  OperationNegate,
+					Expression: a,
+				},
+			},
+			expected: "!(!a)",
+		},
+		"negative literal": {
+			expr: &UnaryExpression{
+				Operation: OperationMinus,
+				Expression: &IntegerExpression{
+					PositiveLiteral: "1",
+					Value:           big.NewInt(-1),
+					Base:            10,
+				},
+			},
+			expected: "-(-1)",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				testDocString(testCase.expr.Doc()),
+			)
+		})
+	}
+}
+
+func TestUnaryExpression_Move(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("identifier", func(t *testing.T) {
+
+		t.Parallel()
+
+		// <- r
+
+		target := &IdentifierExpression{
+			Identifier: Identifier{
+				Identifier: "r",
+				Pos:        Position{Offset: 3, Line: 1, Column: 3},
+			},
+		}
+
+		expr := &UnaryExpression{
+			Operation:  OperationMove,
+			Expression: target,
+			StartPos:   Position{Offset: 0, Line: 1, Column: 0},
+		}
+
+		assert.Equal(t, "<-r", expr.String())
+		assert.Equal(t, "(<-r)", expr.CanonicalString())
+		assert.Equal(t, "<-r", testDocString(expr.Doc()))
+
+		assert.Equal(t,
+			Range{
+				StartPos: Position{Offset: 0, Line: 1, Column: 0},
+				EndPos:   Position{Offset: 3, Line: 1, Column: 3},
+			},
+			NewRangeFromPositioned(expr),
+		)
+
+		var children []Element
+		expr.Walk(func(child Element) {
+			children = append(children, child)
+		})
+		assert.Equal(t, []Element{target}, children)
+	})
+
+	t.Run("force", func(t *testing.T) {
+
+		t.Parallel()
+
+		// <-r!
+		//
+		// The force operator binds tighter than the move operator,
+		// so the moved expression is not parenthesized
+
+		expr := &UnaryExpression{
+			Operation: OperationMove,
+			Expression: &ForceExpression{
+				Expression: newTestIdentifierExpression("r"),
+			},
+		}
+
+		assert.Equal(t, "<-r!", expr.String())
+		assert.Equal(t, "(<-(r!))", expr.CanonicalString())
+		assert.Equal(t, "<-r!", testDocString(expr.Doc()))
+	})
+
+	t.Run("create", func(t *testing.T) {
+
+		t.Parallel()
+
+		// <-create R()
+
+		expr := &UnaryExpression{
+			Operation: OperationMove,
+			Expression: &CreateExpression{
+				InvocationExpression: &InvocationExpression{
+					InvokedExpression: newTestIdentifierExpression("R"),
+				},
+			},
+		}
+
+		assert.Equal(t, "<-create R()", expr.String())
+		assert.Equal(t, "<-create R()", testDocString(expr.Doc()))
+	})
+}
+
+func TestBinaryExpression_Positions(t *testing.T) {
+
+	t.Parallel()
+
+	left := &IdentifierExpression{
+		Identifier: Identifier{
+			Identifier: "a",
+			Pos:        Position{Offset: 1, Line: 2, Column: 3},
+		},
+	}
+
+	right := &IdentifierExpression{
+		Identifier: Identifier{
+			Identifier: "b",
+			Pos:        Position{Offset: 4, Line: 5, Column: 6},
+		},
+	}
+
+	t.Run("complete", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      left,
+			Right:     right,
+		}
+
+		assert.Equal(t, Position{Offset: 1, Line: 2, Column: 3}, expr.StartPosition())
+		assert.Equal(t, Position{Offset: 4, Line: 5, Column: 6}, expr.EndPosition())
+	})
+
+	t.Run("nil left", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &BinaryExpression{
+			Operation: OperationPlus,
+			Right:     right,
+		}
+
+		assert.Equal(t, Position{Offset: 4, Line: 5, Column: 6}, expr.StartPosition())
+		assert.Equal(t, Position{Offset: 4, Line: 5, Column: 6}, expr.EndPosition())
+	})
+
+	t.Run("nil right", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      left,
+		}
+
+		assert.Equal(t, Position{Offset: 1, Line: 2, Column: 3}, expr.StartPosition())
+		assert.Equal(t, Position{Offset: 1, Line: 2, Column: 3}, expr.EndPosition())
+	})
+
+	t.Run("nil operands", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &BinaryExpression{
+			Operation: OperationPlus,
+		}
+
+		assert.Equal(t, Position{}, expr.StartPosition())
+		assert.Equal(t, Position{}, expr.EndPosition())
+	})
+}
+
 func TestBinaryExpression_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -978,7 +3003,7 @@ func TestBinaryExpression_MarshalJSON(t *testing.T) {
 		`
         {
             "Type": "BinaryExpression",
-            "Operation": "OperationPlus",
+            "Operation": "Plus",
             "Left": {
                 "Type": "IntegerExpression",
                 "PositiveLiteral": "42",
@@ -1014,29 +3039,216 @@ func TestBinaryExpression_Doc(t *testing.T) {
 			Value:           big.NewInt(42),
 			Base:            10,
 		},
-		Right: &IntegerExpression{
-			PositiveLiteral: "99",
-			Value:           big.NewInt(99),
-			Base:            10,
+		Right: &IntegerExpression{
+			PositiveLiteral: "99",
+			Value:           big.NewInt(99),
+			Base:            10,
+		},
+	}
+
+	assert.Equal(t,
+		prettier.Group{
+			Doc: prettier.Concat{
+				prettier.Group{
+					Doc: prettier.Text("42"),
+				},
+				prettier.Line{},
+				prettier.Text("+"),
+				prettier.Space,
+				prettier.Group{
+					Doc: prettier.Text("99"),
+				},
+			},
+		},
+		expr.Doc(),
+	)
+}
+
+func TestBinaryExpression_Doc_Parentheses(t *testing.T) {
+
+	t.Parallel()
+
+	a := newTestIdentifierExpression("a")
+	b := newTestIdentifierExpression("b")
+	c := newTestIdentifierExpression("c")
+
+	type testCase struct {
+		expr     Expression
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"lower precedence left": {
+			expr: &BinaryExpression{
+				Operation: OperationMul,
+				Left: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      a,
+					Right:     b,
+				},
+				Right: c,
+			},
+			expected: "(a + b) * c",
+		},
+		"higher precedence right": {
+			expr: &BinaryExpression{
+				Operation: OperationPlus,
+				Left:      a,
+				Right: &BinaryExpression{
+					Operation: OperationMul,
+					Left:      b,
+					Right:     c,
+				},
+			},
+			expected: "a + b * c",
+		},
+		"left-associative, left": {
+			expr: &BinaryExpression{
+				Operation: OperationMinus,
+				Left: &BinaryExpression{
+					Operation: OperationMinus,
+					Left:      a,
+					Right:     b,
+				},
+				Right: c,
+			},
+			expected: "a - b - c",
+		},
+		"left-associative, right": {
+			expr: &BinaryExpression{
+				Operation: OperationMinus,
+				Left:      a,
+				Right: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      b,
+					Right:     c,
+				},
+			},
+			expected: "a - (b + c)",
+		},
+		"arithmetic in comparison": {
+			expr: &BinaryExpression{
+				Operation: OperationLess,
+				Left: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      a,
+					Right:     b,
+				},
+				Right: c,
+			},
+			expected: "a + b < c",
+		},
+		"comparison in comparison": {
+			expr: &BinaryExpression{
+				Operation: OperationEqual,
+				Left:      a,
+				Right: &BinaryExpression{
+					Operation: OperationLess,
+					Left:      b,
+					Right:     c,
+				},
+			},
+			expected: "a == (b < c)",
+		},
+		"or in and": {
+			expr: &BinaryExpression{
+				Operation: OperationAnd,
+				Left:      a,
+				Right: &BinaryExpression{
+					Operation: OperationOr,
+					Left:      b,
+					Right:     c,
+				},
+			},
+			expected: "a && (b || c)",
+		},
+		"and in or": {
+			expr: &BinaryExpression{
+				Operation: OperationOr,
+				Left: &BinaryExpression{
+					Operation: OperationAnd,
+					Left:      a,
+					Right:     b,
+				},
+				Right: c,
+			},
+			expected: "a && b || c",
+		},
+		"right-associative, left": {
+			expr: &BinaryExpression{
+				Operation: OperationOr,
+				Left: &BinaryExpression{
+					Operation: OperationOr,
+					Left:      a,
+					Right:     b,
+				},
+				Right: c,
+			},
+			expected: "(a || b) || c",
 		},
-	}
-
-	assert.Equal(t,
-		prettier.Group{
-			Doc: prettier.Concat{
-				prettier.Group{
-					Doc: prettier.Text("42"),
+		"right-associative, right": {
+			expr: &BinaryExpression{
+				Operation: OperationNilCoalesce,
+				Left:      a,
+				Right: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      b,
+					Right:     c,
 				},
-				prettier.Line{},
-				prettier.Text("+"),
-				prettier.Space,
-				prettier.Group{
-					Doc: prettier.Text("99"),
+			},
+			expected: "a ?? b ?? c",
+		},
+		"nil-coalescing, left": {
+			expr: &BinaryExpression{
+				Operation: OperationNilCoalesce,
+				Left: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      a,
+					Right:     b,
 				},
+				Right: c,
 			},
+			expected: "(a ?? b) ?? c",
 		},
-		expr.Doc(),
-	)
+		"nil-coalescing in arithmetic": {
+			expr: &BinaryExpression{
+				Operation: OperationPlus,
+				Left: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      a,
+					Right:     b,
+				},
+				Right: c,
+			},
+			expected: "(a ?? b) + c",
+		},
+		"nil-coalescing in comparison": {
+			expr: &BinaryExpression{
+				Operation: OperationEqual,
+				Left:      a,
+				Right: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      b,
+					Right:     c,
+				},
+			},
+			expected: "a == b ?? c",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				testDocString(testCase.expr.Doc()),
+			)
+		})
+	}
 }
 
 func TestDestroyExpression_MarshalJSON(t *testing.T) {
@@ -1099,6 +3311,151 @@ func TestDestroyExpression_Doc(t *testing.T) {
 	)
 }
 
+func TestAttachmentExpression_MarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	expr := &AttachmentExpression{
+		Base: &IdentifierExpression{
+			Identifier: Identifier{
+				Identifier: "r",
+				Pos:        Position{Offset: 1, Line: 2, Column: 3},
+			},
+		},
+		Attachment: &InvocationExpression{
+			InvokedExpression: &IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "A",
+					Pos:        Position{Offset: 4, Line: 5, Column: 6},
+				},
+			},
+			TypeArguments:     []*TypeAnnotation{},
+			Arguments:         []*Argument{},
+			ArgumentsStartPos: Position{Offset: 7, Line: 8, Column: 9},
+			EndPos:            Position{Offset: 10, Line: 11, Column: 12},
+		},
+		StartPos: Position{Offset: 13, Line: 14, Column: 15},
+	}
+
+	actual, err := json.Marshal(expr)
+	require.NoError(t, err)
+
+	assert.JSONEq(t,
+		`
+        {
+            "Type": "AttachmentExpression",
+            "Base": {
+                "Type": "IdentifierExpression",
+                "Identifier": {
+                    "Identifier": "r",
+                    "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+                    "EndPos": {"Offset": 1, "Line": 2, "Column": 3}
+                },
+                "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+                "EndPos": {"Offset": 1, "Line": 2, "Column": 3}
+            },
+            "Attachment": {
+                "Type": "InvocationExpression",
+                "InvokedExpression": {
+                    "Type": "IdentifierExpression",
+                    "Identifier": {
+                        "Identifier": "A",
+                        "StartPos": {"Offset": 4, "Line": 5, "Column": 6},
+                        "EndPos": {"Offset": 4, "Line": 5, "Column": 6}
+                    },
+                    "StartPos": {"Offset": 4, "Line": 5, "Column": 6},
+                    "EndPos": {"Offset": 4, "Line": 5, "Column": 6}
+                },
+                "TypeArguments": [],
+                "Arguments": [],
+                "ArgumentsStartPos": {"Offset": 7, "Line": 8, "Column": 9},
+                "StartPos": {"Offset": 4, "Line": 5, "Column": 6},
+                "EndPos": {"Offset": 10, "Line": 11, "Column": 12}
+            },
+            "StartPos": {"Offset": 13, "Line": 14, "Column": 15},
+            "EndPos": {"Offset": 1, "Line": 2, "Column": 3}
+        }
+        `,
+		string(actual),
+	)
+}
+
+func TestAttachmentExpression_String(t *testing.T) {
+
+	t.Parallel()
+
+	expr := &AttachmentExpression{
+		Base: &UnaryExpression{
+			Operation:  OperationMove,
+			Expression: newTestIdentifierExpression("r"),
+		},
+		Attachment: &InvocationExpression{
+			InvokedExpression: newTestIdentifierExpression("A"),
+		},
+	}
+
+	assert.Equal(t, "attach A() to <-r", expr.String())
+}
+
+func TestAttachmentExpression_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("simple", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &AttachmentExpression{
+			Base: &UnaryExpression{
+				Operation:  OperationMove,
+				Expression: newTestIdentifierExpression("r"),
+			},
+			Attachment: &InvocationExpression{
+				InvokedExpression: newTestIdentifierExpression("A"),
+			},
+		}
+
+		assert.Equal(t, "attach A() to <-r", testDocString(expr.Doc()))
+	})
+
+	t.Run("base is not parenthesized", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &AttachmentExpression{
+			Base: &ConditionalExpression{
+				Test: newTestIdentifierExpression("a"),
+				Then: newTestIdentifierExpression("b"),
+				Else: newTestIdentifierExpression("c"),
+			},
+			Attachment: &InvocationExpression{
+				InvokedExpression: newTestIdentifierExpression("A"),
+			},
+		}
+
+		assert.Equal(t, "attach A() to a ? b : c", testDocString(expr.Doc()))
+	})
+
+	t.Run("parenthesized as operand", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &MemberExpression{
+			Expression: &AttachmentExpression{
+				Base: newTestIdentifierExpression("r"),
+				Attachment: &InvocationExpression{
+					InvokedExpression: newTestIdentifierExpression("A"),
+				},
+			},
+			Identifier: Identifier{
+				Identifier: "foo",
+			},
+		}
+
+		assert.Equal(t, "(attach A() to r).foo", testDocString(expr.Doc()))
+	})
+}
+
 func TestForceExpression_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -1158,6 +3515,73 @@ func TestForceExpression_Doc(t *testing.T) {
 	)
 }
 
+func TestConditionalExpression_Positions(t *testing.T) {
+
+	t.Parallel()
+
+	newIdentifier := func(name string, offset int) *IdentifierExpression {
+		return &IdentifierExpression{
+			Identifier: Identifier{
+				Identifier: name,
+				Pos:        Position{Offset: offset, Line: 1, Column: offset},
+			},
+		}
+	}
+
+	test := newIdentifier("a", 0)
+	then := newIdentifier("b", 4)
+	els := newIdentifier("c", 8)
+
+	type testCase struct {
+		expr  *ConditionalExpression
+		start Position
+		end   Position
+	}
+
+	testCases := map[string]testCase{
+		"complete": {
+			expr:  &ConditionalExpression{Test: test, Then: then, Else: els},
+			start: Position{Offset: 0, Line: 1, Column: 0},
+			end:   Position{Offset: 8, Line: 1, Column: 8},
+		},
+		"nil test": {
+			expr:  &ConditionalExpression{Then: then, Else: els},
+			start: Position{Offset: 4, Line: 1, Column: 4},
+			end:   Position{Offset: 8, Line: 1, Column: 8},
+		},
+		"nil else": {
+			expr:  &ConditionalExpression{Test: test, Then: then},
+			start: Position{Offset: 0, Line: 1, Column: 0},
+			end:   Position{Offset: 4, Line: 1, Column: 4},
+		},
+		"only test": {
+			expr:  &ConditionalExpression{Test: test},
+			start: Position{Offset: 0, Line: 1, Column: 0},
+			end:   Position{Offset: 0, Line: 1, Column: 0},
+		},
+		"only else": {
+			expr:  &ConditionalExpression{Else: els},
+			start: Position{Offset: 8, Line: 1, Column: 8},
+			end:   Position{Offset: 8, Line: 1, Column: 8},
+		},
+		"empty": {
+			expr: &ConditionalExpression{},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t, testCase.start, testCase.expr.StartPosition())
+			assert.Equal(t, testCase.end, testCase.expr.EndPosition())
+		})
+	}
+}
+
 func TestConditionalExpression_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -1247,32 +3671,246 @@ func TestConditionalExpression_Doc(t *testing.T) {
 		},
 	}
 
-	assert.Equal(t,
-		prettier.Group{
-			Doc: prettier.Concat{
-				prettier.Text(`false`),
-				prettier.Indent{
-					Doc: prettier.Concat{
-						prettier.Concat{
-							prettier.Line{},
-							prettier.Text("? "),
-						},
-						prettier.Indent{
-							Doc: prettier.Text(`42`),
-						},
-						prettier.Concat{
-							prettier.Line{},
-							prettier.Text(": "),
-						},
-						prettier.Indent{
-							Doc: prettier.Text(`99`),
-						},
-					},
+	assert.Equal(t,
+		prettier.Group{
+			Doc: prettier.Concat{
+				prettier.Text(`false`),
+				prettier.Indent{
+					Doc: prettier.Concat{
+						prettier.Concat{
+							prettier.Line{},
+							prettier.Text("? "),
+						},
+						prettier.Indent{
+							Doc: prettier.Text(`42`),
+						},
+						prettier.Concat{
+							prettier.Line{},
+							prettier.Text(": "),
+						},
+						prettier.Indent{
+							Doc: prettier.Text(`99`),
+						},
+					},
+				},
+			},
+		},
+		expr.Doc(),
+	)
+}
+
+func TestConditionalExpression_Doc_Parentheses(t *testing.T) {
+
+	t.Parallel()
+
+	a := newTestIdentifierExpression("a")
+	b := newTestIdentifierExpression("b")
+	c := newTestIdentifierExpression("c")
+	d := newTestIdentifierExpression("d")
+	e := newTestIdentifierExpression("e")
+
+	type testCase struct {
+		expr     Expression
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"nested test": {
+			expr: &ConditionalExpression{
+				Test: &ConditionalExpression{
+					Test: a,
+					Then: b,
+					Else: c,
+				},
+				Then: d,
+				Else: e,
+			},
+			expected: "(a ? b : c) ? d : e",
+		},
+		"nested else": {
+			expr: &ConditionalExpression{
+				Test: a,
+				Then: b,
+				Else: &ConditionalExpression{
+					Test: c,
+					Then: d,
+					Else: e,
+				},
+			},
+			expected: "a ? b : c ? d : e",
+		},
+		"nested then": {
+			expr: &ConditionalExpression{
+				Test: a,
+				Then: &ConditionalExpression{
+					Test: b,
+					Then: c,
+					Else: d,
+				},
+				Else: e,
+			},
+			expected: "a ? b ? c : d : e",
+		},
+		"binary test": {
+			expr: &ConditionalExpression{
+				Test: &BinaryExpression{
+					Operation: OperationOr,
+					Left:      a,
+					Right:     b,
+				},
+				Then: c,
+				Else: d,
+			},
+			expected: "a || b ? c : d",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				testDocString(testCase.expr.Doc()),
+			)
+		})
+	}
+}
+
+func TestNewInvocationExpression(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("type arguments and labeled argument", func(t *testing.T) {
+
+		t.Parallel()
+
+		// f<T>(label: x)
+
+		labelStartPos := Position{Offset: 5, Line: 1, Column: 5}
+		labelEndPos := Position{Offset: 9, Line: 1, Column: 9}
+
+		argument := &Argument{
+			Label:         "label",
+			LabelStartPos: &labelStartPos,
+			LabelEndPos:   &labelEndPos,
+			Expression: &IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "x",
+					Pos:        Position{Offset: 12, Line: 1, Column: 12},
+				},
+			},
+		}
+
+		typeArgument := &TypeAnnotation{
+			Type: &NominalType{
+				Identifier: Identifier{
+					Identifier: "T",
+					Pos:        Position{Offset: 2, Line: 1, Column: 2},
+				},
+			},
+			StartPos: Position{Offset: 2, Line: 1, Column: 2},
+		}
+
+		expr := NewInvocationExpression(
+			&IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "f",
+					Pos:        Position{Offset: 0, Line: 1, Column: 0},
+				},
+			},
+			[]*TypeAnnotation{typeArgument},
+			[]*Argument{argument},
+		)
+
+		assert.Equal(t,
+			Position{Offset: 4, Line: 1, Column: 4},
+			expr.ArgumentsStartPos,
+		)
+		assert.Equal(t,
+			Position{Offset: 13, Line: 1, Column: 13},
+			expr.EndPos,
+		)
+		assert.Equal(t,
+			Position{Offset: 13, Line: 1, Column: 13},
+			argument.TrailingSeparatorPos,
+		)
+		assert.Equal(t,
+			Position{Offset: 0, Line: 1, Column: 0},
+			expr.StartPosition(),
+		)
+
+		assert.Equal(t, "f<T>(label: x)", testDocString(expr.Doc()))
+	})
+
+	t.Run("multiple arguments", func(t *testing.T) {
+
+		t.Parallel()
+
+		// f(a, b)
+
+		first := &Argument{
+			Expression: &IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "a",
+					Pos:        Position{Offset: 2, Line: 1, Column: 2},
+				},
+			},
+		}
+
+		second := &Argument{
+			Expression: &IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "b",
+					Pos:        Position{Offset: 5, Line: 1, Column: 5},
+				},
+			},
+		}
+
+		expr := NewInvocationExpression(
+			&IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "f",
+					Pos:        Position{Offset: 0, Line: 1, Column: 0},
+				},
+			},
+			nil,
+			[]*Argument{first, second},
+		)
+
+		assert.Equal(t, Position{Offset: 1, Line: 1, Column: 1}, expr.ArgumentsStartPos)
+		assert.Equal(t, Position{Offset: 3, Line: 1, Column: 3}, first.TrailingSeparatorPos)
+		assert.Equal(t, Position{Offset: 6, Line: 1, Column: 6}, second.TrailingSeparatorPos)
+		assert.Equal(t, Position{Offset: 6, Line: 1, Column: 6}, expr.EndPos)
+
+		assert.Equal(t, "f(a, b)", testDocString(expr.Doc()))
+	})
+
+	t.Run("no arguments", func(t *testing.T) {
+
+		t.Parallel()
+
+		// f()
+
+		expr := NewInvocationExpression(
+			&IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "f",
+					Pos:        Position{Offset: 0, Line: 1, Column: 0},
 				},
 			},
-		},
-		expr.Doc(),
-	)
+			nil,
+			nil,
+		)
+
+		assert.Equal(t, Position{Offset: 1, Line: 1, Column: 1}, expr.ArgumentsStartPos)
+		assert.Equal(t, Position{Offset: 2, Line: 1, Column: 2}, expr.EndPos)
+
+		assert.Equal(t, "f()", testDocString(expr.Doc()))
+	})
 }
 
 func TestInvocationExpression_MarshalJSON(t *testing.T) {
@@ -1440,7 +4078,10 @@ func TestInvocationExpression_Doc(t *testing.T) {
 						prettier.Indent{
 							Doc: prettier.Concat{
 								prettier.SoftLine{},
-								nil,
+								prettier.Concat{
+									prettier.Text("@"),
+									prettier.Text("AB"),
+								},
 							},
 						},
 						prettier.SoftLine{},
@@ -1469,6 +4110,275 @@ func TestInvocationExpression_Doc(t *testing.T) {
 	})
 }
 
+func TestInvocationExpression_Doc_Parentheses(t *testing.T) {
+
+	t.Parallel()
+
+	a := newTestIdentifierExpression("a")
+	b := newTestIdentifierExpression("b")
+
+	type testCase struct {
+		expr     Expression
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"nil-coalescing": {
+			expr: &InvocationExpression{
+				InvokedExpression: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      a,
+					Right:     b,
+				},
+				Arguments: []*Argument{
+					{
+						Expression: newTestIdentifierExpression("x"),
+					},
+				},
+			},
+			expected: "(a ?? b)(x)",
+		},
+		"casting": {
+			expr: &InvocationExpression{
+				InvokedExpression: &CastingExpression{
+					Operation:  OperationCast,
+					Expression: newTestIdentifierExpression("g"),
+					TypeAnnotation: &TypeAnnotation{
+						Type: &NominalType{
+							Identifier: Identifier{
+								Identifier: "Fn",
+							},
+						},
+					},
+				},
+			},
+			expected: "(g as Fn)()",
+		},
+		"conditional": {
+			expr: &InvocationExpression{
+				InvokedExpression: &ConditionalExpression{
+					Test: newTestIdentifierExpression("cond"),
+					Then: newTestIdentifierExpression("f"),
+					Else: newTestIdentifierExpression("g"),
+				},
+			},
+			expected: "(cond ? f : g)()",
+		},
+		"force": {
+			expr: &InvocationExpression{
+				InvokedExpression: &ForceExpression{
+					Expression: newTestIdentifierExpression("foo"),
+				},
+			},
+			expected: "foo!()",
+		},
+		"member": {
+			expr: &InvocationExpression{
+				InvokedExpression: &MemberExpression{
+					Expression: a,
+					Identifier: Identifier{
+						Identifier: "b",
+					},
+				},
+			},
+			expected: "a.b()",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				testDocString(testCase.expr.Doc()),
+			)
+		})
+	}
+}
+
+func TestInvocationExpression_Arguments(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("mixed arguments, type arguments", func(t *testing.T) {
+
+		t.Parallel()
+
+		// f<Int, String>(a, to: b, c, from: d)
+
+		arguments := Arguments{
+			{
+				Expression: newTestIdentifierExpression("a"),
+			},
+			{
+				Label:      "to",
+				Expression: newTestIdentifierExpression("b"),
+			},
+			{
+				Expression: newTestIdentifierExpression("c"),
+			},
+			{
+				Label:      "from",
+				Expression: newTestIdentifierExpression("d"),
+			},
+		}
+
+		expr := &InvocationExpression{
+			InvokedExpression: newTestIdentifierExpression("f"),
+			TypeArguments: []*TypeAnnotation{
+				{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "Int"},
+					},
+				},
+				{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "String"},
+					},
+				},
+			},
+			Arguments: arguments,
+		}
+
+		assert.Equal(t, 4, expr.ArgumentCount())
+		assert.Equal(t, 2, expr.TypeArgumentCount())
+
+		argument, ok := expr.ArgumentByLabel("to")
+		assert.True(t, ok)
+		assert.Same(t, arguments[1], argument)
+
+		argument, ok = expr.ArgumentByLabel("from")
+		assert.True(t, ok)
+		assert.Same(t, arguments[3], argument)
+
+		for _, label := range []string{"", "a", "by"} {
+			argument, ok = expr.ArgumentByLabel(label)
+			assert.False(t, ok)
+			assert.Nil(t, argument)
+		}
+
+		for i, expected := range arguments {
+			argument, ok = expr.PositionalArgument(i)
+			assert.True(t, ok)
+			assert.Same(t, expected, argument)
+		}
+
+		for _, index := range []int{-1, 4} {
+			argument, ok = expr.PositionalArgument(index)
+			assert.False(t, ok)
+			assert.Nil(t, argument)
+		}
+	})
+
+	t.Run("no arguments, no type arguments", func(t *testing.T) {
+
+		t.Parallel()
+
+		// f()
+
+		expr := &InvocationExpression{
+			InvokedExpression: newTestIdentifierExpression("f"),
+		}
+
+		assert.Equal(t, 0, expr.ArgumentCount())
+		assert.Equal(t, 0, expr.TypeArgumentCount())
+
+		_, ok := expr.ArgumentByLabel("a")
+		assert.False(t, ok)
+
+		_, ok = expr.PositionalArgument(0)
+		assert.False(t, ok)
+	})
+}
+
+func TestCastingExpression_Positions(t *testing.T) {
+
+	t.Parallel()
+
+	expression := &IdentifierExpression{
+		Identifier: Identifier{
+			Identifier: "a",
+			Pos:        Position{Offset: 0, Line: 1, Column: 0},
+		},
+	}
+
+	typeAnnotation := &TypeAnnotation{
+		Type: &NominalType{
+			Identifier: Identifier{
+				Identifier: "Int",
+				Pos:        Position{Offset: 5, Line: 1, Column: 5},
+			},
+		},
+		StartPos: Position{Offset: 5, Line: 1, Column: 5},
+	}
+
+	type testCase struct {
+		expr  *CastingExpression
+		start Position
+		end   Position
+	}
+
+	testCases := map[string]testCase{
+		"complete": {
+			expr: &CastingExpression{
+				Expression:     expression,
+				Operation:      OperationCast,
+				TypeAnnotation: typeAnnotation,
+			},
+			start: Position{Offset: 0, Line: 1, Column: 0},
+			end:   Position{Offset: 7, Line: 1, Column: 7},
+		},
+		"nil expression": {
+			expr: &CastingExpression{
+				Operation:      OperationCast,
+				TypeAnnotation: typeAnnotation,
+			},
+			start: Position{Offset: 5, Line: 1, Column: 5},
+			end:   Position{Offset: 7, Line: 1, Column: 7},
+		},
+		"nil type annotation": {
+			expr: &CastingExpression{
+				Expression: expression,
+				Operation:  OperationCast,
+			},
+			start: Position{Offset: 0, Line: 1, Column: 0},
+			end:   Position{Offset: 0, Line: 1, Column: 0},
+		},
+		"nil type": {
+			expr: &CastingExpression{
+				Expression: expression,
+				Operation:  OperationCast,
+				TypeAnnotation: &TypeAnnotation{
+					StartPos: Position{Offset: 5, Line: 1, Column: 5},
+				},
+			},
+			start: Position{Offset: 0, Line: 1, Column: 0},
+			end:   Position{Offset: 0, Line: 1, Column: 0},
+		},
+		"empty": {
+			expr: &CastingExpression{
+				Operation: OperationCast,
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t, testCase.start, testCase.expr.StartPosition())
+			assert.Equal(t, testCase.end, testCase.expr.EndPosition())
+		})
+	}
+}
+
 func TestCastingExpression_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -1510,7 +4420,7 @@ func TestCastingExpression_MarshalJSON(t *testing.T) {
                "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
                "EndPos": {"Offset": 6, "Line": 2, "Column": 8}
             },
-            "Operation": "OperationForceCast",
+            "Operation": "ForceCast",
             "TypeAnnotation": {
                "IsResource": true,
                "AnnotatedType": {
@@ -1564,13 +4474,163 @@ func TestCastingExpression_Doc(t *testing.T) {
 				prettier.Line{},
 				prettier.Text("as?"),
 				prettier.Space,
-				// TODO: type
+				prettier.Concat{
+					prettier.Text("@"),
+					prettier.Text("Int"),
+				},
 			},
 		},
 		expr.Doc(),
 	)
 }
 
+func TestCastingExpression_Doc_Parentheses(t *testing.T) {
+
+	t.Parallel()
+
+	a := newTestIdentifierExpression("a")
+	b := newTestIdentifierExpression("b")
+
+	newTypeAnnotation := func(identifier string) *TypeAnnotation {
+		return &TypeAnnotation{
+			Type: &NominalType{
+				Identifier: Identifier{
+					Identifier: identifier,
+				},
+			},
+		}
+	}
+
+	type testCase struct {
+		expr     Expression
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"nil-coalescing": {
+			expr: &CastingExpression{
+				Operation: OperationFailableCast,
+				Expression: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      a,
+					Right:     b,
+				},
+				TypeAnnotation: newTypeAnnotation("T"),
+			},
+			expected: "(a ?? b) as? T",
+		},
+		"binary": {
+			expr: &CastingExpression{
+				Operation: OperationCast,
+				Expression: &BinaryExpression{
+					Operation: OperationMul,
+					Left:      a,
+					Right:     b,
+				},
+				TypeAnnotation: newTypeAnnotation("T"),
+			},
+			expected: "(a * b) as T",
+		},
+		"conditional": {
+			expr: &CastingExpression{
+				Operation: OperationForceCast,
+				Expression: &ConditionalExpression{
+					Test: a,
+					Then: b,
+					Else: a,
+				},
+				TypeAnnotation: newTypeAnnotation("T"),
+			},
+			expected: "(a ? b : a) as! T",
+		},
+		"unary": {
+			expr: &CastingExpression{
+				Operation: OperationCast,
+				Expression: &UnaryExpression{
+					Operation:  OperationMinus,
+					Expression: a,
+				},
+				TypeAnnotation: newTypeAnnotation("T"),
+			},
+			expected: "-a as T",
+		},
+		"nested cast": {
+			// casting is left-associative,
+			// so `(a as T) as? U` is printed as `a as T as? U`
+			expr: &CastingExpression{
+				Operation: OperationFailableCast,
+				Expression: &CastingExpression{
+					Operation:      OperationCast,
+					Expression:     a,
+					TypeAnnotation: newTypeAnnotation("T"),
+				},
+				TypeAnnotation: newTypeAnnotation("U"),
+			},
+			expected: "a as T as? U",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				testDocString(testCase.expr.Doc()),
+			)
+		})
+	}
+}
+
+func TestCastingExpression_Kinds(t *testing.T) {
+
+	t.Parallel()
+
+	type kinds struct {
+		static   bool
+		failable bool
+		forced   bool
+	}
+
+	testCases := map[Operation]kinds{
+		OperationCast:         {static: true},
+		OperationFailableCast: {failable: true},
+		OperationForceCast:    {forced: true},
+	}
+
+	for operation, expected := range testCases {
+		operation := operation
+		expected := expected
+
+		t.Run(operation.Symbol(), func(t *testing.T) {
+
+			t.Parallel()
+
+			expr := &CastingExpression{
+				Expression: newTestIdentifierExpression("x"),
+				Operation:  operation,
+				TypeAnnotation: &TypeAnnotation{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "T"},
+					},
+				},
+			}
+
+			assert.Equal(t,
+				expected,
+				kinds{
+					static:   expr.IsStatic(),
+					failable: expr.IsFailable(),
+					forced:   expr.IsForced(),
+				},
+			)
+		})
+	}
+}
+
 func TestCreateExpression_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -1789,13 +4849,74 @@ func TestReferenceExpression_Doc(t *testing.T) {
 				prettier.Line{},
 				prettier.Text("as"),
 				prettier.Space,
-				// TODO: type
+				prettier.Concat{
+					prettier.Text("auth "),
+					prettier.Text("&"),
+					prettier.Text("Int"),
+				},
 			},
 		},
 		expr.Doc(),
 	)
 }
 
+func TestReferenceExpression_BorrowType(t *testing.T) {
+
+	t.Parallel()
+
+	newReferenceExpression := func(authorized bool) *ReferenceExpression {
+		return &ReferenceExpression{
+			Expression: newTestIdentifierExpression("x"),
+			Type: &ReferenceType{
+				Authorized: authorized,
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "T"},
+				},
+			},
+		}
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+
+		t.Parallel()
+
+		// &x as &T
+
+		expr := newReferenceExpression(false)
+
+		assert.Same(t, expr.Type, expr.BorrowType())
+		assert.Equal(t, "&T", expr.BorrowType().String())
+		assert.False(t, expr.IsAuthorized())
+	})
+
+	t.Run("authorized", func(t *testing.T) {
+
+		t.Parallel()
+
+		// &x as auth &T
+
+		expr := newReferenceExpression(true)
+
+		assert.Same(t, expr.Type, expr.BorrowType())
+		assert.Equal(t, "auth &T", expr.BorrowType().String())
+		assert.True(t, expr.IsAuthorized())
+	})
+
+	t.Run("not a reference type", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := &ReferenceExpression{
+			Expression: newTestIdentifierExpression("x"),
+			Type: &NominalType{
+				Identifier: Identifier{Identifier: "T"},
+			},
+		}
+
+		assert.False(t, expr.IsAuthorized())
+	})
+}
+
 func TestFunctionExpression_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -2025,6 +5146,7 @@ func TestFunctionExpression_Doc(t *testing.T) {
 											prettier.Space,
 											prettier.Text("b"),
 											prettier.Text(": "),
+											prettier.Text("C"),
 										},
 										prettier.Concat{
 											prettier.Text(","),
@@ -2033,6 +5155,7 @@ func TestFunctionExpression_Doc(t *testing.T) {
 										prettier.Concat{
 											prettier.Text("d"),
 											prettier.Text(": "),
+											prettier.Text("E"),
 										},
 									},
 								},
@@ -2042,7 +5165,10 @@ func TestFunctionExpression_Doc(t *testing.T) {
 						},
 					},
 					prettier.Text(": "),
-					// TODO: type
+					prettier.Concat{
+						prettier.Text("@"),
+						prettier.Text("Int"),
+					},
 				},
 			},
 			prettier.Text(" {"),
@@ -0,0 +1,373 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"io"
+	"reflect"
+	"strings"
+)
+
+// WriteExpression writes the string representation of the given expression
+// to the given writer, i.e. the same result as String, but without building
+// the whole string in memory first.
+//
+// It returns the number of bytes written and the first error encountered, if any.
+// Writing stops after the first error.
+func WriteExpression(w io.Writer, expression Expression) (int64, error) {
+	writer := newExpressionWriter(w)
+	writer.writeExpression(expression)
+	return writer.written, writer.err
+}
+
+// WriteCanonicalExpression writes the canonical string representation of the given expression
+// to the given writer, i.e. the same result as CanonicalString, but without building
+// the whole string in memory first.
+//
+// It returns the number of bytes written and the first error encountered, if any.
+// Writing stops after the first error.
+func WriteCanonicalExpression(w io.Writer, expression Expression) (int64, error) {
+	writer := newExpressionWriter(w)
+	writer.canonical = true
+	writer.writeExpression(expression)
+	return writer.written, writer.err
+}
+
+// writtenExpressionString returns the string representation of the given expression,
+// written by an expressionWriter
+func writtenExpressionString(expression Expression) string {
+	var builder strings.Builder
+	// NOTE: writing to a strings.Builder never fails
+	_, _ = WriteExpression(&builder, expression)
+	return builder.String()
+}
+
+// writtenCanonicalExpressionString returns the canonical string representation
+// of the given expression, written by an expressionWriter
+func writtenCanonicalExpressionString(expression Expression) string {
+	var builder strings.Builder
+	// NOTE: writing to a strings.Builder never fails
+	_, _ = WriteCanonicalExpression(&builder, expression)
+	return builder.String()
+}
+
+// missingPlaceholder is written in place of a missing (nil) child of an incomplete expression,
+// e.g. an expression which is still being edited
+const missingPlaceholder = "_"
+
+// isNilExpression returns true if the given expression is missing,
+// i.e. it is nil, or a nil pointer
+func isNilExpression(expression Expression) bool {
+	if expression == nil {
+		return true
+	}
+	value := reflect.ValueOf(expression)
+	return value.Kind() == reflect.Ptr && value.IsNil()
+}
+
+// expressionStringOrPlaceholder returns the string representation of the given expression,
+// or a placeholder if the expression is missing
+func expressionStringOrPlaceholder(expression Expression) string {
+	if isNilExpression(expression) {
+		return missingPlaceholder
+	}
+	return expression.String()
+}
+
+// expressionWriter writes the string representations of expressions.
+//
+// By default, subexpressions are only parenthesized where needed, like in documents.
+// In canonical mode, all operator expressions are parenthesized instead.
+type expressionWriter struct {
+	writer       io.Writer
+	stringWriter io.StringWriter
+	written      int64
+	err          error
+	canonical    bool
+}
+
+func newExpressionWriter(writer io.Writer) *expressionWriter {
+	stringWriter, _ := writer.(io.StringWriter)
+	return &expressionWriter{
+		writer:       writer,
+		stringWriter: stringWriter,
+	}
+}
+
+func (w *expressionWriter) writeString(s string) {
+	if w.err != nil {
+		return
+	}
+
+	var n int
+	if w.stringWriter != nil {
+		n, w.err = w.stringWriter.WriteString(s)
+	} else {
+		n, w.err = w.writer.Write([]byte(s))
+	}
+	w.written += int64(n)
+}
+
+func (w *expressionWriter) writeStringer(stringer interface{ String() string }) {
+	w.writeString(stringer.String())
+}
+
+func (w *expressionWriter) writeArguments(arguments Arguments) {
+	w.writeString("(")
+	for i, argument := range arguments {
+		if i > 0 {
+			w.writeString(", ")
+		}
+		if argument == nil {
+			w.writeString(missingPlaceholder)
+			continue
+		}
+		if argument.Label != "" {
+			w.writeString(argument.Label)
+			w.writeString(": ")
+		}
+		w.writeExpression(argument.Expression)
+	}
+	w.writeString(")")
+}
+
+func (w *expressionWriter) writeInvocation(expression *InvocationExpression) {
+	if expression == nil {
+		w.writeString(missingPlaceholder)
+		return
+	}
+
+	w.writeSubexpression(expression.InvokedExpression, PrecedenceUnaryPostfix)
+	if len(expression.TypeArguments) > 0 {
+		w.writeString("<")
+		for i, typeArgument := range expression.TypeArguments {
+			if i > 0 {
+				w.writeString(", ")
+			}
+			w.writeTypeAnnotation(typeArgument)
+		}
+		w.writeString(">")
+	}
+	w.writeArguments(expression.Arguments)
+}
+
+func (w *expressionWriter) writeTypeAnnotation(typeAnnotation *TypeAnnotation) {
+	if typeAnnotation == nil {
+		w.writeString(missingPlaceholder)
+		return
+	}
+	if typeAnnotation.IsResource {
+		w.writeString("@")
+	}
+	w.writeType(typeAnnotation.Type)
+}
+
+func (w *expressionWriter) writeType(ty Type) {
+	if ty == nil {
+		w.writeString(missingPlaceholder)
+		return
+	}
+	w.writeString(ty.String())
+}
+
+func (w *expressionWriter) writeExpression(expression Expression) {
+	if w.err != nil {
+		return
+	}
+
+	if isNilExpression(expression) {
+		w.writeString(missingPlaceholder)
+		return
+	}
+
+	// In canonical mode, all operator expressions are parenthesized,
+	// so their subexpressions never need additional parentheses.
+	// Literals, e.g. negative integer literals, are only parenthesized where needed,
+	// see writeParenthesizedIf
+
+	if w.canonical &&
+		expressionPrecedence(expression) < PrecedenceAccess &&
+		!isLiteralExpression(expression) {
+
+		w.writeString("(")
+		w.writeExpressionWithoutParentheses(expression)
+		w.writeString(")")
+		return
+	}
+
+	w.writeExpressionWithoutParentheses(expression)
+}
+
+// writeSubexpression writes the given subexpression,
+// parenthesized if it binds weaker than the given precedence
+func (w *expressionWriter) writeSubexpression(expression Expression, precedence int) {
+	w.writeParenthesizedIf(
+		needsParentheses(expression, precedence),
+		expression,
+	)
+}
+
+// writeParenthesizedIf writes the given expression, parenthesized if requested.
+//
+// In canonical mode, operator expressions are already parenthesized by writeExpression,
+// so only literals, e.g. negative integer literals, are parenthesized.
+func (w *expressionWriter) writeParenthesizedIf(parenthesize bool, expression Expression) {
+	if w.canonical && !isLiteralExpression(expression) {
+		parenthesize = false
+	}
+
+	if !parenthesize {
+		w.writeExpression(expression)
+		return
+	}
+
+	w.writeString("(")
+	w.writeExpression(expression)
+	w.writeString(")")
+}
+
+func (w *expressionWriter) writeExpressionWithoutParentheses(expression Expression) {
+	switch expression := expression.(type) {
+	case *StringTemplateExpression:
+		var builder strings.Builder
+		w.writeString(`"`)
+		for i, segment := range expression.Segments {
+			builder.Reset()
+			writeEscapedString(&builder, segment)
+			w.writeString(builder.String())
+			if i < len(expression.Values) {
+				w.writeString(`\(`)
+				w.writeExpression(expression.Values[i])
+				w.writeString(")")
+			}
+		}
+		w.writeString(`"`)
+
+	case *ArrayExpression:
+		w.writeString("[")
+		for i, value := range expression.Values {
+			if i > 0 {
+				w.writeString(", ")
+			}
+			w.writeExpression(value)
+		}
+		w.writeString("]")
+
+	case *DictionaryExpression:
+		w.writeString("{")
+		for i, entry := range expression.Entries {
+			if i > 0 {
+				w.writeString(", ")
+			}
+			w.writeExpression(entry.Key)
+			w.writeString(": ")
+			w.writeExpression(entry.Value)
+		}
+		w.writeString("}")
+
+	case *InvocationExpression:
+		w.writeInvocation(expression)
+
+	case *MemberExpression:
+		w.writeParenthesizedIf(
+			memberTargetNeedsParentheses(expression.Expression),
+			expression.Expression,
+		)
+		if expression.Optional {
+			w.writeString("?")
+		}
+		w.writeString(".")
+		w.writeString(expression.Identifier.Identifier)
+
+	case *IndexExpression:
+		w.writeSubexpression(expression.TargetExpression, PrecedenceUnaryPostfix)
+		w.writeString("[")
+		w.writeExpression(expression.IndexingExpression)
+		w.writeString("]")
+
+	case *ConditionalExpression:
+		// The conditional operator is right-associative:
+		// The test must bind tighter than the conditional operator,
+		// but the branches may contain conditional expressions without parentheses
+		w.writeSubexpression(expression.Test, PrecedenceTernary+1)
+		w.writeString(" ? ")
+		w.writeSubexpression(expression.Then, PrecedenceTernary)
+		w.writeString(" : ")
+		w.writeSubexpression(expression.Else, PrecedenceTernary)
+
+	case *UnaryExpression:
+		w.writeString(expression.Operation.Symbol())
+		w.writeParenthesizedIf(
+			unaryOperandNeedsParentheses(expression.Expression),
+			expression.Expression,
+		)
+
+	case *BinaryExpression:
+		operation := expression.Operation
+		w.writeParenthesizedIf(
+			binaryOperandNeedsParentheses(operation, expression.Left, true),
+			expression.Left,
+		)
+		w.writeString(" ")
+		w.writeString(operation.Symbol())
+		w.writeString(" ")
+		w.writeParenthesizedIf(
+			binaryOperandNeedsParentheses(operation, expression.Right, false),
+			expression.Right,
+		)
+
+	case *CastingExpression:
+		w.writeSubexpression(expression.Expression, expression.Operation.Precedence())
+		w.writeString(" ")
+		w.writeString(expression.Operation.Symbol())
+		w.writeString(" ")
+		w.writeTypeAnnotation(expression.TypeAnnotation)
+
+	case *CreateExpression:
+		w.writeString("create ")
+		w.writeInvocation(expression.InvocationExpression)
+
+	case *DestroyExpression:
+		w.writeString("destroy ")
+		w.writeSubexpression(expression.Expression, PrecedenceUnaryPrefix)
+
+	case *AttachmentExpression:
+		w.writeString("attach ")
+		w.writeInvocation(expression.Attachment)
+		w.writeString(" to ")
+		// The base extends as far to the right as possible,
+		// so it never needs to be parenthesized
+		w.writeExpression(expression.Base)
+
+	case *ReferenceExpression:
+		w.writeString("&")
+		w.writeSubexpression(expression.Expression, PrecedenceCasting)
+		w.writeString(" as ")
+		w.writeType(expression.Type)
+
+	case *ForceExpression:
+		w.writeSubexpression(expression.Expression, PrecedenceUnaryPostfix)
+		w.writeString("!")
+
+	default:
+		// The string representations of all other expressions,
+		// e.g. literals and identifiers, are short
+		w.writeStringer(expression)
+	}
+}
@@ -0,0 +1,549 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteExpression(t *testing.T) {
+
+	t.Parallel()
+
+	expressions := []Expression{
+		&BoolExpression{Value: true},
+		&NilExpression{},
+		&StringExpression{Value: "a\nb"},
+		&StringTemplateExpression{
+			Segments: []string{"x = ", "!"},
+			Values:   []Expression{newTestIdentifierExpression("x")},
+		},
+		&IntegerExpression{
+			PositiveLiteral: "42",
+			Value:           big.NewInt(42),
+			Base:            10,
+		},
+		&ArrayExpression{
+			Values: []Expression{
+				newTestIdentifierExpression("a"),
+				newTestIdentifierExpression("b"),
+			},
+		},
+		&DictionaryExpression{
+			Entries: []DictionaryEntry{
+				{
+					Key:   newTestIdentifierExpression("k"),
+					Value: newTestIdentifierExpression("v"),
+				},
+			},
+		},
+		&InvocationExpression{
+			InvokedExpression: &MemberExpression{
+				Expression: newTestIdentifierExpression("a"),
+				Optional:   true,
+				Identifier: Identifier{Identifier: "b"},
+			},
+			TypeArguments: []*TypeAnnotation{
+				{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "Int"},
+					},
+				},
+			},
+			Arguments: Arguments{
+				{
+					Label:      "x",
+					Expression: newTestIdentifierExpression("c"),
+				},
+				{
+					Expression: &IndexExpression{
+						TargetExpression:   newTestIdentifierExpression("d"),
+						IndexingExpression: newTestIdentifierExpression("e"),
+					},
+				},
+			},
+		},
+		&ConditionalExpression{
+			Test: &UnaryExpression{
+				Operation:  OperationNegate,
+				Expression: newTestIdentifierExpression("a"),
+			},
+			Then: &BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression("b"),
+				Right:     newTestIdentifierExpression("c"),
+			},
+			Else: &ForceExpression{
+				Expression: newTestIdentifierExpression("d"),
+			},
+		},
+		&CastingExpression{
+			Operation:  OperationFailableCast,
+			Expression: newTestIdentifierExpression("a"),
+			TypeAnnotation: &TypeAnnotation{
+				IsResource: true,
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "R"},
+				},
+			},
+		},
+		&DestroyExpression{
+			Expression: &CreateExpression{
+				InvocationExpression: &InvocationExpression{
+					InvokedExpression: newTestIdentifierExpression("R"),
+				},
+			},
+		},
+		&AttachmentExpression{
+			Base: newTestIdentifierExpression("r"),
+			Attachment: &InvocationExpression{
+				InvokedExpression: newTestIdentifierExpression("A"),
+			},
+		},
+		&ReferenceExpression{
+			Expression: newTestIdentifierExpression("a"),
+			Type: &ReferenceType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "T"},
+				},
+			},
+		},
+	}
+
+	for _, expression := range expressions {
+		var builder strings.Builder
+		n, err := WriteExpression(&builder, expression)
+		require.NoError(t, err)
+
+		expected := expression.String()
+		assert.Equal(t, expected, builder.String())
+		assert.Equal(t, int64(len(expected)), n)
+
+		builder.Reset()
+		n, err = WriteCanonicalExpression(&builder, expression)
+		require.NoError(t, err)
+
+		expected = expression.CanonicalString()
+		assert.Equal(t, expected, builder.String())
+		assert.Equal(t, int64(len(expected)), n)
+	}
+}
+
+func TestExpression_CanonicalString(t *testing.T) {
+
+	t.Parallel()
+
+	a := newTestIdentifierExpression("a")
+	b := newTestIdentifierExpression("b")
+	c := newTestIdentifierExpression("c")
+
+	type testCase struct {
+		expr      Expression
+		string    string
+		canonical string
+	}
+
+	testCases := map[string]testCase{
+		"identifier": {
+			expr:      a,
+			string:    "a",
+			canonical: "a",
+		},
+		"binary, lower precedence operand": {
+			expr: &BinaryExpression{
+				Operation: OperationMul,
+				Left: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      a,
+					Right:     b,
+				},
+				Right: c,
+			},
+			string:    "(a + b) * c",
+			canonical: "((a + b) * c)",
+		},
+		"binary, higher precedence operand": {
+			expr: &BinaryExpression{
+				Operation: OperationPlus,
+				Left:      a,
+				Right: &BinaryExpression{
+					Operation: OperationMul,
+					Left:      b,
+					Right:     c,
+				},
+			},
+			string:    "a + b * c",
+			canonical: "(a + (b * c))",
+		},
+		"unary": {
+			expr: &UnaryExpression{
+				Operation: OperationMinus,
+				Expression: &UnaryExpression{
+					Operation:  OperationMinus,
+					Expression: a,
+				},
+			},
+			string:    "-(-a)",
+			canonical: "(-(-a))",
+		},
+		"conditional": {
+			expr: &ConditionalExpression{
+				Test: &ConditionalExpression{
+					Test: a,
+					Then: b,
+					Else: c,
+				},
+				Then: a,
+				Else: &ConditionalExpression{
+					Test: a,
+					Then: b,
+					Else: c,
+				},
+			},
+			string:    "(a ? b : c) ? a : a ? b : c",
+			canonical: "((a ? b : c) ? a : (a ? b : c))",
+		},
+		"casting": {
+			expr: &CastingExpression{
+				Operation: OperationForceCast,
+				Expression: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      a,
+					Right:     b,
+				},
+				TypeAnnotation: &TypeAnnotation{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "T"},
+					},
+				},
+			},
+			string:    "(a ?? b) as! T",
+			canonical: "((a ?? b) as! T)",
+		},
+		"member of binary": {
+			expr: &MemberExpression{
+				Expression: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      a,
+					Right:     b,
+				},
+				Identifier: Identifier{Identifier: "c"},
+			},
+			string:    "(a + b).c",
+			canonical: "(a + b).c",
+		},
+		"index of force": {
+			expr: &IndexExpression{
+				TargetExpression: &ForceExpression{
+					Expression: a,
+				},
+				IndexingExpression: &BinaryExpression{
+					Operation: OperationMinus,
+					Left:      b,
+					Right:     c,
+				},
+			},
+			string:    "a![b - c]",
+			canonical: "(a!)[(b - c)]",
+		},
+		"invocation in create": {
+			expr: &CreateExpression{
+				InvocationExpression: &InvocationExpression{
+					InvokedExpression: newTestIdentifierExpression("R"),
+					Arguments: Arguments{
+						{
+							Expression: &UnaryExpression{
+								Operation:  OperationNegate,
+								Expression: a,
+							},
+						},
+					},
+				},
+			},
+			string:    "create R(!a)",
+			canonical: "(create R((!a)))",
+		},
+		"destroy": {
+			expr: &DestroyExpression{
+				Expression: a,
+			},
+			string:    "destroy a",
+			canonical: "(destroy a)",
+		},
+		"attachment": {
+			expr: &AttachmentExpression{
+				Base: a,
+				Attachment: &InvocationExpression{
+					InvokedExpression: newTestIdentifierExpression("A"),
+				},
+			},
+			string:    "attach A() to a",
+			canonical: "(attach A() to a)",
+		},
+		"reference": {
+			expr: &ReferenceExpression{
+				Expression: a,
+				Type: &ReferenceType{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "T"},
+					},
+				},
+			},
+			string:    "&a as &T",
+			canonical: "(&a as &T)",
+		},
+		"array": {
+			expr: &ArrayExpression{
+				Values: []Expression{
+					&BinaryExpression{
+						Operation: OperationPlus,
+						Left:      a,
+						Right:     b,
+					},
+				},
+			},
+			string:    "[a + b]",
+			canonical: "[(a + b)]",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t, testCase.string, testCase.expr.String())
+			assert.Equal(t, testCase.canonical, testCase.expr.CanonicalString())
+
+			// The string representation is consistent with the document
+
+			assert.Equal(t, testCase.string, testDocString(testCase.expr.Doc()))
+		})
+	}
+}
+
+type testFailingWriter struct {
+	limit   int
+	written int
+}
+
+var testWriterError = errors.New("test")
+
+func (w *testFailingWriter) Write(p []byte) (int, error) {
+	if w.written+len(p) > w.limit {
+		n := w.limit - w.written
+		w.written = w.limit
+		return n, testWriterError
+	}
+	w.written += len(p)
+	return len(p), nil
+}
+
+func TestWriteExpression_Error(t *testing.T) {
+
+	t.Parallel()
+
+	expression := &BinaryExpression{
+		Operation: OperationPlus,
+		Left:      newTestIdentifierExpression("abc"),
+		Right:     newTestIdentifierExpression("def"),
+	}
+
+	writer := &testFailingWriter{limit: 3}
+
+	n, err := WriteExpression(writer, expression)
+	require.ErrorIs(t, err, testWriterError)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, 3, writer.written)
+}
+
+func TestExpression_String_NilChildren(t *testing.T) {
+
+	t.Parallel()
+
+	a := newTestIdentifierExpression("a")
+
+	type testCase struct {
+		expr     Expression
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"string template": {
+			expr: &StringTemplateExpression{
+				Segments: []string{"x", "y"},
+				Values:   []Expression{nil},
+			},
+			expected: `"x\(_)y"`,
+		},
+		"array": {
+			expr: &ArrayExpression{
+				Values: []Expression{a, nil},
+			},
+			expected: "[a, _]",
+		},
+		"dictionary": {
+			expr: &DictionaryExpression{
+				Entries: []DictionaryEntry{
+					{Key: nil, Value: a},
+					{Key: a, Value: nil},
+				},
+			},
+			expected: "{_: a, a: _}",
+		},
+		"invocation": {
+			expr: &InvocationExpression{
+				TypeArguments: []*TypeAnnotation{
+					nil,
+					{IsResource: true},
+				},
+				Arguments: Arguments{
+					{Label: "x", Expression: nil},
+					nil,
+				},
+			},
+			expected: "_<_, @_>(x: _, _)",
+		},
+		"member": {
+			expr: &MemberExpression{
+				Optional:   true,
+				Identifier: Identifier{Identifier: "b"},
+			},
+			expected: "_?.b",
+		},
+		"index": {
+			expr:     &IndexExpression{},
+			expected: "_[_]",
+		},
+		"conditional": {
+			expr: &ConditionalExpression{
+				Then: a,
+			},
+			expected: "_ ? a : _",
+		},
+		"unary": {
+			expr: &UnaryExpression{
+				Operation: OperationMinus,
+			},
+			expected: "-_",
+		},
+		"binary": {
+			expr: &BinaryExpression{
+				Operation: OperationPlus,
+				Left:      a,
+			},
+			expected: "a + _",
+		},
+		"casting": {
+			expr: &CastingExpression{
+				Operation: OperationFailableCast,
+			},
+			expected: "_ as? _",
+		},
+		"create": {
+			expr:     &CreateExpression{},
+			expected: "create _",
+		},
+		"destroy": {
+			expr:     &DestroyExpression{},
+			expected: "destroy _",
+		},
+		"attachment": {
+			expr:     &AttachmentExpression{},
+			expected: "attach _ to _",
+		},
+		"reference": {
+			expr:     &ReferenceExpression{},
+			expected: "&_ as _",
+		},
+		"force": {
+			expr:     &ForceExpression{},
+			expected: "_!",
+		},
+		"integer": {
+			expr:     &IntegerExpression{PositiveLiteral: "42"},
+			expected: "42",
+		},
+		"integer without value": {
+			expr:     &IntegerExpression{Base: 16},
+			expected: "_",
+		},
+		"fixed-point without parts": {
+			expr:     &FixedPointExpression{Negative: true},
+			expected: "-_._",
+		},
+		"fixed-point without fractional part": {
+			expr: &FixedPointExpression{
+				UnsignedInteger: big.NewInt(1),
+				Scale:           2,
+			},
+			expected: "1._",
+		},
+		"nil pointer": {
+			expr: &MemberExpression{
+				Expression: (*IdentifierExpression)(nil),
+				Identifier: Identifier{Identifier: "b"},
+			},
+			expected: "_.b",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t, testCase.expected, testCase.expr.String())
+		})
+	}
+
+	t.Run("arguments", func(t *testing.T) {
+
+		t.Parallel()
+
+		arguments := Arguments{
+			{Label: "x"},
+			nil,
+		}
+
+		assert.Equal(t, "(x: _, _)", arguments.String())
+	})
+
+	t.Run("integer without value, digit grouping", func(t *testing.T) {
+
+		t.Parallel()
+
+		formatted := Format(
+			&IntegerExpression{Base: 10},
+			FormatOptions{DigitGrouping: 3},
+		)
+
+		assert.Equal(t, "_", formatted)
+	})
+}
@@ -0,0 +1,55 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+// FindInnermost returns the deepest element of the given AST
+// whose range contains the given position, or nil if there is none.
+//
+// If the position is contained in both an element and one of its children,
+// the child is preferred.
+func FindInnermost(root Element, position Position) Element {
+	finder := &innermostFinder{
+		position: position,
+	}
+	Walk(finder, root)
+	return finder.result
+}
+
+type innermostFinder struct {
+	position Position
+	result   Element
+}
+
+func (f *innermostFinder) Walk(element Element) Walker {
+	if element == nil {
+		return nil
+	}
+
+	// Children are contained in their parents,
+	// so there is no need to walk the children
+	// of an element which does not contain the position
+
+	if !NewRangeFromPositioned(element).Contains(f.position) {
+		return nil
+	}
+
+	f.result = element
+
+	return f
+}
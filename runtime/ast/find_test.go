@@ -0,0 +1,127 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindInnermost(t *testing.T) {
+
+	t.Parallel()
+
+	// a.b(c.d)
+
+	a := &IdentifierExpression{
+		Identifier: Identifier{
+			Identifier: "a",
+			Pos:        Position{Offset: 0, Line: 1, Column: 0},
+		},
+	}
+
+	member := &MemberExpression{
+		Expression: a,
+		Identifier: Identifier{
+			Identifier: "b",
+			Pos:        Position{Offset: 2, Line: 1, Column: 2},
+		},
+	}
+
+	c := &IdentifierExpression{
+		Identifier: Identifier{
+			Identifier: "c",
+			Pos:        Position{Offset: 4, Line: 1, Column: 4},
+		},
+	}
+
+	argument := &MemberExpression{
+		Expression: c,
+		Identifier: Identifier{
+			Identifier: "d",
+			Pos:        Position{Offset: 6, Line: 1, Column: 6},
+		},
+	}
+
+	invocation := &InvocationExpression{
+		InvokedExpression: member,
+		Arguments: []*Argument{
+			{
+				Expression: argument,
+			},
+		},
+		ArgumentsStartPos: Position{Offset: 3, Line: 1, Column: 3},
+		EndPos:            Position{Offset: 7, Line: 1, Column: 7},
+	}
+
+	type testCase struct {
+		position Position
+		expected Element
+	}
+
+	testCases := map[string]testCase{
+		"invoked member expression, identifier": {
+			position: Position{Offset: 0, Line: 1, Column: 0},
+			expected: a,
+		},
+		"invoked member expression, dot": {
+			position: Position{Offset: 1, Line: 1, Column: 1},
+			expected: member,
+		},
+		"invoked member expression, member": {
+			position: Position{Offset: 2, Line: 1, Column: 2},
+			expected: member,
+		},
+		"opening parenthesis": {
+			position: Position{Offset: 3, Line: 1, Column: 3},
+			expected: invocation,
+		},
+		"argument, identifier": {
+			position: Position{Offset: 4, Line: 1, Column: 4},
+			expected: c,
+		},
+		"argument, member": {
+			position: Position{Offset: 6, Line: 1, Column: 6},
+			expected: argument,
+		},
+		"closing parenthesis": {
+			position: Position{Offset: 7, Line: 1, Column: 7},
+			expected: invocation,
+		},
+		"outside": {
+			position: Position{Offset: 8, Line: 1, Column: 8},
+			expected: nil,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				FindInnermost(invocation, testCase.position),
+			)
+		})
+	}
+}
@@ -0,0 +1,535 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/turbolent/prettier"
+)
+
+const (
+	DefaultFormatMaxLineWidth = 80
+	DefaultFormatIndent       = "    "
+)
+
+// FormatOptions configures how an element is pretty-printed by Format.
+type FormatOptions struct {
+	// MaxLineWidth is the maximum width of a line the renderer tries to fit the output into.
+	// If zero or negative, DefaultFormatMaxLineWidth is used.
+	MaxLineWidth int
+	// Indent is the indentation of one level, e.g. four spaces.
+	// If empty, DefaultFormatIndent is used.
+	// Ignored if UseTabs is set.
+	Indent string
+	// UseTabs indents using a single tab per level instead of Indent.
+	UseTabs bool
+	// TrailingComma adds a comma after the last element of a list of arguments or parameters
+	// if the list is broken across multiple lines.
+	// Array and dictionary literals never get a trailing comma,
+	// as the parser rejects one before a closing bracket or brace on a line of its own.
+	TrailingComma bool
+	// Trivia are the comments which are emitted along with the elements they are attached to.
+	Trivia TriviaMap
+	// DigitGrouping regroups the digits of integer literals from the right
+	// into groups of the given size, separated by underscores, e.g. `1_000_000` for 3.
+	// If zero, the digits of integer literals are left as-is.
+	DigitGrouping int
+	// StripSeparators removes the underscores from integer literals, e.g. `1000000`.
+	// Ignored if DigitGrouping is set, as regrouping replaces the existing underscores.
+	StripSeparators bool
+	// AlignDictionaryValues pads the keys of dictionary literals
+	// which are broken across multiple lines, so that the colons line up.
+	AlignDictionaryValues bool
+	// DocBuilder builds the documents of expressions, see DocBuilder.
+	// If nil, the documents are built as by the Doc methods.
+	DocBuilder DocBuilder
+	// MaxDepth is the maximum nesting depth of the element, as determined by Depth.
+	// If positive, elements which are nested deeper are rejected before their documents are generated,
+	// as the generation recurses as deeply as the element is nested, e.g. for untrusted input.
+	// TryFormat returns a MaxDepthExceededError for them, and Format returns FormatTruncationMarker.
+	MaxDepth int
+}
+
+// FormatTruncationMarker is returned by Format for elements which are nested deeper
+// than the maximum depth given in the options.
+const FormatTruncationMarker = "…"
+
+// MaxDepthExceededError is returned by TryFormat for elements which are nested deeper
+// than the maximum depth given in the options.
+type MaxDepthExceededError struct {
+	MaxDepth int
+	Depth    int
+}
+
+func (e *MaxDepthExceededError) Error() string {
+	return fmt.Sprintf(
+		"cannot format element: depth %d exceeds maximum depth %d",
+		e.Depth,
+		e.MaxDepth,
+	)
+}
+
+func (options FormatOptions) maxLineWidth() int {
+	if options.MaxLineWidth <= 0 {
+		return DefaultFormatMaxLineWidth
+	}
+	return options.MaxLineWidth
+}
+
+func (options FormatOptions) indent() string {
+	if options.UseTabs {
+		return "\t"
+	}
+	if options.Indent == "" {
+		return DefaultFormatIndent
+	}
+	return options.Indent
+}
+
+func (options FormatOptions) docContext() docContext {
+	return docContext{
+		trailingComma:         options.TrailingComma,
+		trivia:                options.Trivia,
+		digitGrouping:         options.DigitGrouping,
+		stripSeparators:       options.StripSeparators,
+		alignDictionaryValues: options.AlignDictionaryValues,
+		builder:               options.DocBuilder,
+	}
+}
+
+// Format pretty-prints the given element using the given options.
+//
+// Elements which do not support pretty-printing (i.e. have no Doc method)
+// are formatted using their String method, if any.
+//
+// Elements which are nested deeper than the maximum depth given in the options
+// are formatted as FormatTruncationMarker.
+func Format(element Element, options FormatOptions) string {
+	result, err := TryFormat(element, options)
+	if err != nil {
+		return FormatTruncationMarker
+	}
+	return result
+}
+
+// TryFormat is like Format, but returns a MaxDepthExceededError
+// for elements which are nested deeper than the maximum depth given in the options.
+//
+// The depth is determined without recursion, so the check itself is safe
+// for arbitrarily deeply nested elements.
+func TryFormat(element Element, options FormatOptions) (string, error) {
+	if options.MaxDepth > 0 {
+		depth := Depth(element)
+		if depth > options.MaxDepth {
+			return "", &MaxDepthExceededError{
+				MaxDepth: options.MaxDepth,
+				Depth:    depth,
+			}
+		}
+	}
+
+	return format(element, options), nil
+}
+
+func format(element Element, options FormatOptions) string {
+	context := options.docContext()
+
+	doc, ok := elementDoc(element, context)
+	if !ok {
+		if stringer, ok := element.(fmt.Stringer); ok {
+			return stringer.String()
+		}
+		return ""
+	}
+
+	var builder strings.Builder
+
+	indent := options.indent()
+
+	prettier.Prettier(
+		&builder,
+		doc,
+		options.maxLineWidth(),
+		indent,
+	)
+
+	result := builder.String()
+
+	if context.alignDictionaryValues {
+		result = resolveDictionaryAlignment(result, indent)
+	}
+
+	if context.trailingComma {
+		result = resolveTrailingCommas(result, indent)
+	}
+
+	// A trailing line comment of an inner element is followed by a line break,
+	// which is superfluous at the end of the output
+	if len(context.trivia) > 0 {
+		result = strings.TrimRight(result, " \t\n")
+	}
+
+	return result
+}
+
+// compactFormatMaxLineWidth is the maximum line width used by FormatCompact.
+// It is large enough so that no group is ever broken
+const compactFormatMaxLineWidth = math.MaxInt32
+
+// FormatCompact pretty-prints the given element onto as few lines as possible,
+// regardless of its width, e.g. for inline diagnostics.
+//
+// No optional line break is ever broken: Lines are rendered as single spaces,
+// and soft lines are omitted. Only mandatory line breaks remain,
+// e.g. the ones between the statements of a block.
+func FormatCompact(element Element) string {
+	return Format(
+		element,
+		FormatOptions{
+			MaxLineWidth: compactFormatMaxLineWidth,
+		},
+	)
+}
+
+// docContext is threaded through the generation of documents,
+// and carries the formatting options which affect the documents.
+//
+// The zero value is the default context:
+// The documents generated in it are the ones returned by the Doc methods.
+type docContext struct {
+	trailingComma         bool
+	trivia                TriviaMap
+	digitGrouping         int
+	stripSeparators       bool
+	alignDictionaryValues bool
+	builder               DocBuilder
+	// listElement is the element of the list whose document is being generated.
+	// Its trailing comments are emitted by listDoc, after the separator
+	listElement Element
+}
+
+func (context docContext) isDefault() bool {
+	return !context.trailingComma &&
+		len(context.trivia) == 0 &&
+		context.digitGrouping <= 0 &&
+		!context.stripSeparators &&
+		!context.alignDictionaryValues &&
+		context.builder == nil
+}
+
+// contextualDoc is implemented by elements which generate their document
+// from the documents of their children, and pass the context on to them.
+type contextualDoc interface {
+	doc(context docContext) prettier.Doc
+}
+
+// expressionDoc returns the document for the given subexpression in the given context,
+// including the comments attached to it.
+func expressionDoc(expression Expression, context docContext) prettier.Doc {
+	if context.isDefault() {
+		return expression.Doc()
+	}
+
+	doc := bareExpressionDoc(expression, context)
+	if expression == context.listElement {
+		return context.trivia.leadingDoc(expression, doc)
+	}
+	return context.trivia.doc(expression, doc, false)
+}
+
+// bareExpressionDoc returns the document for the given expression in the given context,
+// excluding the comments attached to it.
+//
+// If the context has a document builder, the document is built by it.
+func bareExpressionDoc(expression Expression, context docContext) prettier.Doc {
+	if context.builder != nil {
+		return buildExpressionDoc(context.builder, expression, context)
+	}
+	return builtinExpressionDoc(expression, context)
+}
+
+// builtinExpressionDoc returns the document for the given expression in the given context,
+// excluding the comments attached to it, as built by the expression itself.
+func builtinExpressionDoc(expression Expression, context docContext) prettier.Doc {
+	if !context.isDefault() {
+		if expression, ok := expression.(contextualDoc); ok {
+			return expression.doc(context)
+		}
+	}
+	return expression.Doc()
+}
+
+// elementDoc returns the document for the given element in the given context,
+// including the comments attached to it, and false if the element does not support pretty-printing.
+//
+// The element is assumed to be followed by a line break or the end of the output,
+// e.g. a statement.
+func elementDoc(element Element, context docContext) (prettier.Doc, bool) {
+	var doc prettier.Doc
+
+	switch element := element.(type) {
+	case Expression:
+		doc = bareExpressionDoc(element, context)
+	case contextualDoc:
+		doc = element.doc(context)
+	case interface{ Doc() prettier.Doc }:
+		doc = element.Doc()
+	default:
+		return nil, false
+	}
+
+	return context.trivia.doc(element, doc, true), true
+}
+
+// trailingCommaPlaceholders maps closing delimiters of lists to placeholders.
+//
+// The prettier renderer cannot emit text only if a group is broken,
+// so when trailing commas are requested, the closing delimiter of a list
+// is rendered as a placeholder of the same width, which is resolved after rendering,
+// see resolveTrailingCommas.
+//
+// The placeholders are control characters, which never appear literally
+// in the rendered output otherwise, as string literals escape them.
+//
+// Only lists of arguments and parameters, which are closed by parentheses, get trailing commas:
+// The parser rejects a trailing comma in array and dictionary literals
+// if the closing delimiter is on a line of its own.
+var trailingCommaPlaceholders = map[string]string{
+	")": "\x01",
+}
+
+var trailingCommaPlaceholderDelimiters = func() map[byte]byte {
+	delimiters := make(map[byte]byte, len(trailingCommaPlaceholders))
+	for delimiter, placeholder := range trailingCommaPlaceholders {
+		delimiters[placeholder[0]] = delimiter[0]
+	}
+	return delimiters
+}()
+
+// listElementContext returns the context for the document of the list element
+// which is, or ends with, the given expression, e.g. the value of a dictionary entry.
+// The trailing comments of the expression are emitted by listDoc
+func listElementContext(expression Expression, context docContext) docContext {
+	if len(context.trivia) > 0 {
+		context.listElement = expression
+	}
+	return context
+}
+
+// listDoc returns the document for a list of elements,
+// enclosed in the given delimiters and separated by commas,
+// e.g. the elements of an array literal.
+//
+// The given elements are the ones whose trailing comments follow the documents,
+// see listElementContext. The comments are emitted after the separator,
+// so a trailing line comment does not swallow it.
+//
+// If the list is broken, e.g. because a line comment is attached to an element,
+// each element is on a line of its own, regardless of the width of the list.
+func listDoc(
+	open string,
+	elementDocs []prettier.Doc,
+	elements []Element,
+	broken bool,
+	close string,
+	context docContext,
+) prettier.Doc {
+	trailingComments := make([][]*Comment, len(elementDocs))
+	for i, element := range elements {
+		if trivia := context.trivia[element]; element != nil && trivia != nil {
+			trailingComments[i] = trivia.Trailing
+		}
+	}
+
+	if broken {
+		result := make(prettier.Concat, 0, len(elementDocs)*4)
+		for i, elementDoc := range elementDocs {
+			result = append(result, prettier.HardLine{}, elementDoc)
+			if i < len(elementDocs)-1 ||
+				(context.trailingComma && trailingCommaPlaceholders[close] != "") {
+
+				result = append(result, listSeparatorDoc)
+			}
+			if len(trailingComments[i]) > 0 {
+				result = append(result, trailingCommentsDoc(trailingComments[i], true))
+			}
+		}
+
+		return prettier.Concat{
+			prettier.Text(open),
+			prettier.Indent{Doc: result},
+			prettier.HardLine{},
+			prettier.Text(close),
+		}
+	}
+
+	for i, comments := range trailingComments {
+		if len(comments) > 0 {
+			elementDocs[i] = prettier.Concat{
+				elementDocs[i],
+				trailingCommentsDoc(comments, true),
+			}
+		}
+	}
+
+	closeDoc := prettier.Text(close)
+	if context.trailingComma {
+		if placeholder, ok := trailingCommaPlaceholders[close]; ok {
+			closeDoc = prettier.Text(placeholder)
+		}
+	}
+
+	return prettier.Wrap(
+		prettier.Text(open),
+		prettier.Join(
+			prettier.Concat{
+				listSeparatorDoc,
+				prettier.Line{},
+			},
+			elementDocs...,
+		),
+		closeDoc,
+		prettier.SoftLine{},
+	)
+}
+
+var listSeparatorDoc prettier.Doc = prettier.Text(",")
+
+// resolveTrailingCommas replaces the placeholders for closing delimiters of lists
+// in the given rendered output with the delimiters.
+//
+// If a list was broken across multiple lines, its closing delimiter is on a line of its own,
+// i.e. only preceded by indentation. In that case, a comma is added to the end of the previous line,
+// i.e. after the last element of the list.
+//
+// NOTE: The renderer does not account for the width of the added comma.
+func resolveTrailingCommas(output string, indent string) string {
+	result := make([]byte, 0, len(output)+8)
+
+	// The index of the last newline in the result, if any
+	lastNewline := -1
+
+	for i := 0; i < len(output); i++ {
+		c := output[i]
+
+		delimiter, ok := trailingCommaPlaceholderDelimiters[c]
+		if !ok {
+			if c == '\n' {
+				lastNewline = len(result)
+			}
+			result = append(result, c)
+			continue
+		}
+
+		if lastNewline >= 0 &&
+			strings.TrimLeft(string(result[lastNewline+1:]), indent) == "" {
+
+			result = append(result, 0)
+			copy(result[lastNewline+1:], result[lastNewline:])
+			result[lastNewline] = ','
+			lastNewline++
+		}
+
+		result = append(result, delimiter)
+	}
+
+	return string(result)
+}
+
+// The placeholders for the alignment of dictionary entries, see resolveDictionaryAlignment.
+//
+// Like the trailing comma placeholders, they are control characters,
+// which never appear literally in the rendered output otherwise.
+const (
+	dictionaryEntryStartPlaceholder = "\x04"
+	dictionaryKeyPaddingPlaceholder = "\x05"
+)
+
+// resolveDictionaryAlignment replaces the placeholders for the alignment of dictionary entries
+// in the given rendered output.
+//
+// The renderer cannot emit text only if a group is broken, so the start of each aligned entry
+// is marked with a placeholder, and its key is followed by padding placeholders.
+// If a dictionary was broken across multiple lines, its entries are on lines of their own,
+// i.e. the start of each entry is only preceded by indentation.
+// In that case, the padding placeholders of the entry are replaced with spaces,
+// otherwise they are removed.
+//
+// NOTE: The renderer accounts for the width of the placeholders,
+// so a dictionary may be broken even though it would fit without them.
+func resolveDictionaryAlignment(output string, indent string) string {
+	result := make([]byte, 0, len(output))
+
+	// The index of the last newline in the result, if any
+	lastNewline := -1
+
+	// Whether the entries which have started, but whose keys have not been padded yet,
+	// are aligned. Keys may contain nested dictionaries, so this is a stack
+	var aligned []bool
+
+	for i := 0; i < len(output); i++ {
+		c := output[i]
+
+		switch c {
+		case dictionaryEntryStartPlaceholder[0]:
+			aligned = append(
+				aligned,
+				strings.TrimLeft(string(result[lastNewline+1:]), indent) == "",
+			)
+
+		case dictionaryKeyPaddingPlaceholder[0]:
+			end := i + 1
+			for end < len(output) && output[end] == c {
+				end++
+			}
+
+			lastIndex := len(aligned) - 1
+			if lastIndex >= 0 {
+				if aligned[lastIndex] {
+					result = append(result, strings.Repeat(" ", end-i)...)
+				}
+				aligned = aligned[:lastIndex]
+			}
+
+			i = end - 1
+
+		default:
+			if c == '\n' {
+				lastNewline = len(result)
+			}
+			result = append(result, c)
+		}
+	}
+
+	return string(result)
+}
+
+// flatDocWidth returns the width of the given document,
+// when rendered on a single line
+func flatDocWidth(doc prettier.Doc) int {
+	const maxLineWidth = math.MaxInt32
+
+	var builder strings.Builder
+	prettier.Prettier(&builder, doc.Flatten(), maxLineWidth, "")
+	return utf8.RuneCountInString(builder.String())
+}
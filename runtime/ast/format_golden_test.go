@@ -0,0 +1,742 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbolent/prettier"
+)
+
+// testDocNode is a node which can be pretty-printed,
+// e.g. an expression or a type
+type testDocNode interface {
+	Doc() prettier.Doc
+}
+
+// assertFormat asserts that the document of the given node
+// is rendered to exactly the expected string at the given maximum line width,
+// using an indentation of four spaces.
+func assertFormat(t *testing.T, node testDocNode, width int, expected string) {
+	t.Helper()
+
+	var builder strings.Builder
+	prettier.Prettier(&builder, node.Doc(), width, "    ")
+
+	assert.Equal(t,
+		expected,
+		builder.String(),
+		"width %d", width,
+	)
+}
+
+// formatGoldenTestCase is a node and its expected renderings, by maximum line width
+type formatGoldenTestCase struct {
+	node    testDocNode
+	goldens map[int]string
+}
+
+func runFormatGoldenTestCases(t *testing.T, testCases map[string]formatGoldenTestCase) {
+	for name, testCase := range testCases {
+		for width, expected := range testCase.goldens {
+			testCase := testCase
+			width := width
+			expected := expected
+
+			t.Run(fmt.Sprintf("%s, width %d", name, width), func(t *testing.T) {
+
+				t.Parallel()
+
+				assertFormat(t, testCase.node, width, expected)
+			})
+		}
+	}
+}
+
+func TestFormat_Golden_Expressions(t *testing.T) {
+
+	t.Parallel()
+
+	runFormatGoldenTestCases(t, map[string]formatGoldenTestCase{
+		"BoolExpression": {
+			node: &BoolExpression{Value: true},
+			goldens: map[int]string{
+				80: `true`,
+				20: `true`,
+			},
+		},
+		"NilExpression": {
+			node: &NilExpression{},
+			goldens: map[int]string{
+				80: `nil`,
+				20: `nil`,
+			},
+		},
+		"VoidExpression": {
+			node: &VoidExpression{},
+			goldens: map[int]string{
+				80: `()`,
+				20: `()`,
+			},
+		},
+		"StringExpression": {
+			node: &StringExpression{Value: "hello\n\"world\""},
+			goldens: map[int]string{
+				80: `"hello\n\"world\""`,
+				20: `"hello\n\"world\""`,
+			},
+		},
+		"StringTemplateExpression": {
+			node: &StringTemplateExpression{
+				Segments: []string{"sum: ", ", product: ", ""},
+				Values: []Expression{
+					&BinaryExpression{
+						Operation: OperationPlus,
+						Left:      newTestIdentifierExpression("first"),
+						Right:     newTestIdentifierExpression("second"),
+					},
+					&BinaryExpression{
+						Operation: OperationMul,
+						Left:      newTestIdentifierExpression("first"),
+						Right:     newTestIdentifierExpression("second"),
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `"sum: \(first + second), product: \(first * second)"`,
+				20: `"sum: \(first + second), product: \(first * second)"`,
+			},
+		},
+		"IntegerExpression": {
+			node: &IntegerExpression{
+				PositiveLiteral: "0x2A",
+				Value:           big.NewInt(-42),
+				Base:            16,
+			},
+			goldens: map[int]string{
+				80: `-0x2A`,
+				20: `-0x2A`,
+			},
+		},
+		"FixedPointExpression": {
+			node: &FixedPointExpression{
+				PositiveLiteral: "1_234.5",
+				Negative:        true,
+				UnsignedInteger: big.NewInt(1234),
+				Fractional:      big.NewInt(5),
+				Scale:           1,
+			},
+			goldens: map[int]string{
+				80: `-1_234.5`,
+				20: `-1_234.5`,
+			},
+		},
+		"ArrayExpression": {
+			node: &ArrayExpression{
+				Values: []Expression{
+					newTestIdentifierExpression("alpha"),
+					newTestIdentifierExpression("beta"),
+					newTestIdentifierExpression("gamma"),
+				},
+			},
+			goldens: map[int]string{
+				80: `[alpha, beta, gamma]`,
+				20: `[alpha, beta, gamma]`,
+			},
+		},
+		"DictionaryExpression": {
+			node: &DictionaryExpression{
+				Entries: []DictionaryEntry{
+					{
+						Key:   &StringExpression{Value: "alpha"},
+						Value: newTestIdentifierExpression("one"),
+					},
+					{
+						Key:   &StringExpression{Value: "beta"},
+						Value: newTestIdentifierExpression("two"),
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `{"alpha": one, "beta": two}`,
+				20: `{
+    "alpha": one,
+    "beta": two
+}`,
+			},
+		},
+		"IdentifierExpression": {
+			node: newTestIdentifierExpression("identifier"),
+			goldens: map[int]string{
+				80: `identifier`,
+				20: `identifier`,
+			},
+		},
+		"InvocationExpression": {
+			node: &InvocationExpression{
+				InvokedExpression: newTestIdentifierExpression("transfer"),
+				TypeArguments: []*TypeAnnotation{
+					{
+						IsResource: true,
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "Vault"},
+						},
+					},
+				},
+				Arguments: Arguments{
+					{
+						Label:      "from",
+						Expression: newTestIdentifierExpression("sender"),
+					},
+					{
+						Label:      "to",
+						Expression: newTestIdentifierExpression("recipient"),
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `transfer<@Vault>(from: sender, to: recipient)`,
+				20: `transfer<@Vault>(
+    from: sender,
+    to: recipient
+)`,
+			},
+		},
+		"MemberExpression": {
+			node: &MemberExpression{
+				Expression: &MemberExpression{
+					Expression: &BinaryExpression{
+						Operation: OperationNilCoalesce,
+						Left:      newTestIdentifierExpression("optional"),
+						Right:     newTestIdentifierExpression("fallback"),
+					},
+					Optional: true,
+					Identifier: Identifier{
+						Identifier: "account",
+					},
+				},
+				Identifier: Identifier{
+					Identifier: "balance",
+				},
+			},
+			goldens: map[int]string{
+				80: `(optional ?? fallback)?.account.balance`,
+				20: `(
+    optional
+    ?? fallback
+)?.account.balance`,
+			},
+		},
+		"IndexExpression": {
+			node: &IndexExpression{
+				TargetExpression: newTestIdentifierExpression("dictionary"),
+				IndexingExpression: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      newTestIdentifierExpression("offset"),
+					Right:     newTestIdentifierExpression("index"),
+				},
+			},
+			goldens: map[int]string{
+				80: `dictionary[offset + index]`,
+				20: `dictionary[
+    offset + index
+]`,
+			},
+		},
+		"ConditionalExpression": {
+			node: &ConditionalExpression{
+				Test: &BinaryExpression{
+					Operation: OperationGreater,
+					Left:      newTestIdentifierExpression("balance"),
+					Right:     newTestIdentifierExpression("amount"),
+				},
+				Then: newTestIdentifierExpression("withdraw"),
+				Else: &ConditionalExpression{
+					Test: newTestIdentifierExpression("fallback"),
+					Then: newTestIdentifierExpression("borrow"),
+					Else: newTestIdentifierExpression("abort"),
+				},
+			},
+			goldens: map[int]string{
+				80: `balance > amount ? withdraw : fallback ? borrow : abort`,
+				20: `balance > amount
+    ? withdraw
+    : fallback
+            ? borrow
+            : abort`,
+			},
+		},
+		"UnaryExpression": {
+			node: &UnaryExpression{
+				Operation: OperationNegate,
+				Expression: &BinaryExpression{
+					Operation: OperationAnd,
+					Left:      newTestIdentifierExpression("isValid"),
+					Right:     newTestIdentifierExpression("isAuthorized"),
+				},
+			},
+			goldens: map[int]string{
+				80: `!(isValid && isAuthorized)`,
+				20: `!(
+    isValid
+    && isAuthorized
+)`,
+			},
+		},
+		"BinaryExpression": {
+			node: &BinaryExpression{
+				Operation: OperationMul,
+				Left: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      newTestIdentifierExpression("principal"),
+					Right:     newTestIdentifierExpression("interest"),
+				},
+				Right: &BinaryExpression{
+					Operation: OperationMinus,
+					Left:      newTestIdentifierExpression("rate"),
+					Right: &BinaryExpression{
+						Operation: OperationMinus,
+						Left:      newTestIdentifierExpression("fee"),
+						Right:     newTestIdentifierExpression("discount"),
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `(principal + interest) * (rate - (fee - discount))`,
+				20: `(
+    principal
+    + interest
+)
+* (
+    rate
+    - (fee - discount)
+)`,
+			},
+		},
+		"FunctionExpression": {
+			node: &FunctionExpression{
+				ParameterList: &ParameterList{
+					Parameters: []*Parameter{
+						{
+							Label:      "from",
+							Identifier: Identifier{Identifier: "sender"},
+							TypeAnnotation: &TypeAnnotation{
+								Type: &NominalType{
+									Identifier: Identifier{Identifier: "Address"},
+								},
+							},
+						},
+						{
+							Identifier: Identifier{Identifier: "amount"},
+							TypeAnnotation: &TypeAnnotation{
+								Type: &NominalType{
+									Identifier: Identifier{Identifier: "UFix64"},
+								},
+							},
+						},
+					},
+				},
+				ReturnTypeAnnotation: &TypeAnnotation{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "Bool"},
+					},
+				},
+				FunctionBlock: &FunctionBlock{
+					Block: &Block{
+						Statements: []Statement{
+							&ReturnStatement{
+								Expression: &BoolExpression{Value: true},
+							},
+						},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `fun (from sender: Address, amount: UFix64): Bool {
+    return true
+}`,
+				20: `fun (
+    from sender: Address,
+    amount: UFix64
+): Bool {
+    return true
+}`,
+			},
+		},
+		"CastingExpression": {
+			node: &CastingExpression{
+				Operation: OperationFailableCast,
+				Expression: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      newTestIdentifierExpression("value"),
+					Right:     newTestIdentifierExpression("fallback"),
+				},
+				TypeAnnotation: &TypeAnnotation{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "Int"},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `(value ?? fallback) as? Int`,
+				20: `(value ?? fallback)
+as? Int`,
+			},
+		},
+		"CreateExpression": {
+			node: &CreateExpression{
+				InvocationExpression: &InvocationExpression{
+					InvokedExpression: &MemberExpression{
+						Expression: newTestIdentifierExpression("Collection"),
+						Identifier: Identifier{
+							Identifier: "Vault",
+						},
+					},
+					Arguments: Arguments{
+						{
+							Label:      "balance",
+							Expression: newTestIdentifierExpression("initialBalance"),
+						},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `create Collection.Vault(balance: initialBalance)`,
+				20: `create Collection.Vault(
+    balance: initialBalance
+)`,
+			},
+		},
+		"DestroyExpression": {
+			node: &DestroyExpression{
+				Expression: &ForceExpression{
+					Expression: &InvocationExpression{
+						InvokedExpression: &MemberExpression{
+							Expression: newTestIdentifierExpression("collection"),
+							Identifier: Identifier{
+								Identifier: "remove",
+							},
+						},
+						Arguments: Arguments{
+							{
+								Label:      "at",
+								Expression: newTestIdentifierExpression("index"),
+							},
+						},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `destroy collection.remove(at: index)!`,
+				20: `destroy collection.remove(
+    at: index
+)!`,
+			},
+		},
+		"AttachmentExpression": {
+			node: &AttachmentExpression{
+				Base: newTestIdentifierExpression("collection"),
+				Attachment: &InvocationExpression{
+					InvokedExpression: newTestIdentifierExpression("Metadata"),
+					Arguments: Arguments{
+						{
+							Label:      "name",
+							Expression: newTestIdentifierExpression("displayName"),
+						},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `attach Metadata(name: displayName) to collection`,
+				20: `attach Metadata(
+    name: displayName
+) to collection`,
+			},
+		},
+		"ReferenceExpression": {
+			node: &ReferenceExpression{
+				Expression: &IndexExpression{
+					TargetExpression:   newTestIdentifierExpression("collections"),
+					IndexingExpression: newTestIdentifierExpression("owner"),
+				},
+				Type: &ReferenceType{
+					Authorized: true,
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "Collection"},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `&collections[owner] as auth &Collection`,
+				20: `&collections[owner]
+as auth &Collection`,
+			},
+		},
+		"ForceExpression": {
+			node: &ForceExpression{
+				Expression: &IndexExpression{
+					TargetExpression:   newTestIdentifierExpression("balances"),
+					IndexingExpression: newTestIdentifierExpression("owner"),
+				},
+			},
+			goldens: map[int]string{
+				80: `balances[owner]!`,
+				20: `balances[owner]!`,
+			},
+		},
+		"DestroyExpression, nil-coalescing": {
+			node: &DestroyExpression{
+				Expression: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      newTestIdentifierExpression("first"),
+					Right:     newTestIdentifierExpression("second"),
+				},
+			},
+			goldens: map[int]string{
+				80: `destroy (first ?? second)`,
+				20: `destroy (
+    first ?? second
+)`,
+			},
+		},
+		"ReferenceExpression, nil-coalescing": {
+			node: &ReferenceExpression{
+				Expression: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      newTestIdentifierExpression("first"),
+					Right:     newTestIdentifierExpression("second"),
+				},
+				Type: &ReferenceType{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "T"},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `&(first ?? second) as &T`,
+				20: `&(first ?? second)
+as &T`,
+			},
+		},
+		"ForceExpression, nil-coalescing": {
+			node: &ForceExpression{
+				Expression: &BinaryExpression{
+					Operation: OperationNilCoalesce,
+					Left:      newTestIdentifierExpression("first"),
+					Right:     newTestIdentifierExpression("second"),
+				},
+			},
+			goldens: map[int]string{
+				80: `(first ?? second)!`,
+				20: `(first ?? second)!`,
+			},
+		},
+		"ForceExpression, negation": {
+			node: &ForceExpression{
+				Expression: &UnaryExpression{
+					Operation:  OperationMinus,
+					Expression: newTestIdentifierExpression("value"),
+				},
+			},
+			goldens: map[int]string{
+				80: `(-value)!`,
+				20: `(-value)!`,
+			},
+		},
+		"PathExpression": {
+			node: &PathExpression{
+				Domain:     Identifier{Identifier: "storage"},
+				Identifier: Identifier{Identifier: "flowTokenVault"},
+			},
+			goldens: map[int]string{
+				80: `/storage/flowTokenVault`,
+				20: `/storage/flowTokenVault`,
+			},
+		},
+	})
+}
+
+func TestFormat_Golden_Types(t *testing.T) {
+
+	t.Parallel()
+
+	runFormatGoldenTestCases(t, map[string]formatGoldenTestCase{
+		"NominalType": {
+			node: &NominalType{
+				Identifier: Identifier{Identifier: "FungibleToken"},
+				NestedIdentifiers: []Identifier{
+					{Identifier: "Vault"},
+				},
+			},
+			goldens: map[int]string{
+				80: `FungibleToken.Vault`,
+				20: `FungibleToken.Vault`,
+			},
+		},
+		"OptionalType": {
+			node: &OptionalType{
+				Type: &OptionalType{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "Int"},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `Int??`,
+				20: `Int??`,
+			},
+		},
+		"VariableSizedType": {
+			node: &VariableSizedType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "String"},
+				},
+			},
+			goldens: map[int]string{
+				80: `[String]`,
+				20: `[String]`,
+			},
+		},
+		"ConstantSizedType": {
+			node: &ConstantSizedType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "UInt8"},
+				},
+				Size: &IntegerExpression{
+					PositiveLiteral: "32",
+					Value:           big.NewInt(32),
+					Base:            10,
+				},
+			},
+			goldens: map[int]string{
+				80: `[UInt8; 32]`,
+				20: `[UInt8; 32]`,
+			},
+		},
+		"DictionaryType": {
+			node: &DictionaryType{
+				KeyType: &NominalType{
+					Identifier: Identifier{Identifier: "Address"},
+				},
+				ValueType: &VariableSizedType{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "String"},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `{Address: [String]}`,
+				20: `{Address: [String]}`,
+			},
+		},
+		"FunctionType": {
+			node: &FunctionType{
+				ParameterTypeAnnotations: []*TypeAnnotation{
+					{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "Address"},
+						},
+					},
+					{
+						IsResource: true,
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "Vault"},
+						},
+					},
+				},
+				ReturnTypeAnnotation: &TypeAnnotation{
+					Type: &OptionalType{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "Receipt"},
+						},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `((Address, @Vault): Receipt?)`,
+				20: `(
+    (
+        Address,
+        @Vault
+    ): Receipt?
+)`,
+			},
+		},
+		"ReferenceType": {
+			node: &ReferenceType{
+				Authorized: true,
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "Vault"},
+				},
+			},
+			goldens: map[int]string{
+				80: `auth &Vault`,
+				20: `auth &Vault`,
+			},
+		},
+		"RestrictedType": {
+			node: &RestrictedType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "Vault"},
+				},
+				Restrictions: []*NominalType{
+					{
+						Identifier: Identifier{Identifier: "Receiver"},
+					},
+					{
+						Identifier: Identifier{Identifier: "Balance"},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `Vault{Receiver, Balance}`,
+				20: `Vault{
+    Receiver,
+    Balance
+}`,
+			},
+		},
+		"InstantiationType": {
+			node: &InstantiationType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "Capability"},
+				},
+				TypeArguments: []*TypeAnnotation{
+					{
+						Type: &ReferenceType{
+							Type: &NominalType{
+								Identifier: Identifier{Identifier: "Collection"},
+							},
+						},
+					},
+				},
+			},
+			goldens: map[int]string{
+				80: `Capability<&Collection>`,
+				20: `Capability<
+    &Collection
+>`,
+			},
+		},
+	})
+}
@@ -0,0 +1,373 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/parser2"
+)
+
+func TestFormat_TriviaRoundTrip(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `fun (x: Int) {
+    // leading
+    return [/* first */ x, 1] // trailing
+}`
+
+	// The parser drops the comments
+
+	expr, errs := parser2.ParseExpression(code)
+	require.Empty(t, errs)
+
+	function := expr.(*ast.FunctionExpression)
+	statement := function.FunctionBlock.Block.Statements[0].(*ast.ReturnStatement)
+	array := statement.Expression.(*ast.ArrayExpression)
+
+	assert.Equal(t,
+		`fun (x: Int) {
+    return [x, 1]
+}`,
+		ast.Format(function, ast.FormatOptions{}),
+	)
+
+	// Attach the comments again
+
+	trivia := ast.TriviaMap{}
+	trivia.AddLeading(statement, &ast.Comment{Text: " leading"})
+	trivia.AddTrailing(statement, &ast.Comment{Text: " trailing"})
+	trivia.AddLeading(array.Values[0], &ast.Comment{Text: " first ", Block: true})
+
+	formatted := ast.Format(function, ast.FormatOptions{Trivia: trivia})
+	assert.Equal(t, code, formatted)
+
+	// The formatted code parses again
+
+	reparsed, errs := parser2.ParseExpression(formatted)
+	require.Empty(t, errs)
+
+	assert.Equal(t,
+		ast.Format(function, ast.FormatOptions{}),
+		ast.Format(reparsed, ast.FormatOptions{}),
+	)
+}
+
+func TestComment_String_Parse(t *testing.T) {
+
+	t.Parallel()
+
+	// The source code of a comment is always a single comment,
+	// i.e. the code following the comment is not commented out
+
+	texts := []string{
+		"",
+		" test ",
+		" a /* b */ c ",
+		" a */ b ",
+		" a /* b ",
+		"*/ /*",
+		"/*/",
+		"*/*",
+		"/",
+		"*",
+		" a /",
+		"\n first\n second\n",
+	}
+
+	for _, text := range texts {
+		for _, block := range []bool{true, false} {
+
+			comment := &ast.Comment{
+				Text:  text,
+				Block: block,
+			}
+
+			code := comment.String() + "\n1"
+
+			expr, errs := parser2.ParseExpression(code)
+			require.Empty(t, errs, code)
+
+			assert.IsType(t, &ast.IntegerExpression{}, expr, code)
+		}
+	}
+}
+
+func TestFormat_SwapStatementRoundTrip(t *testing.T) {
+
+	t.Parallel()
+
+	const code = "a <-> b[c]"
+
+	statements, errs := parser2.ParseStatements(code)
+	require.Empty(t, errs)
+	require.Len(t, statements, 1)
+
+	statement := statements[0].(*ast.SwapStatement)
+
+	assert.Equal(t,
+		ast.Range{
+			StartPos: ast.Position{Offset: 0, Line: 1, Column: 0},
+			EndPos:   ast.Position{Offset: 9, Line: 1, Column: 9},
+		},
+		ast.NewRangeFromPositioned(statement),
+	)
+
+	assert.Equal(t, code, statement.String())
+	assert.Equal(t, code, ast.Format(statement, ast.FormatOptions{}))
+
+	// The formatted statement parses to the same statement
+
+	reparsed, errs := parser2.ParseStatements(ast.Format(statement, ast.FormatOptions{}))
+	require.Empty(t, errs)
+	assert.Equal(t, statements, reparsed)
+}
+
+func TestFormat_ReferenceAndForceRoundTrip(t *testing.T) {
+
+	t.Parallel()
+
+	codes := []string{
+		"&a as &T",
+		"&(a ?? b) as &T",
+		"&(a ? b : c) as &T",
+		"a!",
+		"(a ?? b)!",
+		"(-a)!",
+		"(-1)!",
+		"(-1.5)!",
+		"(a as? T)!",
+		"a.b!.c",
+	}
+
+	for _, code := range codes {
+		code := code
+
+		t.Run(code, func(t *testing.T) {
+
+			t.Parallel()
+
+			expression, errs := parser2.ParseExpression(code)
+			require.Empty(t, errs)
+
+			assert.Equal(t, code, expression.String())
+			assert.Equal(t, code, ast.Format(expression, ast.FormatOptions{}))
+
+			// The formatted expression parses to the same expression
+
+			reparsed, errs := parser2.ParseExpression(ast.Format(expression, ast.FormatOptions{}))
+			require.Empty(t, errs)
+			assert.Equal(t, expression, reparsed)
+		})
+	}
+}
+
+func TestFormat_DestroyRoundTrip(t *testing.T) {
+
+	t.Parallel()
+
+	type testCase struct {
+		code     string
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"identifier": {
+			code:     "destroy a",
+			expected: "destroy a",
+		},
+		"force": {
+			code:     "destroy a.remove(b)!",
+			expected: "destroy a.remove(b)!",
+		},
+		"nil-coalescing": {
+			code:     "destroy (a ?? b)",
+			expected: "destroy (a ?? b)",
+		},
+		"nil-coalescing, unparenthesized": {
+			code:     "destroy a ?? b",
+			expected: "destroy (a ?? b)",
+		},
+		"create": {
+			code:     "create R(a: b)",
+			expected: "create R(a: b)",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			expression, errs := parser2.ParseExpression(testCase.code)
+			require.Empty(t, errs)
+
+			assert.Equal(t, testCase.expected, expression.String())
+
+			formatted := ast.Format(expression, ast.FormatOptions{})
+			assert.Equal(t, testCase.expected, formatted)
+
+			// The formatted expression parses to an equal expression
+
+			reparsed, errs := parser2.ParseExpression(formatted)
+			require.Empty(t, errs)
+			assert.True(t, ast.EqualExpressions(expression, reparsed))
+		})
+	}
+}
+
+func TestFormat_TrailingCommaRoundTrip(t *testing.T) {
+
+	t.Parallel()
+
+	// The formatted expressions are broken across multiple lines,
+	// and still parse to the same expressions
+
+	codes := []string{
+		`transfer(from: sender, to: recipient, amount: [1, 2, 3], metadata: {"alpha": a, "beta": b})`,
+		`[first(argument: one), second(argument: two), {"key": third(argument: three)}]`,
+		`{"alpha": [one, two, three], "beta": f(x: four, y: five)}`,
+	}
+
+	for _, code := range codes {
+		code := code
+
+		t.Run(code, func(t *testing.T) {
+
+			t.Parallel()
+
+			expression, errs := parser2.ParseExpression(code)
+			require.Empty(t, errs)
+
+			formatted := ast.Format(expression, ast.FormatOptions{
+				MaxLineWidth:  20,
+				TrailingComma: true,
+			})
+			require.Contains(t, formatted, "\n")
+
+			reparsed, errs := parser2.ParseExpression(formatted)
+			require.Empty(t, errs, formatted)
+			assert.True(t, ast.EqualExpressions(expression, reparsed), formatted)
+		})
+	}
+}
+
+func TestFormat_Parentheses(t *testing.T) {
+
+	t.Parallel()
+
+	// Parentheses are not represented in the AST,
+	// so only the parentheses required by the structure of the tree are rendered
+
+	type testCase struct {
+		code     string
+		expected string
+	}
+
+	testCases := map[string]testCase{
+		"redundant": {
+			code:     "((a))",
+			expected: "a",
+		},
+		"required": {
+			code:     "(a + b) * c",
+			expected: "(a + b) * c",
+		},
+		"precedence": {
+			code:     "a + (b * c)",
+			expected: "a + b * c",
+		},
+		"invoked": {
+			code:     "(a)(b)",
+			expected: "a(b)",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			expression, errs := parser2.ParseExpression(testCase.code)
+			require.Empty(t, errs)
+
+			assert.Equal(t, testCase.expected, expression.String())
+			assert.Equal(t, testCase.expected, ast.Format(expression, ast.FormatOptions{}))
+		})
+	}
+}
+
+func TestFormat_LiteralOperandsRoundTrip(t *testing.T) {
+
+	t.Parallel()
+
+	codes := []string{
+		"(-1).foo",
+		"(1).foo",
+		"0x1.foo",
+		"1.5.foo",
+		"(-1.5).foo",
+		"(-1)[0]",
+		"1[0]",
+		"(-1)(2)",
+		"1(2)",
+		"(-1)!",
+		"(-1.5)!",
+		"1!",
+		"-1 + 2",
+		"-(-1)",
+		"-1 as Int",
+	}
+
+	for _, code := range codes {
+		code := code
+
+		t.Run(code, func(t *testing.T) {
+
+			t.Parallel()
+
+			expression, errs := parser2.ParseExpression(code)
+			require.Empty(t, errs)
+
+			assert.Equal(t, code, expression.String())
+			assert.Equal(t, code, ast.Format(expression, ast.FormatOptions{}))
+
+			// The formatted and the canonical string representation
+			// parse to an equal expression
+
+			for _, formatted := range []string{
+				ast.Format(expression, ast.FormatOptions{}),
+				expression.CanonicalString(),
+			} {
+				reparsed, errs := parser2.ParseExpression(formatted)
+				require.Empty(t, errs, formatted)
+				assert.True(t, ast.EqualExpressionsLiterally(expression, reparsed), formatted)
+			}
+		})
+	}
+}
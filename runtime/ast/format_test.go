@@ -0,0 +1,826 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFormatExpression() Expression {
+	return &ArrayExpression{
+		Values: []Expression{
+			&StringExpression{Value: "alpha"},
+			&StringExpression{Value: "beta"},
+			&StringExpression{Value: "gamma"},
+			&StringExpression{Value: "delta"},
+			&DictionaryExpression{
+				Entries: []DictionaryEntry{
+					{
+						Key:   &StringExpression{Value: "epsilon"},
+						Value: newTestIdentifierExpression("one"),
+					},
+					{
+						Key:   &StringExpression{Value: "zeta"},
+						Value: newTestIdentifierExpression("two"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestFormat(t *testing.T) {
+
+	t.Parallel()
+
+	expr := newTestFormatExpression()
+
+	t.Run("width 40", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			`[
+    "alpha",
+    "beta",
+    "gamma",
+    "delta",
+    {"epsilon": one, "zeta": two}
+]`,
+			Format(expr, FormatOptions{MaxLineWidth: 40}),
+		)
+	})
+
+	t.Run("width 120", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			`["alpha", "beta", "gamma", "delta", {"epsilon": one, "zeta": two}]`,
+			Format(expr, FormatOptions{MaxLineWidth: 120}),
+		)
+	})
+
+	t.Run("custom indent", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			`[
+  "alpha",
+  "beta",
+  "gamma",
+  "delta",
+  {
+    "epsilon": one,
+    "zeta": two
+  }
+]`,
+			Format(expr, FormatOptions{MaxLineWidth: 20, Indent: "  "}),
+		)
+	})
+
+	t.Run("tabs", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			"[\n"+
+				"\t\"alpha\",\n"+
+				"\t\"beta\",\n"+
+				"\t\"gamma\",\n"+
+				"\t\"delta\",\n"+
+				"\t{\"epsilon\": one, \"zeta\": two}\n"+
+				"]",
+			Format(expr, FormatOptions{MaxLineWidth: 40, Indent: "  ", UseTabs: true}),
+		)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			testDocString(expr.Doc()),
+			Format(expr, FormatOptions{}),
+		)
+	})
+
+	t.Run("statement", func(t *testing.T) {
+
+		t.Parallel()
+
+		statement := &ReturnStatement{
+			Expression: expr,
+		}
+
+		assert.Equal(t,
+			`return [
+    "alpha",
+    "beta",
+    "gamma",
+    "delta",
+    {"epsilon": one, "zeta": two}
+]`,
+			Format(statement, FormatOptions{MaxLineWidth: 40}),
+		)
+	})
+}
+
+func TestFormatCompact(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("large array", func(t *testing.T) {
+
+		t.Parallel()
+
+		const count = 1000
+
+		values := make([]Expression, count)
+		expectedValues := make([]string, count)
+
+		for i := 0; i < count; i++ {
+			values[i] = &InvocationExpression{
+				InvokedExpression: newTestIdentifierExpression("f"),
+				Arguments: Arguments{
+					{
+						Label:      "value",
+						Expression: newTestFormatExpression(),
+					},
+					{
+						Expression: &IntegerExpression{
+							PositiveLiteral: strconv.Itoa(i),
+							Value:           big.NewInt(int64(i)),
+							Base:            10,
+						},
+					},
+				},
+			}
+			expectedValues[i] = `f(value: ["alpha", "beta", "gamma", "delta", {"epsilon": one, "zeta": two}], ` +
+				strconv.Itoa(i) +
+				`)`
+		}
+
+		expr := &ArrayExpression{
+			Values: values,
+		}
+
+		actual := FormatCompact(expr)
+
+		assert.NotContains(t, actual, "\n")
+		assert.Equal(t,
+			"["+strings.Join(expectedValues, ", ")+"]",
+			actual,
+		)
+	})
+
+	t.Run("fits", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := newTestFormatExpression()
+
+		assert.Equal(t,
+			Format(expr, FormatOptions{MaxLineWidth: 120}),
+			FormatCompact(expr),
+		)
+	})
+}
+
+func TestFormat_MaxDepth(t *testing.T) {
+
+	t.Parallel()
+
+	// a.m.m.m...
+
+	newMemberChain := func(depth int) Expression {
+		var expr Expression = newTestIdentifierExpression("a")
+		for i := 1; i < depth; i++ {
+			expr = &MemberExpression{
+				Expression: expr,
+				Identifier: Identifier{Identifier: "m"},
+			}
+		}
+		return expr
+	}
+
+	t.Run("exceeded", func(t *testing.T) {
+
+		t.Parallel()
+
+		const depth = 20_000
+
+		expr := newMemberChain(depth)
+
+		options := FormatOptions{
+			MaxDepth: 100,
+		}
+
+		_, err := TryFormat(expr, options)
+		require.Error(t, err)
+
+		var depthErr *MaxDepthExceededError
+		require.ErrorAs(t, err, &depthErr)
+		assert.Equal(t,
+			&MaxDepthExceededError{
+				MaxDepth: 100,
+				Depth:    depth,
+			},
+			depthErr,
+		)
+
+		assert.Equal(t,
+			FormatTruncationMarker,
+			Format(expr, options),
+		)
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := newMemberChain(4)
+
+		options := FormatOptions{
+			MaxDepth: 4,
+		}
+
+		actual, err := TryFormat(expr, options)
+		require.NoError(t, err)
+		assert.Equal(t, "a.m.m.m", actual)
+
+		assert.Equal(t, "a.m.m.m", Format(expr, options))
+	})
+
+	// x as Int??...
+
+	newCastingToOptional := func(optionals int) Expression {
+		var ty Type = &NominalType{
+			Identifier: Identifier{Identifier: "Int"},
+		}
+		for i := 0; i < optionals; i++ {
+			ty = &OptionalType{Type: ty}
+		}
+		return &CastingExpression{
+			Operation:  OperationCast,
+			Expression: newTestIdentifierExpression("x"),
+			TypeAnnotation: &TypeAnnotation{
+				Type: ty,
+			},
+		}
+	}
+
+	t.Run("nested types, exceeded", func(t *testing.T) {
+
+		t.Parallel()
+
+		// The types are nested too, so they are part of the depth,
+		// and formatting them would overflow the stack
+
+		const optionals = 1_000_000
+
+		expr := newCastingToOptional(optionals)
+
+		options := FormatOptions{
+			MaxDepth: 100,
+		}
+
+		_, err := TryFormat(expr, options)
+		require.Error(t, err)
+
+		var depthErr *MaxDepthExceededError
+		require.ErrorAs(t, err, &depthErr)
+		assert.Equal(t,
+			&MaxDepthExceededError{
+				MaxDepth: 100,
+				// The casting expression, the optional types, and the nominal type
+				Depth: optionals + 2,
+			},
+			depthErr,
+		)
+
+		assert.Equal(t,
+			FormatTruncationMarker,
+			Format(expr, options),
+		)
+	})
+
+	t.Run("nested types, within limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		expr := newCastingToOptional(2)
+
+		actual, err := TryFormat(expr, FormatOptions{MaxDepth: 4})
+		require.NoError(t, err)
+		assert.Equal(t, "x as Int??", actual)
+
+		_, err = TryFormat(expr, FormatOptions{MaxDepth: 3})
+		require.Error(t, err)
+	})
+}
+
+func TestFormat_TrailingComma(t *testing.T) {
+
+	t.Parallel()
+
+	expr := newTestFormatExpression()
+
+	t.Run("array, broken", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Array and dictionary literals never get a trailing comma
+
+		assert.Equal(t,
+			`[
+    "alpha",
+    "beta",
+    "gamma",
+    "delta",
+    {"epsilon": one, "zeta": two}
+]`,
+			Format(expr, FormatOptions{
+				MaxLineWidth:  40,
+				TrailingComma: true,
+			}),
+		)
+	})
+
+	t.Run("array, unbroken", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			`["alpha", "beta", "gamma", "delta", {"epsilon": one, "zeta": two}]`,
+			Format(expr, FormatOptions{
+				MaxLineWidth:  120,
+				TrailingComma: true,
+			}),
+		)
+	})
+
+	t.Run("nested, broken", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			`[
+    "alpha",
+    "beta",
+    "gamma",
+    "delta",
+    {
+        "epsilon": one,
+        "zeta": two
+    }
+]`,
+			Format(expr, FormatOptions{
+				MaxLineWidth:  20,
+				TrailingComma: true,
+			}),
+		)
+	})
+
+	t.Run("arguments", func(t *testing.T) {
+
+		t.Parallel()
+
+		invocation := &InvocationExpression{
+			InvokedExpression: newTestIdentifierExpression("transfer"),
+			Arguments: Arguments{
+				{
+					Label:      "from",
+					Expression: newTestIdentifierExpression("sender"),
+				},
+				{
+					Label:      "to",
+					Expression: newTestIdentifierExpression("recipient"),
+				},
+			},
+		}
+
+		assert.Equal(t,
+			`transfer(from: sender, to: recipient)`,
+			Format(invocation, FormatOptions{
+				TrailingComma: true,
+			}),
+		)
+
+		assert.Equal(t,
+			`transfer(
+  from: sender,
+  to: recipient,
+)`,
+			Format(invocation, FormatOptions{
+				MaxLineWidth:  20,
+				Indent:        "  ",
+				TrailingComma: true,
+			}),
+		)
+	})
+
+	t.Run("parameters", func(t *testing.T) {
+
+		t.Parallel()
+
+		function := &FunctionExpression{
+			ParameterList: &ParameterList{
+				Parameters: []*Parameter{
+					{
+						Identifier: Identifier{Identifier: "first"},
+						TypeAnnotation: &TypeAnnotation{
+							Type: &NominalType{
+								Identifier: Identifier{Identifier: "Int"},
+							},
+						},
+					},
+					{
+						Identifier: Identifier{Identifier: "second"},
+						TypeAnnotation: &TypeAnnotation{
+							Type: &NominalType{
+								Identifier: Identifier{Identifier: "String"},
+							},
+						},
+					},
+				},
+			},
+			FunctionBlock: &FunctionBlock{
+				Block: &Block{},
+			},
+		}
+
+		assert.Equal(t,
+			`fun (first: Int, second: String) {}`,
+			Format(function, FormatOptions{
+				TrailingComma: true,
+			}),
+		)
+
+		assert.Equal(t,
+			"fun (\n"+
+				"\tfirst: Int,\n"+
+				"\tsecond: String,\n"+
+				") {}",
+			Format(function, FormatOptions{
+				MaxLineWidth:  20,
+				UseTabs:       true,
+				TrailingComma: true,
+			}),
+		)
+	})
+
+	t.Run("statements", func(t *testing.T) {
+
+		t.Parallel()
+
+		function := &FunctionExpression{
+			FunctionBlock: &FunctionBlock{
+				Block: &Block{
+					Statements: []Statement{
+						&ReturnStatement{
+							Expression: expr,
+						},
+					},
+				},
+			},
+		}
+
+		assert.Equal(t,
+			`fun () {
+    return [
+        "alpha",
+        "beta",
+        "gamma",
+        "delta",
+        {"epsilon": one, "zeta": two}
+    ]
+}`,
+			Format(function, FormatOptions{
+				MaxLineWidth:  40,
+				TrailingComma: true,
+			}),
+		)
+	})
+
+	t.Run("string literal", func(t *testing.T) {
+
+		t.Parallel()
+
+		invocation := &InvocationExpression{
+			InvokedExpression: newTestIdentifierExpression("f"),
+			Arguments: Arguments{
+				{Expression: &StringExpression{Value: "\x01)"}},
+				{Expression: &StringExpression{Value: "\n)"}},
+			},
+		}
+
+		assert.Equal(t,
+			`f(
+    "\u{1})",
+    "\n)",
+)`,
+			Format(invocation, FormatOptions{
+				MaxLineWidth:  10,
+				TrailingComma: true,
+			}),
+		)
+	})
+
+	t.Run("default", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			Format(expr, FormatOptions{MaxLineWidth: 20}),
+			Format(expr, FormatOptions{MaxLineWidth: 20, TrailingComma: false}),
+		)
+		assert.NotContains(t,
+			Format(expr, FormatOptions{MaxLineWidth: 20}),
+			"},",
+		)
+	})
+}
+
+func TestFormat_DigitGrouping(t *testing.T) {
+
+	t.Parallel()
+
+	newInteger := func(literal string, value int64, base int) *IntegerExpression {
+		return &IntegerExpression{
+			PositiveLiteral: literal,
+			Value:           big.NewInt(value),
+			Base:            base,
+		}
+	}
+
+	type testCase struct {
+		expression Expression
+		options    FormatOptions
+		expected   string
+	}
+
+	testCases := map[string]testCase{
+		"group": {
+			expression: newInteger("1000000", 1000000, 10),
+			options:    FormatOptions{DigitGrouping: 3},
+			expected:   "1_000_000",
+		},
+		"strip": {
+			expression: newInteger("1_000_000", 1000000, 10),
+			options:    FormatOptions{StripSeparators: true},
+			expected:   "1000000",
+		},
+		"regroup": {
+			expression: newInteger("10_00_000", 1000000, 10),
+			options:    FormatOptions{DigitGrouping: 3},
+			expected:   "1_000_000",
+		},
+		"regroup instead of strip": {
+			expression: newInteger("10_00_000", 1000000, 10),
+			options: FormatOptions{
+				DigitGrouping:   3,
+				StripSeparators: true,
+			},
+			expected: "1_000_000",
+		},
+		"leave as-is": {
+			expression: newInteger("10_00_000", 1000000, 10),
+			options:    FormatOptions{},
+			expected:   "10_00_000",
+		},
+		"short": {
+			expression: newInteger("100", 100, 10),
+			options:    FormatOptions{DigitGrouping: 3},
+			expected:   "100",
+		},
+		"negative": {
+			expression: newInteger("1234567", -1234567, 10),
+			options:    FormatOptions{DigitGrouping: 3},
+			expected:   "-1_234_567",
+		},
+		"hex": {
+			expression: newInteger("0xDEADBEEF", 3735928559, 16),
+			options:    FormatOptions{DigitGrouping: 4},
+			expected:   "0xDEAD_BEEF",
+		},
+		"binary, strip": {
+			expression: newInteger("0b1010_1010", 170, 2),
+			options:    FormatOptions{StripSeparators: true},
+			expected:   "0b10101010",
+		},
+		"nested": {
+			expression: &BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newInteger("1000", 1000, 10),
+				Right: &ArrayExpression{
+					Values: []Expression{
+						newInteger("0o7777", 4095, 8),
+					},
+				},
+			},
+			options:  FormatOptions{DigitGrouping: 2},
+			expected: "10_00 + [0o77_77]",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				Format(testCase.expression, testCase.options),
+			)
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := newInteger("1000000", 1000000, 10)
+
+		grouped := Format(expression, FormatOptions{DigitGrouping: 3})
+		require.Equal(t, "1_000_000", grouped)
+
+		expression.PositiveLiteral = grouped
+
+		assert.Equal(t,
+			"1000000",
+			Format(expression, FormatOptions{StripSeparators: true}),
+		)
+	})
+}
+
+func TestFormat_AlignDictionaryValues(t *testing.T) {
+
+	t.Parallel()
+
+	newEntry := func(key string, value Expression) DictionaryEntry {
+		return DictionaryEntry{
+			Key:   &StringExpression{Value: key},
+			Value: value,
+		}
+	}
+
+	// {"a": one, "bbb": two, "cc": three}
+
+	expr := &DictionaryExpression{
+		Entries: []DictionaryEntry{
+			newEntry("a", newTestIdentifierExpression("one")),
+			newEntry("bbb", newTestIdentifierExpression("two")),
+			newEntry("cc", newTestIdentifierExpression("three")),
+		},
+	}
+
+	t.Run("broken", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			`{
+    "a"  : one,
+    "bbb": two,
+    "cc" : three
+}`,
+			Format(expr, FormatOptions{
+				MaxLineWidth:          20,
+				AlignDictionaryValues: true,
+			}),
+		)
+	})
+
+	t.Run("unbroken", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			`{"a": one, "bbb": two, "cc": three}`,
+			Format(expr, FormatOptions{
+				MaxLineWidth:          80,
+				AlignDictionaryValues: true,
+			}),
+		)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		// The alignment of the inner dictionary is independent of the outer dictionary
+
+		nested := &DictionaryExpression{
+			Entries: []DictionaryEntry{
+				newEntry("outer", expr),
+				newEntry("o", newTestIdentifierExpression("four")),
+			},
+		}
+
+		assert.Equal(t,
+			`{
+    "outer":
+    {
+        "a"  : one,
+        "bbb": two,
+        "cc" : three
+    },
+    "o"    : four
+}`,
+			Format(nested, FormatOptions{
+				MaxLineWidth:          20,
+				AlignDictionaryValues: true,
+			}),
+		)
+	})
+
+	t.Run("nested, inner unbroken", func(t *testing.T) {
+
+		t.Parallel()
+
+		inner := &DictionaryExpression{
+			Entries: []DictionaryEntry{
+				newEntry("a", newTestIdentifierExpression("b")),
+				newEntry("cc", newTestIdentifierExpression("d")),
+			},
+		}
+
+		nested := &DictionaryExpression{
+			Entries: []DictionaryEntry{
+				newEntry("x", inner),
+				newEntry("yyyy", newTestIdentifierExpression("z")),
+			},
+		}
+
+		assert.Equal(t,
+			`{
+    "x"   : {"a": b, "cc": d},
+    "yyyy": z
+}`,
+			Format(nested, FormatOptions{
+				MaxLineWidth:          30,
+				AlignDictionaryValues: true,
+			}),
+		)
+	})
+
+	t.Run("trailing comma", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			`{
+    "a"  : one,
+    "bbb": two,
+    "cc" : three
+}`,
+			Format(expr, FormatOptions{
+				MaxLineWidth:          20,
+				AlignDictionaryValues: true,
+				TrailingComma:         true,
+			}),
+		)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			`{
+    "a": one,
+    "bbb": two,
+    "cc": three
+}`,
+			Format(expr, FormatOptions{
+				MaxLineWidth: 20,
+			}),
+		)
+	})
+}
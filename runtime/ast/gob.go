@@ -0,0 +1,72 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/gob"
+)
+
+// RegisterGob registers all expressions and types with encoding/gob,
+// so that values of the Expression and Type interfaces can be gob-encoded and -decoded.
+//
+// Statements and declarations are not registered,
+// so function expressions can only be encoded if their function block is empty.
+//
+// It is safe to call RegisterGob multiple times.
+func RegisterGob() {
+
+	// Expressions
+
+	gob.Register(&BoolExpression{})
+	gob.Register(&NilExpression{})
+	gob.Register(&VoidExpression{})
+	gob.Register(&StringExpression{})
+	gob.Register(&StringTemplateExpression{})
+	gob.Register(&IntegerExpression{})
+	gob.Register(&FixedPointExpression{})
+	gob.Register(&ArrayExpression{})
+	gob.Register(&DictionaryExpression{})
+	gob.Register(&IdentifierExpression{})
+	gob.Register(&InvocationExpression{})
+	gob.Register(&MemberExpression{})
+	gob.Register(&IndexExpression{})
+	gob.Register(&ConditionalExpression{})
+	gob.Register(&UnaryExpression{})
+	gob.Register(&BinaryExpression{})
+	gob.Register(&FunctionExpression{})
+	gob.Register(&CastingExpression{})
+	gob.Register(&CreateExpression{})
+	gob.Register(&DestroyExpression{})
+	gob.Register(&AttachmentExpression{})
+	gob.Register(&ReferenceExpression{})
+	gob.Register(&ForceExpression{})
+	gob.Register(&PathExpression{})
+
+	// Types
+
+	gob.Register(&NominalType{})
+	gob.Register(&OptionalType{})
+	gob.Register(&VariableSizedType{})
+	gob.Register(&ConstantSizedType{})
+	gob.Register(&DictionaryType{})
+	gob.Register(&FunctionType{})
+	gob.Register(&ReferenceType{})
+	gob.Register(&RestrictedType{})
+	gob.Register(&InstantiationType{})
+}
@@ -0,0 +1,52 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterGob(t *testing.T) {
+
+	t.Parallel()
+
+	RegisterGob()
+	// Registering again is allowed
+	RegisterGob()
+
+	expression := newTestJSONExpression()
+
+	var buffer bytes.Buffer
+	err := gob.NewEncoder(&buffer).Encode(&expression)
+	require.NoError(t, err)
+
+	var decoded Expression
+	err = gob.NewDecoder(&buffer).Decode(&decoded)
+	require.NoError(t, err)
+
+	err = expression.CheckEqual(decoded, DefaultExpressionEqualityChecker{})
+	require.NoError(t, err)
+
+	assert.Equal(t, expression.String(), decoded.String())
+}
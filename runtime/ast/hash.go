@@ -0,0 +1,390 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+
+	"github.com/onflow/cadence/runtime/errors"
+)
+
+// HashExpression returns a structural hash of the given expression,
+// which is independent of positions and stable across processes.
+//
+// Expressions which are equal according to EqualExpressions have the same hash.
+func HashExpression(expression Expression) uint64 {
+	hasher := newStructuralHasher()
+	hasher.hashExpression(expression)
+	return uint64(hasher)
+}
+
+// HashType returns a structural hash of the given type,
+// which is independent of positions and stable across processes.
+//
+// Types which are equal according to EqualTypes have the same hash,
+// also when the restrictions of restricted types are compared regardless of their order.
+func HashType(ty Type) uint64 {
+	hasher := newStructuralHasher()
+	hasher.hashType(ty)
+	return uint64(hasher)
+}
+
+const (
+	hashKindNil byte = iota
+	hashKindBoolExpression
+	hashKindNilExpression
+	hashKindVoidExpression
+	hashKindStringExpression
+	hashKindStringTemplateExpression
+	hashKindIntegerExpression
+	hashKindFixedPointExpression
+	hashKindArrayExpression
+	hashKindDictionaryExpression
+	hashKindIdentifierExpression
+	hashKindInvocationExpression
+	hashKindMemberExpression
+	hashKindIndexExpression
+	hashKindConditionalExpression
+	hashKindUnaryExpression
+	hashKindBinaryExpression
+	hashKindFunctionExpression
+	hashKindCastingExpression
+	hashKindCreateExpression
+	hashKindDestroyExpression
+	hashKindAttachmentExpression
+	hashKindReferenceExpression
+	hashKindForceExpression
+	hashKindPathExpression
+	hashKindTypeAnnotation
+	hashKindNominalType
+	hashKindOptionalType
+	hashKindVariableSizedType
+	hashKindConstantSizedType
+	hashKindDictionaryType
+	hashKindFunctionType
+	hashKindReferenceType
+	hashKindRestrictedType
+	hashKindInstantiationType
+)
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// structuralHasher is a 64-bit FNV-1a hash,
+// implemented inline to avoid allocations
+type structuralHasher uint64
+
+func newStructuralHasher() structuralHasher {
+	return fnvOffset64
+}
+
+func (h *structuralHasher) writeByte(b byte) {
+	*h ^= structuralHasher(b)
+	*h *= fnvPrime64
+}
+
+func (h *structuralHasher) writeUint64(value uint64) {
+	for i := 0; i < 8; i++ {
+		h.writeByte(byte(value >> (i * 8)))
+	}
+}
+
+func (h *structuralHasher) writeBool(value bool) {
+	if value {
+		h.writeByte(1)
+	} else {
+		h.writeByte(0)
+	}
+}
+
+// writeString writes the length of the string before its bytes,
+// so that consecutive strings cannot be confused, e.g. "ab", "c" and "a", "bc"
+func (h *structuralHasher) writeString(value string) {
+	h.writeUint64(uint64(len(value)))
+	for i := 0; i < len(value); i++ {
+		h.writeByte(value[i])
+	}
+}
+
+func (h *structuralHasher) writeBigInt(value *big.Int) {
+	if value == nil {
+		h.writeByte(hashKindNil)
+		return
+	}
+
+	h.writeUint64(uint64(value.Sign() + 1))
+	words := value.Bits()
+	h.writeUint64(uint64(len(words)))
+	for _, word := range words {
+		h.writeUint64(uint64(word))
+	}
+}
+
+func (h *structuralHasher) hashExpressions(expressions []Expression) {
+	h.writeUint64(uint64(len(expressions)))
+	for _, expression := range expressions {
+		h.hashExpression(expression)
+	}
+}
+
+func (h *structuralHasher) hashExpression(expression Expression) {
+	switch expression := expression.(type) {
+	case nil:
+		h.writeByte(hashKindNil)
+
+	case *BoolExpression:
+		h.writeByte(hashKindBoolExpression)
+		h.writeBool(expression.Value)
+
+	case *NilExpression:
+		h.writeByte(hashKindNilExpression)
+
+	case *VoidExpression:
+		h.writeByte(hashKindVoidExpression)
+
+	case *StringExpression:
+		h.writeByte(hashKindStringExpression)
+		h.writeString(expression.Value)
+
+	case *StringTemplateExpression:
+		h.writeByte(hashKindStringTemplateExpression)
+		h.writeUint64(uint64(len(expression.Segments)))
+		for _, segment := range expression.Segments {
+			h.writeString(segment)
+		}
+		h.hashExpressions(expression.Values)
+
+	case *IntegerExpression:
+		// NOTE: the value is hashed, not the literal,
+		// as literals are compared by value
+		h.writeByte(hashKindIntegerExpression)
+		h.writeBigInt(expression.Value)
+
+	case *FixedPointExpression:
+		h.writeByte(hashKindFixedPointExpression)
+		h.writeBool(expression.Negative)
+		h.writeUint64(uint64(expression.Scale))
+		h.writeBigInt(expression.UnsignedInteger)
+		h.writeBigInt(expression.Fractional)
+
+	case *ArrayExpression:
+		h.writeByte(hashKindArrayExpression)
+		h.hashExpressions(expression.Values)
+
+	case *DictionaryExpression:
+		h.writeByte(hashKindDictionaryExpression)
+		h.writeUint64(uint64(len(expression.Entries)))
+		for _, entry := range expression.Entries {
+			h.hashExpression(entry.Key)
+			h.hashExpression(entry.Value)
+		}
+
+	case *IdentifierExpression:
+		h.writeByte(hashKindIdentifierExpression)
+		h.writeString(expression.Identifier.Identifier)
+
+	case *InvocationExpression:
+		h.writeByte(hashKindInvocationExpression)
+		h.hashExpression(expression.InvokedExpression)
+		h.writeUint64(uint64(len(expression.TypeArguments)))
+		for _, typeArgument := range expression.TypeArguments {
+			h.hashTypeAnnotation(typeArgument)
+		}
+		h.writeUint64(uint64(len(expression.Arguments)))
+		for _, argument := range expression.Arguments {
+			h.writeString(argument.Label)
+			h.hashExpression(argument.Expression)
+		}
+
+	case *MemberExpression:
+		h.writeByte(hashKindMemberExpression)
+		h.writeBool(expression.Optional)
+		h.writeString(expression.Identifier.Identifier)
+		h.hashExpression(expression.Expression)
+
+	case *IndexExpression:
+		h.writeByte(hashKindIndexExpression)
+		h.hashExpression(expression.TargetExpression)
+		h.hashExpression(expression.IndexingExpression)
+
+	case *ConditionalExpression:
+		h.writeByte(hashKindConditionalExpression)
+		h.hashExpression(expression.Test)
+		h.hashExpression(expression.Then)
+		h.hashExpression(expression.Else)
+
+	case *UnaryExpression:
+		h.writeByte(hashKindUnaryExpression)
+		h.writeUint64(uint64(expression.Operation))
+		h.hashExpression(expression.Expression)
+
+	case *BinaryExpression:
+		h.writeByte(hashKindBinaryExpression)
+		h.writeUint64(uint64(expression.Operation))
+		h.hashExpression(expression.Left)
+		h.hashExpression(expression.Right)
+
+	case *FunctionExpression:
+		h.writeByte(hashKindFunctionExpression)
+		var parameters []*Parameter
+		if expression.ParameterList != nil {
+			parameters = expression.ParameterList.Parameters
+		}
+		h.writeUint64(uint64(len(parameters)))
+		for _, parameter := range parameters {
+			h.writeString(parameter.Label)
+			h.writeString(parameter.Identifier.Identifier)
+			h.hashTypeAnnotation(parameter.TypeAnnotation)
+		}
+		h.hashTypeAnnotation(expression.ReturnTypeAnnotation)
+		// NOTE: function bodies are not compared structurally,
+		// only whether they are empty is significant
+		h.writeBool(expression.FunctionBlock.IsEmpty())
+
+	case *CastingExpression:
+		h.writeByte(hashKindCastingExpression)
+		h.writeUint64(uint64(expression.Operation))
+		h.hashExpression(expression.Expression)
+		h.hashTypeAnnotation(expression.TypeAnnotation)
+
+	case *CreateExpression:
+		h.writeByte(hashKindCreateExpression)
+		h.hashExpression(expression.InvocationExpression)
+
+	case *DestroyExpression:
+		h.writeByte(hashKindDestroyExpression)
+		h.hashExpression(expression.Expression)
+
+	case *AttachmentExpression:
+		h.writeByte(hashKindAttachmentExpression)
+		h.hashExpression(expression.Attachment)
+		h.hashExpression(expression.Base)
+
+	case *ReferenceExpression:
+		h.writeByte(hashKindReferenceExpression)
+		h.hashExpression(expression.Expression)
+		h.hashType(expression.Type)
+
+	case *ForceExpression:
+		h.writeByte(hashKindForceExpression)
+		h.hashExpression(expression.Expression)
+
+	case *PathExpression:
+		h.writeByte(hashKindPathExpression)
+		h.writeString(expression.Domain.Identifier)
+		h.writeString(expression.Identifier.Identifier)
+
+	default:
+		panic(errors.NewUnreachableError())
+	}
+}
+
+func (h *structuralHasher) hashTypeAnnotation(typeAnnotation *TypeAnnotation) {
+	if typeAnnotation == nil {
+		h.writeByte(hashKindNil)
+		return
+	}
+
+	h.writeByte(hashKindTypeAnnotation)
+	h.writeBool(typeAnnotation.IsResource)
+	h.hashType(typeAnnotation.Type)
+}
+
+func (h *structuralHasher) hashTypeAnnotations(typeAnnotations []*TypeAnnotation) {
+	h.writeUint64(uint64(len(typeAnnotations)))
+	for _, typeAnnotation := range typeAnnotations {
+		h.hashTypeAnnotation(typeAnnotation)
+	}
+}
+
+func (h *structuralHasher) hashNominalType(ty *NominalType) {
+	h.writeByte(hashKindNominalType)
+	h.writeString(ty.Identifier.Identifier)
+	h.writeUint64(uint64(len(ty.NestedIdentifiers)))
+	for _, nestedIdentifier := range ty.NestedIdentifiers {
+		h.writeString(nestedIdentifier.Identifier)
+	}
+}
+
+func (h *structuralHasher) hashType(ty Type) {
+	switch ty := ty.(type) {
+	case nil:
+		h.writeByte(hashKindNil)
+
+	case *NominalType:
+		h.hashNominalType(ty)
+
+	case *OptionalType:
+		h.writeByte(hashKindOptionalType)
+		h.hashType(ty.Type)
+
+	case *VariableSizedType:
+		h.writeByte(hashKindVariableSizedType)
+		h.hashType(ty.Type)
+
+	case *ConstantSizedType:
+		h.writeByte(hashKindConstantSizedType)
+		h.hashType(ty.Type)
+		if ty.Size == nil {
+			h.writeByte(hashKindNil)
+		} else {
+			h.writeBigInt(ty.Size.Value)
+		}
+
+	case *DictionaryType:
+		h.writeByte(hashKindDictionaryType)
+		h.hashType(ty.KeyType)
+		h.hashType(ty.ValueType)
+
+	case *FunctionType:
+		h.writeByte(hashKindFunctionType)
+		h.hashTypeAnnotations(ty.ParameterTypeAnnotations)
+		h.hashTypeAnnotation(ty.ReturnTypeAnnotation)
+
+	case *ReferenceType:
+		h.writeByte(hashKindReferenceType)
+		h.writeBool(ty.Authorized)
+		h.hashType(ty.Type)
+
+	case *RestrictedType:
+		h.writeByte(hashKindRestrictedType)
+		h.hashType(ty.Type)
+		h.writeUint64(uint64(len(ty.Restrictions)))
+		// Combine the hashes of the restrictions independent of their order,
+		// so restricted types which are only equal when ignoring the order
+		// of their restrictions also have the same hash
+		var restrictionsHash uint64
+		for _, restriction := range ty.Restrictions {
+			restrictionHasher := newStructuralHasher()
+			restrictionHasher.hashNominalType(restriction)
+			restrictionsHash += uint64(restrictionHasher)
+		}
+		h.writeUint64(restrictionsHash)
+
+	case *InstantiationType:
+		h.writeByte(hashKindInstantiationType)
+		h.hashType(ty.Type)
+		h.hashTypeAnnotations(ty.TypeArguments)
+
+	default:
+		panic(errors.NewUnreachableError())
+	}
+}
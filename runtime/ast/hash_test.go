@@ -0,0 +1,305 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashExpression(t *testing.T) {
+
+	t.Parallel()
+
+	newInvocation := func(literal string, base int, offset int) *InvocationExpression {
+		return &InvocationExpression{
+			InvokedExpression: &IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "f",
+					Pos:        Position{Offset: offset, Line: 1, Column: offset},
+				},
+			},
+			Arguments: Arguments{
+				{
+					Label: "x",
+					Expression: &IntegerExpression{
+						PositiveLiteral: literal,
+						Value:           big.NewInt(10),
+						Base:            base,
+						Range: Range{
+							StartPos: Position{Offset: offset + 5, Line: 1, Column: offset + 5},
+							EndPos:   Position{Offset: offset + 6, Line: 1, Column: offset + 6},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("equal expressions", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := newInvocation("10", 10, 0)
+		b := newInvocation("0xA", 16, 42)
+
+		assert.True(t, EqualExpressions(a, b))
+		assert.Equal(t, HashExpression(a), HashExpression(b))
+	})
+
+	t.Run("different expressions", func(t *testing.T) {
+
+		t.Parallel()
+
+		type testCase struct {
+			a, b Expression
+		}
+
+		testCases := map[string]testCase{
+			"kind": {
+				a: &NilExpression{},
+				b: &VoidExpression{},
+			},
+			"identifier": {
+				a: newTestIdentifierExpression("a"),
+				b: newTestIdentifierExpression("b"),
+			},
+			"string": {
+				a: &StringExpression{Value: "a"},
+				b: &StringExpression{Value: "b"},
+			},
+			"integer": {
+				a: &IntegerExpression{Value: big.NewInt(1)},
+				b: &IntegerExpression{Value: big.NewInt(-1)},
+			},
+			"fixed-point": {
+				a: &FixedPointExpression{
+					UnsignedInteger: big.NewInt(1),
+					Fractional:      big.NewInt(5),
+					Scale:           1,
+				},
+				b: &FixedPointExpression{
+					UnsignedInteger: big.NewInt(1),
+					Fractional:      big.NewInt(5),
+					Scale:           2,
+				},
+			},
+			"operation": {
+				a: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      newTestIdentifierExpression("a"),
+					Right:     newTestIdentifierExpression("b"),
+				},
+				b: &BinaryExpression{
+					Operation: OperationMinus,
+					Left:      newTestIdentifierExpression("a"),
+					Right:     newTestIdentifierExpression("b"),
+				},
+			},
+			"order": {
+				a: &ArrayExpression{
+					Values: []Expression{
+						newTestIdentifierExpression("a"),
+						newTestIdentifierExpression("b"),
+					},
+				},
+				b: &ArrayExpression{
+					Values: []Expression{
+						newTestIdentifierExpression("b"),
+						newTestIdentifierExpression("a"),
+					},
+				},
+			},
+			"string boundaries": {
+				a: &PathExpression{
+					Domain:     Identifier{Identifier: "ab"},
+					Identifier: Identifier{Identifier: "c"},
+				},
+				b: &PathExpression{
+					Domain:     Identifier{Identifier: "a"},
+					Identifier: Identifier{Identifier: "bc"},
+				},
+			},
+			"argument label": {
+				a: newInvocation("10", 10, 0),
+				b: &InvocationExpression{
+					InvokedExpression: newTestIdentifierExpression("f"),
+					Arguments: Arguments{
+						{
+							Label: "y",
+							Expression: &IntegerExpression{
+								Value: big.NewInt(10),
+							},
+						},
+					},
+				},
+			},
+			"type": {
+				a: &CastingExpression{
+					Operation:  OperationCast,
+					Expression: newTestIdentifierExpression("a"),
+					TypeAnnotation: &TypeAnnotation{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "T"},
+						},
+					},
+				},
+				b: &CastingExpression{
+					Operation:  OperationCast,
+					Expression: newTestIdentifierExpression("a"),
+					TypeAnnotation: &TypeAnnotation{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "U"},
+						},
+					},
+				},
+			},
+		}
+
+		for name, testCase := range testCases {
+			testCase := testCase
+
+			t.Run(name, func(t *testing.T) {
+
+				t.Parallel()
+
+				assert.False(t, EqualExpressions(testCase.a, testCase.b))
+				assert.NotEqual(t, HashExpression(testCase.a), HashExpression(testCase.b))
+			})
+		}
+	})
+}
+
+func TestHashType(t *testing.T) {
+
+	t.Parallel()
+
+	newType := func(offset int, authorized bool) Type {
+		return &ReferenceType{
+			Authorized: authorized,
+			Type: &RestrictedType{
+				Type: &NominalType{
+					Identifier: Identifier{
+						Identifier: "R",
+						Pos:        Position{Offset: offset, Line: 1, Column: offset},
+					},
+				},
+				Restrictions: []*NominalType{
+					{
+						Identifier: Identifier{
+							Identifier: "I",
+							Pos:        Position{Offset: offset + 2, Line: 1, Column: offset + 2},
+						},
+					},
+				},
+			},
+			StartPos: Position{Offset: offset, Line: 1, Column: offset},
+		}
+	}
+
+	a := newType(0, true)
+	b := newType(10, true)
+	c := newType(0, false)
+
+	assert.True(t, EqualTypes(a, b))
+	assert.Equal(t, HashType(a), HashType(b))
+
+	assert.False(t, EqualTypes(a, c))
+	assert.NotEqual(t, HashType(a), HashType(c))
+}
+
+func newBenchmarkExpression() Expression {
+	values := make([]Expression, 1000)
+	for i := range values {
+		values[i] = &InvocationExpression{
+			InvokedExpression: &MemberExpression{
+				Expression: newTestIdentifierExpression("a"),
+				Identifier: Identifier{
+					Identifier: "b",
+				},
+			},
+			Arguments: Arguments{
+				{
+					Label: "x",
+					Expression: &BinaryExpression{
+						Operation: OperationPlus,
+						Left: &IntegerExpression{
+							Value: big.NewInt(int64(i)),
+						},
+						Right: &StringExpression{
+							Value: "test",
+						},
+					},
+				},
+			},
+		}
+	}
+	return &ArrayExpression{
+		Values: values,
+	}
+}
+
+func BenchmarkHashExpression(b *testing.B) {
+
+	expression := newBenchmarkExpression()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		HashExpression(expression)
+	}
+}
+
+func BenchmarkHashType(b *testing.B) {
+
+	ty := &FunctionType{
+		ParameterTypeAnnotations: []*TypeAnnotation{
+			{
+				Type: &DictionaryType{
+					KeyType: &NominalType{
+						Identifier: Identifier{Identifier: "String"},
+					},
+					ValueType: &VariableSizedType{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "Int"},
+						},
+					},
+				},
+			},
+		},
+		ReturnTypeAnnotation: &TypeAnnotation{
+			Type: &OptionalType{
+				Type: &NominalType{
+					Identifier:        Identifier{Identifier: "A"},
+					NestedIdentifiers: []Identifier{{Identifier: "B"}},
+				},
+			},
+		},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		HashType(ty)
+	}
+}
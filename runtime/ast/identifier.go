@@ -51,3 +51,18 @@ func (i Identifier) MarshalJSON() ([]byte, error) {
 		Range:      NewRangeFromPositioned(i),
 	})
 }
+
+func (i *Identifier) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Identifier string
+		Range
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	i.Identifier = v.Identifier
+	i.Pos = v.StartPos
+	return nil
+}
@@ -0,0 +1,234 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+// IdentifierCollectionOptions are the options for CollectIdentifiers.
+type IdentifierCollectionOptions struct {
+	// ExcludeMemberNames specifies that the names of accessed members,
+	// e.g. `bar` in `foo.bar`, are not collected.
+	ExcludeMemberNames bool
+	// ExcludeParameters specifies that identifiers which refer to a parameter
+	// of an enclosing function expression are not collected,
+	// as they are bindings, not references to the surrounding environment.
+	ExcludeParameters bool
+	// ExcludeLocalDeclarations specifies that identifiers which refer to a local declaration
+	// in an enclosing block are not collected, e.g. to a variable declared by a preceding statement,
+	// or to the variable of an enclosing for-statement.
+	ExcludeLocalDeclarations bool
+}
+
+type identifierCollector struct {
+	options     IdentifierCollectionOptions
+	identifiers *[]Identifier
+	// bindings are the names of the parameters and local declarations in scope,
+	// which are not collected
+	bindings map[string]struct{}
+}
+
+// withBindings returns a collector for the scope of the given bindings,
+// i.e. which also does not collect references to the bindings
+func (c *identifierCollector) withBindings(identifiers ...Identifier) *identifierCollector {
+	if len(identifiers) == 0 {
+		return c
+	}
+
+	bindings := make(map[string]struct{}, len(c.bindings)+len(identifiers))
+	for name := range c.bindings {
+		bindings[name] = struct{}{}
+	}
+	for _, identifier := range identifiers {
+		bindings[identifier.Identifier] = struct{}{}
+	}
+
+	return &identifierCollector{
+		options:     c.options,
+		identifiers: c.identifiers,
+		bindings:    bindings,
+	}
+}
+
+func (c *identifierCollector) walk(element Element) {
+	if element == nil {
+		return
+	}
+	Walk(c, element)
+}
+
+// walkStatements walks the given statements in order,
+// and collects the references in each statement in the scope of the preceding local declarations
+func (c *identifierCollector) walkStatements(statements []Statement) {
+	current := c
+	for _, statement := range statements {
+		switch statement := statement.(type) {
+		case *VariableDeclaration:
+			// The value is not in the scope of the declaration
+			current.walk(statement)
+			current = current.withBindings(statement.Identifier)
+
+		case *FunctionDeclaration:
+			// The function is in scope of its own body
+			current = current.withBindings(statement.Identifier)
+			current.walk(statement)
+
+		default:
+			current.walk(statement)
+		}
+	}
+}
+
+func (c *identifierCollector) Walk(element Element) Walker {
+	switch element := element.(type) {
+	case *IdentifierExpression:
+		if _, ok := c.bindings[element.Identifier.Identifier]; !ok {
+			*c.identifiers = append(*c.identifiers, element.Identifier)
+		}
+
+	case *MemberExpression:
+		if !c.options.ExcludeMemberNames {
+			// The member name is collected after the identifiers of the accessed expression,
+			// i.e. in the order they occur in the source
+			Walk(c, element.Expression)
+			*c.identifiers = append(*c.identifiers, element.Identifier)
+			return nil
+		}
+
+	case *FunctionExpression:
+		if c.options.ExcludeParameters && element.ParameterList != nil {
+			// Walk the function body with a nested collector,
+			// which also excludes the parameters of this function
+			return c.withBindings(parameterIdentifiers(element.ParameterList)...)
+		}
+
+	case *FunctionDeclaration:
+		if c.options.ExcludeParameters && element.ParameterList != nil {
+			return c.withBindings(parameterIdentifiers(element.ParameterList)...)
+		}
+
+	case *Block:
+		if c.options.ExcludeLocalDeclarations {
+			c.walkStatements(element.Statements)
+			return nil
+		}
+
+	case *SwitchStatement:
+		if c.options.ExcludeLocalDeclarations {
+			c.walk(element.Expression)
+			for _, switchCase := range element.Cases {
+				c.walk(switchCase.Expression)
+				c.walkStatements(switchCase.Statements)
+			}
+			return nil
+		}
+
+	case *ForStatement:
+		if c.options.ExcludeLocalDeclarations {
+			c.walk(element.Value)
+
+			bindings := []Identifier{element.Identifier}
+			if element.Index != nil {
+				bindings = append(bindings, *element.Index)
+			}
+			c.withBindings(bindings...).walk(element.Block)
+			return nil
+		}
+
+	case *IfStatement:
+		declaration, ok := element.Test.(*VariableDeclaration)
+		if ok && c.options.ExcludeLocalDeclarations {
+			// The declaration of an if-let statement is only in scope of the then-branch
+			c.walk(declaration)
+			c.withBindings(declaration.Identifier).walk(element.Then)
+			if element.Else != nil {
+				c.walk(element.Else)
+			}
+			return nil
+		}
+	}
+
+	return c
+}
+
+// parameterIdentifiers returns the identifiers of the parameters in the given parameter list
+func parameterIdentifiers(parameterList *ParameterList) []Identifier {
+	identifiers := make([]Identifier, 0, len(parameterList.Parameters))
+	for _, parameter := range parameterList.Parameters {
+		identifiers = append(identifiers, parameter.Identifier)
+	}
+	return identifiers
+}
+
+// CollectIdentifiers returns the identifiers referenced in the given expression,
+// i.e. the identifiers of all identifier expressions and,
+// unless excluded, the names of all accessed members.
+//
+// The identifiers are returned in the order they occur in the source,
+// and an identifier is returned for each occurrence,
+// so the positions of all references are available.
+func CollectIdentifiers(expression Expression, options IdentifierCollectionOptions) []Identifier {
+	var identifiers []Identifier
+	if expression == nil {
+		return identifiers
+	}
+
+	collector := &identifierCollector{
+		options:     options,
+		identifiers: &identifiers,
+	}
+	Walk(collector, expression)
+	return identifiers
+}
+
+// FreeVariables returns the identifiers referenced in the body of the given function expression
+// which are not bound by its parameter list, by a local declaration in its body,
+// or by the parameter list of a nested function expression enclosing the reference.
+// For example, a parameter `x` of a nested function expression shadows `x` in the nested function's body,
+// but `x` is still free in the rest of the body of the given function expression.
+//
+// Like for SubstituteIdentifiers, parameters and local declarations are bindings,
+// e.g. a variable declared by a preceding statement of an enclosing block,
+// and the names of accessed members are not references.
+//
+// Each free variable is returned once, in the order of its first occurrence.
+func FreeVariables(fn *FunctionExpression) []Identifier {
+	if fn == nil {
+		return nil
+	}
+
+	identifiers := CollectIdentifiers(
+		fn,
+		IdentifierCollectionOptions{
+			ExcludeMemberNames:       true,
+			ExcludeParameters:        true,
+			ExcludeLocalDeclarations: true,
+		},
+	)
+
+	var freeVariables []Identifier
+	seen := make(map[string]struct{}, len(identifiers))
+
+	for _, identifier := range identifiers {
+		if _, ok := seen[identifier.Identifier]; ok {
+			continue
+		}
+		seen[identifier.Identifier] = struct{}{}
+		freeVariables = append(freeVariables, identifier)
+	}
+
+	return freeVariables
+}
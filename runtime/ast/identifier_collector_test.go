@@ -0,0 +1,327 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestFunctionExpression returns a function expression with the given parameters,
+// which returns the given expression
+func newTestFunctionExpression(parameterNames []string, result Expression) *FunctionExpression {
+	parameters := make([]*Parameter, len(parameterNames))
+	for i, parameterName := range parameterNames {
+		parameters[i] = &Parameter{
+			Identifier: Identifier{
+				Identifier: parameterName,
+			},
+			TypeAnnotation: &TypeAnnotation{
+				Type: &NominalType{
+					Identifier: Identifier{
+						Identifier: "Int",
+					},
+				},
+			},
+		}
+	}
+
+	return &FunctionExpression{
+		ParameterList: &ParameterList{
+			Parameters: parameters,
+		},
+		FunctionBlock: &FunctionBlock{
+			Block: &Block{
+				Statements: []Statement{
+					&ReturnStatement{
+						Expression: result,
+					},
+				},
+			},
+		},
+	}
+}
+
+func identifierNames(identifiers []Identifier) []string {
+	names := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		names[i] = identifier.Identifier
+	}
+	return names
+}
+
+func TestCollectIdentifiers(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("nil", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Empty(t, CollectIdentifiers(nil, IdentifierCollectionOptions{}))
+	})
+
+	t.Run("call chain", func(t *testing.T) {
+
+		t.Parallel()
+
+		// a.b(c).d(e, a)
+
+		expression := &InvocationExpression{
+			InvokedExpression: &MemberExpression{
+				Expression: &InvocationExpression{
+					InvokedExpression: &MemberExpression{
+						Expression: newTestIdentifierExpression("a"),
+						Identifier: Identifier{Identifier: "b"},
+					},
+					Arguments: Arguments{
+						{Expression: newTestIdentifierExpression("c")},
+					},
+				},
+				Identifier: Identifier{Identifier: "d"},
+			},
+			Arguments: Arguments{
+				{Expression: newTestIdentifierExpression("e")},
+				{Expression: newTestIdentifierExpression("a")},
+			},
+		}
+
+		assert.Equal(t,
+			[]string{"a", "b", "c", "d", "e", "a"},
+			identifierNames(CollectIdentifiers(expression, IdentifierCollectionOptions{})),
+		)
+
+		assert.Equal(t,
+			[]string{"a", "c", "e", "a"},
+			identifierNames(CollectIdentifiers(
+				expression,
+				IdentifierCollectionOptions{
+					ExcludeMemberNames: true,
+				},
+			)),
+		)
+	})
+
+	t.Run("function expression", func(t *testing.T) {
+
+		t.Parallel()
+
+		// fun (x: Int): Int { return x + fun (y: Int): Int { return x + y + z } }
+
+		expression := newTestFunctionExpression(
+			[]string{"x"},
+			&BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression("x"),
+				Right: newTestFunctionExpression(
+					[]string{"y"},
+					&BinaryExpression{
+						Operation: OperationPlus,
+						Left: &BinaryExpression{
+							Operation: OperationPlus,
+							Left:      newTestIdentifierExpression("x"),
+							Right:     newTestIdentifierExpression("y"),
+						},
+						Right: newTestIdentifierExpression("z"),
+					},
+				),
+			},
+		)
+
+		assert.Equal(t,
+			[]string{"x", "x", "y", "z"},
+			identifierNames(CollectIdentifiers(expression, IdentifierCollectionOptions{})),
+		)
+
+		assert.Equal(t,
+			[]string{"z"},
+			identifierNames(CollectIdentifiers(
+				expression,
+				IdentifierCollectionOptions{
+					ExcludeParameters: true,
+				},
+			)),
+		)
+	})
+}
+
+func TestFreeVariables(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("nil", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Empty(t, FreeVariables(nil))
+	})
+
+	t.Run("parameters", func(t *testing.T) {
+
+		t.Parallel()
+
+		// fun (x: Int): Int { return x + y.z + y }
+
+		fn := newTestFunctionExpression(
+			[]string{"x"},
+			&BinaryExpression{
+				Operation: OperationPlus,
+				Left: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      newTestIdentifierExpression("x"),
+					Right: &MemberExpression{
+						Expression: newTestIdentifierExpression("y"),
+						Identifier: Identifier{Identifier: "z"},
+					},
+				},
+				Right: newTestIdentifierExpression("y"),
+			},
+		)
+
+		assert.Equal(t,
+			[]string{"y"},
+			identifierNames(FreeVariables(fn)),
+		)
+	})
+
+	t.Run("nested, rebinding", func(t *testing.T) {
+
+		t.Parallel()
+
+		// fun (x: Int): Int {
+		//     return a + fun (a: Int, y: Int): Int {
+		//         return a + x + y + fun (x: Int): Int { return x + b }
+		//     }
+		// }
+
+		fn := newTestFunctionExpression(
+			[]string{"x"},
+			&BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression("a"),
+				Right: newTestFunctionExpression(
+					[]string{"a", "y"},
+					&BinaryExpression{
+						Operation: OperationPlus,
+						Left: &BinaryExpression{
+							Operation: OperationPlus,
+							Left: &BinaryExpression{
+								Operation: OperationPlus,
+								Left:      newTestIdentifierExpression("a"),
+								Right:     newTestIdentifierExpression("x"),
+							},
+							Right: newTestIdentifierExpression("y"),
+						},
+						Right: newTestFunctionExpression(
+							[]string{"x"},
+							&BinaryExpression{
+								Operation: OperationPlus,
+								Left:      newTestIdentifierExpression("x"),
+								Right:     newTestIdentifierExpression("b"),
+							},
+						),
+					},
+				),
+			},
+		)
+
+		assert.Equal(t,
+			[]string{"a", "b"},
+			identifierNames(FreeVariables(fn)),
+		)
+
+		// The nested function expressions capture the parameters of the enclosing functions
+
+		inner := fn.FunctionBlock.Block.Statements[0].(*ReturnStatement).
+			Expression.(*BinaryExpression).
+			Right.(*FunctionExpression)
+
+		assert.Equal(t,
+			[]string{"x", "b"},
+			identifierNames(FreeVariables(inner)),
+		)
+	})
+	t.Run("local declarations", func(t *testing.T) {
+
+		t.Parallel()
+
+		// fun (x: Int): Int {
+		//     y + a
+		//     let y = x + b
+		//     for i in y { c + i }
+		//     if let z = d { z } else { z }
+		//     return y + i
+		// }
+
+		newPlus := func(left, right string) Expression {
+			return &BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression(left),
+				Right:     newTestIdentifierExpression(right),
+			}
+		}
+
+		newExpressionBlock := func(expression Expression) *Block {
+			return &Block{
+				Statements: []Statement{
+					&ExpressionStatement{Expression: expression},
+				},
+			}
+		}
+
+		fn := newTestFunctionExpression([]string{"x"}, nil)
+		fn.FunctionBlock.Block.Statements = []Statement{
+			&ExpressionStatement{
+				Expression: newPlus("y", "a"),
+			},
+			&VariableDeclaration{
+				IsConstant: true,
+				Identifier: Identifier{Identifier: "y"},
+				Value:      newPlus("x", "b"),
+			},
+			&ForStatement{
+				Identifier: Identifier{Identifier: "i"},
+				Value:      newTestIdentifierExpression("y"),
+				Block:      newExpressionBlock(newPlus("c", "i")),
+			},
+			&IfStatement{
+				Test: &VariableDeclaration{
+					IsConstant: true,
+					Identifier: Identifier{Identifier: "z"},
+					Value:      newTestIdentifierExpression("d"),
+				},
+				Then: newExpressionBlock(newTestIdentifierExpression("z")),
+				Else: newExpressionBlock(newTestIdentifierExpression("z")),
+			},
+			&ReturnStatement{
+				Expression: newPlus("y", "i"),
+			},
+		}
+
+		// The variables are only bound in the scope of their declaration,
+		// e.g. `y` is free before its declaration,
+		// and `i` is free after the for-statement
+
+		assert.Equal(t,
+			[]string{"y", "a", "b", "c", "d", "z", "i"},
+			identifierNames(FreeVariables(fn)),
+		)
+	})
+}
@@ -0,0 +1,111 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+// SubstituteIdentifiers returns the given expression with each identifier expression
+// whose identifier is a key of the given substitutions replaced by a copy
+// of the corresponding replacement expression.
+//
+// Identifiers of member accesses are not substituted,
+// and neither are identifiers in the scope of a binding with the same name,
+// i.e. in the bodies of function expressions which declare a parameter with the same name,
+// or in the scope of a local declaration with the same name,
+// e.g. in the statements following a variable declaration in a block.
+func SubstituteIdentifiers(expression Expression, substitutions map[string]Expression) Expression {
+	if len(substitutions) == 0 {
+		return expression
+	}
+	return newIdentifierSubstitutionMapper(substitutions).mapExpression(expression)
+}
+
+func newIdentifierSubstitutionMapper(substitutions map[string]Expression) *expressionMapper {
+	return &expressionMapper{
+		mapFunc: func(expression Expression) Expression {
+			identifierExpression, ok := expression.(*IdentifierExpression)
+			if !ok {
+				return expression
+			}
+
+			replacement, ok := substitutions[identifierExpression.Identifier.Identifier]
+			if !ok {
+				return expression
+			}
+
+			// NOTE: copy the replacement, so the same node
+			// does not occur multiple times in the result
+			return replacement.Clone()
+		},
+		functionBodyMapper: func(functionExpression *FunctionExpression) *expressionMapper {
+			return substitutionMapperForFunctionBody(substitutions, functionExpression)
+		},
+		scopeMapper: func(declared []Identifier) *expressionMapper {
+			return substitutionMapperWithoutBindings(substitutions, func(identifier string) bool {
+				for _, declaredIdentifier := range declared {
+					if declaredIdentifier.Identifier == identifier {
+						return true
+					}
+				}
+				return false
+			})
+		},
+	}
+}
+
+// substitutionMapperForFunctionBody returns the mapper for the body of the given function expression,
+// which only substitutes the identifiers that are not shadowed by a parameter,
+// or nil if all identifiers are shadowed.
+func substitutionMapperForFunctionBody(
+	substitutions map[string]Expression,
+	functionExpression *FunctionExpression,
+) *expressionMapper {
+
+	if functionExpression.ParameterList == nil {
+		return newIdentifierSubstitutionMapper(substitutions)
+	}
+
+	parametersByIdentifier := functionExpression.ParameterList.ParametersByIdentifier()
+
+	return substitutionMapperWithoutBindings(substitutions, func(identifier string) bool {
+		_, ok := parametersByIdentifier[identifier]
+		return ok
+	})
+}
+
+// substitutionMapperWithoutBindings returns the mapper for the scope of bindings,
+// which only substitutes the identifiers that are not shadowed by a binding,
+// or nil if all identifiers are shadowed.
+func substitutionMapperWithoutBindings(
+	substitutions map[string]Expression,
+	isBound func(identifier string) bool,
+) *expressionMapper {
+
+	remainingSubstitutions := make(map[string]Expression, len(substitutions))
+	for identifier, replacement := range substitutions {
+		if isBound(identifier) {
+			continue
+		}
+		remainingSubstitutions[identifier] = replacement
+	}
+
+	if len(remainingSubstitutions) == 0 {
+		return nil
+	}
+
+	return newIdentifierSubstitutionMapper(remainingSubstitutions)
+}
@@ -0,0 +1,286 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubstituteIdentifiers(t *testing.T) {
+
+	t.Parallel()
+
+	newSubstitutions := func() map[string]Expression {
+		return map[string]Expression{
+			"x": &MemberExpression{
+				Expression: newTestIdentifierExpression("self"),
+				Identifier: Identifier{
+					Identifier: "x",
+				},
+			},
+			"y": newTestIdentifierExpression("z"),
+		}
+	}
+
+	newFunctionExpression := func(parameterNames []string, result Expression) *FunctionExpression {
+		parameters := make([]*Parameter, len(parameterNames))
+		for i, parameterName := range parameterNames {
+			parameters[i] = &Parameter{
+				Identifier: Identifier{
+					Identifier: parameterName,
+				},
+				TypeAnnotation: &TypeAnnotation{
+					Type: &NominalType{
+						Identifier: Identifier{
+							Identifier: "Int",
+						},
+					},
+				},
+			}
+		}
+
+		return &FunctionExpression{
+			ParameterList: &ParameterList{
+				Parameters: parameters,
+			},
+			FunctionBlock: &FunctionBlock{
+				Block: &Block{
+					Statements: []Statement{
+						&ReturnStatement{
+							Expression: result,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	returnedExpression := func(expression Expression) Expression {
+		return expression.(*FunctionExpression).
+			FunctionBlock.Block.Statements[0].(*ReturnStatement).
+			Expression
+	}
+
+	t.Run("array and dictionary literals", func(t *testing.T) {
+
+		t.Parallel()
+
+		original := &ArrayExpression{
+			Values: []Expression{
+				newTestIdentifierExpression("x"),
+				&DictionaryExpression{
+					Entries: []DictionaryEntry{
+						{
+							Key:   newTestIdentifierExpression("y"),
+							Value: newTestIdentifierExpression("w"),
+						},
+						{
+							Key:   newTestIdentifierExpression("w"),
+							Value: newTestIdentifierExpression("x"),
+						},
+					},
+				},
+			},
+		}
+
+		result := SubstituteIdentifiers(original, newSubstitutions())
+
+		assert.Equal(t, "[self.x, {z: w, w: self.x}]", result.String())
+		assert.Equal(t, "[x, {y: w, w: x}]", original.String())
+	})
+
+	t.Run("member access", func(t *testing.T) {
+
+		t.Parallel()
+
+		original := &MemberExpression{
+			Expression: newTestIdentifierExpression("y"),
+			Identifier: Identifier{
+				Identifier: "x",
+			},
+		}
+
+		result := SubstituteIdentifiers(original, newSubstitutions())
+
+		assert.Equal(t, "z.x", result.String())
+	})
+
+	t.Run("replacements are copied", func(t *testing.T) {
+
+		t.Parallel()
+
+		original := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      newTestIdentifierExpression("x"),
+			Right:     newTestIdentifierExpression("x"),
+		}
+
+		result := SubstituteIdentifiers(original, newSubstitutions()).(*BinaryExpression)
+
+		assert.Equal(t, "self.x + self.x", result.String())
+		assert.NotSame(t, result.Left, result.Right)
+	})
+
+	t.Run("function, not shadowed", func(t *testing.T) {
+
+		t.Parallel()
+
+		original := newFunctionExpression(
+			[]string{"a"},
+			&BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression("x"),
+				Right:     newTestIdentifierExpression("a"),
+			},
+		)
+
+		result := SubstituteIdentifiers(original, newSubstitutions())
+
+		assert.Equal(t, "self.x + a", returnedExpression(result).String())
+	})
+
+	t.Run("function, partially shadowed", func(t *testing.T) {
+
+		t.Parallel()
+
+		original := newFunctionExpression(
+			[]string{"x"},
+			&BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression("x"),
+				Right:     newTestIdentifierExpression("y"),
+			},
+		)
+
+		result := SubstituteIdentifiers(original, newSubstitutions())
+
+		assert.Equal(t, "x + z", returnedExpression(result).String())
+	})
+
+	t.Run("function, fully shadowed", func(t *testing.T) {
+
+		t.Parallel()
+
+		original := newFunctionExpression(
+			[]string{"x", "y"},
+			&BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression("x"),
+				Right:     newTestIdentifierExpression("y"),
+			},
+		)
+
+		result := SubstituteIdentifiers(original, newSubstitutions())
+
+		assert.Same(t, original, result)
+	})
+
+	t.Run("nested function, shadowed", func(t *testing.T) {
+
+		t.Parallel()
+
+		// fun (a) { return fun (x) { return x + y } }
+
+		original := newFunctionExpression(
+			[]string{"a"},
+			newFunctionExpression(
+				[]string{"x"},
+				&BinaryExpression{
+					Operation: OperationPlus,
+					Left:      newTestIdentifierExpression("x"),
+					Right:     newTestIdentifierExpression("y"),
+				},
+			),
+		)
+
+		result := SubstituteIdentifiers(original, newSubstitutions())
+
+		inner := returnedExpression(result)
+		require.IsType(t, &FunctionExpression{}, inner)
+		assert.Equal(t, "x + z", returnedExpression(inner).String())
+	})
+	t.Run("function, local declarations", func(t *testing.T) {
+
+		t.Parallel()
+
+		// fun (a) {
+		//     x
+		//     let x = y
+		//     for y in x { y }
+		//     return x + y
+		// }
+
+		original := newFunctionExpression([]string{"a"}, nil)
+		original.FunctionBlock.Block.Statements = []Statement{
+			&ExpressionStatement{
+				Expression: newTestIdentifierExpression("x"),
+			},
+			&VariableDeclaration{
+				IsConstant: true,
+				Identifier: Identifier{Identifier: "x"},
+				Value:      newTestIdentifierExpression("y"),
+			},
+			&ForStatement{
+				Identifier: Identifier{Identifier: "y"},
+				Value:      newTestIdentifierExpression("x"),
+				Block: &Block{
+					Statements: []Statement{
+						&ExpressionStatement{
+							Expression: newTestIdentifierExpression("y"),
+						},
+					},
+				},
+			},
+			&ReturnStatement{
+				Expression: &BinaryExpression{
+					Operation: OperationPlus,
+					Left:      newTestIdentifierExpression("x"),
+					Right:     newTestIdentifierExpression("y"),
+				},
+			},
+		}
+
+		result := SubstituteIdentifiers(original, newSubstitutions())
+
+		statements := result.(*FunctionExpression).FunctionBlock.Block.Statements
+		require.Len(t, statements, 4)
+
+		// `x` is only shadowed after its declaration,
+		// and `y` is only shadowed in the block of the for-statement
+
+		assert.Equal(t, "self.x", statements[0].(*ExpressionStatement).Expression.String())
+		assert.Equal(t, "z", statements[1].(*VariableDeclaration).Value.String())
+
+		forStatement := statements[2].(*ForStatement)
+		assert.Equal(t, "x", forStatement.Value.String())
+		assert.Equal(t, "y", forStatement.Block.Statements[0].(*ExpressionStatement).Expression.String())
+
+		assert.Equal(t, "x + z", statements[3].(*ReturnStatement).Expression.String())
+
+		// The original is not mutated
+
+		assert.Equal(t,
+			"x",
+			original.FunctionBlock.Block.Statements[0].(*ExpressionStatement).Expression.String(),
+		)
+	})
+}
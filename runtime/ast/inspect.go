@@ -31,3 +31,34 @@ func (f inspector) Walk(element Element) Walker {
 func Inspect(element Element, f func(Element) bool) {
 	Walk(inspector(f), element)
 }
+
+// InspectPrePost traverses an AST in depth-first order.
+//
+// The pre function is called for each element before its children are traversed.
+// If it returns false, the children of the element are skipped,
+// and post is not called for the element.
+//
+// The post function is called for each element after all its children have been traversed.
+//
+// Unlike for Inspect, neither function is called with nil.
+// Either function may be nil.
+func InspectPrePost(element Element, pre func(Element) bool, post func(Element)) {
+	// NOTE: Walk is not used, as it calls the walker with nil both for absent children,
+	// e.g. a missing operand, and to signal that all children of an element have been walked
+
+	if element == nil {
+		return
+	}
+
+	if pre != nil && !pre(element) {
+		return
+	}
+
+	element.Walk(func(child Element) {
+		InspectPrePost(child, pre, post)
+	})
+
+	if post != nil {
+		post(element)
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspectPrePost(t *testing.T) {
+
+	t.Parallel()
+
+	// f(a + b, -c)
+
+	newExpression := func() *InvocationExpression {
+		return &InvocationExpression{
+			InvokedExpression: newTestIdentifierExpression("f"),
+			Arguments: Arguments{
+				{
+					Expression: &BinaryExpression{
+						Operation: OperationPlus,
+						Left:      newTestIdentifierExpression("a"),
+						Right:     newTestIdentifierExpression("b"),
+					},
+				},
+				{
+					Expression: &UnaryExpression{
+						Operation:  OperationMinus,
+						Expression: newTestIdentifierExpression("c"),
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("order", func(t *testing.T) {
+
+		t.Parallel()
+
+		var events []string
+
+		InspectPrePost(
+			newExpression(),
+			func(element Element) bool {
+				events = append(events, "pre "+element.(Expression).String())
+				return true
+			},
+			func(element Element) {
+				events = append(events, "post "+element.(Expression).String())
+			},
+		)
+
+		assert.Equal(t,
+			[]string{
+				"pre f(a + b, -c)",
+				"pre f",
+				"post f",
+				"pre a + b",
+				"pre a",
+				"post a",
+				"pre b",
+				"post b",
+				"post a + b",
+				"pre -c",
+				"pre c",
+				"post c",
+				"post -c",
+				"post f(a + b, -c)",
+			},
+			events,
+		)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+
+		t.Parallel()
+
+		var pre, post []string
+
+		InspectPrePost(
+			newExpression(),
+			func(element Element) bool {
+				pre = append(pre, element.(Expression).String())
+				_, isBinary := element.(*BinaryExpression)
+				return !isBinary
+			},
+			func(element Element) {
+				post = append(post, element.(Expression).String())
+			},
+		)
+
+		assert.Equal(t,
+			[]string{"f(a + b, -c)", "f", "a + b", "-c", "c"},
+			pre,
+		)
+		assert.Equal(t,
+			[]string{"f", "c", "-c", "f(a + b, -c)"},
+			post,
+		)
+	})
+
+	t.Run("depth", func(t *testing.T) {
+
+		t.Parallel()
+
+		depth := 0
+		maxDepth := 0
+
+		InspectPrePost(
+			newExpression(),
+			func(_ Element) bool {
+				depth++
+				if depth > maxDepth {
+					maxDepth = depth
+				}
+				return true
+			},
+			func(_ Element) {
+				depth--
+			},
+		)
+
+		assert.Equal(t, 0, depth)
+		assert.Equal(t, 3, maxDepth)
+	})
+
+	t.Run("nil functions", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.NotPanics(t, func() {
+			InspectPrePost(newExpression(), nil, nil)
+		})
+	})
+	t.Run("nil operand", func(t *testing.T) {
+
+		t.Parallel()
+
+		// [a + _, b], with a missing right operand
+
+		expression := &ArrayExpression{
+			Values: []Expression{
+				&BinaryExpression{
+					Operation: OperationPlus,
+					Left:      newTestIdentifierExpression("a"),
+				},
+				newTestIdentifierExpression("b"),
+			},
+		}
+
+		var events []string
+
+		InspectPrePost(
+			expression,
+			func(element Element) bool {
+				events = append(events, "pre "+element.(Expression).String())
+				return true
+			},
+			func(element Element) {
+				events = append(events, "post "+element.(Expression).String())
+			},
+		)
+
+		assert.Equal(t,
+			[]string{
+				"pre [a + _, b]",
+				"pre a + _",
+				"pre a",
+				"post a",
+				"post a + _",
+				"pre b",
+				"post b",
+				"post [a + _, b]",
+			},
+			events,
+		)
+	})
+}
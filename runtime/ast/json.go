@@ -0,0 +1,932 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// MarshalCompact returns the JSON representation of the given element,
+// like json.Marshal, but omits all positions and ranges.
+//
+// The result is considerably smaller, and still contains the type discriminators,
+// so expressions can be decoded again using UnmarshalExpression.
+// The positions of the decoded expressions are zero.
+func MarshalCompact(element Element) ([]byte, error) {
+	value, err := decodeJSONValue(element)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeJSONValue(stripJSONPositions(value))
+}
+
+// stripJSONPositions removes all positions from the given decoded JSON value, recursively.
+// Positions are objects which have exactly the fields of Position
+func stripJSONPositions(value interface{}) interface{} {
+	switch value := value.(type) {
+	case jsonObject:
+		result := value[:0]
+		for _, field := range value {
+			if isJSONPosition(field.Value) {
+				continue
+			}
+			field.Value = stripJSONPositions(field.Value)
+			result = append(result, field)
+		}
+		return result
+
+	case []interface{}:
+		for i, element := range value {
+			value[i] = stripJSONPositions(element)
+		}
+	}
+
+	return value
+}
+
+func isJSONPosition(value interface{}) bool {
+	object, ok := value.(jsonObject)
+	if !ok || len(object) != 3 {
+		return false
+	}
+
+	for i, key := range []string{"Offset", "Line", "Column"} {
+		if object[i].Key != key {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isJSONNull(data []byte) bool {
+	return len(data) == 0 ||
+		bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+func unmarshalJSONType(data []byte) (string, error) {
+	var discriminator struct {
+		Type string
+	}
+	err := json.Unmarshal(data, &discriminator)
+	if err != nil {
+		return "", err
+	}
+	return discriminator.Type, nil
+}
+
+func unmarshalBigInt(s string) (*big.Int, error) {
+	value, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer: %q", s)
+	}
+	return value, nil
+}
+
+// UnmarshalExpression decodes an expression from its JSON representation,
+// as produced by json.Marshal or MarshalCompact.
+//
+// Function expressions are not supported, as statements cannot be decoded.
+func UnmarshalExpression(data []byte) (Expression, error) {
+	if isJSONNull(data) {
+		return nil, nil
+	}
+
+	ty, err := unmarshalJSONType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ty {
+	case "BoolExpression":
+		var v struct {
+			Value bool
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		return &BoolExpression{
+			Value: v.Value,
+			Range: v.Range,
+		}, nil
+
+	case "NilExpression":
+		var v struct {
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		return &NilExpression{
+			Pos: v.StartPos,
+		}, nil
+
+	case "VoidExpression":
+		var v struct {
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		return &VoidExpression{
+			Pos: v.StartPos,
+		}, nil
+
+	case "StringExpression":
+		var v struct {
+			Value string
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		return &StringExpression{
+			Value: v.Value,
+			Range: v.Range,
+		}, nil
+
+	case "StringTemplateExpression":
+		var v struct {
+			Values   []json.RawMessage
+			Segments []string
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		values, err := unmarshalExpressions(v.Values)
+		if err != nil {
+			return nil, err
+		}
+		return &StringTemplateExpression{
+			Values:   values,
+			Segments: v.Segments,
+			Range:    v.Range,
+		}, nil
+
+	case "IntegerExpression":
+		var v struct {
+			PositiveLiteral string
+			// The value is a string, or a number,
+			// see JSONOptions.NumericIntegerValues
+			Value json.Number
+			Base  int
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		value, err := unmarshalBigInt(v.Value.String())
+		if err != nil {
+			return nil, err
+		}
+		return &IntegerExpression{
+			PositiveLiteral: v.PositiveLiteral,
+			Value:           value,
+			Base:            v.Base,
+			Range:           v.Range,
+		}, nil
+
+	case "FixedPointExpression":
+		var v struct {
+			PositiveLiteral string
+			Negative        bool
+			UnsignedInteger string
+			Fractional      string
+			Scale           uint
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		unsignedInteger, err := unmarshalBigInt(v.UnsignedInteger)
+		if err != nil {
+			return nil, err
+		}
+		fractional, err := unmarshalBigInt(v.Fractional)
+		if err != nil {
+			return nil, err
+		}
+		return &FixedPointExpression{
+			PositiveLiteral: v.PositiveLiteral,
+			Negative:        v.Negative,
+			UnsignedInteger: unsignedInteger,
+			Fractional:      fractional,
+			Scale:           v.Scale,
+			Range:           v.Range,
+		}, nil
+
+	case "ArrayExpression":
+		var v struct {
+			Values []json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		values, err := unmarshalExpressions(v.Values)
+		if err != nil {
+			return nil, err
+		}
+		return &ArrayExpression{
+			Values: values,
+			Range:  v.Range,
+		}, nil
+
+	case "DictionaryExpression":
+		var v struct {
+			Entries []struct {
+				Key   json.RawMessage
+				Value json.RawMessage
+			}
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		var entries []DictionaryEntry
+		if v.Entries != nil {
+			entries = make([]DictionaryEntry, len(v.Entries))
+		}
+		for i, entry := range v.Entries {
+			entries[i].Key, err = UnmarshalExpression(entry.Key)
+			if err != nil {
+				return nil, err
+			}
+			entries[i].Value, err = UnmarshalExpression(entry.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &DictionaryExpression{
+			Entries: entries,
+			Range:   v.Range,
+		}, nil
+
+	case "IdentifierExpression":
+		var v struct {
+			Identifier Identifier
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		return &IdentifierExpression{
+			Identifier: v.Identifier,
+		}, nil
+
+	case "InvocationExpression":
+		return unmarshalInvocationExpression(data)
+
+	case "MemberExpression":
+		var v struct {
+			Expression json.RawMessage
+			Optional   bool
+			AccessPos  Position
+			Identifier Identifier
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		expression, err := UnmarshalExpression(v.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &MemberExpression{
+			Expression: expression,
+			Optional:   v.Optional,
+			AccessPos:  v.AccessPos,
+			Identifier: v.Identifier,
+		}, nil
+
+	case "IndexExpression":
+		var v struct {
+			TargetExpression   json.RawMessage
+			IndexingExpression json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		targetExpression, err := UnmarshalExpression(v.TargetExpression)
+		if err != nil {
+			return nil, err
+		}
+		indexingExpression, err := UnmarshalExpression(v.IndexingExpression)
+		if err != nil {
+			return nil, err
+		}
+		return &IndexExpression{
+			TargetExpression:   targetExpression,
+			IndexingExpression: indexingExpression,
+			Range:              v.Range,
+		}, nil
+
+	case "ConditionalExpression":
+		var v struct {
+			Test json.RawMessage
+			Then json.RawMessage
+			Else json.RawMessage
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		expressions, err := unmarshalExpressions([]json.RawMessage{v.Test, v.Then, v.Else})
+		if err != nil {
+			return nil, err
+		}
+		return &ConditionalExpression{
+			Test: expressions[0],
+			Then: expressions[1],
+			Else: expressions[2],
+		}, nil
+
+	case "UnaryExpression":
+		var v struct {
+			Operation  Operation
+			Expression json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		expression, err := UnmarshalExpression(v.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpression{
+			Operation:  v.Operation,
+			Expression: expression,
+			StartPos:   v.StartPos,
+		}, nil
+
+	case "BinaryExpression":
+		var v struct {
+			Operation Operation
+			Left      json.RawMessage
+			Right     json.RawMessage
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		left, err := UnmarshalExpression(v.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := UnmarshalExpression(v.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryExpression{
+			Operation: v.Operation,
+			Left:      left,
+			Right:     right,
+		}, nil
+
+	case "CastingExpression":
+		var v struct {
+			Expression     json.RawMessage
+			Operation      Operation
+			TypeAnnotation json.RawMessage
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		expression, err := UnmarshalExpression(v.Expression)
+		if err != nil {
+			return nil, err
+		}
+		typeAnnotation, err := unmarshalTypeAnnotation(v.TypeAnnotation)
+		if err != nil {
+			return nil, err
+		}
+		return &CastingExpression{
+			Expression:     expression,
+			Operation:      v.Operation,
+			TypeAnnotation: typeAnnotation,
+		}, nil
+
+	case "CreateExpression":
+		var v struct {
+			InvocationExpression json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		invocationExpression, err := unmarshalInvocationExpression(v.InvocationExpression)
+		if err != nil {
+			return nil, err
+		}
+		return &CreateExpression{
+			InvocationExpression: invocationExpression,
+			StartPos:             v.StartPos,
+		}, nil
+
+	case "DestroyExpression":
+		var v struct {
+			Expression json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		expression, err := UnmarshalExpression(v.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &DestroyExpression{
+			Expression: expression,
+			StartPos:   v.StartPos,
+		}, nil
+
+	case "AttachmentExpression":
+		var v struct {
+			Base       json.RawMessage
+			Attachment json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		base, err := UnmarshalExpression(v.Base)
+		if err != nil {
+			return nil, err
+		}
+		attachment, err := unmarshalInvocationExpression(v.Attachment)
+		if err != nil {
+			return nil, err
+		}
+		return &AttachmentExpression{
+			Base:       base,
+			Attachment: attachment,
+			StartPos:   v.StartPos,
+		}, nil
+
+	case "ReferenceExpression":
+		var v struct {
+			Expression json.RawMessage
+			TargetType json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		expression, err := UnmarshalExpression(v.Expression)
+		if err != nil {
+			return nil, err
+		}
+		targetType, err := UnmarshalType(v.TargetType)
+		if err != nil {
+			return nil, err
+		}
+		return &ReferenceExpression{
+			Expression: expression,
+			Type:       targetType,
+			StartPos:   v.StartPos,
+		}, nil
+
+	case "ForceExpression":
+		var v struct {
+			Expression json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		expression, err := UnmarshalExpression(v.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &ForceExpression{
+			Expression: expression,
+			EndPos:     v.EndPos,
+		}, nil
+
+	case "PathExpression":
+		var v struct {
+			Domain     Identifier
+			Identifier Identifier
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		return &PathExpression{
+			StartPos:   v.StartPos,
+			Domain:     v.Domain,
+			Identifier: v.Identifier,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("cannot unmarshal expression: unsupported type %q", ty)
+	}
+}
+
+func unmarshalExpressions(data []json.RawMessage) ([]Expression, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	expressions := make([]Expression, len(data))
+	for i, elementData := range data {
+		expression, err := UnmarshalExpression(elementData)
+		if err != nil {
+			return nil, err
+		}
+		expressions[i] = expression
+	}
+	return expressions, nil
+}
+
+func unmarshalInvocationExpression(data []byte) (*InvocationExpression, error) {
+	if isJSONNull(data) {
+		return nil, nil
+	}
+
+	var v struct {
+		Type              string
+		InvokedExpression json.RawMessage
+		TypeArguments     []json.RawMessage
+		Arguments         []struct {
+			Label                string
+			LabelStartPos        *Position
+			LabelEndPos          *Position
+			TrailingSeparatorPos Position
+			Expression           json.RawMessage
+		}
+		ArgumentsStartPos Position
+		Range
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Type != "InvocationExpression" {
+		return nil, fmt.Errorf("cannot unmarshal invocation expression: unexpected type %q", v.Type)
+	}
+
+	invokedExpression, err := UnmarshalExpression(v.InvokedExpression)
+	if err != nil {
+		return nil, err
+	}
+
+	var typeArguments []*TypeAnnotation
+	if v.TypeArguments != nil {
+		typeArguments = make([]*TypeAnnotation, len(v.TypeArguments))
+	}
+	for i, typeArgumentData := range v.TypeArguments {
+		typeArguments[i], err = unmarshalTypeAnnotation(typeArgumentData)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var arguments Arguments
+	if v.Arguments != nil {
+		arguments = make(Arguments, len(v.Arguments))
+	}
+	for i, argument := range v.Arguments {
+		expression, err := UnmarshalExpression(argument.Expression)
+		if err != nil {
+			return nil, err
+		}
+		arguments[i] = &Argument{
+			Label:                argument.Label,
+			LabelStartPos:        argument.LabelStartPos,
+			LabelEndPos:          argument.LabelEndPos,
+			TrailingSeparatorPos: argument.TrailingSeparatorPos,
+			Expression:           expression,
+		}
+	}
+
+	return &InvocationExpression{
+		InvokedExpression: invokedExpression,
+		TypeArguments:     typeArguments,
+		Arguments:         arguments,
+		ArgumentsStartPos: v.ArgumentsStartPos,
+		EndPos:            v.EndPos,
+	}, nil
+}
+
+func unmarshalTypeAnnotation(data []byte) (*TypeAnnotation, error) {
+	if isJSONNull(data) {
+		return nil, nil
+	}
+
+	var v struct {
+		IsResource    bool
+		AnnotatedType json.RawMessage
+		Range
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	ty, err := UnmarshalType(v.AnnotatedType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypeAnnotation{
+		IsResource: v.IsResource,
+		Type:       ty,
+		StartPos:   v.StartPos,
+	}, nil
+}
+
+// UnmarshalType decodes a type from its JSON representation,
+// as produced by json.Marshal or MarshalCompact.
+//
+// Nested types, type annotations, and the sizes of constant sized types are decoded recursively.
+func UnmarshalType(data []byte) (Type, error) {
+	if isJSONNull(data) {
+		return nil, nil
+	}
+
+	ty, err := unmarshalJSONType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ty {
+	case "NominalType":
+		return unmarshalNominalType(data)
+
+	case "OptionalType":
+		var v struct {
+			ElementType json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		elementType, err := UnmarshalType(v.ElementType)
+		if err != nil {
+			return nil, err
+		}
+		return &OptionalType{
+			Type:   elementType,
+			EndPos: v.EndPos,
+		}, nil
+
+	case "VariableSizedType":
+		var v struct {
+			ElementType json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		elementType, err := UnmarshalType(v.ElementType)
+		if err != nil {
+			return nil, err
+		}
+		return &VariableSizedType{
+			Type:  elementType,
+			Range: v.Range,
+		}, nil
+
+	case "ConstantSizedType":
+		var v struct {
+			ElementType json.RawMessage
+			Size        json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		elementType, err := UnmarshalType(v.ElementType)
+		if err != nil {
+			return nil, err
+		}
+		size, err := UnmarshalExpression(v.Size)
+		if err != nil {
+			return nil, err
+		}
+		integerSize, ok := size.(*IntegerExpression)
+		if size != nil && !ok {
+			return nil, fmt.Errorf("cannot unmarshal constant sized type: invalid size %s", size)
+		}
+		return &ConstantSizedType{
+			Type:  elementType,
+			Size:  integerSize,
+			Range: v.Range,
+		}, nil
+
+	case "DictionaryType":
+		var v struct {
+			KeyType   json.RawMessage
+			ValueType json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		keyType, err := UnmarshalType(v.KeyType)
+		if err != nil {
+			return nil, err
+		}
+		valueType, err := UnmarshalType(v.ValueType)
+		if err != nil {
+			return nil, err
+		}
+		return &DictionaryType{
+			KeyType:   keyType,
+			ValueType: valueType,
+			Range:     v.Range,
+		}, nil
+
+	case "FunctionType":
+		var v struct {
+			ParameterTypeAnnotations []json.RawMessage
+			ReturnTypeAnnotation     json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		parameterTypeAnnotations, err := unmarshalTypeAnnotations(v.ParameterTypeAnnotations)
+		if err != nil {
+			return nil, err
+		}
+		returnTypeAnnotation, err := unmarshalTypeAnnotation(v.ReturnTypeAnnotation)
+		if err != nil {
+			return nil, err
+		}
+		return &FunctionType{
+			ParameterTypeAnnotations: parameterTypeAnnotations,
+			ReturnTypeAnnotation:     returnTypeAnnotation,
+			Range:                    v.Range,
+		}, nil
+
+	case "ReferenceType":
+		var v struct {
+			Authorized     bool
+			ReferencedType json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		referencedType, err := UnmarshalType(v.ReferencedType)
+		if err != nil {
+			return nil, err
+		}
+		return &ReferenceType{
+			Authorized: v.Authorized,
+			Type:       referencedType,
+			StartPos:   v.StartPos,
+		}, nil
+
+	case "RestrictedType":
+		var v struct {
+			RestrictedType json.RawMessage
+			Restrictions   []json.RawMessage
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		restrictedType, err := UnmarshalType(v.RestrictedType)
+		if err != nil {
+			return nil, err
+		}
+		var restrictions []*NominalType
+		if v.Restrictions != nil {
+			restrictions = make([]*NominalType, len(v.Restrictions))
+		}
+		for i, restrictionData := range v.Restrictions {
+			restrictions[i], err = unmarshalNominalType(restrictionData)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &RestrictedType{
+			Type:         restrictedType,
+			Restrictions: restrictions,
+			Range:        v.Range,
+		}, nil
+
+	case "InstantiationType":
+		var v struct {
+			InstantiatedType      json.RawMessage
+			TypeArguments         []json.RawMessage
+			TypeArgumentsStartPos Position
+			Range
+		}
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+		instantiatedType, err := UnmarshalType(v.InstantiatedType)
+		if err != nil {
+			return nil, err
+		}
+		typeArguments, err := unmarshalTypeAnnotations(v.TypeArguments)
+		if err != nil {
+			return nil, err
+		}
+		return &InstantiationType{
+			Type:                  instantiatedType,
+			TypeArguments:         typeArguments,
+			TypeArgumentsStartPos: v.TypeArgumentsStartPos,
+			EndPos:                v.EndPos,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("cannot unmarshal type: unsupported type %q", ty)
+	}
+}
+
+func unmarshalTypeAnnotations(data []json.RawMessage) ([]*TypeAnnotation, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	typeAnnotations := make([]*TypeAnnotation, len(data))
+	for i, elementData := range data {
+		typeAnnotation, err := unmarshalTypeAnnotation(elementData)
+		if err != nil {
+			return nil, err
+		}
+		typeAnnotations[i] = typeAnnotation
+	}
+	return typeAnnotations, nil
+}
+
+func unmarshalNominalType(data []byte) (*NominalType, error) {
+	if isJSONNull(data) {
+		return nil, nil
+	}
+
+	var v struct {
+		Type              string
+		Identifier        Identifier
+		NestedIdentifiers []Identifier
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Type != "NominalType" {
+		return nil, fmt.Errorf("cannot unmarshal nominal type: unexpected type %q", v.Type)
+	}
+
+	return &NominalType{
+		Identifier:        v.Identifier,
+		NestedIdentifiers: v.NestedIdentifiers,
+	}, nil
+}
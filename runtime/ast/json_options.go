@@ -0,0 +1,152 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JSONOptions are the options for MarshalJSONWithOptions
+type JSONOptions struct {
+	// NumericIntegerValues specifies that the values of integer expressions
+	// are encoded as JSON numbers instead of strings, if they are safe integers,
+	// i.e. if their magnitude is at most 2^53-1, so they can be represented
+	// by a double-precision floating-point number without loss of precision,
+	// and can be consumed by e.g. JavaScript clients as-is.
+	//
+	// The values of other integer expressions are still encoded as strings,
+	// and the encoding of the integer expression has an additional field `ValueIsString`.
+	NumericIntegerValues bool
+}
+
+// maxSafeJSONInteger is the maximum magnitude of an integer
+// which can be encoded as a JSON number without loss of precision, i.e. 2^53-1
+var maxSafeJSONInteger = new(big.Int).Sub(
+	new(big.Int).Lsh(big.NewInt(1), 53),
+	big.NewInt(1),
+)
+
+// isSafeJSONInteger returns true if the given integer
+// can be encoded as a JSON number without loss of precision
+func isSafeJSONInteger(value *big.Int) bool {
+	return new(big.Int).Abs(value).Cmp(maxSafeJSONInteger) <= 0
+}
+
+// MarshalJSONWithOptions returns the JSON encoding of the given value,
+// e.g. an element or a type, like json.Marshal, but with the given options.
+//
+// The layout of the encoding is otherwise the same, e.g. fields stay in the same order.
+func MarshalJSONWithOptions(value json.Marshaler, options JSONOptions) ([]byte, error) {
+	if options == (JSONOptions{}) {
+		return json.Marshal(value)
+	}
+
+	decoded, err := decodeJSONValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	rewritten, err := applyJSONOptions(decoded, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeJSONValue(rewritten)
+}
+
+// applyJSONOptions applies the given options to the encodings of the elements
+// in the given decoded JSON value, recursively, see decodeJSONValue
+func applyJSONOptions(value interface{}, options JSONOptions) (interface{}, error) {
+	switch value := value.(type) {
+	case jsonObject:
+		// The type discriminator is the first field of the encodings of elements,
+		// so it is known before the other fields are rewritten
+		var discriminator string
+
+		result := make(jsonObject, 0, len(value))
+
+		for _, field := range value {
+			if field.Key == "Value" &&
+				discriminator == "IntegerExpression" &&
+				options.NumericIntegerValues {
+
+				fields, err := numericIntegerValueFields(field.Value)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, fields...)
+				continue
+			}
+
+			if field.Key == "Type" {
+				discriminator, _ = field.Value.(string)
+			}
+
+			fieldValue, err := applyJSONOptions(field.Value, options)
+			if err != nil {
+				return nil, err
+			}
+			field.Value = fieldValue
+			result = append(result, field)
+		}
+
+		return result, nil
+
+	case []interface{}:
+		for i, element := range value {
+			rewritten, err := applyJSONOptions(element, options)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = rewritten
+		}
+		return value, nil
+
+	default:
+		return value, nil
+	}
+}
+
+// numericIntegerValueFields returns the fields for the given decoded value of an integer expression,
+// the decimal string of the value: the value as a JSON number, if the value is a safe integer,
+// and otherwise the decimal string and the additional field `ValueIsString`
+func numericIntegerValueFields(value interface{}) ([]jsonField, error) {
+	literal, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal JSON: invalid integer value %v", value)
+	}
+
+	integer, ok := new(big.Int).SetString(literal, 10)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal JSON: invalid integer value %q", literal)
+	}
+
+	if isSafeJSONInteger(integer) {
+		return []jsonField{
+			{Key: "Value", Value: json.Number(integer.String())},
+		}, nil
+	}
+
+	return []jsonField{
+		{Key: "Value", Value: literal},
+		{Key: "ValueIsString", Value: true},
+	}, nil
+}
@@ -0,0 +1,211 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalJSONWithOptions(t *testing.T) {
+
+	t.Parallel()
+
+	newIntegerExpression := func(value *big.Int) *IntegerExpression {
+		return &IntegerExpression{
+			PositiveLiteral: new(big.Int).Abs(value).String(),
+			Value:           value,
+			Base:            10,
+			Range: Range{
+				StartPos: Position{Offset: 1, Line: 2, Column: 3},
+				EndPos:   Position{Offset: 4, Line: 5, Column: 6},
+			},
+		}
+	}
+
+	// 2^53
+	maxSafeIntegerPlusOne := new(big.Int).Lsh(big.NewInt(1), 53)
+	// 2^53-1
+	maxSafeInteger := new(big.Int).Sub(maxSafeIntegerPlusOne, big.NewInt(1))
+
+	options := JSONOptions{
+		NumericIntegerValues: true,
+	}
+
+	t.Run("safe integers", func(t *testing.T) {
+
+		t.Parallel()
+
+		for _, value := range []*big.Int{
+			big.NewInt(0),
+			big.NewInt(42),
+			maxSafeInteger,
+			new(big.Int).Neg(maxSafeInteger),
+		} {
+			actual, err := MarshalJSONWithOptions(newIntegerExpression(value), options)
+			require.NoError(t, err)
+
+			assert.Equal(t,
+				`{"Type":"IntegerExpression","Value":`+value.String()+`,`+
+					`"PositiveLiteral":"`+new(big.Int).Abs(value).String()+`","Base":10,`+
+					`"StartPos":{"Offset":1,"Line":2,"Column":3},`+
+					`"EndPos":{"Offset":4,"Line":5,"Column":6}}`,
+				string(actual),
+			)
+		}
+	})
+
+	t.Run("unsafe integers", func(t *testing.T) {
+
+		t.Parallel()
+
+		for _, value := range []*big.Int{
+			maxSafeIntegerPlusOne,
+			new(big.Int).Neg(maxSafeIntegerPlusOne),
+			new(big.Int).Lsh(big.NewInt(1), 128),
+		} {
+			actual, err := MarshalJSONWithOptions(newIntegerExpression(value), options)
+			require.NoError(t, err)
+
+			assert.Equal(t,
+				`{"Type":"IntegerExpression","Value":"`+value.String()+`","ValueIsString":true,`+
+					`"PositiveLiteral":"`+new(big.Int).Abs(value).String()+`","Base":10,`+
+					`"StartPos":{"Offset":1,"Line":2,"Column":3},`+
+					`"EndPos":{"Offset":4,"Line":5,"Column":6}}`,
+				string(actual),
+			)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := &ArrayExpression{
+			Values: []Expression{
+				newIntegerExpression(big.NewInt(1)),
+				&StringExpression{Value: "2"},
+				newIntegerExpression(maxSafeIntegerPlusOne),
+			},
+		}
+
+		actual, err := MarshalJSONWithOptions(expression, options)
+		require.NoError(t, err)
+
+		assert.JSONEq(t,
+			`
+            {
+                "Type": "ArrayExpression",
+                "Values": [
+                    {
+                        "Type": "IntegerExpression",
+                        "Value": 1,
+                        "PositiveLiteral": "1",
+                        "Base": 10,
+                        "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+                        "EndPos": {"Offset": 4, "Line": 5, "Column": 6}
+                    },
+                    {
+                        "Type": "StringExpression",
+                        "Value": "2",
+                        "StartPos": {"Offset": 0, "Line": 0, "Column": 0},
+                        "EndPos": {"Offset": 0, "Line": 0, "Column": 0}
+                    },
+                    {
+                        "Type": "IntegerExpression",
+                        "Value": "9007199254740992",
+                        "ValueIsString": true,
+                        "PositiveLiteral": "9007199254740992",
+                        "Base": 10,
+                        "StartPos": {"Offset": 1, "Line": 2, "Column": 3},
+                        "EndPos": {"Offset": 4, "Line": 5, "Column": 6}
+                    }
+                ],
+                "StartPos": {"Offset": 0, "Line": 0, "Column": 0},
+                "EndPos": {"Offset": 0, "Line": 0, "Column": 0}
+            }
+            `,
+			string(actual),
+		)
+	})
+
+	t.Run("type", func(t *testing.T) {
+
+		t.Parallel()
+
+		ty := &ConstantSizedType{
+			Type: &NominalType{
+				Identifier: Identifier{Identifier: "T"},
+			},
+			Size: newIntegerExpression(big.NewInt(3)),
+		}
+
+		actual, err := MarshalJSONWithOptions(ty, options)
+		require.NoError(t, err)
+
+		var decoded struct {
+			Size struct {
+				Value json.Number
+			}
+		}
+		err = json.Unmarshal(actual, &decoded)
+		require.NoError(t, err)
+
+		assert.Equal(t, json.Number("3"), decoded.Size.Value)
+	})
+
+	t.Run("round-trip", func(t *testing.T) {
+
+		t.Parallel()
+
+		for _, value := range []*big.Int{
+			big.NewInt(-42),
+			maxSafeIntegerPlusOne,
+		} {
+			expression := newIntegerExpression(value)
+
+			data, err := MarshalJSONWithOptions(expression, options)
+			require.NoError(t, err)
+
+			decoded, err := UnmarshalExpression(data)
+			require.NoError(t, err)
+
+			assert.Equal(t, expression, decoded)
+		}
+	})
+
+	t.Run("default options", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := newIntegerExpression(big.NewInt(42))
+
+		expected, err := json.Marshal(expression)
+		require.NoError(t, err)
+
+		actual, err := MarshalJSONWithOptions(expression, JSONOptions{})
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, actual)
+	})
+}
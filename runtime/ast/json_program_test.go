@@ -0,0 +1,84 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/parser2"
+)
+
+func TestMarshalCompact_Program(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      pub contract Test {
+
+          pub resource R {
+              pub let id: UInt64
+              pub var balances: {String: [UFix64]}
+
+              init(id: UInt64) {
+                  self.id = id
+                  self.balances = {"a": [1.0, 2.5], "b": []}
+              }
+
+              pub fun total(key: String): UFix64 {
+                  var total = 0.0
+                  for balance in self.balances[key] ?? [] {
+                      total = total + balance
+                  }
+                  return total
+              }
+          }
+
+          pub fun createR(id: UInt64): @R {
+              return <-create R(id: id)
+          }
+
+          pub fun check(_ values: [Int]): Bool {
+              let ref = &values as &[Int]
+              return values.length > 0 && ref[0] != 1 || !(values.length == 2)
+          }
+      }
+    `
+
+	program, err := parser2.ParseProgram(code)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(program)
+	require.NoError(t, err)
+
+	compactData, err := ast.MarshalCompact(program)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(compactData), "StartPos")
+	assert.NotContains(t, string(compactData), "EndPos")
+
+	t.Logf("JSON: %d bytes, compact JSON: %d bytes", len(data), len(compactData))
+
+	// Positions make up the majority of the JSON representation
+	assert.Less(t, len(compactData), len(data)/2)
+}
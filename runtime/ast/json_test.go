@@ -0,0 +1,401 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJSONExpression() Expression {
+
+	position := func(offset int) Position {
+		return Position{Offset: offset, Line: 1, Column: offset}
+	}
+
+	return &ConditionalExpression{
+		Test: &BinaryExpression{
+			Operation: OperationLess,
+			Left: &UnaryExpression{
+				Operation: OperationMinus,
+				Expression: &IntegerExpression{
+					PositiveLiteral: "0x2A",
+					Value:           big.NewInt(42),
+					Base:            16,
+					Range:           Range{StartPos: position(1), EndPos: position(4)},
+				},
+				StartPos: position(0),
+			},
+			Right: &FixedPointExpression{
+				PositiveLiteral: "1.5",
+				UnsignedInteger: big.NewInt(1),
+				Fractional:      big.NewInt(5),
+				Scale:           1,
+				Range:           Range{StartPos: position(8), EndPos: position(10)},
+			},
+		},
+		Then: &InvocationExpression{
+			InvokedExpression: &MemberExpression{
+				Expression: &IdentifierExpression{
+					Identifier: Identifier{Identifier: "a", Pos: position(14)},
+				},
+				Optional:   true,
+				AccessPos:  position(15),
+				Identifier: Identifier{Identifier: "b", Pos: position(17)},
+			},
+			TypeArguments: []*TypeAnnotation{
+				{
+					IsResource: true,
+					Type: &OptionalType{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "R", Pos: position(20)},
+						},
+						EndPos: position(21),
+					},
+					StartPos: position(19),
+				},
+			},
+			Arguments: Arguments{
+				{
+					Label:         "x",
+					LabelStartPos: &Position{Offset: 24, Line: 1, Column: 24},
+					LabelEndPos:   &Position{Offset: 24, Line: 1, Column: 24},
+					Expression: &ArrayExpression{
+						Values: []Expression{
+							&NilExpression{Pos: position(28)},
+							&StringTemplateExpression{
+								Segments: []string{"n = ", ""},
+								Values: []Expression{
+									&PathExpression{
+										StartPos:   position(40),
+										Domain:     Identifier{Identifier: "storage", Pos: position(41)},
+										Identifier: Identifier{Identifier: "foo", Pos: position(49)},
+									},
+								},
+								Range: Range{StartPos: position(32), EndPos: position(53)},
+							},
+						},
+						Range: Range{StartPos: position(27), EndPos: position(54)},
+					},
+				},
+			},
+			ArgumentsStartPos: position(23),
+			EndPos:            position(55),
+		},
+		Else: &CastingExpression{
+			Operation: OperationFailableCast,
+			Expression: &ReferenceExpression{
+				Expression: &ForceExpression{
+					Expression: &DictionaryExpression{
+						Entries: []DictionaryEntry{
+							{
+								Key:   &StringExpression{Value: "k", Range: Range{StartPos: position(61), EndPos: position(63)}},
+								Value: &BoolExpression{Value: true, Range: Range{StartPos: position(66), EndPos: position(69)}},
+							},
+						},
+						Range: Range{StartPos: position(60), EndPos: position(70)},
+					},
+					EndPos: position(71),
+				},
+				Type: &ReferenceType{
+					Authorized: true,
+					Type: &RestrictedType{
+						Restrictions: []*NominalType{
+							{
+								Identifier: Identifier{Identifier: "I", Pos: position(85)},
+							},
+						},
+						Range: Range{StartPos: position(84), EndPos: position(86)},
+					},
+					StartPos: position(76),
+				},
+				StartPos: position(58),
+			},
+			TypeAnnotation: &TypeAnnotation{
+				Type: &ConstantSizedType{
+					Type: &DictionaryType{
+						KeyType: &NominalType{
+							Identifier: Identifier{Identifier: "String", Pos: position(94)},
+						},
+						ValueType: &VariableSizedType{
+							Type: &FunctionType{
+								ParameterTypeAnnotations: []*TypeAnnotation{
+									{
+										Type: &NominalType{
+											Identifier: Identifier{Identifier: "Int", Pos: position(104)},
+										},
+										StartPos: position(104),
+									},
+								},
+								ReturnTypeAnnotation: &TypeAnnotation{
+									Type: &InstantiationType{
+										Type: &NominalType{
+											Identifier: Identifier{Identifier: "Foo", Pos: position(110)},
+										},
+										TypeArguments:         []*TypeAnnotation{},
+										TypeArgumentsStartPos: position(113),
+										EndPos:                position(114),
+									},
+									StartPos: position(110),
+								},
+								Range: Range{StartPos: position(102), EndPos: position(115)},
+							},
+							Range: Range{StartPos: position(101), EndPos: position(116)},
+						},
+						Range: Range{StartPos: position(93), EndPos: position(117)},
+					},
+					Size: &IntegerExpression{
+						PositiveLiteral: "2",
+						Value:           big.NewInt(2),
+						Base:            10,
+						Range:           Range{StartPos: position(120), EndPos: position(120)},
+					},
+					Range: Range{StartPos: position(92), EndPos: position(121)},
+				},
+				StartPos: position(92),
+			},
+		},
+	}
+}
+
+func TestUnmarshalExpression(t *testing.T) {
+
+	t.Parallel()
+
+	expression := newTestJSONExpression()
+
+	data, err := json.Marshal(expression)
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalExpression(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, expression, decoded)
+}
+
+func TestUnmarshalExpression_Unsupported(t *testing.T) {
+
+	t.Parallel()
+
+	data, err := json.Marshal(&FunctionExpression{
+		ParameterList: &ParameterList{},
+		FunctionBlock: &FunctionBlock{
+			Block: &Block{},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = UnmarshalExpression(data)
+	require.EqualError(t, err, `cannot unmarshal expression: unsupported type "FunctionExpression"`)
+}
+
+func TestTypeAnnotation_UnmarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	position := func(offset int) Position {
+		return Position{Offset: offset, Line: 1, Column: offset}
+	}
+
+	// {String: [Foo<Int>]?}
+
+	typeAnnotation := &TypeAnnotation{
+		Type: &DictionaryType{
+			KeyType: &NominalType{
+				Identifier: Identifier{Identifier: "String", Pos: position(1)},
+			},
+			ValueType: &OptionalType{
+				Type: &VariableSizedType{
+					Type: &InstantiationType{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "Foo", Pos: position(10)},
+						},
+						TypeArguments: []*TypeAnnotation{
+							{
+								Type: &NominalType{
+									Identifier: Identifier{Identifier: "Int", Pos: position(14)},
+								},
+								StartPos: position(14),
+							},
+						},
+						TypeArgumentsStartPos: position(13),
+						EndPos:                position(17),
+					},
+					Range: Range{StartPos: position(9), EndPos: position(18)},
+				},
+				EndPos: position(19),
+			},
+			Range: Range{StartPos: position(0), EndPos: position(20)},
+		},
+		StartPos: position(0),
+	}
+
+	data, err := json.Marshal(typeAnnotation)
+	require.NoError(t, err)
+
+	t.Run("type annotation", func(t *testing.T) {
+
+		t.Parallel()
+
+		var decoded TypeAnnotation
+		err := json.Unmarshal(data, &decoded)
+		require.NoError(t, err)
+
+		assert.Equal(t, typeAnnotation, &decoded)
+		assert.Equal(t, "{String: [Foo<Int>]?}", decoded.String())
+	})
+
+	t.Run("nested in struct", func(t *testing.T) {
+
+		t.Parallel()
+
+		var decoded struct {
+			TypeAnnotations []*TypeAnnotation
+		}
+		err := json.Unmarshal(
+			[]byte(`{"TypeAnnotations": [`+string(data)+`, null]}`),
+			&decoded,
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			[]*TypeAnnotation{typeAnnotation, nil},
+			decoded.TypeAnnotations,
+		)
+	})
+
+	t.Run("type", func(t *testing.T) {
+
+		t.Parallel()
+
+		typeData, err := json.Marshal(typeAnnotation.Type)
+		require.NoError(t, err)
+
+		decoded, err := UnmarshalType(typeData)
+		require.NoError(t, err)
+
+		assert.Equal(t, typeAnnotation.Type, decoded)
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+
+		t.Parallel()
+
+		var decoded TypeAnnotation
+		err := json.Unmarshal(
+			[]byte(`{"IsResource": false, "AnnotatedType": {"Type": "UnknownType"}}`),
+			&decoded,
+		)
+		require.EqualError(t, err, `cannot unmarshal type: unsupported type "UnknownType"`)
+	})
+}
+
+func TestMarshalCompact(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("positions", func(t *testing.T) {
+
+		t.Parallel()
+
+		data, err := MarshalCompact(&MemberExpression{
+			Expression: &IdentifierExpression{
+				Identifier: Identifier{
+					Identifier: "a",
+					Pos:        Position{Offset: 1, Line: 2, Column: 3},
+				},
+			},
+			AccessPos: Position{Offset: 4, Line: 5, Column: 6},
+			Identifier: Identifier{
+				Identifier: "b",
+				Pos:        Position{Offset: 7, Line: 8, Column: 9},
+			},
+		})
+		require.NoError(t, err)
+
+		assert.JSONEq(t,
+			// language=json
+			`
+            {
+                "Type": "MemberExpression",
+                "Expression": {
+                    "Type": "IdentifierExpression",
+                    "Identifier": {
+                        "Identifier": "a"
+                    }
+                },
+                "Optional": false,
+                "Identifier": {
+                    "Identifier": "b"
+                }
+            }
+            `,
+			string(data),
+		)
+	})
+
+	t.Run("round-trip", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := newTestJSONExpression()
+
+		data, err := MarshalCompact(expression)
+		require.NoError(t, err)
+
+		decoded, err := UnmarshalExpression(data)
+		require.NoError(t, err)
+
+		err = expression.CheckEqual(decoded, DefaultExpressionEqualityChecker{})
+		require.NoError(t, err)
+
+		// Positions are not preserved
+
+		member := decoded.(*ConditionalExpression).
+			Then.(*InvocationExpression).
+			InvokedExpression.(*MemberExpression)
+		assert.Equal(t, Position{}, member.AccessPos)
+		assert.Equal(t, Position{}, member.Identifier.Pos)
+	})
+}
+
+func TestOperation_UnmarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	for operation := OperationUnknown; int(operation) < OperationCount(); operation++ {
+
+		data, err := json.Marshal(operation)
+		require.NoError(t, err)
+
+		var decoded Operation
+		err = json.Unmarshal(data, &decoded)
+		require.NoError(t, err)
+
+		assert.Equal(t, operation, decoded)
+	}
+
+	var decoded Operation
+	err := json.Unmarshal([]byte(`"OperationFoo"`), &decoded)
+	require.EqualError(t, err, `unknown operation: "OperationFoo"`)
+}
@@ -0,0 +1,188 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// The encodings of elements are post-processed, e.g. to strip positions or to apply options,
+// by decoding the JSON encoding into a tree of generic values, rewriting the tree,
+// and encoding it again.
+//
+// Unlike the values decoded by json.Unmarshal into an interface{},
+// the tree preserves the layout of the encoding, e.g. the order of the fields of objects,
+// so the type discriminator of an element stays the first field.
+
+// jsonField is a field of a decoded JSON object
+type jsonField struct {
+	Key   string
+	Value interface{}
+}
+
+// jsonObject is a decoded JSON object, see decodeJSONValue
+type jsonObject []jsonField
+
+// decodeJSONValue returns the JSON encoding of the given value, decoded as a tree:
+// Objects are decoded as jsonObject, arrays as []interface{}, numbers as json.Number,
+// and all other scalars like by json.Unmarshal
+func decodeJSONValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Preserve numbers as-is, e.g. large integers
+	decoder.UseNumber()
+
+	result, err := decodeNextJSONValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	// The data is a single JSON value
+	_, err = decoder.Token()
+	if err != io.EOF {
+		return nil, fmt.Errorf("cannot decode JSON: unexpected data after value")
+	}
+
+	return result, nil
+}
+
+func decodeNextJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '{':
+		object := jsonObject{}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := keyToken.(string)
+			if !ok {
+				return nil, fmt.Errorf("cannot decode JSON: invalid object key %v", keyToken)
+			}
+
+			value, err := decodeNextJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			object = append(object, jsonField{
+				Key:   key,
+				Value: value,
+			})
+		}
+		return object, consumeJSONDelim(decoder, '}')
+
+	case '[':
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeNextJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		return array, consumeJSONDelim(decoder, ']')
+
+	default:
+		return nil, fmt.Errorf("cannot decode JSON: unexpected delimiter %s", delim)
+	}
+}
+
+func consumeJSONDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("cannot decode JSON: expected %s, got %v", delim, token)
+	}
+	return nil
+}
+
+// encodeJSONValue returns the JSON encoding of the given decoded value, see decodeJSONValue
+func encodeJSONValue(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	err := writeJSONValue(&buffer, value)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func writeJSONValue(buffer *bytes.Buffer, value interface{}) error {
+	switch value := value.(type) {
+	case jsonObject:
+		buffer.WriteByte('{')
+		for i, field := range value {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			err := writeJSONValue(buffer, field.Key)
+			if err != nil {
+				return err
+			}
+			buffer.WriteByte(':')
+			err = writeJSONValue(buffer, field.Value)
+			if err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte('}')
+		return nil
+
+	case []interface{}:
+		buffer.WriteByte('[')
+		for i, element := range value {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			err := writeJSONValue(buffer, element)
+			if err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(']')
+		return nil
+
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buffer.Write(data)
+		return nil
+	}
+}
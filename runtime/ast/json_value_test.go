@@ -0,0 +1,77 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSONValue(t *testing.T) {
+
+	t.Parallel()
+
+	expression := &ArrayExpression{
+		Values: []Expression{
+			&BoolExpression{Value: true},
+			&NilExpression{},
+		},
+		Range: Range{
+			StartPos: Position{Offset: 1, Line: 2, Column: 3},
+			EndPos:   Position{Offset: 4, Line: 5, Column: 6},
+		},
+	}
+
+	decoded, err := decodeJSONValue(expression)
+	require.NoError(t, err)
+
+	object, ok := decoded.(jsonObject)
+	require.True(t, ok)
+
+	// The order of the fields is preserved,
+	// e.g. the type discriminator is the first field
+
+	keys := make([]string, 0, len(object))
+	for _, field := range object {
+		keys = append(keys, field.Key)
+	}
+	assert.Equal(t, []string{"Type", "Values", "StartPos", "EndPos"}, keys)
+
+	assert.Equal(t,
+		jsonObject{
+			{Key: "Offset", Value: json.Number("1")},
+			{Key: "Line", Value: json.Number("2")},
+			{Key: "Column", Value: json.Number("3")},
+		},
+		object[2].Value,
+	)
+
+	// Encoding the decoded value results in the original encoding
+
+	expected, err := json.Marshal(expression)
+	require.NoError(t, err)
+
+	actual, err := encodeJSONValue(decoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(expected), string(actual))
+}
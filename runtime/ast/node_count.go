@@ -0,0 +1,155 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"reflect"
+)
+
+// CountNodes returns the number of elements in the given element,
+// i.e. the element itself and all its descendants.
+//
+// Like WalkIter, the traversal is not recursive,
+// so CountNodes may be used on arbitrarily deeply nested trees.
+func CountNodes(element Element) int {
+	count := 0
+	WalkIter(element, func(_ Element) {
+		count++
+	})
+	return count
+}
+
+// CountNodesByKind returns the number of elements in the given element,
+// i.e. the element itself and all its descendants, by kind.
+//
+// The kind of an element is the type discriminator of its JSON encoding,
+// e.g. "BinaryExpression" or "IdentifierExpression".
+func CountNodesByKind(element Element) map[string]int {
+	counts := map[string]int{}
+	WalkIter(element, func(element Element) {
+		counts[nodeKind(element)]++
+	})
+	return counts
+}
+
+// nodeKind returns the kind of the given node, e.g. an element or a type,
+// i.e. the type discriminator of its JSON encoding,
+// which is the name of the node's type, e.g. `BinaryExpression`
+func nodeKind(node interface{}) string {
+	ty := reflect.TypeOf(node)
+	for ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+	return ty.Name()
+}
+
+// Depth returns the maximum nesting depth of the given element,
+// i.e. the number of nodes on the longest path from the element to one of its descendants,
+// including the element itself.
+//
+// The types which elements refer to, e.g. the type of a casting expression, are nodes too,
+// as they are nested just like elements, e.g. in an optional type.
+//
+// Like WalkIter, the traversal is not recursive, but uses an explicit stack,
+// so Depth may be used on arbitrarily deeply nested trees
+// without overflowing the goroutine stack.
+func Depth(element Element) int {
+	if element == nil {
+		return 0
+	}
+
+	// An entry is either an element or a type
+	type entry struct {
+		element Element
+		ty      Type
+		depth   int
+	}
+
+	stack := []entry{{element: element, depth: 1}}
+
+	maxDepth := 0
+
+	for len(stack) > 0 {
+		lastIndex := len(stack) - 1
+		current := stack[lastIndex]
+		stack[lastIndex] = entry{}
+		stack = stack[:lastIndex]
+
+		if current.depth > maxDepth {
+			maxDepth = current.depth
+		}
+
+		pushType := func(ty Type) {
+			if ty == nil {
+				return
+			}
+			stack = append(stack, entry{
+				ty:    ty,
+				depth: current.depth + 1,
+			})
+		}
+
+		if current.ty != nil {
+			current.ty.Walk(pushType)
+			continue
+		}
+
+		walkReferencedTypes(current.element, pushType)
+
+		current.element.Walk(func(child Element) {
+			if child == nil {
+				return
+			}
+			stack = append(stack, entry{
+				element: child,
+				depth:   current.depth + 1,
+			})
+		})
+	}
+
+	return maxDepth
+}
+
+// walkReferencedTypes calls walkType for each type the given element refers to,
+// i.e. the types of typed elements, see TypedElement,
+// and the annotated types of variable and function declarations
+func walkReferencedTypes(element Element, walkType func(Type)) {
+	walkTypeAnnotation := func(typeAnnotation *TypeAnnotation) {
+		if typeAnnotation == nil {
+			return
+		}
+		walkType(typeAnnotation.Type)
+	}
+
+	switch element := element.(type) {
+	case TypedElement:
+		element.WalkTypes(walkType)
+
+	case *VariableDeclaration:
+		walkTypeAnnotation(element.TypeAnnotation)
+
+	case *FunctionDeclaration:
+		if element.ParameterList != nil {
+			for _, parameter := range element.ParameterList.Parameters {
+				walkTypeAnnotation(parameter.TypeAnnotation)
+			}
+		}
+		walkTypeAnnotation(element.ReturnTypeAnnotation)
+	}
+}
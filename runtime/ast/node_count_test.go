@@ -0,0 +1,267 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountNodes(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("expression", func(t *testing.T) {
+
+		t.Parallel()
+
+		// f(a + 1, [b, c])!
+
+		expression := &ForceExpression{
+			Expression: &InvocationExpression{
+				InvokedExpression: newTestIdentifierExpression("f"),
+				Arguments: Arguments{
+					{
+						Expression: &BinaryExpression{
+							Operation: OperationPlus,
+							Left:      newTestIdentifierExpression("a"),
+							Right: &IntegerExpression{
+								PositiveLiteral: "1",
+								Value:           big.NewInt(1),
+								Base:            10,
+							},
+						},
+					},
+					{
+						Expression: &ArrayExpression{
+							Values: []Expression{
+								newTestIdentifierExpression("b"),
+								newTestIdentifierExpression("c"),
+							},
+						},
+					},
+				},
+			},
+		}
+
+		assert.Equal(t, 9, CountNodes(expression))
+
+		assert.Equal(t,
+			map[string]int{
+				"ForceExpression":      1,
+				"InvocationExpression": 1,
+				"IdentifierExpression": 4,
+				"BinaryExpression":     1,
+				"IntegerExpression":    1,
+				"ArrayExpression":      1,
+			},
+			CountNodesByKind(expression),
+		)
+	})
+
+	t.Run("program", func(t *testing.T) {
+
+		t.Parallel()
+
+		program := newTestPositionedProgram(0)
+
+		assert.Equal(t, 11, CountNodes(program))
+
+		assert.Equal(t,
+			map[string]int{
+				"Program":              1,
+				"FunctionDeclaration":  1,
+				"FunctionBlock":        1,
+				"Block":                1,
+				"VariableDeclaration":  1,
+				"CastingExpression":    1,
+				"InvocationExpression": 1,
+				"IdentifierExpression": 3,
+				"ReturnStatement":      1,
+			},
+			CountNodesByKind(program),
+		)
+	})
+
+	t.Run("deeply nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		// The traversal is not recursive,
+		// so deeply nested trees do not overflow the stack
+
+		const depth = 100_000
+
+		var expression Expression = newTestIdentifierExpression("x")
+		for i := 0; i < depth; i++ {
+			expression = &UnaryExpression{
+				Operation:  OperationMinus,
+				Expression: expression,
+			}
+		}
+
+		assert.Equal(t, depth+1, CountNodes(expression))
+		assert.Equal(t,
+			map[string]int{
+				"UnaryExpression":      depth,
+				"IdentifierExpression": 1,
+			},
+			CountNodesByKind(expression),
+		)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, 0, CountNodes(nil))
+		assert.Empty(t, CountNodesByKind(nil))
+	})
+
+	t.Run("kinds are JSON type discriminators", func(t *testing.T) {
+
+		t.Parallel()
+
+		Inspect(newTestPositionedProgram(0), func(element Element) bool {
+			if element == nil {
+				return true
+			}
+
+			data, err := json.Marshal(element)
+			require.NoError(t, err)
+
+			var discriminated struct {
+				Type string
+			}
+			err = json.Unmarshal(data, &discriminated)
+			require.NoError(t, err)
+
+			assert.Equal(t, discriminated.Type, nodeKind(element))
+
+			return true
+		})
+	})
+}
+
+func TestDepth(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("right-nested binary expression", func(t *testing.T) {
+
+		t.Parallel()
+
+		// x0 + (x1 + (x2 + ... (xn-1 + xn)))
+
+		const operationCount = 10_000
+
+		var expression Expression = newTestIdentifierExpression("x")
+		for i := 0; i < operationCount; i++ {
+			expression = &BinaryExpression{
+				Operation: OperationPlus,
+				Left:      newTestIdentifierExpression("x"),
+				Right:     expression,
+			}
+		}
+
+		// Each binary expression nests one level deeper,
+		// and the innermost identifier expression is the deepest element
+
+		assert.Equal(t, operationCount+1, Depth(expression))
+	})
+
+	t.Run("unbalanced", func(t *testing.T) {
+
+		t.Parallel()
+
+		// [a, [[b]], c]
+
+		expression := &ArrayExpression{
+			Values: []Expression{
+				newTestIdentifierExpression("a"),
+				&ArrayExpression{
+					Values: []Expression{
+						&ArrayExpression{
+							Values: []Expression{
+								newTestIdentifierExpression("b"),
+							},
+						},
+					},
+				},
+				newTestIdentifierExpression("c"),
+			},
+		}
+
+		assert.Equal(t, 4, Depth(expression))
+	})
+
+	t.Run("program", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Program, function declaration, function block, block,
+		// variable declaration, casting expression, invocation expression,
+		// identifier expression
+
+		assert.Equal(t, 8, Depth(newTestPositionedProgram(0)))
+	})
+
+	t.Run("types", func(t *testing.T) {
+
+		t.Parallel()
+
+		// x as Int??
+		//
+		// Casting expression, optional type, optional type, nominal type
+
+		expression := &CastingExpression{
+			Operation:  OperationCast,
+			Expression: newTestIdentifierExpression("x"),
+			TypeAnnotation: &TypeAnnotation{
+				Type: &OptionalType{
+					Type: &OptionalType{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "Int"},
+						},
+					},
+				},
+			},
+		}
+
+		assert.Equal(t, 4, Depth(expression))
+	})
+
+	t.Run("leaf", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, 1, Depth(newTestIdentifierExpression("x")))
+	})
+
+	t.Run("nil", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, 0, Depth(nil))
+	})
+}
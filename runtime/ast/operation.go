@@ -20,6 +20,8 @@ package ast
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/onflow/cadence/runtime/errors"
 )
@@ -115,6 +117,208 @@ func (s Operation) Symbol() string {
 	panic(errors.NewUnreachableError())
 }
 
+// operationsBySymbol maps the symbols of all operations to the operations
+var operationsBySymbol = func() map[string]Operation {
+	count := OperationCount()
+	operations := make(map[string]Operation, count-1)
+	for operation := OperationUnknown + 1; operation < Operation(count); operation++ {
+		operations[operation.Symbol()] = operation
+	}
+	return operations
+}()
+
+// OperationFromSymbol returns the operation with the given symbol, i.e. the inverse of Symbol,
+// and true if there is such an operation, or false otherwise.
+//
+// OperationMinus is returned for the symbol `-`, for both subtraction and negation.
+func OperationFromSymbol(symbol string) (Operation, bool) {
+	operation, ok := operationsBySymbol[symbol]
+	return operation, ok
+}
+
+// Precedence returns the precedence of the operation, one of the Precedence* levels.
+//
+// OperationMinus is both a binary and a unary operation,
+// the returned precedence is the one of the binary (additive) operation.
+func (s Operation) Precedence() int {
+	switch s {
+	case OperationOr:
+		return PrecedenceLogicalOr
+	case OperationAnd:
+		return PrecedenceLogicalAnd
+	case OperationEqual,
+		OperationNotEqual,
+		OperationLess,
+		OperationGreater,
+		OperationLessEqual,
+		OperationGreaterEqual:
+		return PrecedenceComparison
+	case OperationNilCoalesce:
+		return PrecedenceNilCoalescing
+	case OperationBitwiseOr:
+		return PrecedenceBitwiseOr
+	case OperationBitwiseXor:
+		return PrecedenceBitwiseXor
+	case OperationBitwiseAnd:
+		return PrecedenceBitwiseAnd
+	case OperationBitwiseLeftShift,
+		OperationBitwiseRightShift:
+		return PrecedenceBitwiseShift
+	case OperationPlus,
+		OperationMinus:
+		return PrecedenceAddition
+	case OperationMul,
+		OperationDiv,
+		OperationMod:
+		return PrecedenceMultiplication
+	case OperationCast,
+		OperationFailableCast,
+		OperationForceCast:
+		return PrecedenceCasting
+	case OperationNegate,
+		OperationMove:
+		return PrecedenceUnaryPrefix
+	}
+
+	panic(errors.NewUnreachableError())
+}
+
+// Associativity returns the associativity of the operation.
+//
+// The logical operations and the nil-coalescing operation are right-associative,
+// all other binary operations are left-associative.
+// Unary operations have no associativity.
+func (s Operation) Associativity() Associativity {
+	switch s {
+	case OperationOr,
+		OperationAnd,
+		OperationNilCoalesce:
+		return AssociativityRight
+	case OperationEqual,
+		OperationNotEqual,
+		OperationLess,
+		OperationGreater,
+		OperationLessEqual,
+		OperationGreaterEqual,
+		OperationBitwiseOr,
+		OperationBitwiseXor,
+		OperationBitwiseAnd,
+		OperationBitwiseLeftShift,
+		OperationBitwiseRightShift,
+		OperationPlus,
+		OperationMinus,
+		OperationMul,
+		OperationDiv,
+		OperationMod,
+		OperationCast,
+		OperationFailableCast,
+		OperationForceCast:
+		return AssociativityLeft
+	case OperationNegate,
+		OperationMove:
+		return AssociativityNone
+	}
+
+	panic(errors.NewUnreachableError())
+}
+
+// IsArithmetic returns true if the operation is an arithmetic operation,
+// i.e. addition, subtraction, multiplication, division, or remainder.
+//
+// OperationMinus is both a binary and a unary operation (negation),
+// both are arithmetic operations.
+func (s Operation) IsArithmetic() bool {
+	switch s {
+	case OperationPlus,
+		OperationMinus,
+		OperationMul,
+		OperationDiv,
+		OperationMod:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsComparison returns true if the operation is an equality or a relational operation.
+func (s Operation) IsComparison() bool {
+	switch s {
+	case OperationEqual,
+		OperationNotEqual,
+		OperationLess,
+		OperationGreater,
+		OperationLessEqual,
+		OperationGreaterEqual:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsLogical returns true if the operation is a logical operation,
+// i.e. logical disjunction, conjunction, or negation.
+func (s Operation) IsLogical() bool {
+	switch s {
+	case OperationOr,
+		OperationAnd,
+		OperationNegate:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsBitwise returns true if the operation is a bitwise operation,
+// including the bitwise shifts.
+func (s Operation) IsBitwise() bool {
+	switch s {
+	case OperationBitwiseOr,
+		OperationBitwiseXor,
+		OperationBitwiseAnd,
+		OperationBitwiseLeftShift,
+		OperationBitwiseRightShift:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsBooleanResult returns true if the result of the operation is always a boolean,
+// i.e. if the operation is a comparison or a logical operation.
+func (s Operation) IsBooleanResult() bool {
+	return s.IsComparison() || s.IsLogical()
+}
+
+// Name returns the name of the operation, e.g. `Plus` for OperationPlus,
+// i.e. the name of the constant without the `Operation` prefix.
+//
+// Unlike Symbol, which is ambiguous for OperationMinus (`-`),
+// the name uniquely identifies the operation.
+func (s Operation) Name() string {
+	return strings.TrimPrefix(s.String(), "Operation")
+}
+
+// MarshalJSON encodes the operation as its name, e.g. `"Plus"`, see Name,
+// which is stable and the same whether the operation is encoded standalone or as part of a node.
 func (s Operation) MarshalJSON() ([]byte, error) {
-	return json.Marshal(s.String())
+	return json.Marshal(s.Name())
+}
+
+// UnmarshalJSON decodes the operation from its name, e.g. `"Plus"`,
+// i.e. the inverse of MarshalJSON. The name of its constant, e.g. `"OperationPlus"`, is also accepted.
+func (s *Operation) UnmarshalJSON(data []byte) error {
+	var name string
+	err := json.Unmarshal(data, &name)
+	if err != nil {
+		return err
+	}
+
+	for operation := OperationUnknown; int(operation) < OperationCount(); operation++ {
+		if operation.String() == name || operation.Name() == name {
+			*s = operation
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown operation: %q", name)
 }
@@ -35,6 +35,189 @@ func TestOperation_MarshalJSON(t *testing.T) {
 		actual, err := json.Marshal(operation)
 		require.NoError(t, err)
 
-		assert.JSONEq(t, fmt.Sprintf(`"%s"`, operation), string(actual))
+		assert.JSONEq(t, fmt.Sprintf(`"%s"`, operation.Name()), string(actual))
+	}
+
+	actual, err := json.Marshal(OperationPlus)
+	require.NoError(t, err)
+
+	assert.Equal(t, `"Plus"`, string(actual))
+}
+
+func TestOperation_UnmarshalJSON_ConstantName(t *testing.T) {
+
+	t.Parallel()
+
+	// The name of the constant, e.g. `OperationPlus`, is also accepted
+
+	for operation := Operation(0); operation < Operation(OperationCount()); operation++ {
+		var decoded Operation
+		err := json.Unmarshal([]byte(fmt.Sprintf(`"%s"`, operation)), &decoded)
+		require.NoError(t, err)
+
+		assert.Equal(t, operation, decoded)
+	}
+}
+
+func TestOperation_Name(t *testing.T) {
+
+	t.Parallel()
+
+	assert.Equal(t, "Plus", OperationPlus.Name())
+	assert.Equal(t, "Equal", OperationEqual.Name())
+	assert.Equal(t, "Minus", OperationMinus.Name())
+	assert.Equal(t, "BitwiseRightShift", OperationBitwiseRightShift.Name())
+
+	names := map[string]Operation{}
+	for operation := Operation(0); operation < Operation(OperationCount()); operation++ {
+		name := operation.Name()
+		require.NotContains(t, names, name)
+		names[name] = operation
+	}
+}
+
+func TestOperation_Precedence(t *testing.T) {
+
+	t.Parallel()
+
+	for operation := Operation(1); operation < Operation(OperationCount()); operation++ {
+		precedence := operation.Precedence()
+		assert.Greater(t, precedence, PrecedenceTernary, operation.String())
+		assert.Less(t, precedence, PrecedenceUnaryPostfix, operation.String())
+	}
+
+	orderedOperations := []Operation{
+		OperationOr,
+		OperationAnd,
+		OperationEqual,
+		OperationNilCoalesce,
+		OperationBitwiseOr,
+		OperationBitwiseXor,
+		OperationBitwiseAnd,
+		OperationBitwiseLeftShift,
+		OperationPlus,
+		OperationMul,
+		OperationCast,
+		OperationNegate,
+	}
+
+	for i := 1; i < len(orderedOperations); i++ {
+		lower := orderedOperations[i-1]
+		higher := orderedOperations[i]
+		assert.Less(t,
+			lower.Precedence(),
+			higher.Precedence(),
+			fmt.Sprintf("%s < %s", lower, higher),
+		)
+	}
+
+	assert.Equal(t, OperationLess.Precedence(), OperationNotEqual.Precedence())
+	assert.Equal(t, OperationMinus.Precedence(), OperationPlus.Precedence())
+	assert.Equal(t, OperationForceCast.Precedence(), OperationFailableCast.Precedence())
+	assert.Equal(t, OperationMove.Precedence(), OperationNegate.Precedence())
+}
+
+func TestOperation_Associativity(t *testing.T) {
+
+	t.Parallel()
+
+	for operation := Operation(1); operation < Operation(OperationCount()); operation++ {
+
+		var expected Associativity
+		switch operation {
+		case OperationOr, OperationAnd, OperationNilCoalesce:
+			expected = AssociativityRight
+		case OperationNegate, OperationMove:
+			expected = AssociativityNone
+		default:
+			expected = AssociativityLeft
+		}
+
+		assert.Equal(t, expected, operation.Associativity(), operation.String())
+	}
+}
+
+func TestOperation_Categories(t *testing.T) {
+
+	t.Parallel()
+
+	type categories struct {
+		arithmetic    bool
+		comparison    bool
+		logical       bool
+		bitwise       bool
+		booleanResult bool
+	}
+
+	arithmetic := categories{arithmetic: true}
+	comparison := categories{comparison: true, booleanResult: true}
+	logical := categories{logical: true, booleanResult: true}
+	bitwise := categories{bitwise: true}
+	other := categories{}
+
+	expected := map[Operation]categories{
+		OperationUnknown:           other,
+		OperationOr:                logical,
+		OperationAnd:               logical,
+		OperationEqual:             comparison,
+		OperationNotEqual:          comparison,
+		OperationLess:              comparison,
+		OperationGreater:           comparison,
+		OperationLessEqual:         comparison,
+		OperationGreaterEqual:      comparison,
+		OperationPlus:              arithmetic,
+		OperationMinus:             arithmetic,
+		OperationMul:               arithmetic,
+		OperationDiv:               arithmetic,
+		OperationMod:               arithmetic,
+		OperationNegate:            logical,
+		OperationNilCoalesce:       other,
+		OperationMove:              other,
+		OperationCast:              other,
+		OperationFailableCast:      other,
+		OperationForceCast:         other,
+		OperationBitwiseOr:         bitwise,
+		OperationBitwiseXor:        bitwise,
+		OperationBitwiseAnd:        bitwise,
+		OperationBitwiseLeftShift:  bitwise,
+		OperationBitwiseRightShift: bitwise,
+	}
+
+	// Ensure all operations are covered
+
+	require.Len(t, expected, OperationCount())
+
+	for operation, expectedCategories := range expected {
+		assert.Equal(t,
+			expectedCategories,
+			categories{
+				arithmetic:    operation.IsArithmetic(),
+				comparison:    operation.IsComparison(),
+				logical:       operation.IsLogical(),
+				bitwise:       operation.IsBitwise(),
+				booleanResult: operation.IsBooleanResult(),
+			},
+			operation.String(),
+		)
+	}
+}
+
+func TestOperationFromSymbol(t *testing.T) {
+
+	t.Parallel()
+
+	for operation := OperationUnknown + 1; operation < Operation(OperationCount()); operation++ {
+
+		symbol := operation.Symbol()
+
+		actual, ok := OperationFromSymbol(symbol)
+		require.True(t, ok, symbol)
+		assert.Equal(t, operation, actual, symbol)
+	}
+
+	for _, symbol := range []string{"", "=", "===", "!!", "?", "as?!", "+ "} {
+		actual, ok := OperationFromSymbol(symbol)
+		assert.False(t, ok, symbol)
+		assert.Equal(t, OperationUnknown, actual, symbol)
 	}
 }
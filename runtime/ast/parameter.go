@@ -18,6 +18,10 @@
 
 package ast
 
+// argumentLabelNotRequired is the argument label of a parameter
+// which indicates that arguments for the parameter require no label
+const argumentLabelNotRequired = "_"
+
 type Parameter struct {
 	Label          string
 	Identifier     Identifier
@@ -54,3 +54,39 @@ func (l *ParameterList) initialize() {
 	}
 	l._parametersByIdentifier = parametersByIdentifier
 }
+
+// Len returns the number of parameters in the list.
+// A nil list has no parameters.
+func (l *ParameterList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.Parameters)
+}
+
+// ByIdentifier returns the parameter with the given identifier (parameter name),
+// and true if the parameter exists, or false otherwise.
+func (l *ParameterList) ByIdentifier(name string) (*Parameter, bool) {
+	if l == nil {
+		return nil, false
+	}
+	parameter, ok := l.ParametersByIdentifier()[name]
+	return parameter, ok
+}
+
+// ByLabel returns the first parameter with the given effective argument label,
+// and true if the parameter exists, or false otherwise.
+//
+// Parameters which are declared with the `_` label require no argument label,
+// so they are never found by label, not even by the label `_`.
+func (l *ParameterList) ByLabel(label string) (*Parameter, bool) {
+	if l == nil || label == argumentLabelNotRequired {
+		return nil, false
+	}
+	for _, parameter := range l.Parameters {
+		if parameter.EffectiveArgumentLabel() == label {
+			return parameter, true
+		}
+	}
+	return nil, false
+}
@@ -22,6 +22,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -83,3 +84,86 @@ func TestParameterList_ParametersByIdentifier(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+func TestParameterList_Lookup(t *testing.T) {
+
+	t.Parallel()
+
+	// (from a: Int, b: Int, _ c: Int)
+
+	l := &ParameterList{
+		Parameters: []*Parameter{
+			{
+				Label:      "from",
+				Identifier: Identifier{Identifier: "a"},
+			},
+			{
+				Identifier: Identifier{Identifier: "b"},
+			},
+			{
+				Label:      "_",
+				Identifier: Identifier{Identifier: "c"},
+			},
+		},
+	}
+
+	t.Run("Len", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, 3, l.Len())
+		assert.Equal(t, 0, (&ParameterList{}).Len())
+		assert.Equal(t, 0, (*ParameterList)(nil).Len())
+	})
+
+	t.Run("ByIdentifier", func(t *testing.T) {
+
+		t.Parallel()
+
+		for i, name := range []string{"a", "b", "c"} {
+			parameter, ok := l.ByIdentifier(name)
+			require.True(t, ok, name)
+			assert.Same(t, l.Parameters[i], parameter, name)
+		}
+
+		// Labels are not identifiers
+
+		for _, name := range []string{"from", "_", "d"} {
+			parameter, ok := l.ByIdentifier(name)
+			assert.False(t, ok, name)
+			assert.Nil(t, parameter, name)
+		}
+
+		_, ok := (*ParameterList)(nil).ByIdentifier("a")
+		assert.False(t, ok)
+	})
+
+	t.Run("ByLabel", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Explicit label
+
+		parameter, ok := l.ByLabel("from")
+		require.True(t, ok)
+		assert.Same(t, l.Parameters[0], parameter)
+
+		// No label: the identifier is the label
+
+		parameter, ok = l.ByLabel("b")
+		require.True(t, ok)
+		assert.Same(t, l.Parameters[1], parameter)
+
+		// The identifier of a labeled parameter is not a label,
+		// and parameters with the `_` label have no label
+
+		for _, label := range []string{"a", "c", "_", ""} {
+			parameter, ok := l.ByLabel(label)
+			assert.False(t, ok, label)
+			assert.Nil(t, parameter, label)
+		}
+
+		_, ok = (*ParameterList)(nil).ByLabel("from")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,67 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+// Parents returns a map from each element in the tree with the given root
+// to its parent element. The root has no entry.
+//
+// The tree is traversed once. A well-formed tree does not share elements,
+// but if an element is reachable multiple times, only its first parent is recorded,
+// and its children are not traversed again.
+func Parents(root Element) map[Element]Element {
+	parents := map[Element]Element{}
+	visited := map[Element]struct{}{}
+	var stack []Element
+
+	InspectPrePost(
+		root,
+		func(element Element) bool {
+			if _, ok := visited[element]; ok {
+				return false
+			}
+			visited[element] = struct{}{}
+
+			if len(stack) > 0 {
+				parents[element] = stack[len(stack)-1]
+			}
+			stack = append(stack, element)
+			return true
+		},
+		func(_ Element) {
+			stack = stack[:len(stack)-1]
+		},
+	)
+
+	return parents
+}
+
+// Ancestors returns the ancestors of the given target element
+// in the tree with the given root, starting with the parent of the target,
+// and ending with the root.
+//
+// The result is empty if the target is the root or is not part of the tree.
+func Ancestors(root Element, target Element) []Element {
+	parents := Parents(root)
+
+	var ancestors []Element
+	for parent, ok := parents[target]; ok; parent, ok = parents[parent] {
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
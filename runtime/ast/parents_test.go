@@ -0,0 +1,112 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParents(t *testing.T) {
+
+	t.Parallel()
+
+	// f(a + g(b))
+
+	b := newTestIdentifierExpression("b")
+	g := newTestIdentifierExpression("g")
+	innerInvocation := &InvocationExpression{
+		InvokedExpression: g,
+		Arguments: Arguments{
+			{Expression: b},
+		},
+	}
+	a := newTestIdentifierExpression("a")
+	binary := &BinaryExpression{
+		Operation: OperationPlus,
+		Left:      a,
+		Right:     innerInvocation,
+	}
+	f := newTestIdentifierExpression("f")
+	outerInvocation := &InvocationExpression{
+		InvokedExpression: f,
+		Arguments: Arguments{
+			{Expression: binary},
+		},
+	}
+
+	t.Run("parents", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			map[Element]Element{
+				f:               outerInvocation,
+				binary:          outerInvocation,
+				a:               binary,
+				innerInvocation: binary,
+				g:               innerInvocation,
+				b:               innerInvocation,
+			},
+			Parents(outerInvocation),
+		)
+	})
+
+	t.Run("ancestors", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			[]Element{innerInvocation, binary, outerInvocation},
+			Ancestors(outerInvocation, b),
+		)
+
+		assert.Empty(t, Ancestors(outerInvocation, outerInvocation))
+		assert.Empty(t, Ancestors(outerInvocation, newTestIdentifierExpression("c")))
+	})
+
+	t.Run("shared", func(t *testing.T) {
+
+		t.Parallel()
+
+		// The same expression is used twice, and contains itself
+		// through an (ill-formed) self-referential binary expression
+
+		shared := &BinaryExpression{
+			Operation: OperationMinus,
+			Left:      newTestIdentifierExpression("x"),
+		}
+		shared.Right = shared
+
+		root := &ArrayExpression{
+			Values: []Expression{shared, shared},
+		}
+
+		parents := Parents(root)
+		require.Len(t, parents, 2)
+		assert.Equal(t, root, parents[shared])
+
+		assert.Equal(t,
+			[]Element{shared, root},
+			Ancestors(root, shared.Left),
+		)
+	})
+}
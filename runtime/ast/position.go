@@ -23,6 +23,10 @@ import (
 )
 
 // Position defines a row/column within a Cadence script.
+//
+// The JSON encoding of a position includes all fields,
+// i.e. the byte offset in addition to the line and column,
+// so an encoded position can be mapped back to the source.
 type Position struct {
 	// offset, starting at 0
 	Offset int
@@ -60,6 +64,27 @@ func (position Position) Compare(other Position) int {
 	}
 }
 
+// compareLocation compares the positions by line and column.
+// Positions without line information (line 0) are compared by offset.
+func (position Position) compareLocation(other Position) int {
+	if position.Line == 0 || other.Line == 0 {
+		return position.Compare(other)
+	}
+
+	switch {
+	case position.Line < other.Line:
+		return -1
+	case position.Line > other.Line:
+		return 1
+	case position.Column < other.Column:
+		return -1
+	case position.Column > other.Column:
+		return 1
+	default:
+		return 0
+	}
+}
+
 func EndPosition(startPosition Position, end int) Position {
 	length := end - startPosition.Offset
 	return startPosition.Shifted(length)
@@ -87,6 +112,107 @@ func (e Range) EndPosition() Position {
 	return e.EndPos
 }
 
+// Contains returns true if the given position is within the range.
+// Both the start and end position of the range are inclusive.
+func (e Range) Contains(position Position) bool {
+	return e.StartPos.compareLocation(position) <= 0 &&
+		position.compareLocation(e.EndPos) <= 0
+}
+
+// ContainsRange returns true if the given range is fully within the range.
+func (e Range) ContainsRange(other Range) bool {
+	return e.Contains(other.StartPos) &&
+		e.Contains(other.EndPos)
+}
+
+// Overlaps returns true if the range and the given range have at least one position in common.
+// Both the start and end position of the ranges are inclusive,
+// so ranges which only share an end position overlap.
+func (e Range) Overlaps(other Range) bool {
+	return e.StartPos.compareLocation(other.EndPos) <= 0 &&
+		other.StartPos.compareLocation(e.EndPos) <= 0
+}
+
+// Adjacent returns true if the range and the given range do not overlap,
+// but touch, i.e. one range starts directly after the other range ends.
+func (e Range) Adjacent(other Range) bool {
+	return other.StartPos.isDirectlyAfter(e.EndPos) ||
+		e.StartPos.isDirectlyAfter(other.EndPos)
+}
+
+// isDirectlyAfter returns true if the position is the position directly after the given position,
+// on the same line. Positions without line information (line 0) are compared by offset.
+func (position Position) isDirectlyAfter(other Position) bool {
+	if position.Line == 0 || other.Line == 0 {
+		return position.Offset == other.Offset+1
+	}
+
+	return position.Line == other.Line &&
+		position.Column == other.Column+1
+}
+
+// UnionRanges returns the smallest range which contains all given ranges,
+// i.e. the range from the earliest start position to the latest end position.
+// The ranges may be given in any order, and may overlap.
+//
+// The zero range is returned if no ranges are given.
+func UnionRanges(ranges ...Range) Range {
+	if len(ranges) == 0 {
+		return Range{}
+	}
+
+	result := ranges[0]
+	for _, r := range ranges[1:] {
+		if r.StartPos.compareLocation(result.StartPos) < 0 {
+			result.StartPos = r.StartPos
+		}
+		if r.EndPos.compareLocation(result.EndPos) > 0 {
+			result.EndPos = r.EndPos
+		}
+	}
+	return result
+}
+
+// RangeOf returns the smallest range which contains all given positioned elements,
+// see UnionRanges. Absent elements, i.e. nil interface values, are ignored.
+func RangeOf(positioned ...HasPosition) Range {
+	ranges := make([]Range, 0, len(positioned))
+	for _, hasPosition := range positioned {
+		if hasPosition == nil {
+			continue
+		}
+		ranges = append(ranges, NewRangeFromPositioned(hasPosition))
+	}
+	return UnionRanges(ranges...)
+}
+
+// firstStartPosition returns the start position of the first of the given elements which is not nil,
+// or the zero position if all elements are nil.
+//
+// NOTE: Absent elements must be nil interface values, not nil pointers.
+func firstStartPosition(elements ...HasPosition) Position {
+	for _, element := range elements {
+		if element != nil {
+			return element.StartPosition()
+		}
+	}
+	return Position{}
+}
+
+// lastEndPosition returns the end position of the last of the given elements which is not nil,
+// or the zero position if all elements are nil.
+//
+// NOTE: Absent elements must be nil interface values, not nil pointers.
+func lastEndPosition(elements ...HasPosition) Position {
+	for i := len(elements) - 1; i >= 0; i-- {
+		element := elements[i]
+		if element != nil {
+			return element.EndPosition()
+		}
+	}
+	return Position{}
+}
+
 // NewRangeFromPositioned
 
 func NewRangeFromPositioned(hasPosition HasPosition) Range {
@@ -0,0 +1,314 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"reflect"
+)
+
+// StripPositions resets all positions of the given element and all its descendants
+// to the zero position, in place, e.g. so that trees parsed from differently formatted sources
+// can be compared using reflect.DeepEqual.
+//
+// Only the positions of the given tree are reset: Enclosing elements which are referenced by it,
+// e.g. the parent variable declaration of a casting expression, are not modified.
+//
+// Positions which are computed from other positions, e.g. the end position of a nil expression,
+// are computed from the zero position afterwards.
+func StripPositions(element Element) {
+	rewritePositions(element, func(_ Position) Position {
+		return Position{}
+	})
+}
+
+// WithoutPositions returns a deep copy of the given element, see copyElement,
+// with all positions reset to the zero position, see StripPositions.
+// The given element is not mutated.
+//
+// Unlike clones of expressions, the copy shares no nodes with the given element,
+// e.g. the function blocks of function expressions are copied,
+// so copies of trees which only differ in their positions can be compared using reflect.DeepEqual.
+func WithoutPositions(element Element) Element {
+	if element == nil {
+		return nil
+	}
+
+	result := copyElement(element)
+	StripPositions(result)
+	return result
+}
+
+// ShiftPositions shifts all positions of the given element and all its descendants
+// by the given deltas, in place, e.g. when splicing the element into a larger document.
+//
+// All positions are shifted uniformly, so ranges keep their extent,
+// and positions which are computed from other positions,
+// e.g. the end position of a nil expression, are shifted consistently.
+// Absent positions, i.e. zero positions, are not shifted.
+//
+// Only the positions of the given tree are shifted: Enclosing elements which are referenced by it,
+// e.g. the parent variable declaration of a casting expression, and their children are not shifted.
+func ShiftPositions(element Element, lineDelta, columnDelta, offsetDelta int) {
+	rewritePositions(element, func(position Position) Position {
+		if position == (Position{}) {
+			return position
+		}
+		return Position{
+			Offset: position.Offset + offsetDelta,
+			Line:   position.Line + lineDelta,
+			Column: position.Column + columnDelta,
+		}
+	})
+}
+
+var (
+	positionType = reflect.TypeOf(Position{})
+	bigIntType   = reflect.TypeOf(&big.Int{})
+	elementType  = reflect.TypeOf((*Element)(nil)).Elem()
+	programType  = reflect.TypeOf(&Program{})
+	membersType  = reflect.TypeOf(&Members{})
+)
+
+// isParentReference returns true if the given field of a node refers to an element
+// which is not a child of the node, but an enclosing element,
+// e.g. CastingExpression.ParentVariableDeclaration.
+// Like in JSON, where such fields are omitted, they are not followed when traversing a tree
+func isParentReference(field reflect.StructField) bool {
+	return field.Type.Implements(elementType) &&
+		field.Tag.Get("json") == "-"
+}
+
+// pointerKey identifies a pointer during a traversal.
+// The type is part of the key, as a pointer to a struct
+// has the same address as a pointer to its first field
+type pointerKey struct {
+	address uintptr
+	typ     reflect.Type
+}
+
+func newPointerKey(value reflect.Value) pointerKey {
+	return pointerKey{
+		address: value.Pointer(),
+		typ:     value.Type(),
+	}
+}
+
+// positionRewriter replaces each position in a tree with the result of a function.
+//
+// The tree is traversed reflectively through the exported fields of nodes,
+// so that positions in non-element nodes, e.g. identifiers, types, and parameters,
+// are also rewritten. Elements which are only reachable through unexported fields,
+// e.g. the declarations of programs, are reached through Walk.
+//
+// Parent references are not followed, see isParentReference,
+// so only the positions of the tree are rewritten, and not the ones of the enclosing elements.
+// Each pointer is only followed once, so shared nodes and shared positions
+// are only rewritten once.
+type positionRewriter struct {
+	rewrite func(Position) Position
+	visited map[pointerKey]struct{}
+}
+
+func newPositionRewriter(rewrite func(Position) Position) *positionRewriter {
+	return &positionRewriter{
+		rewrite: rewrite,
+		visited: map[pointerKey]struct{}{},
+	}
+}
+
+func rewritePositions(root Element, rewrite func(Position) Position) {
+	rewriter := newPositionRewriter(rewrite)
+
+	WalkIter(root, func(element Element) {
+		rewriter.rewriteValue(reflect.ValueOf(element))
+	})
+}
+
+func (r *positionRewriter) rewriteValue(value reflect.Value) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() || value.Type() == bigIntType {
+			return
+		}
+
+		key := newPointerKey(value)
+		if _, ok := r.visited[key]; ok {
+			return
+		}
+		r.visited[key] = struct{}{}
+
+		r.rewriteValue(value.Elem())
+
+	case reflect.Interface:
+		if value.IsNil() {
+			return
+		}
+
+		// NOTE: Values which are not pointers cannot be modified,
+		// but no node which contains positions is stored as a value in an interface
+		r.rewriteValue(value.Elem())
+
+	case reflect.Struct:
+		if value.Type() == positionType {
+			if value.CanSet() {
+				position := value.Interface().(Position)
+				value.Set(reflect.ValueOf(r.rewrite(position)))
+			}
+			return
+		}
+
+		valueType := value.Type()
+		for i := 0; i < value.NumField(); i++ {
+			field := valueType.Field(i)
+			if field.PkgPath != "" {
+				// Unexported fields only contain caches
+				// and elements which are reached through Walk
+				continue
+			}
+			if isParentReference(field) {
+				continue
+			}
+			r.rewriteValue(value.Field(i))
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			r.rewriteValue(value.Index(i))
+		}
+	}
+}
+
+// copyElement returns a deep copy of the given element.
+//
+// The tree is traversed reflectively, like by positionRewriter.
+// Shared nodes are copied once, so the copy shares them in the same way.
+// Parent references are redirected to the copy of the enclosing element, if it is part of the copy,
+// and otherwise refer to the same element as the given element.
+// Unexported fields are not copied, as they only contain caches,
+// except for the declarations of programs and members.
+func copyElement(element Element) Element {
+	copier := &elementCopier{
+		copies: map[pointerKey]reflect.Value{},
+	}
+	return copier.copyValue(reflect.ValueOf(element)).Interface().(Element)
+}
+
+type elementCopier struct {
+	copies map[pointerKey]reflect.Value
+}
+
+func (c *elementCopier) copyValue(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+
+		if value.Type() == bigIntType {
+			return reflect.ValueOf(new(big.Int).Set(value.Interface().(*big.Int)))
+		}
+
+		key := newPointerKey(value)
+		if result, ok := c.copies[key]; ok {
+			return result
+		}
+
+		result := reflect.New(value.Type().Elem())
+		c.copies[key] = result
+
+		switch value.Type() {
+		case programType:
+			program := result.Interface().(*Program)
+			program.declarations = c.copyDeclarations(value.Interface().(*Program).declarations)
+
+		case membersType:
+			members := result.Interface().(*Members)
+			members.declarations = c.copyDeclarations(value.Interface().(*Members).declarations)
+
+		default:
+			c.copyInto(result.Elem(), value.Elem())
+		}
+
+		return result
+
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+
+		result := reflect.New(value.Type()).Elem()
+		result.Set(c.copyValue(value.Elem()))
+		return result
+
+	case reflect.Struct:
+		result := reflect.New(value.Type()).Elem()
+		c.copyInto(result, value)
+		return result
+
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+
+		result := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			result.Index(i).Set(c.copyValue(value.Index(i)))
+		}
+		return result
+
+	case reflect.Array:
+		result := reflect.New(value.Type()).Elem()
+		for i := 0; i < value.Len(); i++ {
+			result.Index(i).Set(c.copyValue(value.Index(i)))
+		}
+		return result
+
+	default:
+		return value
+	}
+}
+
+func (c *elementCopier) copyInto(result reflect.Value, value reflect.Value) {
+	valueType := value.Type()
+	for i := 0; i < value.NumField(); i++ {
+		field := valueType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+
+		if isParentReference(field) {
+			if !fieldValue.IsNil() {
+				if parent, ok := c.copies[newPointerKey(fieldValue)]; ok {
+					fieldValue = parent
+				}
+			}
+			result.Field(i).Set(fieldValue)
+			continue
+		}
+
+		result.Field(i).Set(c.copyValue(fieldValue))
+	}
+}
+
+func (c *elementCopier) copyDeclarations(declarations []Declaration) []Declaration {
+	return c.copyValue(reflect.ValueOf(declarations)).Interface().([]Declaration)
+}
@@ -0,0 +1,559 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPositionedProgram returns the program
+//
+//	fun test(_ x: Int): Int {
+//	    let y = f(a: x) as! Int
+//	    return y
+//	}
+//
+// with all positions shifted by the given offset,
+// i.e. as if the program was preceded by the given number of spaces.
+func newTestPositionedProgram(offset int) *Program {
+
+	position := func(line, column int) Position {
+		// NOTE: the lengths of the lines are irrelevant
+		return Position{
+			Offset: offset + line*100 + column,
+			Line:   line,
+			Column: offset + column,
+		}
+	}
+
+	positionPointer := func(line, column int) *Position {
+		pos := position(line, column)
+		return &pos
+	}
+
+	intTypeAnnotation := func(line, column int) *TypeAnnotation {
+		return &TypeAnnotation{
+			Type: &NominalType{
+				Identifier: Identifier{Identifier: "Int", Pos: position(line, column)},
+			},
+			StartPos: position(line, column),
+		}
+	}
+
+	casting := &CastingExpression{
+		Operation: OperationForceCast,
+		Expression: &InvocationExpression{
+			InvokedExpression: &IdentifierExpression{
+				Identifier: Identifier{Identifier: "f", Pos: position(2, 12)},
+			},
+			Arguments: Arguments{
+				{
+					Label:         "a",
+					LabelStartPos: positionPointer(2, 14),
+					LabelEndPos:   positionPointer(2, 14),
+					Expression: &IdentifierExpression{
+						Identifier: Identifier{Identifier: "x", Pos: position(2, 17)},
+					},
+					TrailingSeparatorPos: position(2, 18),
+				},
+			},
+			ArgumentsStartPos: position(2, 13),
+			EndPos:            position(2, 18),
+		},
+		TypeAnnotation: intTypeAnnotation(2, 24),
+	}
+
+	variableDeclaration := &VariableDeclaration{
+		IsConstant: true,
+		Identifier: Identifier{Identifier: "y", Pos: position(2, 8)},
+		Value:      casting,
+		Transfer: &Transfer{
+			Operation: TransferOperationCopy,
+			Pos:       position(2, 10),
+		},
+		StartPos: position(2, 4),
+	}
+
+	casting.ParentVariableDeclaration = variableDeclaration
+
+	return NewProgram([]Declaration{
+		&FunctionDeclaration{
+			Identifier: Identifier{Identifier: "test", Pos: position(1, 4)},
+			ParameterList: &ParameterList{
+				Parameters: []*Parameter{
+					{
+						Label:          "_",
+						Identifier:     Identifier{Identifier: "x", Pos: position(1, 11)},
+						TypeAnnotation: intTypeAnnotation(1, 14),
+						Range: Range{
+							StartPos: position(1, 9),
+							EndPos:   position(1, 16),
+						},
+					},
+				},
+				Range: Range{
+					StartPos: position(1, 8),
+					EndPos:   position(1, 17),
+				},
+			},
+			ReturnTypeAnnotation: intTypeAnnotation(1, 20),
+			FunctionBlock: &FunctionBlock{
+				Block: &Block{
+					Statements: []Statement{
+						variableDeclaration,
+						&ReturnStatement{
+							Expression: &IdentifierExpression{
+								Identifier: Identifier{Identifier: "y", Pos: position(3, 11)},
+							},
+							Range: Range{
+								StartPos: position(3, 4),
+								EndPos:   position(3, 11),
+							},
+						},
+					},
+					Range: Range{
+						StartPos: position(1, 24),
+						EndPos:   position(4, 0),
+					},
+				},
+			},
+			StartPos: position(1, 0),
+		},
+	})
+}
+
+func TestStripPositions(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("program", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := newTestPositionedProgram(0)
+		b := newTestPositionedProgram(4)
+
+		require.False(t, reflect.DeepEqual(a, b))
+
+		StripPositions(a)
+		StripPositions(b)
+
+		assert.True(t, reflect.DeepEqual(a, b))
+
+		// All positions are stripped, including positions in declarations, statements,
+		// identifiers, parameters, type annotations, and argument labels
+
+		Inspect(a, func(element Element) bool {
+			if element != nil {
+				assert.Equal(t, Position{}, element.StartPosition(), "%T", element)
+			}
+			return true
+		})
+
+		function := a.FunctionDeclarations()[0]
+		argument := function.FunctionBlock.Block.Statements[0].(*VariableDeclaration).
+			Value.(*CastingExpression).
+			Expression.(*InvocationExpression).
+			Arguments[0]
+
+		assert.Equal(t, &Position{}, argument.LabelStartPos)
+		assert.Equal(t, Position{}, argument.TrailingSeparatorPos)
+		assert.Equal(t, Position{}, function.ParameterList.Parameters[0].Identifier.Pos)
+	})
+
+	t.Run("subtree", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Stripping the positions of a casting expression does not strip
+		// the positions of the variable declaration it refers to
+
+		program := newTestPositionedProgram(0)
+
+		declaration := program.FunctionDeclarations()[0].
+			FunctionBlock.Block.Statements[0].(*VariableDeclaration)
+
+		casting := declaration.Value.(*CastingExpression)
+
+		StripPositions(casting)
+
+		assert.Equal(t, Position{}, casting.StartPosition())
+		assert.Equal(t, Position{Offset: 204, Line: 2, Column: 4}, declaration.StartPos)
+		assert.Equal(t, Position{Offset: 208, Line: 2, Column: 8}, declaration.Identifier.Pos)
+	})
+
+	t.Run("computed end position", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := &NilExpression{
+			Pos: Position{Offset: 10, Line: 2, Column: 3},
+		}
+
+		StripPositions(expression)
+
+		assert.Equal(t,
+			Range{
+				StartPos: Position{},
+				EndPos:   Position{Offset: 2, Column: 2},
+			},
+			NewRangeFromPositioned(expression),
+		)
+	})
+}
+
+func TestWithoutPositions(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("expression", func(t *testing.T) {
+
+		t.Parallel()
+
+		// f(a: [x, nil]) as! Int
+
+		position := func(offset int) Position {
+			return Position{Offset: offset, Line: 1, Column: offset}
+		}
+
+		positionPointer := func(offset int) *Position {
+			pos := position(offset)
+			return &pos
+		}
+
+		newExpression := func() *CastingExpression {
+			return &CastingExpression{
+				Operation: OperationForceCast,
+				Expression: &InvocationExpression{
+					InvokedExpression: &IdentifierExpression{
+						Identifier: Identifier{Identifier: "f", Pos: position(0)},
+					},
+					Arguments: Arguments{
+						{
+							Label:         "a",
+							LabelStartPos: positionPointer(2),
+							LabelEndPos:   positionPointer(2),
+							Expression: &ArrayExpression{
+								Values: []Expression{
+									&IdentifierExpression{
+										Identifier: Identifier{Identifier: "x", Pos: position(6)},
+									},
+									&NilExpression{Pos: position(9)},
+								},
+								Range: Range{StartPos: position(5), EndPos: position(12)},
+							},
+							TrailingSeparatorPos: position(13),
+						},
+					},
+					ArgumentsStartPos: position(1),
+					EndPos:            position(13),
+				},
+				TypeAnnotation: &TypeAnnotation{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "Int", Pos: position(19)},
+					},
+					StartPos: position(19),
+				},
+			}
+		}
+
+		original := newExpression()
+
+		result := WithoutPositions(original)
+
+		// The original is not mutated
+
+		assert.Equal(t, newExpression(), original)
+
+		expected := newExpression()
+		StripPositions(expected)
+
+		assert.True(t, reflect.DeepEqual(expected, result))
+	})
+
+	t.Run("function expression", func(t *testing.T) {
+
+		t.Parallel()
+
+		// fun (): Int { return 1 }, with all positions shifted by the given offset
+
+		newExpression := func(offset int) *FunctionExpression {
+			position := func(column int) Position {
+				return Position{Offset: offset + column, Line: 1, Column: offset + column}
+			}
+
+			return &FunctionExpression{
+				ParameterList: &ParameterList{
+					Range: Range{StartPos: position(4), EndPos: position(5)},
+				},
+				ReturnTypeAnnotation: &TypeAnnotation{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "Int", Pos: position(8)},
+					},
+					StartPos: position(8),
+				},
+				FunctionBlock: &FunctionBlock{
+					Block: &Block{
+						Statements: []Statement{
+							&ReturnStatement{
+								Expression: &IntegerExpression{
+									PositiveLiteral: "1",
+									Value:           big.NewInt(1),
+									Base:            10,
+									Range:           Range{StartPos: position(21), EndPos: position(21)},
+								},
+								Range: Range{StartPos: position(14), EndPos: position(21)},
+							},
+						},
+						Range: Range{StartPos: position(12), EndPos: position(23)},
+					},
+				},
+				StartPos: position(0),
+			}
+		}
+
+		original := newExpression(0)
+
+		a := WithoutPositions(original)
+		b := WithoutPositions(newExpression(4))
+
+		assert.True(t, reflect.DeepEqual(a, b))
+
+		// The function block is not shared with the original,
+		// and the positions of the original are not reset
+
+		require.IsType(t, &FunctionExpression{}, a)
+		assert.NotSame(t, original.FunctionBlock, a.(*FunctionExpression).FunctionBlock)
+		assert.Equal(t, newExpression(0), original)
+	})
+
+	t.Run("parent reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		program := newTestPositionedProgram(0)
+
+		result := WithoutPositions(program).(*Program)
+
+		assert.Equal(t, newTestPositionedProgram(0), program)
+
+		expected := newTestPositionedProgram(0)
+		StripPositions(expected)
+
+		assert.True(t, reflect.DeepEqual(expected, result))
+
+		// The parent reference of the casting expression refers to the copy
+		// of the variable declaration, not to the original
+
+		declaration := result.FunctionDeclarations()[0].
+			FunctionBlock.Block.Statements[0].(*VariableDeclaration)
+
+		assert.Same(t,
+			declaration,
+			declaration.Value.(*CastingExpression).ParentVariableDeclaration,
+		)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Nil(t, WithoutPositions(nil))
+	})
+}
+
+func TestShiftPositions(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("nested expression", func(t *testing.T) {
+
+		t.Parallel()
+
+		// [x, nil][0]
+
+		position := func(offset int) Position {
+			return Position{Offset: offset, Line: 1, Column: offset}
+		}
+
+		newExpression := func() *IndexExpression {
+			return &IndexExpression{
+				TargetExpression: &ArrayExpression{
+					Values: []Expression{
+						&IdentifierExpression{
+							Identifier: Identifier{Identifier: "x", Pos: position(1)},
+						},
+						&NilExpression{Pos: position(4)},
+					},
+					Range: Range{StartPos: position(0), EndPos: position(7)},
+				},
+				IndexingExpression: &IntegerExpression{
+					PositiveLiteral: "0",
+					Value:           big.NewInt(0),
+					Base:            10,
+					Range:           Range{StartPos: position(9), EndPos: position(9)},
+				},
+				Range: Range{StartPos: position(0), EndPos: position(10)},
+			}
+		}
+
+		original := newExpression()
+		shifted := newExpression()
+
+		ShiftPositions(shifted, 2, 4, 104)
+
+		shiftPosition := func(position Position) Position {
+			return Position{
+				Offset: position.Offset + 104,
+				Line:   position.Line + 2,
+				Column: position.Column + 4,
+			}
+		}
+
+		var originalRanges []Range
+		Inspect(original, func(element Element) bool {
+			if element != nil {
+				originalRanges = append(originalRanges, NewRangeFromPositioned(element))
+			}
+			return true
+		})
+
+		var shiftedRanges []Range
+		Inspect(shifted, func(element Element) bool {
+			if element != nil {
+				shiftedRanges = append(shiftedRanges, NewRangeFromPositioned(element))
+			}
+			return true
+		})
+
+		require.Len(t, shiftedRanges, 5)
+		require.Len(t, originalRanges, len(shiftedRanges))
+
+		for i, originalRange := range originalRanges {
+			assert.Equal(t,
+				Range{
+					StartPos: shiftPosition(originalRange.StartPos),
+					EndPos:   shiftPosition(originalRange.EndPos),
+				},
+				shiftedRanges[i],
+			)
+		}
+
+		// The end position of the nil expression is computed,
+		// and is consistent with its shifted start position
+
+		assert.Equal(t,
+			Range{
+				StartPos: Position{Offset: 108, Line: 3, Column: 8},
+				EndPos:   Position{Offset: 110, Line: 3, Column: 10},
+			},
+			NewRangeFromPositioned(shifted.TargetExpression.(*ArrayExpression).Values[1]),
+		)
+	})
+
+	t.Run("program", func(t *testing.T) {
+
+		t.Parallel()
+
+		// The positions of a program shifted by four columns and offsets
+		// are the positions of the program indented by four spaces
+
+		program := newTestPositionedProgram(0)
+
+		ShiftPositions(program, 0, 4, 4)
+
+		assert.Equal(t, newTestPositionedProgram(4), program)
+	})
+
+	t.Run("subtree", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Shifting a casting expression only shifts the casting expression,
+		// but not the variable declaration it refers to, nor the rest of the function
+
+		program := newTestPositionedProgram(0)
+
+		function := program.FunctionDeclarations()[0]
+		declaration := function.FunctionBlock.Block.Statements[0].(*VariableDeclaration)
+		casting := declaration.Value.(*CastingExpression)
+
+		ShiftPositions(casting, 0, 4, 4)
+
+		expected := newTestPositionedProgram(0)
+		expectedFunction := expected.FunctionDeclarations()[0]
+		expectedDeclaration := expectedFunction.FunctionBlock.Block.Statements[0].(*VariableDeclaration)
+
+		assert.Equal(t,
+			newTestPositionedProgram(4).FunctionDeclarations()[0].
+				FunctionBlock.Block.Statements[0].(*VariableDeclaration).
+				Value.(*CastingExpression).
+				Expression,
+			casting.Expression,
+		)
+
+		assert.Equal(t, expectedDeclaration.StartPos, declaration.StartPos)
+		assert.Equal(t, expectedDeclaration.Identifier, declaration.Identifier)
+		assert.Equal(t, expectedDeclaration.Transfer, declaration.Transfer)
+		assert.Equal(t, expectedFunction.FunctionBlock.Block.Range, function.FunctionBlock.Block.Range)
+		assert.Equal(t,
+			expectedFunction.FunctionBlock.Block.Statements[1],
+			function.FunctionBlock.Block.Statements[1],
+		)
+	})
+
+	t.Run("shared positions", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Shared positions are only shifted once
+
+		labelPos := &Position{Offset: 2, Line: 1, Column: 2}
+
+		invocation := &InvocationExpression{
+			InvokedExpression: &IdentifierExpression{
+				Identifier: Identifier{Identifier: "f", Pos: Position{Offset: 0, Line: 1, Column: 0}},
+			},
+			Arguments: Arguments{
+				{
+					Label:         "a",
+					LabelStartPos: labelPos,
+					LabelEndPos:   labelPos,
+					Expression: &IdentifierExpression{
+						Identifier: Identifier{Identifier: "x", Pos: Position{Offset: 5, Line: 1, Column: 5}},
+					},
+				},
+			},
+		}
+
+		ShiftPositions(invocation, 1, 0, 10)
+
+		assert.Equal(t, &Position{Offset: 12, Line: 2, Column: 2}, labelPos)
+
+		// Absent positions are not shifted
+
+		assert.Equal(t, Position{}, invocation.Arguments[0].TrailingSeparatorPos)
+		assert.Equal(t, Position{}, invocation.EndPos)
+	})
+}
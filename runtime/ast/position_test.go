@@ -0,0 +1,475 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRange_Contains(t *testing.T) {
+
+	t.Parallel()
+
+	r := Range{
+		StartPos: Position{Offset: 4, Line: 2, Column: 2},
+		EndPos:   Position{Offset: 12, Line: 3, Column: 1},
+	}
+
+	type testCase struct {
+		position Position
+		expected bool
+	}
+
+	testCases := map[string]testCase{
+		"before, previous line": {
+			position: Position{Offset: 1, Line: 1, Column: 1},
+			expected: false,
+		},
+		"before, same line": {
+			position: Position{Offset: 3, Line: 2, Column: 1},
+			expected: false,
+		},
+		"at start": {
+			position: Position{Offset: 4, Line: 2, Column: 2},
+			expected: true,
+		},
+		"inside, first line": {
+			position: Position{Offset: 8, Line: 2, Column: 6},
+			expected: true,
+		},
+		"inside, last line": {
+			position: Position{Offset: 11, Line: 3, Column: 0},
+			expected: true,
+		},
+		"at end": {
+			position: Position{Offset: 12, Line: 3, Column: 1},
+			expected: true,
+		},
+		"after, same line": {
+			position: Position{Offset: 13, Line: 3, Column: 2},
+			expected: false,
+		},
+		"after, next line": {
+			position: Position{Offset: 20, Line: 4, Column: 0},
+			expected: false,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t, testCase.expected, r.Contains(testCase.position))
+		})
+	}
+}
+
+func TestRange_Contains_Shifted(t *testing.T) {
+
+	t.Parallel()
+
+	nilExpression := &NilExpression{
+		Pos: Position{Offset: 5, Line: 1, Column: 5},
+	}
+
+	r := NewRangeFromPositioned(nilExpression)
+
+	assert.False(t, r.Contains(Position{Offset: 4, Line: 1, Column: 4}))
+	assert.True(t, r.Contains(Position{Offset: 5, Line: 1, Column: 5}))
+	assert.True(t, r.Contains(Position{Offset: 7, Line: 1, Column: 7}))
+	assert.False(t, r.Contains(Position{Offset: 8, Line: 1, Column: 8}))
+}
+
+func TestRange_Contains_OffsetOnly(t *testing.T) {
+
+	t.Parallel()
+
+	r := Range{
+		StartPos: Position{Offset: 2},
+		EndPos:   Position{Offset: 4},
+	}
+
+	assert.False(t, r.Contains(Position{Offset: 1}))
+	assert.True(t, r.Contains(Position{Offset: 2}))
+	assert.True(t, r.Contains(Position{Offset: 4}))
+	assert.False(t, r.Contains(Position{Offset: 5}))
+}
+
+func TestRange_ContainsRange(t *testing.T) {
+
+	t.Parallel()
+
+	r := Range{
+		StartPos: Position{Offset: 4, Line: 2, Column: 2},
+		EndPos:   Position{Offset: 12, Line: 3, Column: 1},
+	}
+
+	type testCase struct {
+		other    Range
+		expected bool
+	}
+
+	testCases := map[string]testCase{
+		"same": {
+			other:    r,
+			expected: true,
+		},
+		"inside": {
+			other: Range{
+				StartPos: Position{Offset: 5, Line: 2, Column: 3},
+				EndPos:   Position{Offset: 8, Line: 2, Column: 6},
+			},
+			expected: true,
+		},
+		"overlapping start": {
+			other: Range{
+				StartPos: Position{Offset: 3, Line: 2, Column: 1},
+				EndPos:   Position{Offset: 8, Line: 2, Column: 6},
+			},
+			expected: false,
+		},
+		"overlapping end": {
+			other: Range{
+				StartPos: Position{Offset: 8, Line: 2, Column: 6},
+				EndPos:   Position{Offset: 13, Line: 3, Column: 2},
+			},
+			expected: false,
+		},
+		"surrounding": {
+			other: Range{
+				StartPos: Position{Offset: 0, Line: 1, Column: 0},
+				EndPos:   Position{Offset: 20, Line: 4, Column: 0},
+			},
+			expected: false,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t, testCase.expected, r.ContainsRange(testCase.other))
+		})
+	}
+}
+
+func TestUnionRanges(t *testing.T) {
+
+	t.Parallel()
+
+	first := Range{
+		StartPos: Position{Offset: 2, Line: 1, Column: 2},
+		EndPos:   Position{Offset: 6, Line: 1, Column: 6},
+	}
+	second := Range{
+		StartPos: Position{Offset: 4, Line: 1, Column: 4},
+		EndPos:   Position{Offset: 12, Line: 2, Column: 3},
+	}
+	third := Range{
+		StartPos: Position{Offset: 20, Line: 3, Column: 0},
+		EndPos:   Position{Offset: 24, Line: 3, Column: 4},
+	}
+
+	expected := Range{
+		StartPos: Position{Offset: 2, Line: 1, Column: 2},
+		EndPos:   Position{Offset: 24, Line: 3, Column: 4},
+	}
+
+	t.Run("in order", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, expected, UnionRanges(first, second, third))
+	})
+
+	t.Run("out of order", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, expected, UnionRanges(third, first, second))
+		assert.Equal(t, expected, UnionRanges(second, third, first))
+	})
+
+	t.Run("overlapping", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t,
+			Range{
+				StartPos: Position{Offset: 2, Line: 1, Column: 2},
+				EndPos:   Position{Offset: 12, Line: 2, Column: 3},
+			},
+			UnionRanges(second, first),
+		)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, expected, UnionRanges(first, expected))
+	})
+
+	t.Run("single", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, second, UnionRanges(second))
+	})
+
+	t.Run("none", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Equal(t, Range{}, UnionRanges())
+	})
+}
+
+func TestRangeOf(t *testing.T) {
+
+	t.Parallel()
+
+	// x + y
+
+	left := &IdentifierExpression{
+		Identifier: Identifier{
+			Identifier: "x",
+			Pos:        Position{Offset: 0, Line: 1, Column: 0},
+		},
+	}
+	right := &IdentifierExpression{
+		Identifier: Identifier{
+			Identifier: "y",
+			Pos:        Position{Offset: 4, Line: 1, Column: 4},
+		},
+	}
+
+	expected := Range{
+		StartPos: Position{Offset: 0, Line: 1, Column: 0},
+		EndPos:   Position{Offset: 4, Line: 1, Column: 4},
+	}
+
+	assert.Equal(t, expected, RangeOf(right, left))
+	assert.Equal(t, expected, RangeOf(left, nil, right))
+	assert.Equal(t, NewRangeFromPositioned(left), RangeOf(left))
+	assert.Equal(t, Range{}, RangeOf())
+}
+
+func TestRange_Overlaps_Adjacent(t *testing.T) {
+
+	t.Parallel()
+
+	r := Range{
+		StartPos: Position{Offset: 4, Line: 2, Column: 2},
+		EndPos:   Position{Offset: 10, Line: 2, Column: 8},
+	}
+
+	type testCase struct {
+		other    Range
+		overlaps bool
+		adjacent bool
+	}
+
+	testCases := map[string]testCase{
+		"equal": {
+			other:    r,
+			overlaps: true,
+		},
+		"nested": {
+			other: Range{
+				StartPos: Position{Offset: 5, Line: 2, Column: 3},
+				EndPos:   Position{Offset: 8, Line: 2, Column: 6},
+			},
+			overlaps: true,
+		},
+		"surrounding": {
+			other: Range{
+				StartPos: Position{Offset: 0, Line: 1, Column: 0},
+				EndPos:   Position{Offset: 20, Line: 4, Column: 0},
+			},
+			overlaps: true,
+		},
+		"overlapping start": {
+			other: Range{
+				StartPos: Position{Offset: 2, Line: 2, Column: 0},
+				EndPos:   Position{Offset: 5, Line: 2, Column: 3},
+			},
+			overlaps: true,
+		},
+		"sharing end position": {
+			other: Range{
+				StartPos: Position{Offset: 10, Line: 2, Column: 8},
+				EndPos:   Position{Offset: 12, Line: 2, Column: 10},
+			},
+			overlaps: true,
+		},
+		"touching after": {
+			other: Range{
+				StartPos: Position{Offset: 11, Line: 2, Column: 9},
+				EndPos:   Position{Offset: 12, Line: 2, Column: 10},
+			},
+			adjacent: true,
+		},
+		"touching before": {
+			other: Range{
+				StartPos: Position{Offset: 2, Line: 2, Column: 0},
+				EndPos:   Position{Offset: 3, Line: 2, Column: 1},
+			},
+			adjacent: true,
+		},
+		"disjoint after": {
+			other: Range{
+				StartPos: Position{Offset: 12, Line: 2, Column: 10},
+				EndPos:   Position{Offset: 14, Line: 2, Column: 12},
+			},
+		},
+		"disjoint, next line": {
+			other: Range{
+				StartPos: Position{Offset: 11, Line: 3, Column: 0},
+				EndPos:   Position{Offset: 14, Line: 3, Column: 3},
+			},
+		},
+		"disjoint before": {
+			other: Range{
+				StartPos: Position{Offset: 0, Line: 1, Column: 0},
+				EndPos:   Position{Offset: 1, Line: 1, Column: 1},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t, testCase.overlaps, r.Overlaps(testCase.other))
+			assert.Equal(t, testCase.overlaps, testCase.other.Overlaps(r))
+
+			assert.Equal(t, testCase.adjacent, r.Adjacent(testCase.other))
+			assert.Equal(t, testCase.adjacent, testCase.other.Adjacent(r))
+		})
+	}
+
+	t.Run("offset only", func(t *testing.T) {
+
+		t.Parallel()
+
+		r := Range{
+			StartPos: Position{Offset: 4},
+			EndPos:   Position{Offset: 10},
+		}
+
+		touching := Range{
+			StartPos: Position{Offset: 11},
+			EndPos:   Position{Offset: 12},
+		}
+
+		assert.False(t, r.Overlaps(touching))
+		assert.True(t, r.Adjacent(touching))
+
+		overlapping := Range{
+			StartPos: Position{Offset: 10},
+			EndPos:   Position{Offset: 12},
+		}
+
+		assert.True(t, r.Overlaps(overlapping))
+		assert.False(t, r.Adjacent(overlapping))
+	})
+}
+
+func TestPosition_MarshalJSON(t *testing.T) {
+
+	t.Parallel()
+
+	position := Position{Offset: 42, Line: 3, Column: 7}
+
+	data, err := json.Marshal(position)
+	require.NoError(t, err)
+
+	assert.JSONEq(t,
+		`{"Offset": 42, "Line": 3, "Column": 7}`,
+		string(data),
+	)
+
+	var decoded Position
+	err = json.Unmarshal(data, &decoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, position, decoded)
+}
+
+func TestRange_MarshalJSON_Offsets(t *testing.T) {
+
+	t.Parallel()
+
+	// The offsets of the range of an expression survive a marshal/unmarshal cycle
+
+	expression := &MemberExpression{
+		Expression: &IdentifierExpression{
+			Identifier: Identifier{
+				Identifier: "foo",
+				Pos:        Position{Offset: 10, Line: 2, Column: 4},
+			},
+		},
+		AccessPos: Position{Offset: 13, Line: 2, Column: 7},
+		Identifier: Identifier{
+			Identifier: "bar",
+			Pos:        Position{Offset: 14, Line: 2, Column: 8},
+		},
+	}
+
+	expectedRange := Range{
+		StartPos: Position{Offset: 10, Line: 2, Column: 4},
+		EndPos:   Position{Offset: 16, Line: 2, Column: 10},
+	}
+
+	require.Equal(t, expectedRange, NewRangeFromPositioned(expression))
+
+	data, err := json.Marshal(expression)
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalExpression(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, expression, decoded)
+	assert.Equal(t, expectedRange, NewRangeFromPositioned(decoded))
+
+	// The range itself also survives a marshal/unmarshal cycle
+
+	data, err = json.Marshal(expectedRange)
+	require.NoError(t, err)
+
+	var decodedRange Range
+	err = json.Unmarshal(data, &decodedRange)
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedRange, decodedRange)
+}
@@ -0,0 +1,227 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"github.com/turbolent/prettier"
+)
+
+// The precedence levels of expressions, from lowest to highest,
+// i.e. an expression with a higher precedence binds tighter
+// than an expression with a lower precedence.
+// The levels match the binding powers used by the parser:
+//
+// The conditional (ternary) operator binds weakest,
+// followed by the logical operators (`||`, then `&&`),
+// the comparison operators, the nil-coalescing operator,
+// the bitwise operators (`|`, `^`, `&`, then shifts),
+// the arithmetic operators (additive, then multiplicative),
+// the casting operators, the unary prefix and postfix operators,
+// and finally member access, indexing, and invocation.
+// Literals and identifiers bind tightest.
+const (
+	PrecedenceUnknown = iota
+	PrecedenceTernary
+	PrecedenceLogicalOr
+	PrecedenceLogicalAnd
+	PrecedenceComparison
+	PrecedenceNilCoalescing
+	PrecedenceBitwiseOr
+	PrecedenceBitwiseXor
+	PrecedenceBitwiseAnd
+	PrecedenceBitwiseShift
+	PrecedenceAddition
+	PrecedenceMultiplication
+	PrecedenceCasting
+	PrecedenceUnaryPrefix
+	PrecedenceUnaryPostfix
+	PrecedenceAccess
+	PrecedenceLiteral
+)
+
+// expressionPrecedence returns the precedence of the given expression,
+// i.e. the precedence of its top-level operator.
+func expressionPrecedence(expression Expression) int {
+	switch expression := expression.(type) {
+	case *BinaryExpression:
+		return expression.Operation.Precedence()
+
+	case *ConditionalExpression:
+		return PrecedenceTernary
+
+	case *CastingExpression:
+		return PrecedenceCasting
+
+	case *UnaryExpression,
+		*CreateExpression:
+
+		return PrecedenceUnaryPrefix
+
+	case *ForceExpression:
+		return PrecedenceUnaryPostfix
+
+	case *InvocationExpression,
+		*MemberExpression,
+		*IndexExpression:
+
+		return PrecedenceAccess
+
+	case *IntegerExpression:
+		// A negative literal starts with a minus sign,
+		// so it binds like a unary prefix expression, e.g. `(-1).foo`
+		if expression.IsNegative() {
+			return PrecedenceUnaryPrefix
+		}
+		return PrecedenceLiteral
+
+	case *FixedPointExpression:
+		if expression.Negative {
+			return PrecedenceUnaryPrefix
+		}
+		return PrecedenceLiteral
+
+	case *ReferenceExpression,
+		*DestroyExpression,
+		*AttachmentExpression:
+
+		// The operand of reference, destroy, and attachment expressions extends
+		// as far to the right as possible, so they always bind weakest
+		return PrecedenceTernary
+
+	default:
+		return PrecedenceLiteral
+	}
+}
+
+// needsParentheses returns true if the given subexpression binds weaker than the given precedence,
+// i.e. if it must be parenthesized.
+//
+// Parentheses are not represented in the AST, i.e. there is no parenthesized expression node:
+// The parser only uses them for grouping, so `((a))` and `a` result in the same tree.
+// Instead, expressions are parenthesized when they are rendered, and only where needed
+// to preserve the structure of the tree, e.g. `(a + b) * c` keeps its parentheses,
+// but `a + (b * c)` is rendered as `a + b * c`.
+// There are thus no redundant parentheses which would have to be removed.
+func needsParentheses(expression Expression, precedence int) bool {
+	return expressionPrecedence(expression) < precedence
+}
+
+// subexpressionDoc returns the document for the given subexpression,
+// parenthesized if the subexpression binds weaker than the given precedence.
+func subexpressionDoc(expression Expression, precedence int, context docContext) prettier.Doc {
+	doc := expressionDoc(expression, context)
+	if !needsParentheses(expression, precedence) {
+		return doc
+	}
+	return parenthesizedDoc(doc)
+}
+
+// memberTargetNeedsParentheses returns true if the given target of a member access
+// binds weaker than the member access, or if it is a decimal integer literal:
+// The dot of the member access would be lexed as part of a fixed-point literal, e.g. `1.foo`.
+func memberTargetNeedsParentheses(target Expression) bool {
+	if integerExpression, ok := target.(*IntegerExpression); ok &&
+		integerExpression.Base == 10 {
+
+		return true
+	}
+
+	return needsParentheses(target, PrecedenceUnaryPostfix)
+}
+
+// memberTargetDoc returns the document for the given target of a member access,
+// parenthesized if needed, see memberTargetNeedsParentheses.
+func memberTargetDoc(target Expression, context docContext) prettier.Doc {
+	doc := expressionDoc(target, context)
+	if !memberTargetNeedsParentheses(target) {
+		return doc
+	}
+	return parenthesizedDoc(doc)
+}
+
+// binaryOperandNeedsParentheses returns true if the given operand of a binary operation
+// binds weaker than the operation.
+//
+// An operand with equal precedence only needs parentheses
+// if it is on the side opposite to the associativity of the operation.
+func binaryOperandNeedsParentheses(operation Operation, operand Expression, isLeft bool) bool {
+	precedence := operation.Precedence()
+	operandPrecedence := expressionPrecedence(operand)
+
+	if operandPrecedence != precedence {
+		return operandPrecedence < precedence
+	}
+
+	switch operation.Associativity() {
+	case AssociativityLeft:
+		return !isLeft
+	case AssociativityRight:
+		return isLeft
+	default:
+		return true
+	}
+}
+
+// binaryOperandDoc returns the document for the given operand of a binary operation,
+// parenthesized if the operand binds weaker than the operation.
+func binaryOperandDoc(operation Operation, operand Expression, isLeft bool, context docContext) prettier.Doc {
+	doc := expressionDoc(operand, context)
+	if !binaryOperandNeedsParentheses(operation, operand, isLeft) {
+		return doc
+	}
+	return parenthesizedDoc(doc)
+}
+
+// unaryOperandNeedsParentheses returns true if the operand of a unary operation
+// binds weaker than the unary operation.
+//
+// Operands which are unary expressions or negative literals themselves
+// also need parentheses, e.g. `-(-x)`, so that the operator symbols
+// do not run together and re-lex as different tokens.
+func unaryOperandNeedsParentheses(operand Expression) bool {
+	switch operand := operand.(type) {
+	case *UnaryExpression:
+		return true
+	case *IntegerExpression:
+		if operand.IsNegative() {
+			return true
+		}
+	case *FixedPointExpression:
+		if operand.Negative {
+			return true
+		}
+	}
+
+	return needsParentheses(operand, PrecedenceUnaryPrefix)
+}
+
+// unaryOperandDoc returns the document for the operand of a unary operation,
+// parenthesized if the operand binds weaker than the unary operation,
+// or if it is a unary expression or negative literal itself.
+func unaryOperandDoc(operand Expression, context docContext) prettier.Doc {
+	doc := expressionDoc(operand, context)
+	if !unaryOperandNeedsParentheses(operand) {
+		return doc
+	}
+	return parenthesizedDoc(doc)
+}
+
+func parenthesizedDoc(doc prettier.Doc) prettier.Doc {
+	return prettier.WrapParentheses(doc, prettier.SoftLine{})
+}
@@ -0,0 +1,138 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"sort"
+)
+
+// RangeIndex is an index of the elements of an AST by their ranges,
+// which allows efficiently finding all elements overlapping a given range.
+//
+// The index is immutable, so it is safe for concurrent use,
+// but it is not updated when the AST is changed.
+type RangeIndex struct {
+	// entries are sorted by start offset
+	entries []rangeIndexEntry
+	// maxEnds is an implicit binary search tree over the entries:
+	// maxEnds[mid] is the maximum end offset of the entries in the subtree
+	// of the entry at index mid
+	maxEnds []int
+}
+
+type rangeIndexEntry struct {
+	element Element
+	start   int
+	end     int
+}
+
+// BuildRangeIndex returns a range index of all elements of the given AST,
+// including the root.
+//
+// Elements are indexed by the offsets of their start and end positions.
+// Building the index takes O(n log n) time, and each query takes O(log n + k) time,
+// where k is the number of results.
+func BuildRangeIndex(root Element) *RangeIndex {
+	var entries []rangeIndexEntry
+	WalkIter(root, func(element Element) {
+		entries = append(entries, rangeIndexEntry{
+			element: element,
+			start:   element.StartPosition().Offset,
+			end:     element.EndPosition().Offset,
+		})
+	})
+
+	// NOTE: stable, so elements with the same start offset
+	// remain in depth-first order, i.e. parents precede their children
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].start < entries[j].start
+	})
+
+	index := &RangeIndex{
+		entries: entries,
+		maxEnds: make([]int, len(entries)),
+	}
+	index.computeMaxEnds(0, len(entries))
+	return index
+}
+
+// computeMaxEnds computes the maximum end offsets of the subtree
+// of the entries in the range [low, high), and returns it.
+func (i *RangeIndex) computeMaxEnds(low, high int) int {
+	if low >= high {
+		return -1
+	}
+
+	mid := low + (high-low)/2
+
+	maxEnd := i.entries[mid].end
+	if leftMaxEnd := i.computeMaxEnds(low, mid); leftMaxEnd > maxEnd {
+		maxEnd = leftMaxEnd
+	}
+	if rightMaxEnd := i.computeMaxEnds(mid+1, high); rightMaxEnd > maxEnd {
+		maxEnd = rightMaxEnd
+	}
+
+	i.maxEnds[mid] = maxEnd
+	return maxEnd
+}
+
+// Len returns the number of indexed elements.
+func (i *RangeIndex) Len() int {
+	return len(i.entries)
+}
+
+// Overlapping returns all indexed elements whose range overlaps the given range,
+// i.e. which have at least one offset in common with it.
+// Both the start and end positions of ranges are inclusive.
+//
+// The elements are returned in the order of their start offsets.
+// Elements with the same start offset are returned in depth-first order.
+func (i *RangeIndex) Overlapping(r Range) []Element {
+	var result []Element
+	i.collectOverlapping(0, len(i.entries), r.StartPos.Offset, r.EndPos.Offset, &result)
+	return result
+}
+
+func (i *RangeIndex) collectOverlapping(low, high, start, end int, result *[]Element) {
+	for low < high {
+		mid := low + (high-low)/2
+
+		// None of the entries in the subtree end at or after the start
+		if i.maxEnds[mid] < start {
+			return
+		}
+
+		i.collectOverlapping(low, mid, start, end, result)
+
+		// The entry, and all entries after it, start after the end
+		entry := i.entries[mid]
+		if entry.start > end {
+			return
+		}
+
+		if entry.end >= start {
+			*result = append(*result, entry.element)
+		}
+
+		// Continue with the right subtree
+		low = mid + 1
+	}
+}
@@ -0,0 +1,238 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRangeIndexExpression returns an array expression with the given number of elements,
+// each a binary expression of two identifiers, e.g. `[a0 + b0, a1 + b1, ...]`,
+// so the result has 3 * elements + 1 nodes.
+func newTestRangeIndexExpression(elements int) *ArrayExpression {
+
+	offset := 0
+
+	position := func() Position {
+		return Position{Offset: offset, Line: 1, Column: offset}
+	}
+
+	newIdentifier := func(name string) *IdentifierExpression {
+		expression := &IdentifierExpression{
+			Identifier: Identifier{
+				Identifier: name,
+				Pos:        position(),
+			},
+		}
+		offset += len(name)
+		return expression
+	}
+
+	startPos := position()
+	// [
+	offset++
+
+	values := make([]Expression, elements)
+	for i := 0; i < elements; i++ {
+		if i > 0 {
+			// ,
+			offset += 2
+		}
+
+		left := newIdentifier(fmt.Sprintf("a%d", i))
+		// +
+		offset += 3
+		right := newIdentifier(fmt.Sprintf("b%d", i))
+
+		values[i] = &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      left,
+			Right:     right,
+		}
+	}
+
+	// ]
+	endPos := position()
+
+	return &ArrayExpression{
+		Values: values,
+		Range: Range{
+			StartPos: startPos,
+			EndPos:   endPos,
+		},
+	}
+}
+
+// bruteForceOverlapping returns all elements of the given AST
+// which overlap the given range, by walking the whole AST.
+func bruteForceOverlapping(root Element, r Range) []Element {
+	var result []Element
+	WalkIter(root, func(element Element) {
+		if element.StartPosition().Offset <= r.EndPos.Offset &&
+			element.EndPosition().Offset >= r.StartPos.Offset {
+
+			result = append(result, element)
+		}
+	})
+	return result
+}
+
+func TestRangeIndex_Overlapping(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("simple", func(t *testing.T) {
+
+		t.Parallel()
+
+		// [a0 + b0, a1 + b1]
+		// 0123456789012345678
+
+		expression := newTestRangeIndexExpression(2)
+
+		index := BuildRangeIndex(expression)
+		require.Equal(t, 7, index.Len())
+
+		offsetRange := func(start, end int) Range {
+			return Range{
+				StartPos: Position{Offset: start},
+				EndPos:   Position{Offset: end},
+			}
+		}
+
+		array := expression
+		first := array.Values[0].(*BinaryExpression)
+		second := array.Values[1].(*BinaryExpression)
+
+		// `b0`: end of the first element
+
+		assert.Equal(t,
+			[]Element{array, first, first.Right},
+			index.Overlapping(offsetRange(6, 6)),
+		)
+
+		// `, `: only the array
+
+		assert.Equal(t,
+			[]Element{array},
+			index.Overlapping(offsetRange(8, 9)),
+		)
+
+		// `b0, a1`: spans both elements
+
+		assert.Equal(t,
+			[]Element{array, first, first.Right, second, second.Left},
+			index.Overlapping(offsetRange(6, 11)),
+		)
+
+		// Outside of the array
+
+		assert.Empty(t, index.Overlapping(offsetRange(19, 30)))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+
+		t.Parallel()
+
+		index := BuildRangeIndex(nil)
+		require.Equal(t, 0, index.Len())
+
+		assert.Empty(t, index.Overlapping(Range{}))
+	})
+
+	t.Run("brute force", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := newTestRangeIndexExpression(1000)
+		index := BuildRangeIndex(expression)
+
+		maxOffset := expression.EndPos.Offset + 10
+
+		random := rand.New(rand.NewSource(42))
+
+		for i := 0; i < 1000; i++ {
+			start := random.Intn(maxOffset)
+			end := start + random.Intn(50)
+
+			r := Range{
+				StartPos: Position{Offset: start},
+				EndPos:   Position{Offset: end},
+			}
+
+			expected := bruteForceOverlapping(expression, r)
+			actual := index.Overlapping(r)
+
+			require.ElementsMatch(t, expected, actual, "%d-%d", start, end)
+
+			// The results are sorted by start offset
+
+			for j := 1; j < len(actual); j++ {
+				require.LessOrEqual(t,
+					actual[j-1].StartPosition().Offset,
+					actual[j].StartPosition().Offset,
+				)
+			}
+		}
+	})
+}
+
+func BenchmarkRangeIndex(b *testing.B) {
+
+	// [a0 + b0, ...] with about 50,000 nodes
+
+	expression := newTestRangeIndexExpression(50_000 / 3)
+
+	r := Range{
+		StartPos: Position{Offset: expression.EndPos.Offset / 2},
+		EndPos:   Position{Offset: expression.EndPos.Offset/2 + 100},
+	}
+
+	b.Run("build", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			BuildRangeIndex(expression)
+		}
+	})
+
+	b.Run("query", func(b *testing.B) {
+		index := BuildRangeIndex(expression)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			index.Overlapping(r)
+		}
+	})
+
+	b.Run("brute force", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			bruteForceOverlapping(expression, r)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"strings"
+)
+
+// SExpr returns an S-expression representation of the given element,
+// which shows the kind of each node and all of its children explicitly,
+// e.g. `(BinaryExpression + (IntegerExpression 1) (IntegerExpression 2))`.
+//
+// Unlike the string representation and the document, the result reflects
+// the structure of the tree, e.g. how expressions are nested.
+// It is intended for debugging.
+func SExpr(element Element) string {
+	var builder strings.Builder
+	Walk(&sExpressionWriter{builder: &builder}, element)
+	return builder.String()
+}
+
+// TypeSExpr returns an S-expression representation of the given type,
+// e.g. `(OptionalType (NominalType Int))`.
+func TypeSExpr(ty Type) string {
+	var builder strings.Builder
+	writeTypeSExpr(&builder, ty)
+	return builder.String()
+}
+
+type sExpressionWriter struct {
+	builder *strings.Builder
+	depth   int
+}
+
+var _ TypeWalker = &sExpressionWriter{}
+
+func (w *sExpressionWriter) Walk(element Element) Walker {
+	if element == nil {
+		w.builder.WriteByte(')')
+		w.depth--
+		return nil
+	}
+
+	if w.depth > 0 {
+		w.builder.WriteByte(' ')
+	}
+	w.depth++
+
+	w.builder.WriteByte('(')
+	w.builder.WriteString(nodeKind(element))
+	for _, atom := range sExprAtoms(element) {
+		w.builder.WriteByte(' ')
+		w.builder.WriteString(atom)
+	}
+
+	return w
+}
+
+func (w *sExpressionWriter) WalkType(ty Type) {
+	w.builder.WriteByte(' ')
+	writeTypeSExpr(w.builder, ty)
+}
+
+func writeTypeSExpr(builder *strings.Builder, ty Type) {
+	if ty == nil {
+		builder.WriteString("()")
+		return
+	}
+
+	builder.WriteByte('(')
+	builder.WriteString(nodeKind(ty))
+
+	switch ty := ty.(type) {
+	case *NominalType:
+		builder.WriteByte(' ')
+		builder.WriteString(ty.String())
+
+	case *ConstantSizedType:
+		if ty.Size != nil {
+			builder.WriteByte(' ')
+			builder.WriteString(ty.Size.String())
+		}
+
+	case *ReferenceType:
+		if ty.Authorized {
+			builder.WriteString(" auth")
+		}
+	}
+
+	ty.Walk(func(child Type) {
+		builder.WriteByte(' ')
+		writeTypeSExpr(builder, child)
+	})
+
+	builder.WriteByte(')')
+}
+
+// sExprAtoms returns the non-element information of the given element,
+// e.g. the operator of a binary expression, or the name of an identifier.
+func sExprAtoms(element Element) []string {
+	switch element := element.(type) {
+	case *BoolExpression,
+		*IntegerExpression,
+		*FixedPointExpression,
+		*StringExpression,
+		*IdentifierExpression,
+		*PathExpression:
+
+		return []string{element.(Expression).String()}
+
+	case *StringTemplateExpression:
+		segments := make([]string, len(element.Segments))
+		for i, segment := range element.Segments {
+			segments[i] = QuoteString(segment)
+		}
+		return segments
+
+	case *MemberExpression:
+		separator := "."
+		if element.Optional {
+			separator = "?."
+		}
+		return []string{separator + element.Identifier.Identifier}
+
+	case *InvocationExpression:
+		typeArguments := make([]string, len(element.TypeArguments))
+		for i, typeArgument := range element.TypeArguments {
+			typeArguments[i] = TypeSExpr(typeArgument.Type)
+		}
+		return typeArguments
+
+	case *UnaryExpression:
+		return []string{element.Operation.Symbol()}
+
+	case *BinaryExpression:
+		return []string{element.Operation.Symbol()}
+
+	case *CastingExpression:
+		atoms := []string{element.Operation.Symbol()}
+		if element.TypeAnnotation != nil && element.TypeAnnotation.IsResource {
+			atoms = append(atoms, "@")
+		}
+		return atoms
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSExpr(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("binary", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := &BinaryExpression{
+			Operation: OperationPlus,
+			Left: &IntegerExpression{
+				PositiveLiteral: "1",
+				Value:           big.NewInt(1),
+				Base:            10,
+			},
+			Right: &IntegerExpression{
+				PositiveLiteral: "2",
+				Value:           big.NewInt(2),
+				Base:            10,
+			},
+		}
+
+		assert.Equal(t,
+			"(BinaryExpression + (IntegerExpression 1) (IntegerExpression 2))",
+			SExpr(expression),
+		)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		// -a.b?.c(x, "y")[0]
+
+		expression := &UnaryExpression{
+			Operation: OperationMinus,
+			Expression: &IndexExpression{
+				TargetExpression: &InvocationExpression{
+					InvokedExpression: &MemberExpression{
+						Optional: true,
+						Expression: &MemberExpression{
+							Expression: newTestIdentifierExpression("a"),
+							Identifier: Identifier{Identifier: "b"},
+						},
+						Identifier: Identifier{Identifier: "c"},
+					},
+					TypeArguments: []*TypeAnnotation{
+						{
+							Type: &OptionalType{
+								Type: &NominalType{
+									Identifier: Identifier{Identifier: "Int"},
+								},
+							},
+						},
+					},
+					Arguments: Arguments{
+						{Expression: newTestIdentifierExpression("x")},
+						{Expression: &StringExpression{Value: "y"}},
+					},
+				},
+				IndexingExpression: &IntegerExpression{
+					PositiveLiteral: "0",
+					Value:           big.NewInt(0),
+					Base:            10,
+				},
+			},
+		}
+
+		assert.Equal(t,
+			"(UnaryExpression - "+
+				"(IndexExpression "+
+				"(InvocationExpression (OptionalType (NominalType Int)) "+
+				"(MemberExpression ?.c (MemberExpression .b (IdentifierExpression a))) "+
+				"(IdentifierExpression x) "+
+				`(StringExpression "y")) `+
+				"(IntegerExpression 0)))",
+			SExpr(expression),
+		)
+	})
+
+	t.Run("types", func(t *testing.T) {
+
+		t.Parallel()
+
+		expression := &CastingExpression{
+			Operation: OperationFailableCast,
+			Expression: &ReferenceExpression{
+				Expression: newTestIdentifierExpression("r"),
+				Type: &ReferenceType{
+					Authorized: true,
+					Type: &RestrictedType{
+						Restrictions: []*NominalType{
+							{Identifier: Identifier{Identifier: "I"}},
+						},
+					},
+				},
+			},
+			TypeAnnotation: &TypeAnnotation{
+				IsResource: true,
+				Type: &ConstantSizedType{
+					Type: &NominalType{
+						Identifier:        Identifier{Identifier: "A"},
+						NestedIdentifiers: []Identifier{{Identifier: "B"}},
+					},
+					Size: &IntegerExpression{
+						PositiveLiteral: "2",
+						Value:           big.NewInt(2),
+						Base:            10,
+					},
+				},
+			},
+		}
+
+		assert.Equal(t,
+			"(CastingExpression as? @ (ConstantSizedType 2 (NominalType A.B)) "+
+				"(ReferenceExpression (ReferenceType auth (RestrictedType (NominalType I))) "+
+				"(IdentifierExpression r)))",
+			SExpr(expression),
+		)
+	})
+
+	t.Run("statements", func(t *testing.T) {
+
+		t.Parallel()
+
+		statement := &IfStatement{
+			Test: &BoolExpression{Value: true},
+			Then: &Block{
+				Statements: []Statement{
+					&ReturnStatement{
+						Expression: &NilExpression{},
+					},
+				},
+			},
+		}
+
+		assert.Equal(t,
+			"(IfStatement (BoolExpression true) (Block (ReturnStatement (NilExpression))))",
+			SExpr(statement),
+		)
+	})
+}
@@ -52,6 +52,10 @@ const returnStatementKeywordDoc = prettier.Text("return")
 const returnStatementKeywordSpaceDoc = prettier.Text("return ")
 
 func (s *ReturnStatement) Doc() prettier.Doc {
+	return s.doc(docContext{})
+}
+
+func (s *ReturnStatement) doc(context docContext) prettier.Doc {
 	if s.Expression == nil {
 		return returnStatementKeywordDoc
 	}
@@ -59,7 +63,7 @@ func (s *ReturnStatement) Doc() prettier.Doc {
 	return prettier.Concat{
 		returnStatementKeywordSpaceDoc,
 		// TODO: potentially parenthesize
-		s.Expression.Doc(),
+		expressionDoc(s.Expression, context),
 	}
 }
 
@@ -344,6 +348,11 @@ func (s *AssignmentStatement) MarshalJSON() ([]byte, error) {
 
 // SwapStatement
 
+// SwapStatement is a swap of the values of two targets, e.g. `a <-> b`.
+//
+// Swaps are statements, not expressions, so there is no swap expression node.
+// Likewise, forced moves, e.g. `a <-! b`, are assignment statements
+// with the transfer operation TransferOperationMoveForced.
 type SwapStatement struct {
 	Left  Expression
 	Right Expression
@@ -368,6 +377,42 @@ func (s *SwapStatement) Walk(walkChild func(Element)) {
 	walkChild(s.Right)
 }
 
+const swapStatementOperator = "<->"
+
+func (s *SwapStatement) String() string {
+	return joinStrings(
+		"",
+		[]string{
+			expressionStringOrPlaceholder(s.Left),
+			expressionStringOrPlaceholder(s.Right),
+		},
+		" "+swapStatementOperator+" ",
+		"",
+	)
+}
+
+var swapStatementOperatorDoc prettier.Doc = prettier.Text(swapStatementOperator)
+
+func (s *SwapStatement) Doc() prettier.Doc {
+	return s.doc(docContext{})
+}
+
+func (s *SwapStatement) doc(context docContext) prettier.Doc {
+	return prettier.Group{
+		Doc: prettier.Concat{
+			prettier.Group{
+				Doc: expressionDoc(s.Left, context),
+			},
+			prettier.Line{},
+			swapStatementOperatorDoc,
+			prettier.Space,
+			prettier.Group{
+				Doc: expressionDoc(s.Right, context),
+			},
+		},
+	}
+}
+
 func (s *SwapStatement) MarshalJSON() ([]byte, error) {
 	type Alias SwapStatement
 	return json.Marshal(&struct {
@@ -470,6 +470,54 @@ func TestSwapStatement_MarshalJSON(t *testing.T) {
 	)
 }
 
+func TestSwapStatement_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	stmt := &SwapStatement{
+		Left:  newTestIdentifierExpression("a"),
+		Right: newTestIdentifierExpression("b"),
+	}
+
+	assert.Equal(t,
+		prettier.Group{
+			Doc: prettier.Concat{
+				prettier.Group{
+					Doc: prettier.Text("a"),
+				},
+				prettier.Line{},
+				prettier.Text("<->"),
+				prettier.Space,
+				prettier.Group{
+					Doc: prettier.Text("b"),
+				},
+			},
+		},
+		stmt.Doc(),
+	)
+
+	assert.Equal(t, "a <-> b", testDocString(stmt.Doc()))
+}
+
+func TestSwapStatement_String(t *testing.T) {
+
+	t.Parallel()
+
+	stmt := &SwapStatement{
+		Left: newTestIdentifierExpression("a"),
+		Right: &MemberExpression{
+			Expression: newTestIdentifierExpression("b"),
+			Identifier: Identifier{Identifier: "c"},
+		},
+	}
+
+	assert.Equal(t, "a <-> b.c", stmt.String())
+
+	// Missing targets are written as placeholders
+
+	assert.Equal(t, "_ <-> _", (&SwapStatement{}).String())
+}
+
 func TestEmitStatement_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -19,6 +19,7 @@
 package ast
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -27,6 +28,14 @@ import (
 func QuoteString(s string) string {
 	var b strings.Builder
 	b.WriteByte('"')
+	writeEscapedString(&b, s)
+	b.WriteByte('"')
+	return b.String()
+}
+
+// writeEscapedString writes the given string to the builder,
+// escaping all characters which may not appear literally in a string literal
+func writeEscapedString(b *strings.Builder, s string) {
 	for _, r := range s {
 		switch r {
 		case 0:
@@ -56,6 +65,120 @@ func QuoteString(s string) string {
 			}
 		}
 	}
-	b.WriteByte('"')
-	return b.String()
+}
+
+// UnquoteString is the inverse of QuoteString:
+// It returns the value of the given string literal, which must be enclosed in double quotes.
+//
+// The escape sequences `\0`, `\n`, `\r`, `\t`, `\"`, `\'`, `\\`,
+// and Unicode escape sequences with one to eight hexadecimal digits, e.g. `\u{1F496}`, are supported.
+// An error is returned for invalid or incomplete escape sequences.
+func UnquoteString(literal string) (string, error) {
+	length := len(literal)
+	if length < 2 || literal[0] != '"' || literal[length-1] != '"' {
+		return "", fmt.Errorf("invalid string literal: missing enclosing '\"'")
+	}
+
+	s := literal[1 : length-1]
+
+	var builder strings.Builder
+	builder.Grow(len(s))
+
+	for index := 0; index < len(s); {
+		r, width := utf8.DecodeRuneInString(s[index:])
+		index += width
+
+		if r != '\\' {
+			if r == '"' {
+				return "", fmt.Errorf("invalid string literal: unescaped '\"'")
+			}
+			builder.WriteString(s[index-width : index])
+			continue
+		}
+
+		if index >= len(s) {
+			return "", fmt.Errorf("incomplete escape sequence: missing character after escape character")
+		}
+
+		escaped := s[index]
+		index++
+
+		switch escaped {
+		case '0':
+			builder.WriteByte(0)
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 't':
+			builder.WriteByte('\t')
+		case '"':
+			builder.WriteByte('"')
+		case '\'':
+			builder.WriteByte('\'')
+		case '\\':
+			builder.WriteByte('\\')
+		case 'u':
+			if index >= len(s) || s[index] != '{' {
+				return "", fmt.Errorf("invalid Unicode escape sequence: expected '{'")
+			}
+			index++
+
+			end := strings.IndexByte(s[index:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("incomplete Unicode escape sequence: missing '}'")
+			}
+
+			digits := s[index : index+end]
+			index += end + 1
+
+			if len(digits) == 0 || len(digits) > 8 {
+				return "", fmt.Errorf(
+					"invalid Unicode escape sequence: expected one to eight hex digits, got %q",
+					digits,
+				)
+			}
+
+			codePoint, err := strconv.ParseUint(digits, 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid Unicode escape sequence: invalid hex digits %q", digits)
+			}
+
+			if !utf8.ValidRune(rune(codePoint)) {
+				return "", fmt.Errorf("invalid Unicode escape sequence: invalid code point %q", digits)
+			}
+
+			builder.WriteRune(rune(codePoint))
+
+		default:
+			return "", fmt.Errorf("invalid escape character: %q", escaped)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// joinStrings joins the given strings with the given separator,
+// and surrounds the result with the given prefix and suffix.
+// The builder is pre-sized, as the result might be large.
+func joinStrings(prefix string, strs []string, separator string, suffix string) string {
+	length := len(prefix) + len(suffix)
+	for i, str := range strs {
+		if i > 0 {
+			length += len(separator)
+		}
+		length += len(str)
+	}
+
+	var builder strings.Builder
+	builder.Grow(length)
+	builder.WriteString(prefix)
+	for i, str := range strs {
+		if i > 0 {
+			builder.WriteString(separator)
+		}
+		builder.WriteString(str)
+	}
+	builder.WriteString(suffix)
+	return builder.String()
 }
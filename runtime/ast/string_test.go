@@ -23,6 +23,7 @@ import (
 	"testing/quick"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/onflow/cadence/runtime/ast"
 	"github.com/onflow/cadence/runtime/parser2"
@@ -90,3 +91,88 @@ func TestStringQuick(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUnquoteString(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+
+		t.Parallel()
+
+		type testCase struct {
+			literal  string
+			expected string
+		}
+
+		testCases := []testCase{
+			{`""`, ""},
+			{`"test xyz"`, "test xyz"},
+			{`"\0"`, "\x00"},
+			{`"\n"`, "\n"},
+			{`"\r"`, "\r"},
+			{`"\t"`, "\t"},
+			{`"\""`, `"`},
+			{`"\'"`, `'`},
+			{`"\\"`, `\`},
+			{`"\u{1f496}"`, "\U0001f496"},
+			{`"\u{A9}"`, "\u00a9"},
+			{`"\u{0}"`, "\x00"},
+			{`"\u{00002603}"`, "☃"},
+			{`"a\tb\u{2603}c"`, "a\tb\u2603c"},
+			{`"☃"`, "\u2603"},
+		}
+
+		for _, testCase := range testCases {
+			value, err := ast.UnquoteString(testCase.literal)
+			require.NoError(t, err, testCase.literal)
+			assert.Equal(t, testCase.expected, value, testCase.literal)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+
+		t.Parallel()
+
+		type testCase struct {
+			literal string
+			err     string
+		}
+
+		testCases := []testCase{
+			{``, `invalid string literal: missing enclosing '"'`},
+			{`"`, `invalid string literal: missing enclosing '"'`},
+			{`abc`, `invalid string literal: missing enclosing '"'`},
+			{`"abc`, `invalid string literal: missing enclosing '"'`},
+			{`"a"b"`, `invalid string literal: unescaped '"'`},
+			{`"\"`, `incomplete escape sequence: missing character after escape character`},
+			{`"\x"`, `invalid escape character: 'x'`},
+			{`"\u"`, `invalid Unicode escape sequence: expected '{'`},
+			{`"\u1234"`, `invalid Unicode escape sequence: expected '{'`},
+			{`"\u{1234"`, `incomplete Unicode escape sequence: missing '}'`},
+			{`"\u{}"`, `invalid Unicode escape sequence: expected one to eight hex digits, got ""`},
+			{`"\u{123456789}"`, `invalid Unicode escape sequence: expected one to eight hex digits, got "123456789"`},
+			{`"\u{xyz}"`, `invalid Unicode escape sequence: invalid hex digits "xyz"`},
+			{`"\u{110000}"`, `invalid Unicode escape sequence: invalid code point "110000"`},
+			{`"\u{D800}"`, `invalid Unicode escape sequence: invalid code point "D800"`},
+		}
+
+		for _, testCase := range testCases {
+			_, err := ast.UnquoteString(testCase.literal)
+			require.EqualError(t, err, testCase.err, testCase.literal)
+		}
+	})
+}
+
+func TestUnquoteStringQuick(t *testing.T) {
+	f := func(text string) bool {
+		value, err := ast.UnquoteString(ast.QuoteString(text))
+		if err != nil {
+			return false
+		}
+		return value == text
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,170 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"github.com/turbolent/prettier"
+)
+
+// Trivia are the comments attached to an element.
+type Trivia struct {
+	// Leading are the comments before the element
+	Leading []*Comment
+	// Trailing are the comments after the element
+	Trailing []*Comment
+}
+
+// TriviaMap is a side table of the comments attached to elements.
+//
+// The AST does not contain comments. A TriviaMap can be passed to Format
+// in FormatOptions, so the attached comments are emitted along with the elements.
+type TriviaMap map[Element]*Trivia
+
+func (m TriviaMap) trivia(element Element) *Trivia {
+	trivia, ok := m[element]
+	if !ok {
+		trivia = &Trivia{}
+		m[element] = trivia
+	}
+	return trivia
+}
+
+// AddLeading attaches the given comments before the given element.
+func (m TriviaMap) AddLeading(element Element, comments ...*Comment) {
+	trivia := m.trivia(element)
+	trivia.Leading = append(trivia.Leading, comments...)
+}
+
+// AddTrailing attaches the given comments after the given element.
+func (m TriviaMap) AddTrailing(element Element, comments ...*Comment) {
+	trivia := m.trivia(element)
+	trivia.Trailing = append(trivia.Trailing, comments...)
+}
+
+// doc returns the given document of the given element,
+// surrounded by the comments attached to the element.
+//
+// Leading block comments are separated from the element by a space,
+// leading line comments by a line break.
+// Trailing comments are separated from the element by a space.
+//
+// A trailing line comment extends to the end of the line,
+// so unless the element is known to be terminated by a line break anyway
+// (e.g. statements), a line break is added after it.
+func (m TriviaMap) doc(element Element, doc prettier.Doc, terminated bool) prettier.Doc {
+	trivia := m[element]
+	if trivia == nil ||
+		(len(trivia.Leading) == 0 && len(trivia.Trailing) == 0) {
+
+		return doc
+	}
+
+	doc = m.leadingDoc(element, doc)
+	if len(trivia.Trailing) == 0 {
+		return doc
+	}
+
+	return prettier.Concat{
+		doc,
+		trailingCommentsDoc(trivia.Trailing, terminated),
+	}
+}
+
+// leadingDoc returns the given document of the given element,
+// preceded by the leading comments attached to the element.
+func (m TriviaMap) leadingDoc(element Element, doc prettier.Doc) prettier.Doc {
+	trivia := m[element]
+	if trivia == nil || len(trivia.Leading) == 0 {
+		return doc
+	}
+
+	result := make(prettier.Concat, 0, len(trivia.Leading)*2+1)
+
+	for _, comment := range trivia.Leading {
+		var separatorDoc prettier.Doc = prettier.HardLine{}
+		if comment.Block {
+			separatorDoc = prettier.Space
+		}
+		result = append(result, comment.Doc(), separatorDoc)
+	}
+
+	return append(result, doc)
+}
+
+// trailingCommentsDoc returns the document for the given trailing comments,
+// each preceded by a space.
+func trailingCommentsDoc(comments []*Comment, terminated bool) prettier.Doc {
+	result := make(prettier.Concat, 0, len(comments)*3)
+
+	for i, comment := range comments {
+		result = append(result, prettier.Space, comment.Doc())
+
+		if comment.Block {
+			continue
+		}
+
+		isLast := i == len(comments)-1
+		if !isLast || !terminated {
+			result = append(result, prettier.HardLine{})
+		}
+	}
+
+	return result
+}
+
+// hasLineComments returns true if a line comment is attached
+// to any of the given elements, or to any element nested in them.
+func (m TriviaMap) hasLineComments(elements ...Element) bool {
+	if len(m) == 0 {
+		return false
+	}
+
+	found := false
+	for _, element := range elements {
+		if element == nil {
+			continue
+		}
+
+		Inspect(element, func(element Element) bool {
+			if found || element == nil {
+				return false
+			}
+
+			trivia := m[element]
+			if trivia != nil {
+				for _, comments := range [][]*Comment{trivia.Leading, trivia.Trailing} {
+					for _, comment := range comments {
+						if !comment.Block {
+							found = true
+							return false
+						}
+					}
+				}
+			}
+
+			return true
+		})
+
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
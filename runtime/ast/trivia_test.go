@@ -0,0 +1,303 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriviaMap(t *testing.T) {
+
+	t.Parallel()
+
+	a := newTestIdentifierExpression("a")
+
+	first := &Comment{Text: " first"}
+	second := &Comment{Text: " second", Block: true}
+	third := &Comment{Text: " third"}
+
+	trivia := TriviaMap{}
+	trivia.AddLeading(a, first, second)
+	trivia.AddTrailing(a, third)
+
+	assert.Equal(t,
+		TriviaMap{
+			a: {
+				Leading:  []*Comment{first, second},
+				Trailing: []*Comment{third},
+			},
+		},
+		trivia,
+	)
+}
+
+func TestFormat_Trivia(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("expression", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := newTestIdentifierExpression("a")
+		b := newTestIdentifierExpression("b")
+
+		expr := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      a,
+			Right:     b,
+		}
+
+		trivia := TriviaMap{}
+		trivia.AddLeading(a, &Comment{Text: " leading ", Block: true})
+		trivia.AddTrailing(a, &Comment{Text: " trailing ", Block: true})
+		trivia.AddTrailing(b, &Comment{Text: " end"})
+
+		assert.Equal(t,
+			"/* leading */ a /* trailing */ + b // end",
+			Format(expr, FormatOptions{Trivia: trivia}),
+		)
+
+		// Without trivia, no comments are emitted
+
+		assert.Equal(t,
+			"a + b",
+			Format(expr, FormatOptions{}),
+		)
+	})
+
+	t.Run("member chain, invoked member", func(t *testing.T) {
+
+		t.Parallel()
+
+		// a.b(x).c, with a comment attached to the invoked member `a.b`
+
+		invokedMember := &MemberExpression{
+			Expression: newTestIdentifierExpression("a"),
+			Identifier: Identifier{Identifier: "b"},
+		}
+
+		expr := &MemberExpression{
+			Expression: &InvocationExpression{
+				InvokedExpression: invokedMember,
+				Arguments: Arguments{
+					{Expression: newTestIdentifierExpression("x")},
+				},
+			},
+			Identifier: Identifier{Identifier: "c"},
+		}
+
+		trivia := TriviaMap{}
+		trivia.AddTrailing(invokedMember, &Comment{Text: " trailing ", Block: true})
+
+		// The comment is emitted, and the arguments of the invocation are not lost
+
+		assert.Equal(t,
+			"a.b /* trailing */(x).c",
+			Format(expr, FormatOptions{Trivia: trivia}),
+		)
+	})
+
+	t.Run("trailing line comment in expression", func(t *testing.T) {
+
+		t.Parallel()
+
+		// The line comment must not comment out the rest of the expression
+
+		a := newTestIdentifierExpression("a")
+		b := newTestIdentifierExpression("b")
+
+		expr := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      a,
+			Right:     b,
+		}
+
+		trivia := TriviaMap{}
+		trivia.AddTrailing(a, &Comment{Text: " trailing"})
+
+		assert.Equal(t,
+			"a // trailing\n + b",
+			Format(expr, FormatOptions{Trivia: trivia}),
+		)
+	})
+
+	t.Run("statements", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := newTestIdentifierExpression("a")
+
+		statement := &ReturnStatement{
+			Expression: &ArrayExpression{
+				Values: []Expression{
+					a,
+					newTestIdentifierExpression("b"),
+				},
+			},
+		}
+
+		function := &FunctionExpression{
+			FunctionBlock: &FunctionBlock{
+				Block: &Block{
+					Statements: []Statement{
+						statement,
+					},
+				},
+			},
+		}
+
+		trivia := TriviaMap{}
+		trivia.AddLeading(statement, &Comment{Text: " leading"})
+		trivia.AddTrailing(statement, &Comment{Text: " trailing"})
+		trivia.AddLeading(a, &Comment{Text: " first ", Block: true})
+
+		assert.Equal(t,
+			`fun () {
+    // leading
+    return [/* first */ a, b] // trailing
+}`,
+			Format(function, FormatOptions{Trivia: trivia}),
+		)
+
+		assert.Equal(t,
+			`fun () {
+    // leading
+    return [
+        /* first */ a,
+        b
+    ] // trailing
+}`,
+			Format(function, FormatOptions{
+				MaxLineWidth: 20,
+				Trivia:       trivia,
+			}),
+		)
+	})
+
+	t.Run("member chain", func(t *testing.T) {
+
+		t.Parallel()
+
+		// a.b.c, with a comment after a.b
+
+		inner := &MemberExpression{
+			Expression: newTestIdentifierExpression("a"),
+			Identifier: Identifier{Identifier: "b"},
+		}
+
+		expr := &MemberExpression{
+			Expression: inner,
+			Identifier: Identifier{Identifier: "c"},
+		}
+
+		trivia := TriviaMap{}
+		trivia.AddTrailing(inner, &Comment{Text: " inner ", Block: true})
+
+		assert.Equal(t,
+			"a.b /* inner */.c",
+			Format(expr, FormatOptions{Trivia: trivia}),
+		)
+	})
+	t.Run("list, trailing line comment", func(t *testing.T) {
+
+		t.Parallel()
+
+		// The line comment forces the list to break,
+		// and the separators are emitted before the comments
+
+		a := newTestIdentifierExpression("a")
+		c := newTestIdentifierExpression("c")
+
+		expr := &ArrayExpression{
+			Values: []Expression{
+				a,
+				newTestIdentifierExpression("b"),
+				c,
+			},
+		}
+
+		trivia := TriviaMap{}
+		trivia.AddTrailing(a, &Comment{Text: " first"})
+		trivia.AddTrailing(c, &Comment{Text: " last"})
+
+		assert.Equal(t,
+			"[\n    a, // first\n    b,\n    c // last\n]",
+			Format(expr, FormatOptions{Trivia: trivia}),
+		)
+	})
+
+	t.Run("list, trailing line comment, trailing comma", func(t *testing.T) {
+
+		t.Parallel()
+
+		c := newTestIdentifierExpression("c")
+
+		expr := &InvocationExpression{
+			InvokedExpression: newTestIdentifierExpression("f"),
+			Arguments: Arguments{
+				{Expression: newTestIdentifierExpression("a")},
+				{Expression: newTestIdentifierExpression("b")},
+				{Label: "x", Expression: c},
+			},
+		}
+
+		trivia := TriviaMap{}
+		trivia.AddTrailing(c, &Comment{Text: " last"})
+
+		assert.Equal(t,
+			"f(\n    a,\n    b,\n    x: c, // last\n)",
+			Format(expr, FormatOptions{
+				Trivia:        trivia,
+				TrailingComma: true,
+			}),
+		)
+	})
+
+	t.Run("list, nested line comment", func(t *testing.T) {
+
+		t.Parallel()
+
+		// A line comment attached to an element nested in a list element
+		// also forces the list to break
+
+		b := newTestIdentifierExpression("b")
+
+		expr := &DictionaryExpression{
+			Entries: []DictionaryEntry{
+				{
+					Key: &ArrayExpression{
+						Values: []Expression{b},
+					},
+					Value: newTestIdentifierExpression("c"),
+				},
+			},
+		}
+
+		trivia := TriviaMap{}
+		trivia.AddLeading(b, &Comment{Text: " leading"})
+
+		assert.Equal(t,
+			"{\n    [\n        // leading\n        b\n    ]: c\n}",
+			Format(expr, FormatOptions{Trivia: trivia}),
+		)
+	})
+}
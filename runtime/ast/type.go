@@ -22,6 +22,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/turbolent/prettier"
 )
 
 // TypeAnnotation
@@ -39,6 +41,19 @@ func (t *TypeAnnotation) String() string {
 	return fmt.Sprint(t.Type)
 }
 
+var typeAnnotationResourceSymbolDoc prettier.Doc = prettier.Text("@")
+
+func (t *TypeAnnotation) Doc() prettier.Doc {
+	if !t.IsResource {
+		return t.Type.Doc()
+	}
+
+	return prettier.Concat{
+		typeAnnotationResourceSymbolDoc,
+		t.Type.Doc(),
+	}
+}
+
 func (t *TypeAnnotation) StartPosition() Position {
 	return t.StartPos
 }
@@ -58,13 +73,32 @@ func (t *TypeAnnotation) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// Type
+// UnmarshalJSON decodes the type annotation from its JSON representation,
+// as produced by MarshalJSON. The annotated type is decoded using UnmarshalType.
+func (t *TypeAnnotation) UnmarshalJSON(data []byte) error {
+	typeAnnotation, err := unmarshalTypeAnnotation(data)
+	if err != nil {
+		return err
+	}
+	if typeAnnotation == nil {
+		return nil
+	}
+	*t = *typeAnnotation
+	return nil
+}
 
+// Type
+//
+// NOTE: Doc is part of the interface, so all types can be pretty-printed uniformly.
+// Implementations outside of this package must provide it as well.
 type Type interface {
 	HasPosition
 	fmt.Stringer
 	isType()
+	Doc() prettier.Doc
+	Walk(walkChild func(Type))
 	CheckEqual(other Type, checker TypeEqualityChecker) error
+	Clone() Type
 }
 
 func IsEmptyType(t Type) bool {
@@ -72,6 +106,51 @@ func IsEmptyType(t Type) bool {
 	return ok && nominalType.Identifier.Identifier == ""
 }
 
+// ContainsResourceType returns true if the given type is a resource type,
+// or contains a resource type, e.g. as the element type of an optional, array, or dictionary.
+//
+// The AST does not know which named types are resources,
+// so the given isResource function is called for nominal types.
+//
+// Restricted types are resources if their restricted type or any restriction is a resource,
+// and instantiated types are resources if their instantiated type is a resource.
+// References and functions are never resources.
+func ContainsResourceType(ty Type, isResource func(*NominalType) bool) bool {
+	switch ty := ty.(type) {
+	case *NominalType:
+		return isResource(ty)
+
+	case *OptionalType:
+		return ContainsResourceType(ty.Type, isResource)
+
+	case *VariableSizedType:
+		return ContainsResourceType(ty.Type, isResource)
+
+	case *ConstantSizedType:
+		return ContainsResourceType(ty.Type, isResource)
+
+	case *DictionaryType:
+		return ContainsResourceType(ty.KeyType, isResource) ||
+			ContainsResourceType(ty.ValueType, isResource)
+
+	case *RestrictedType:
+		if ty.Type != nil && ContainsResourceType(ty.Type, isResource) {
+			return true
+		}
+		for _, restriction := range ty.Restrictions {
+			if isResource(restriction) {
+				return true
+			}
+		}
+		return false
+
+	case *InstantiationType:
+		return ContainsResourceType(ty.Type, isResource)
+	}
+
+	return false
+}
+
 // NominalType represents a named type
 
 type NominalType struct {
@@ -79,6 +158,39 @@ type NominalType struct {
 	NestedIdentifiers []Identifier `json:",omitempty"`
 }
 
+var _ Type = &NominalType{}
+
+// NewNominalTypeFromQualifiedName returns a nominal type for the given name,
+// which may be qualified, e.g. `Foo.Bar.Baz`.
+// The first part of the name is the identifier, and the other parts are the nested identifiers.
+//
+// The given position is the position of the first identifier,
+// and the positions of the nested identifiers are derived from it,
+// assuming the name has no whitespace.
+func NewNominalTypeFromQualifiedName(name string, pos Position) *NominalType {
+	parts := strings.Split(name, ".")
+
+	identifiers := make([]Identifier, len(parts))
+	for i, part := range parts {
+		identifiers[i] = Identifier{
+			Identifier: part,
+			Pos:        pos,
+		}
+		// Advance past the part and the dot
+		pos = pos.Shifted(len(part) + 1)
+	}
+
+	var nestedIdentifiers []Identifier
+	if len(identifiers) > 1 {
+		nestedIdentifiers = identifiers[1:]
+	}
+
+	return &NominalType{
+		Identifier:        identifiers[0],
+		NestedIdentifiers: nestedIdentifiers,
+	}
+}
+
 func (*NominalType) isType() {}
 
 func (t *NominalType) String() string {
@@ -91,6 +203,14 @@ func (t *NominalType) String() string {
 	return sb.String()
 }
 
+func (*NominalType) Walk(_ func(Type)) {
+	// NO-OP
+}
+
+func (t *NominalType) Doc() prettier.Doc {
+	return prettier.Text(t.String())
+}
+
 func (t *NominalType) StartPosition() Position {
 	return t.Identifier.StartPosition()
 }
@@ -125,6 +245,18 @@ func (t *NominalType) CheckEqual(other Type, checker TypeEqualityChecker) error
 	return checker.CheckNominalTypeEquality(t, other)
 }
 
+func (t *NominalType) Clone() Type {
+	var nestedIdentifiers []Identifier
+	if t.NestedIdentifiers != nil {
+		nestedIdentifiers = make([]Identifier, len(t.NestedIdentifiers))
+		copy(nestedIdentifiers, t.NestedIdentifiers)
+	}
+	return &NominalType{
+		Identifier:        t.Identifier,
+		NestedIdentifiers: nestedIdentifiers,
+	}
+}
+
 // OptionalType represents am optional variant of another type
 
 type OptionalType struct {
@@ -132,12 +264,27 @@ type OptionalType struct {
 	EndPos Position `json:"-"`
 }
 
+var _ Type = &OptionalType{}
+
 func (*OptionalType) isType() {}
 
 func (t *OptionalType) String() string {
 	return fmt.Sprintf("%s?", t.Type)
 }
 
+func (t *OptionalType) Walk(walkChild func(Type)) {
+	walkChild(t.Type)
+}
+
+var optionalTypeSymbolDoc prettier.Doc = prettier.Text("?")
+
+func (t *OptionalType) Doc() prettier.Doc {
+	return prettier.Concat{
+		t.Type.Doc(),
+		optionalTypeSymbolDoc,
+	}
+}
+
 func (t *OptionalType) StartPosition() Position {
 	return t.Type.StartPosition()
 }
@@ -163,6 +310,13 @@ func (t *OptionalType) CheckEqual(other Type, checker TypeEqualityChecker) error
 	return checker.CheckOptionalTypeEquality(t, other)
 }
 
+func (t *OptionalType) Clone() Type {
+	return &OptionalType{
+		Type:   cloneType(t.Type),
+		EndPos: t.EndPos,
+	}
+}
+
 // VariableSizedType is a variable sized array type
 
 type VariableSizedType struct {
@@ -170,12 +324,25 @@ type VariableSizedType struct {
 	Range
 }
 
+var _ Type = &VariableSizedType{}
+
 func (*VariableSizedType) isType() {}
 
 func (t *VariableSizedType) String() string {
 	return fmt.Sprintf("[%s]", t.Type)
 }
 
+func (t *VariableSizedType) Walk(walkChild func(Type)) {
+	walkChild(t.Type)
+}
+
+func (t *VariableSizedType) Doc() prettier.Doc {
+	return prettier.WrapBrackets(
+		t.Type.Doc(),
+		prettier.SoftLine{},
+	)
+}
+
 func (t *VariableSizedType) MarshalJSON() ([]byte, error) {
 	type Alias VariableSizedType
 	return json.Marshal(&struct {
@@ -191,6 +358,13 @@ func (t *VariableSizedType) CheckEqual(other Type, checker TypeEqualityChecker)
 	return checker.CheckVariableSizedTypeEquality(t, other)
 }
 
+func (t *VariableSizedType) Clone() Type {
+	return &VariableSizedType{
+		Type:  cloneType(t.Type),
+		Range: t.Range,
+	}
+}
+
 // ConstantSizedType is a constant sized array type
 
 type ConstantSizedType struct {
@@ -199,12 +373,34 @@ type ConstantSizedType struct {
 	Range
 }
 
+var _ Type = &ConstantSizedType{}
+
 func (*ConstantSizedType) isType() {}
 
 func (t *ConstantSizedType) String() string {
 	return fmt.Sprintf("[%s; %s]", t.Type, t.Size)
 }
 
+func (t *ConstantSizedType) Walk(walkChild func(Type)) {
+	walkChild(t.Type)
+}
+
+var constantSizedTypeSeparatorDoc prettier.Doc = prettier.Concat{
+	prettier.Text(";"),
+	prettier.Line{},
+}
+
+func (t *ConstantSizedType) Doc() prettier.Doc {
+	return prettier.WrapBrackets(
+		prettier.Concat{
+			t.Type.Doc(),
+			constantSizedTypeSeparatorDoc,
+			t.Size.Doc(),
+		},
+		prettier.SoftLine{},
+	)
+}
+
 func (t *ConstantSizedType) MarshalJSON() ([]byte, error) {
 	type Alias ConstantSizedType
 	return json.Marshal(&struct {
@@ -220,6 +416,33 @@ func (t *ConstantSizedType) CheckEqual(other Type, checker TypeEqualityChecker)
 	return checker.CheckConstantSizedTypeEquality(t, other)
 }
 
+func (t *ConstantSizedType) Clone() Type {
+	var size *IntegerExpression
+	if t.Size != nil {
+		size = t.Size.Clone().(*IntegerExpression)
+	}
+	return &ConstantSizedType{
+		Type:  cloneType(t.Type),
+		Size:  size,
+		Range: t.Range,
+	}
+}
+
+// Length returns the size of the constant sized type,
+// and false if the size is missing, negative, or does not fit into an int64
+func (t *ConstantSizedType) Length() (int64, bool) {
+	if t.Size == nil || t.Size.Value == nil {
+		return 0, false
+	}
+
+	value := t.Size.Value
+	if value.Sign() < 0 || !value.IsInt64() {
+		return 0, false
+	}
+
+	return value.Int64(), true
+}
+
 // DictionaryType
 
 type DictionaryType struct {
@@ -228,12 +451,30 @@ type DictionaryType struct {
 	Range
 }
 
+var _ Type = &DictionaryType{}
+
 func (*DictionaryType) isType() {}
 
 func (t *DictionaryType) String() string {
 	return fmt.Sprintf("{%s: %s}", t.KeyType, t.ValueType)
 }
 
+func (t *DictionaryType) Walk(walkChild func(Type)) {
+	walkChild(t.KeyType)
+	walkChild(t.ValueType)
+}
+
+func (t *DictionaryType) Doc() prettier.Doc {
+	return prettier.WrapBraces(
+		prettier.Concat{
+			t.KeyType.Doc(),
+			dictionaryKeyValueSeparatorDoc,
+			t.ValueType.Doc(),
+		},
+		prettier.SoftLine{},
+	)
+}
+
 func (t *DictionaryType) MarshalJSON() ([]byte, error) {
 	type Alias DictionaryType
 	return json.Marshal(&struct {
@@ -249,6 +490,14 @@ func (t *DictionaryType) CheckEqual(other Type, checker TypeEqualityChecker) err
 	return checker.CheckDictionaryTypeEquality(t, other)
 }
 
+func (t *DictionaryType) Clone() Type {
+	return &DictionaryType{
+		KeyType:   cloneType(t.KeyType),
+		ValueType: cloneType(t.ValueType),
+		Range:     t.Range,
+	}
+}
+
 // FunctionType
 
 type FunctionType struct {
@@ -257,6 +506,8 @@ type FunctionType struct {
 	Range
 }
 
+var _ Type = &FunctionType{}
+
 func (*FunctionType) isType() {}
 
 func (t *FunctionType) String() string {
@@ -271,6 +522,43 @@ func (t *FunctionType) String() string {
 	return fmt.Sprintf("((%s): %s)", parameters.String(), t.ReturnTypeAnnotation.String())
 }
 
+func (t *FunctionType) Walk(walkChild func(Type)) {
+	for _, parameterTypeAnnotation := range t.ParameterTypeAnnotations {
+		walkChild(parameterTypeAnnotation.Type)
+	}
+	walkChild(t.ReturnTypeAnnotation.Type)
+}
+
+var functionTypeParameterSeparatorDoc prettier.Doc = prettier.Concat{
+	prettier.Text(","),
+	prettier.Line{},
+}
+
+func (t *FunctionType) Doc() prettier.Doc {
+	var parametersDoc prettier.Doc
+	if len(t.ParameterTypeAnnotations) == 0 {
+		parametersDoc = prettier.Text("()")
+	} else {
+		parameterDocs := make([]prettier.Doc, len(t.ParameterTypeAnnotations))
+		for i, parameterTypeAnnotation := range t.ParameterTypeAnnotations {
+			parameterDocs[i] = parameterTypeAnnotation.Doc()
+		}
+		parametersDoc = prettier.WrapParentheses(
+			prettier.Join(functionTypeParameterSeparatorDoc, parameterDocs...),
+			prettier.SoftLine{},
+		)
+	}
+
+	return prettier.WrapParentheses(
+		prettier.Concat{
+			parametersDoc,
+			typeSeparatorDoc,
+			t.ReturnTypeAnnotation.Doc(),
+		},
+		prettier.SoftLine{},
+	)
+}
+
 func (t *FunctionType) MarshalJSON() ([]byte, error) {
 	type Alias FunctionType
 	return json.Marshal(&struct {
@@ -286,6 +574,45 @@ func (t *FunctionType) CheckEqual(other Type, checker TypeEqualityChecker) error
 	return checker.CheckFunctionTypeEquality(t, other)
 }
 
+func (t *FunctionType) Clone() Type {
+	return &FunctionType{
+		ParameterTypeAnnotations: cloneTypeAnnotations(t.ParameterTypeAnnotations),
+		ReturnTypeAnnotation:     cloneTypeAnnotation(t.ReturnTypeAnnotation),
+		Range:                    t.Range,
+	}
+}
+
+// Arity returns the number of parameters of the function type
+func (t *FunctionType) Arity() int {
+	return len(t.ParameterTypeAnnotations)
+}
+
+// ParameterType returns the type annotation of the parameter at the given index,
+// and false if the function type has no such parameter
+func (t *FunctionType) ParameterType(i int) (*TypeAnnotation, bool) {
+	if i < 0 || i >= len(t.ParameterTypeAnnotations) {
+		return nil, false
+	}
+	return t.ParameterTypeAnnotations[i], true
+}
+
+// IsVoidReturn returns true if the function type does not return a value,
+// i.e. if its return type annotation is missing or empty, or if the return type is `Void`
+func (t *FunctionType) IsVoidReturn() bool {
+	returnTypeAnnotation := t.ReturnTypeAnnotation
+	if returnTypeAnnotation == nil ||
+		returnTypeAnnotation.Type == nil ||
+		IsEmptyType(returnTypeAnnotation.Type) {
+
+		return true
+	}
+
+	nominalType, ok := returnTypeAnnotation.Type.(*NominalType)
+	return ok &&
+		len(nominalType.NestedIdentifiers) == 0 &&
+		nominalType.Identifier.Identifier == "Void"
+}
+
 // ReferenceType
 
 type ReferenceType struct {
@@ -294,6 +621,8 @@ type ReferenceType struct {
 	StartPos   Position `json:"-"`
 }
 
+var _ Type = &ReferenceType{}
+
 func (*ReferenceType) isType() {}
 
 func (t *ReferenceType) String() string {
@@ -306,6 +635,24 @@ func (t *ReferenceType) String() string {
 	return builder.String()
 }
 
+func (t *ReferenceType) Walk(walkChild func(Type)) {
+	walkChild(t.Type)
+}
+
+var referenceTypeAuthKeywordDoc prettier.Doc = prettier.Text("auth ")
+var referenceTypeSymbolDoc prettier.Doc = prettier.Text("&")
+
+func (t *ReferenceType) Doc() prettier.Doc {
+	var doc prettier.Concat
+	if t.Authorized {
+		doc = append(doc, referenceTypeAuthKeywordDoc)
+	}
+	return append(doc,
+		referenceTypeSymbolDoc,
+		t.Type.Doc(),
+	)
+}
+
 func (t *ReferenceType) StartPosition() Position {
 	return t.StartPos
 }
@@ -331,6 +678,14 @@ func (t *ReferenceType) CheckEqual(other Type, checker TypeEqualityChecker) erro
 	return checker.CheckReferenceTypeEquality(t, other)
 }
 
+func (t *ReferenceType) Clone() Type {
+	return &ReferenceType{
+		Authorized: t.Authorized,
+		Type:       cloneType(t.Type),
+		StartPos:   t.StartPos,
+	}
+}
+
 // RestrictedType
 
 type RestrictedType struct {
@@ -339,6 +694,8 @@ type RestrictedType struct {
 	Range
 }
 
+var _ Type = &RestrictedType{}
+
 func (*RestrictedType) isType() {}
 
 func (t *RestrictedType) String() string {
@@ -357,6 +714,44 @@ func (t *RestrictedType) String() string {
 	return builder.String()
 }
 
+func (t *RestrictedType) Walk(walkChild func(Type)) {
+	if t.Type != nil {
+		walkChild(t.Type)
+	}
+	for _, restriction := range t.Restrictions {
+		walkChild(restriction)
+	}
+}
+
+var restrictedTypeSeparatorDoc prettier.Doc = prettier.Concat{
+	prettier.Text(","),
+	prettier.Line{},
+}
+var restrictedTypeEmptyRestrictionsDoc prettier.Doc = prettier.Text("{}")
+
+func (t *RestrictedType) Doc() prettier.Doc {
+	var doc prettier.Concat
+	if t.Type != nil {
+		doc = append(doc, t.Type.Doc())
+	}
+
+	if len(t.Restrictions) == 0 {
+		return append(doc, restrictedTypeEmptyRestrictionsDoc)
+	}
+
+	restrictionDocs := make([]prettier.Doc, len(t.Restrictions))
+	for i, restriction := range t.Restrictions {
+		restrictionDocs[i] = restriction.Doc()
+	}
+
+	return append(doc,
+		prettier.WrapBraces(
+			prettier.Join(restrictedTypeSeparatorDoc, restrictionDocs...),
+			prettier.SoftLine{},
+		),
+	)
+}
+
 func (t *RestrictedType) MarshalJSON() ([]byte, error) {
 	type Alias RestrictedType
 	return json.Marshal(&struct {
@@ -372,6 +767,21 @@ func (t *RestrictedType) CheckEqual(other Type, checker TypeEqualityChecker) err
 	return checker.CheckRestrictedTypeEquality(t, other)
 }
 
+func (t *RestrictedType) Clone() Type {
+	var restrictions []*NominalType
+	if t.Restrictions != nil {
+		restrictions = make([]*NominalType, len(t.Restrictions))
+		for i, restriction := range t.Restrictions {
+			restrictions[i] = restriction.Clone().(*NominalType)
+		}
+	}
+	return &RestrictedType{
+		Type:         cloneType(t.Type),
+		Restrictions: restrictions,
+		Range:        t.Range,
+	}
+}
+
 // InstantiationType represents an instantiation of a generic (nominal) type
 
 type InstantiationType struct {
@@ -381,6 +791,8 @@ type InstantiationType struct {
 	EndPos                Position `json:"-"`
 }
 
+var _ Type = &InstantiationType{}
+
 func (*InstantiationType) isType() {}
 
 func (t *InstantiationType) String() string {
@@ -397,6 +809,42 @@ func (t *InstantiationType) String() string {
 	return sb.String()
 }
 
+func (t *InstantiationType) Walk(walkChild func(Type)) {
+	walkChild(t.Type)
+	for _, typeArgument := range t.TypeArguments {
+		walkChild(typeArgument.Type)
+	}
+}
+
+var instantiationTypeSeparatorDoc prettier.Doc = prettier.Concat{
+	prettier.Text(","),
+	prettier.Line{},
+}
+
+func (t *InstantiationType) Doc() prettier.Doc {
+	if len(t.TypeArguments) == 0 {
+		return prettier.Concat{
+			t.Type.Doc(),
+			prettier.Text("<>"),
+		}
+	}
+
+	typeArgumentDocs := make([]prettier.Doc, len(t.TypeArguments))
+	for i, typeArgument := range t.TypeArguments {
+		typeArgumentDocs[i] = typeArgument.Doc()
+	}
+
+	return prettier.Concat{
+		t.Type.Doc(),
+		prettier.Wrap(
+			prettier.Text("<"),
+			prettier.Join(instantiationTypeSeparatorDoc, typeArgumentDocs...),
+			prettier.Text(">"),
+			prettier.SoftLine{},
+		),
+	}
+}
+
 func (t *InstantiationType) StartPosition() Position {
 	return t.Type.StartPosition()
 }
@@ -422,6 +870,15 @@ func (t *InstantiationType) CheckEqual(other Type, checker TypeEqualityChecker)
 	return checker.CheckInstantiationTypeEquality(t, other)
 }
 
+func (t *InstantiationType) Clone() Type {
+	return &InstantiationType{
+		Type:                  cloneType(t.Type),
+		TypeArguments:         cloneTypeAnnotations(t.TypeArguments),
+		TypeArgumentsStartPos: t.TypeArgumentsStartPos,
+		EndPos:                t.EndPos,
+	}
+}
+
 type TypeEqualityChecker interface {
 	CheckNominalTypeEquality(*NominalType, Type) error
 	CheckOptionalTypeEquality(*OptionalType, Type) error
@@ -0,0 +1,363 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypeMismatchError is returned by the DefaultTypeEqualityChecker
+// for the first pair of types which are not equal
+type TypeMismatchError struct {
+	Expected Type
+	Found    Type
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf(
+		"type mismatch. expected `%s`, found `%s`",
+		typeString(e.Expected),
+		typeString(e.Found),
+	)
+}
+
+// TypeCycleError is returned by the DefaultTypeEqualityChecker
+// if a type contains itself, i.e. if checking the equality of a pair of types
+// requires checking the equality of the same pair again.
+//
+// NOTE: The types are not included in the message,
+// as the string representation of a cyclic type is infinite.
+type TypeCycleError struct {
+	Expected Type
+	Found    Type
+}
+
+func (e *TypeCycleError) Error() string {
+	return fmt.Sprintf(
+		"cannot check type equality: cyclic type. expected %T, found %T",
+		e.Expected,
+		e.Found,
+	)
+}
+
+func typeString(ty Type) string {
+	if ty == nil {
+		return "<nil>"
+	}
+	return ty.String()
+}
+
+// DefaultTypeEqualityChecker is a TypeEqualityChecker
+// which compares types structurally, ignoring positions.
+//
+// By default, the restrictions of restricted types are compared in order.
+// If IgnoreRestrictionOrder is set, they are compared as a set instead.
+// The sizes of constant sized types are compared by value.
+//
+// Cyclic types, which can only be constructed programmatically,
+// are detected, and a TypeCycleError is returned for them.
+type DefaultTypeEqualityChecker struct {
+	// IgnoreRestrictionOrder compares the restrictions of restricted types
+	// regardless of their order, e.g. `R{A, B}` and `R{B, A}` are equal.
+	// Duplicate restrictions are still significant, e.g. `R{A, A}` and `R{A}` are not equal.
+	IgnoreRestrictionOrder bool
+	// checks are the pairs of types which are currently being checked,
+	// shared by the copies of the checker passed down during a check
+	checks *typeEqualityChecks
+}
+
+type typePair struct {
+	expected Type
+	found    Type
+}
+
+// typeEqualityChecks is the set of pairs of types whose equality check has started,
+// but not completed yet. A pair which is checked again while its check is in progress
+// indicates a cycle.
+type typeEqualityChecks struct {
+	pairs map[typePair]struct{}
+}
+
+var _ TypeEqualityChecker = DefaultTypeEqualityChecker{}
+
+// checkTypeEqual checks the equality of the given types using the given checker.
+// Unlike Type.CheckEqual, it also accepts nil types
+func checkTypeEqual(expected Type, found Type, checker TypeEqualityChecker) error {
+	if expected == nil || found == nil {
+		if expected == nil && found == nil {
+			return nil
+		}
+		return &TypeMismatchError{
+			Expected: expected,
+			Found:    found,
+		}
+	}
+	if identicalTypes(expected, found) {
+		return nil
+	}
+	return expected.CheckEqual(found, checker)
+}
+
+// identicalTypes returns true if the given types are the same node.
+//
+// NOTE: Only pointers are compared, as other implementations of Type may not be comparable
+func identicalTypes(a, b Type) bool {
+	return isPointerType(a) && a == b
+}
+
+func isPointerType(ty Type) bool {
+	return reflect.ValueOf(ty).Kind() == reflect.Ptr
+}
+
+func (c DefaultTypeEqualityChecker) checkEqual(expected Type, found Type) error {
+	// Only pointers can be part of a cycle, and only they are comparable
+	if !isPointerType(expected) || !isPointerType(found) {
+		return checkTypeEqual(expected, found, c)
+	}
+
+	if c.checks == nil {
+		c.checks = &typeEqualityChecks{
+			pairs: map[typePair]struct{}{},
+		}
+	}
+
+	pair := typePair{
+		expected: expected,
+		found:    found,
+	}
+
+	if _, ok := c.checks.pairs[pair]; ok {
+		return &TypeCycleError{
+			Expected: expected,
+			Found:    found,
+		}
+	}
+
+	c.checks.pairs[pair] = struct{}{}
+	defer delete(c.checks.pairs, pair)
+
+	return checkTypeEqual(expected, found, c)
+}
+
+func (c DefaultTypeEqualityChecker) checkTypeAnnotationEqual(expected *TypeAnnotation, found *TypeAnnotation) error {
+	if expected == nil || found == nil {
+		if expected == nil && found == nil {
+			return nil
+		}
+		return &TypeAnnotationMismatchError{
+			Expected: expected,
+			Found:    found,
+		}
+	}
+
+	if expected.IsResource != found.IsResource {
+		return &TypeAnnotationMismatchError{
+			Expected: expected,
+			Found:    found,
+		}
+	}
+
+	return c.checkEqual(expected.Type, found.Type)
+}
+
+func (c DefaultTypeEqualityChecker) checkTypeAnnotationsEqual(expected []*TypeAnnotation, found []*TypeAnnotation) error {
+	for i, expectedTypeAnnotation := range expected {
+		err := c.checkTypeAnnotationEqual(expectedTypeAnnotation, found[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func equalNominalTypes(expected *NominalType, found *NominalType) bool {
+	if expected.Identifier.Identifier != found.Identifier.Identifier ||
+		len(expected.NestedIdentifiers) != len(found.NestedIdentifiers) {
+
+		return false
+	}
+
+	for i, expectedIdentifier := range expected.NestedIdentifiers {
+		if expectedIdentifier.Identifier != found.NestedIdentifiers[i].Identifier {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c DefaultTypeEqualityChecker) CheckNominalTypeEquality(expected *NominalType, found Type) error {
+	foundNominalType, ok := found.(*NominalType)
+	if !ok || !equalNominalTypes(expected, foundNominalType) {
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	return nil
+}
+
+func (c DefaultTypeEqualityChecker) CheckOptionalTypeEquality(expected *OptionalType, found Type) error {
+	foundOptionalType, ok := found.(*OptionalType)
+	if !ok {
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	return c.checkEqual(expected.Type, foundOptionalType.Type)
+}
+
+func (c DefaultTypeEqualityChecker) CheckVariableSizedTypeEquality(expected *VariableSizedType, found Type) error {
+	foundVariableSizedType, ok := found.(*VariableSizedType)
+	if !ok {
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	return c.checkEqual(expected.Type, foundVariableSizedType.Type)
+}
+
+func (c DefaultTypeEqualityChecker) CheckConstantSizedTypeEquality(expected *ConstantSizedType, found Type) error {
+	foundConstantSizedType, ok := found.(*ConstantSizedType)
+	if !ok {
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	expectedSize := expected.Size
+	foundSize := foundConstantSizedType.Size
+	if expectedSize == nil || foundSize == nil {
+		if expectedSize != nil || foundSize != nil {
+			return &TypeMismatchError{Expected: expected, Found: found}
+		}
+	} else if !equalBigInts(expectedSize.Value, foundSize.Value) {
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	return c.checkEqual(expected.Type, foundConstantSizedType.Type)
+}
+
+func (c DefaultTypeEqualityChecker) CheckDictionaryTypeEquality(expected *DictionaryType, found Type) error {
+	foundDictionaryType, ok := found.(*DictionaryType)
+	if !ok {
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	err := c.checkEqual(expected.KeyType, foundDictionaryType.KeyType)
+	if err != nil {
+		return err
+	}
+
+	return c.checkEqual(expected.ValueType, foundDictionaryType.ValueType)
+}
+
+func (c DefaultTypeEqualityChecker) CheckFunctionTypeEquality(expected *FunctionType, found Type) error {
+	foundFunctionType, ok := found.(*FunctionType)
+	if !ok ||
+		len(expected.ParameterTypeAnnotations) != len(foundFunctionType.ParameterTypeAnnotations) {
+
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	err := c.checkTypeAnnotationsEqual(
+		expected.ParameterTypeAnnotations,
+		foundFunctionType.ParameterTypeAnnotations,
+	)
+	if err != nil {
+		return err
+	}
+
+	return c.checkTypeAnnotationEqual(
+		expected.ReturnTypeAnnotation,
+		foundFunctionType.ReturnTypeAnnotation,
+	)
+}
+
+func (c DefaultTypeEqualityChecker) CheckReferenceTypeEquality(expected *ReferenceType, found Type) error {
+	foundReferenceType, ok := found.(*ReferenceType)
+	if !ok || expected.Authorized != foundReferenceType.Authorized {
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	return c.checkEqual(expected.Type, foundReferenceType.Type)
+}
+
+func (c DefaultTypeEqualityChecker) CheckRestrictedTypeEquality(expected *RestrictedType, found Type) error {
+	foundRestrictedType, ok := found.(*RestrictedType)
+	if !ok ||
+		len(expected.Restrictions) != len(foundRestrictedType.Restrictions) {
+
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	var equalRestrictions bool
+	if c.IgnoreRestrictionOrder {
+		equalRestrictions = equalNominalTypeMultisets(expected.Restrictions, foundRestrictedType.Restrictions)
+	} else {
+		equalRestrictions = equalNominalTypeSequences(expected.Restrictions, foundRestrictedType.Restrictions)
+	}
+	if !equalRestrictions {
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	return c.checkEqual(expected.Type, foundRestrictedType.Type)
+}
+
+// equalNominalTypeSequences returns true if the given nominal types are equal in order.
+// Both slices must have the same length
+func equalNominalTypeSequences(expected []*NominalType, found []*NominalType) bool {
+	for i, expectedType := range expected {
+		if !equalNominalTypes(expectedType, found[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalNominalTypeMultisets returns true if the given nominal types are equal regardless of order,
+// i.e. each type occurs equally often in both. Both slices must have the same length
+func equalNominalTypeMultisets(expected []*NominalType, found []*NominalType) bool {
+	counts := make(map[string]int, len(expected))
+	for _, expectedType := range expected {
+		counts[expectedType.String()]++
+	}
+	for _, foundType := range found {
+		name := foundType.String()
+		if counts[name] == 0 {
+			return false
+		}
+		counts[name]--
+	}
+	return true
+}
+
+func (c DefaultTypeEqualityChecker) CheckInstantiationTypeEquality(expected *InstantiationType, found Type) error {
+	foundInstantiationType, ok := found.(*InstantiationType)
+	if !ok ||
+		len(expected.TypeArguments) != len(foundInstantiationType.TypeArguments) {
+
+		return &TypeMismatchError{Expected: expected, Found: found}
+	}
+
+	err := c.checkEqual(expected.Type, foundInstantiationType.Type)
+	if err != nil {
+		return err
+	}
+
+	return c.checkTypeAnnotationsEqual(
+		expected.TypeArguments,
+		foundInstantiationType.TypeArguments,
+	)
+}
@@ -0,0 +1,680 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTypeEqualityChecker(t *testing.T) {
+
+	t.Parallel()
+
+	// The offset is used to ensure positions are ignored
+
+	nominal := func(offset int, identifier string, nestedIdentifiers ...string) *NominalType {
+		ty := &NominalType{
+			Identifier: Identifier{
+				Identifier: identifier,
+				Pos:        Position{Offset: offset, Line: 1, Column: offset},
+			},
+		}
+		for _, nestedIdentifier := range nestedIdentifiers {
+			ty.NestedIdentifiers = append(ty.NestedIdentifiers,
+				Identifier{
+					Identifier: nestedIdentifier,
+					Pos:        Position{Offset: offset, Line: 1, Column: offset},
+				},
+			)
+		}
+		return ty
+	}
+
+	annotation := func(isResource bool, ty Type) *TypeAnnotation {
+		return &TypeAnnotation{
+			IsResource: isResource,
+			Type:       ty,
+		}
+	}
+
+	size := func(offset int, literal string, value int64) *IntegerExpression {
+		return &IntegerExpression{
+			PositiveLiteral: literal,
+			Value:           big.NewInt(value),
+			Base:            10,
+			Range: Range{
+				StartPos: Position{Offset: offset, Line: 1, Column: offset},
+				EndPos:   Position{Offset: offset, Line: 1, Column: offset},
+			},
+		}
+	}
+
+	type testCase struct {
+		name     string
+		expected func(offset int) Type
+		found    func(offset int) Type
+	}
+
+	equalCases := []testCase{
+		{
+			name: "nominal",
+			expected: func(offset int) Type {
+				return nominal(offset, "A", "B")
+			},
+		},
+		{
+			name: "optional",
+			expected: func(offset int) Type {
+				return &OptionalType{Type: nominal(offset, "Int")}
+			},
+		},
+		{
+			name: "variable sized",
+			expected: func(offset int) Type {
+				return &VariableSizedType{Type: nominal(offset, "Int")}
+			},
+		},
+		{
+			name: "constant sized",
+			expected: func(offset int) Type {
+				return &ConstantSizedType{
+					Type: nominal(offset, "Int"),
+					Size: size(offset, "2", 2),
+				}
+			},
+			found: func(offset int) Type {
+				return &ConstantSizedType{
+					Type: nominal(offset, "Int"),
+					Size: size(offset, "0x2", 2),
+				}
+			},
+		},
+		{
+			name: "dictionary",
+			expected: func(offset int) Type {
+				return &DictionaryType{
+					KeyType:   nominal(offset, "String"),
+					ValueType: nominal(offset, "Int"),
+				}
+			},
+		},
+		{
+			name: "function",
+			expected: func(offset int) Type {
+				return &FunctionType{
+					ParameterTypeAnnotations: []*TypeAnnotation{
+						annotation(false, nominal(offset, "Int")),
+						annotation(true, nominal(offset, "R")),
+					},
+					ReturnTypeAnnotation: annotation(true, nominal(offset, "R")),
+				}
+			},
+		},
+		{
+			name: "reference",
+			expected: func(offset int) Type {
+				return &ReferenceType{
+					Authorized: true,
+					Type:       nominal(offset, "R"),
+				}
+			},
+		},
+		{
+			name: "restricted",
+			expected: func(offset int) Type {
+				return &RestrictedType{
+					Type: nominal(offset, "R"),
+					Restrictions: []*NominalType{
+						nominal(offset, "I"),
+						nominal(offset, "J"),
+					},
+				}
+			},
+		},
+		{
+			name: "restricted without type",
+			expected: func(offset int) Type {
+				return &RestrictedType{
+					Restrictions: []*NominalType{
+						nominal(offset, "I"),
+					},
+				}
+			},
+		},
+		{
+			name: "instantiation",
+			expected: func(offset int) Type {
+				return &InstantiationType{
+					Type: nominal(offset, "Capability"),
+					TypeArguments: []*TypeAnnotation{
+						annotation(false, &ReferenceType{Type: nominal(offset, "R")}),
+					},
+				}
+			},
+		},
+	}
+
+	for _, testCase := range equalCases {
+		testCase := testCase
+
+		t.Run("equal "+testCase.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			found := testCase.found
+			if found == nil {
+				found = testCase.expected
+			}
+
+			err := testCase.expected(0).CheckEqual(found(10), DefaultTypeEqualityChecker{})
+			require.NoError(t, err)
+		})
+	}
+
+	unequalCases := []testCase{
+		{
+			name: "nominal, different identifier",
+			expected: func(offset int) Type {
+				return nominal(offset, "A")
+			},
+			found: func(offset int) Type {
+				return nominal(offset, "B")
+			},
+		},
+		{
+			name: "nominal, different nested identifiers",
+			expected: func(offset int) Type {
+				return nominal(offset, "A", "B")
+			},
+			found: func(offset int) Type {
+				return nominal(offset, "A", "C")
+			},
+		},
+		{
+			name: "nominal, different number of nested identifiers",
+			expected: func(offset int) Type {
+				return nominal(offset, "A", "B")
+			},
+			found: func(offset int) Type {
+				return nominal(offset, "A")
+			},
+		},
+		{
+			name: "optional, different type",
+			expected: func(offset int) Type {
+				return &OptionalType{Type: nominal(offset, "Int")}
+			},
+			found: func(offset int) Type {
+				return &OptionalType{Type: nominal(offset, "String")}
+			},
+		},
+		{
+			name: "optional, different kind",
+			expected: func(offset int) Type {
+				return &OptionalType{Type: nominal(offset, "Int")}
+			},
+			found: func(offset int) Type {
+				return &VariableSizedType{Type: nominal(offset, "Int")}
+			},
+		},
+		{
+			name: "variable sized, different type",
+			expected: func(offset int) Type {
+				return &VariableSizedType{Type: nominal(offset, "Int")}
+			},
+			found: func(offset int) Type {
+				return &VariableSizedType{Type: nominal(offset, "String")}
+			},
+		},
+		{
+			name: "constant sized, different size",
+			expected: func(offset int) Type {
+				return &ConstantSizedType{
+					Type: nominal(offset, "Int"),
+					Size: size(offset, "2", 2),
+				}
+			},
+			found: func(offset int) Type {
+				return &ConstantSizedType{
+					Type: nominal(offset, "Int"),
+					Size: size(offset, "3", 3),
+				}
+			},
+		},
+		{
+			name: "constant sized, different type",
+			expected: func(offset int) Type {
+				return &ConstantSizedType{
+					Type: nominal(offset, "Int"),
+					Size: size(offset, "2", 2),
+				}
+			},
+			found: func(offset int) Type {
+				return &ConstantSizedType{
+					Type: nominal(offset, "String"),
+					Size: size(offset, "2", 2),
+				}
+			},
+		},
+		{
+			name: "dictionary, different key type",
+			expected: func(offset int) Type {
+				return &DictionaryType{
+					KeyType:   nominal(offset, "String"),
+					ValueType: nominal(offset, "Int"),
+				}
+			},
+			found: func(offset int) Type {
+				return &DictionaryType{
+					KeyType:   nominal(offset, "Int"),
+					ValueType: nominal(offset, "Int"),
+				}
+			},
+		},
+		{
+			name: "dictionary, different value type",
+			expected: func(offset int) Type {
+				return &DictionaryType{
+					KeyType:   nominal(offset, "String"),
+					ValueType: nominal(offset, "Int"),
+				}
+			},
+			found: func(offset int) Type {
+				return &DictionaryType{
+					KeyType:   nominal(offset, "String"),
+					ValueType: nominal(offset, "String"),
+				}
+			},
+		},
+		{
+			name: "function, different parameter count",
+			expected: func(offset int) Type {
+				return &FunctionType{
+					ParameterTypeAnnotations: []*TypeAnnotation{
+						annotation(false, nominal(offset, "Int")),
+					},
+					ReturnTypeAnnotation: annotation(false, nominal(offset, "Int")),
+				}
+			},
+			found: func(offset int) Type {
+				return &FunctionType{
+					ReturnTypeAnnotation: annotation(false, nominal(offset, "Int")),
+				}
+			},
+		},
+		{
+			name: "function, different parameter resource annotation",
+			expected: func(offset int) Type {
+				return &FunctionType{
+					ParameterTypeAnnotations: []*TypeAnnotation{
+						annotation(true, nominal(offset, "R")),
+					},
+					ReturnTypeAnnotation: annotation(false, nominal(offset, "Int")),
+				}
+			},
+			found: func(offset int) Type {
+				return &FunctionType{
+					ParameterTypeAnnotations: []*TypeAnnotation{
+						annotation(false, nominal(offset, "R")),
+					},
+					ReturnTypeAnnotation: annotation(false, nominal(offset, "Int")),
+				}
+			},
+		},
+		{
+			name: "function, different return type",
+			expected: func(offset int) Type {
+				return &FunctionType{
+					ReturnTypeAnnotation: annotation(false, nominal(offset, "Int")),
+				}
+			},
+			found: func(offset int) Type {
+				return &FunctionType{
+					ReturnTypeAnnotation: annotation(false, nominal(offset, "String")),
+				}
+			},
+		},
+		{
+			name: "reference, different authorization",
+			expected: func(offset int) Type {
+				return &ReferenceType{
+					Authorized: true,
+					Type:       nominal(offset, "R"),
+				}
+			},
+			found: func(offset int) Type {
+				return &ReferenceType{
+					Type: nominal(offset, "R"),
+				}
+			},
+		},
+		{
+			name: "reference, different type",
+			expected: func(offset int) Type {
+				return &ReferenceType{Type: nominal(offset, "R")}
+			},
+			found: func(offset int) Type {
+				return &ReferenceType{Type: nominal(offset, "S")}
+			},
+		},
+		{
+			name: "restricted, different order",
+			expected: func(offset int) Type {
+				return &RestrictedType{
+					Type: nominal(offset, "R"),
+					Restrictions: []*NominalType{
+						nominal(offset, "I"),
+						nominal(offset, "J"),
+					},
+				}
+			},
+			found: func(offset int) Type {
+				return &RestrictedType{
+					Type: nominal(offset, "R"),
+					Restrictions: []*NominalType{
+						nominal(offset, "J"),
+						nominal(offset, "I"),
+					},
+				}
+			},
+		},
+		{
+			name: "restricted, different type",
+			expected: func(offset int) Type {
+				return &RestrictedType{
+					Type:         nominal(offset, "R"),
+					Restrictions: []*NominalType{nominal(offset, "I")},
+				}
+			},
+			found: func(offset int) Type {
+				return &RestrictedType{
+					Restrictions: []*NominalType{nominal(offset, "I")},
+				}
+			},
+		},
+		{
+			name: "instantiation, different type arguments",
+			expected: func(offset int) Type {
+				return &InstantiationType{
+					Type: nominal(offset, "Capability"),
+					TypeArguments: []*TypeAnnotation{
+						annotation(false, nominal(offset, "R")),
+					},
+				}
+			},
+			found: func(offset int) Type {
+				return &InstantiationType{
+					Type: nominal(offset, "Capability"),
+					TypeArguments: []*TypeAnnotation{
+						annotation(false, nominal(offset, "S")),
+					},
+				}
+			},
+		},
+		{
+			name: "instantiation, different type",
+			expected: func(offset int) Type {
+				return &InstantiationType{
+					Type: nominal(offset, "Capability"),
+				}
+			},
+			found: func(offset int) Type {
+				return &InstantiationType{
+					Type: nominal(offset, "Path"),
+				}
+			},
+		},
+	}
+
+	for _, testCase := range unequalCases {
+		testCase := testCase
+
+		t.Run("unequal "+testCase.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			err := testCase.expected(0).CheckEqual(testCase.found(10), DefaultTypeEqualityChecker{})
+			require.Error(t, err)
+
+			assert.False(t, EqualTypes(testCase.expected(0), testCase.found(10)))
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := (&OptionalType{Type: nominal(0, "Int")}).
+			CheckEqual(&OptionalType{Type: nominal(0, "String")}, DefaultTypeEqualityChecker{})
+
+		require.EqualError(t, err, "type mismatch. expected `Int`, found `String`")
+	})
+}
+
+func TestDefaultTypeEqualityChecker_IgnoreRestrictionOrder(t *testing.T) {
+
+	t.Parallel()
+
+	checker := DefaultTypeEqualityChecker{
+		IgnoreRestrictionOrder: true,
+	}
+
+	newRestrictedType := func(restrictedType string, restrictions ...string) *RestrictedType {
+		ty := &RestrictedType{}
+		if restrictedType != "" {
+			ty.Type = &NominalType{
+				Identifier: Identifier{Identifier: restrictedType},
+			}
+		}
+		for _, restriction := range restrictions {
+			ty.Restrictions = append(ty.Restrictions,
+				&NominalType{
+					Identifier: Identifier{Identifier: restriction},
+				},
+			)
+		}
+		return ty
+	}
+
+	t.Run("reordered", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := newRestrictedType("R", "A", "B", "C")
+		b := newRestrictedType("R", "C", "A", "B")
+
+		require.NoError(t, a.CheckEqual(b, checker))
+		require.Error(t, a.CheckEqual(b, DefaultTypeEqualityChecker{}))
+
+		assert.Equal(t, HashType(a), HashType(b))
+	})
+
+	t.Run("reordered, without restricted type", func(t *testing.T) {
+
+		t.Parallel()
+
+		require.NoError(t,
+			newRestrictedType("", "A", "B").
+				CheckEqual(newRestrictedType("", "B", "A"), checker),
+		)
+	})
+
+	t.Run("duplicates", func(t *testing.T) {
+
+		t.Parallel()
+
+		require.Error(t,
+			newRestrictedType("R", "A", "A").
+				CheckEqual(newRestrictedType("R", "A", "B"), checker),
+		)
+		require.Error(t,
+			newRestrictedType("R", "A", "A", "B").
+				CheckEqual(newRestrictedType("R", "A", "B", "B"), checker),
+		)
+		require.Error(t,
+			newRestrictedType("R", "A", "A").
+				CheckEqual(newRestrictedType("R", "A"), checker),
+		)
+		require.NoError(t,
+			newRestrictedType("R", "A", "B", "A").
+				CheckEqual(newRestrictedType("R", "A", "A", "B"), checker),
+		)
+	})
+
+	t.Run("different restricted types", func(t *testing.T) {
+
+		t.Parallel()
+
+		require.Error(t,
+			newRestrictedType("R", "A", "B").
+				CheckEqual(newRestrictedType("S", "B", "A"), checker),
+		)
+		require.Error(t,
+			newRestrictedType("R", "A", "B").
+				CheckEqual(newRestrictedType("", "B", "A"), checker),
+		)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := &OptionalType{
+			Type: &ReferenceType{
+				Type: newRestrictedType("R", "A", "B"),
+			},
+		}
+		b := &OptionalType{
+			Type: &ReferenceType{
+				Type: newRestrictedType("R", "B", "A"),
+			},
+		}
+
+		require.NoError(t, a.CheckEqual(b, checker))
+
+		expressionChecker := DefaultExpressionEqualityChecker{
+			TypeEqualityChecker: checker,
+		}
+
+		newCasting := func(ty Type) *CastingExpression {
+			return &CastingExpression{
+				Operation:  OperationFailableCast,
+				Expression: newTestIdentifierExpression("x"),
+				TypeAnnotation: &TypeAnnotation{
+					Type: ty,
+				},
+			}
+		}
+
+		require.NoError(t, newCasting(a).CheckEqual(newCasting(b), expressionChecker))
+	})
+}
+
+func TestDefaultTypeEqualityChecker_Cycles(t *testing.T) {
+
+	t.Parallel()
+
+	newCyclicOptionalType := func() *OptionalType {
+		ty := &OptionalType{}
+		ty.Type = ty
+		return ty
+	}
+
+	t.Run("self-referential optional types", func(t *testing.T) {
+
+		t.Parallel()
+
+		a := newCyclicOptionalType()
+		b := newCyclicOptionalType()
+
+		err := a.CheckEqual(b, DefaultTypeEqualityChecker{})
+		require.Error(t, err)
+
+		var cycleErr *TypeCycleError
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Same(t, a, cycleErr.Expected)
+		assert.Same(t, b, cycleErr.Found)
+
+		assert.EqualError(t,
+			err,
+			"cannot check type equality: cyclic type. expected *ast.OptionalType, found *ast.OptionalType",
+		)
+
+		assert.False(t, EqualTypes(a, b))
+	})
+
+	t.Run("cycle through a type annotation", func(t *testing.T) {
+
+		t.Parallel()
+
+		newCyclicFunctionType := func() *FunctionType {
+			ty := &FunctionType{}
+			ty.ReturnTypeAnnotation = &TypeAnnotation{
+				Type: &VariableSizedType{
+					Type: ty,
+				},
+			}
+			return ty
+		}
+
+		err := newCyclicFunctionType().CheckEqual(newCyclicFunctionType(), DefaultTypeEqualityChecker{})
+
+		var cycleErr *TypeCycleError
+		require.ErrorAs(t, err, &cycleErr)
+	})
+
+	t.Run("identical", func(t *testing.T) {
+
+		t.Parallel()
+
+		// The same node is equal to itself without being traversed
+
+		ty := newCyclicOptionalType()
+
+		assert.True(t, EqualTypes(ty, ty))
+	})
+
+	t.Run("shared, acyclic", func(t *testing.T) {
+
+		t.Parallel()
+
+		// A node which occurs multiple times is not a cycle,
+		// i.e. the same pair of types may be checked multiple times
+
+		newSharedDictionaryType := func() *DictionaryType {
+			shared := &NominalType{
+				Identifier: Identifier{Identifier: "Int"},
+			}
+			return &DictionaryType{
+				KeyType:   shared,
+				ValueType: shared,
+			}
+		}
+
+		a := newSharedDictionaryType()
+		b := newSharedDictionaryType()
+
+		require.NoError(t, a.CheckEqual(b, DefaultTypeEqualityChecker{}))
+		require.NoError(t, b.CheckEqual(a, DefaultTypeEqualityChecker{}))
+	})
+}
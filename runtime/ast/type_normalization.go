@@ -0,0 +1,183 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+// NormalizeType returns a normalized copy of the given type.
+// The given type is not modified.
+//
+// Normalization is a lossy canonicalization, e.g. for comparing types in tooling,
+// and performs the following simplifications:
+//
+//   - Nested optional types are collapsed into a single optional type, e.g. `Int??` becomes `Int?`.
+//     NOTE: This changes the meaning of the type, as nested optionals are distinct types,
+//     e.g. `Int??` has the value `nil` and the value `Int?(nil)`, which are not the same.
+//   - Restricted types without restrictions are replaced by their restricted type, e.g. `T{}` becomes `T`
+//
+// Normalization is idempotent.
+// When a type is simplified, the positions of the outermost type are kept,
+// as far as the remaining type can represent them.
+func NormalizeType(ty Type) Type {
+	switch ty := ty.(type) {
+	case nil:
+		return nil
+
+	case *OptionalType:
+		innerType := NormalizeType(ty.Type)
+		// The inner type is already normalized,
+		// so it has at most one level of optionality
+		if innerOptionalType, ok := innerType.(*OptionalType); ok {
+			innerType = innerOptionalType.Type
+		}
+		return &OptionalType{
+			Type:   innerType,
+			EndPos: ty.EndPos,
+		}
+
+	case *VariableSizedType:
+		return &VariableSizedType{
+			Type:  NormalizeType(ty.Type),
+			Range: ty.Range,
+		}
+
+	case *ConstantSizedType:
+		var size *IntegerExpression
+		if ty.Size != nil {
+			size = ty.Size.Clone().(*IntegerExpression)
+		}
+		return &ConstantSizedType{
+			Type:  NormalizeType(ty.Type),
+			Size:  size,
+			Range: ty.Range,
+		}
+
+	case *DictionaryType:
+		return &DictionaryType{
+			KeyType:   NormalizeType(ty.KeyType),
+			ValueType: NormalizeType(ty.ValueType),
+			Range:     ty.Range,
+		}
+
+	case *FunctionType:
+		return &FunctionType{
+			ParameterTypeAnnotations: normalizeTypeAnnotations(ty.ParameterTypeAnnotations),
+			ReturnTypeAnnotation:     normalizeTypeAnnotation(ty.ReturnTypeAnnotation),
+			Range:                    ty.Range,
+		}
+
+	case *ReferenceType:
+		return &ReferenceType{
+			Authorized: ty.Authorized,
+			Type:       NormalizeType(ty.Type),
+			StartPos:   ty.StartPos,
+		}
+
+	case *RestrictedType:
+		if len(ty.Restrictions) == 0 && ty.Type != nil {
+			return withOuterRange(NormalizeType(ty.Type), ty.Range)
+		}
+
+		var restrictions []*NominalType
+		if ty.Restrictions != nil {
+			restrictions = make([]*NominalType, len(ty.Restrictions))
+			for i, restriction := range ty.Restrictions {
+				restrictions[i] = restriction.Clone().(*NominalType)
+			}
+		}
+
+		return &RestrictedType{
+			Type:         NormalizeType(ty.Type),
+			Restrictions: restrictions,
+			Range:        ty.Range,
+		}
+
+	case *InstantiationType:
+		return &InstantiationType{
+			Type:                  NormalizeType(ty.Type),
+			TypeArguments:         normalizeTypeAnnotations(ty.TypeArguments),
+			TypeArgumentsStartPos: ty.TypeArgumentsStartPos,
+			EndPos:                ty.EndPos,
+		}
+
+	default:
+		// Nominal types, and types defined outside of this package,
+		// cannot be simplified
+		return ty.Clone()
+	}
+}
+
+func normalizeTypeAnnotation(typeAnnotation *TypeAnnotation) *TypeAnnotation {
+	if typeAnnotation == nil {
+		return nil
+	}
+	return &TypeAnnotation{
+		IsResource: typeAnnotation.IsResource,
+		Type:       NormalizeType(typeAnnotation.Type),
+		StartPos:   typeAnnotation.StartPos,
+	}
+}
+
+func normalizeTypeAnnotations(typeAnnotations []*TypeAnnotation) []*TypeAnnotation {
+	if typeAnnotations == nil {
+		return nil
+	}
+	result := make([]*TypeAnnotation, len(typeAnnotations))
+	for i, typeAnnotation := range typeAnnotations {
+		result[i] = normalizeTypeAnnotation(typeAnnotation)
+	}
+	return result
+}
+
+// withOuterRange sets the positions of the given type, which must not be shared,
+// to the given range of the outer type it replaces.
+//
+// Only the positions which the type has are set,
+// e.g. only the end position of an optional type,
+// as its start position is the one of its inner type.
+func withOuterRange(ty Type, outerRange Range) Type {
+	switch ty := ty.(type) {
+	case *NominalType:
+		ty.Identifier.Pos = outerRange.StartPos
+
+	case *OptionalType:
+		ty.EndPos = outerRange.EndPos
+
+	case *VariableSizedType:
+		ty.Range = outerRange
+
+	case *ConstantSizedType:
+		ty.Range = outerRange
+
+	case *DictionaryType:
+		ty.Range = outerRange
+
+	case *FunctionType:
+		ty.Range = outerRange
+
+	case *ReferenceType:
+		ty.StartPos = outerRange.StartPos
+
+	case *RestrictedType:
+		ty.Range = outerRange
+
+	case *InstantiationType:
+		ty.EndPos = outerRange.EndPos
+	}
+
+	return ty
+}
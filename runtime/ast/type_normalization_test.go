@@ -0,0 +1,271 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeType(t *testing.T) {
+
+	t.Parallel()
+
+	position := func(offset int) Position {
+		return Position{Offset: offset, Line: 1, Column: offset}
+	}
+
+	t.Run("nested optionals", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Int???
+
+		ty := &OptionalType{
+			Type: &OptionalType{
+				Type: &OptionalType{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "Int", Pos: position(0)},
+					},
+					EndPos: position(3),
+				},
+				EndPos: position(4),
+			},
+			EndPos: position(5),
+		}
+
+		original := ty.Clone()
+
+		normalized := NormalizeType(ty)
+
+		assert.Equal(t,
+			&OptionalType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "Int", Pos: position(0)},
+				},
+				EndPos: position(5),
+			},
+			normalized,
+		)
+		assert.Equal(t, "Int?", normalized.String())
+
+		// The input is not modified
+		assert.Equal(t, original, ty)
+
+		// Normalization is idempotent
+		assert.Equal(t, normalized, NormalizeType(normalized))
+	})
+
+	t.Run("restricted type without restrictions", func(t *testing.T) {
+
+		t.Parallel()
+
+		// T{}
+
+		ty := &RestrictedType{
+			Type: &NominalType{
+				Identifier: Identifier{Identifier: "T", Pos: position(0)},
+			},
+			Range: Range{StartPos: position(0), EndPos: position(2)},
+		}
+
+		normalized := NormalizeType(ty)
+
+		assert.Equal(t,
+			&NominalType{
+				Identifier: Identifier{Identifier: "T", Pos: position(0)},
+			},
+			normalized,
+		)
+		assert.NotSame(t, ty.Type, normalized)
+	})
+
+	t.Run("restricted type without restrictions, outer positions", func(t *testing.T) {
+
+		t.Parallel()
+
+		// [T]{}
+
+		ty := &RestrictedType{
+			Type: &VariableSizedType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "T", Pos: position(2)},
+				},
+				Range: Range{StartPos: position(1), EndPos: position(3)},
+			},
+			Range: Range{StartPos: position(0), EndPos: position(6)},
+		}
+
+		normalized := NormalizeType(ty)
+
+		assert.Equal(t,
+			&VariableSizedType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "T", Pos: position(2)},
+				},
+				Range: Range{StartPos: position(0), EndPos: position(6)},
+			},
+			normalized,
+		)
+
+		// The input is not modified
+		assert.Equal(t,
+			Range{StartPos: position(1), EndPos: position(3)},
+			ty.Type.(*VariableSizedType).Range,
+		)
+
+		// T?{}
+
+		optionalTy := &RestrictedType{
+			Type: &OptionalType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "T", Pos: position(0)},
+				},
+				EndPos: position(1),
+			},
+			Range: Range{StartPos: position(0), EndPos: position(3)},
+		}
+
+		assert.Equal(t,
+			&OptionalType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "T", Pos: position(0)},
+				},
+				EndPos: position(3),
+			},
+			NormalizeType(optionalTy),
+		)
+	})
+
+	t.Run("restricted type with restrictions", func(t *testing.T) {
+
+		t.Parallel()
+
+		// T{I}
+
+		ty := &RestrictedType{
+			Type: &NominalType{
+				Identifier: Identifier{Identifier: "T", Pos: position(0)},
+			},
+			Restrictions: []*NominalType{
+				{
+					Identifier: Identifier{Identifier: "I", Pos: position(2)},
+				},
+			},
+			Range: Range{StartPos: position(0), EndPos: position(3)},
+		}
+
+		normalized := NormalizeType(ty)
+
+		assert.Equal(t, ty, normalized)
+		assert.NotSame(t, ty.Restrictions[0], normalized.(*RestrictedType).Restrictions[0])
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		// {String: [T{}??]}
+
+		ty := &DictionaryType{
+			KeyType: &NominalType{
+				Identifier: Identifier{Identifier: "String", Pos: position(1)},
+			},
+			ValueType: &VariableSizedType{
+				Type: &OptionalType{
+					Type: &OptionalType{
+						Type: &RestrictedType{
+							Type: &NominalType{
+								Identifier: Identifier{Identifier: "T", Pos: position(10)},
+							},
+							Range: Range{StartPos: position(10), EndPos: position(12)},
+						},
+						EndPos: position(13),
+					},
+					EndPos: position(14),
+				},
+				Range: Range{StartPos: position(9), EndPos: position(15)},
+			},
+			Range: Range{StartPos: position(0), EndPos: position(16)},
+		}
+
+		assert.Equal(t,
+			&DictionaryType{
+				KeyType: &NominalType{
+					Identifier: Identifier{Identifier: "String", Pos: position(1)},
+				},
+				ValueType: &VariableSizedType{
+					Type: &OptionalType{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "T", Pos: position(10)},
+						},
+						EndPos: position(14),
+					},
+					Range: Range{StartPos: position(9), EndPos: position(15)},
+				},
+				Range: Range{StartPos: position(0), EndPos: position(16)},
+			},
+			NormalizeType(ty),
+		)
+	})
+
+	t.Run("function type", func(t *testing.T) {
+
+		t.Parallel()
+
+		// ((Int??): @R{}?)
+
+		ty := &FunctionType{
+			ParameterTypeAnnotations: []*TypeAnnotation{
+				{
+					Type: &OptionalType{
+						Type: &OptionalType{
+							Type: &NominalType{
+								Identifier: Identifier{Identifier: "Int"},
+							},
+						},
+					},
+				},
+			},
+			ReturnTypeAnnotation: &TypeAnnotation{
+				IsResource: true,
+				Type: &OptionalType{
+					Type: &RestrictedType{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "R"},
+						},
+					},
+				},
+			},
+		}
+
+		assert.Equal(t,
+			"((Int?): @R?)",
+			NormalizeType(ty).String(),
+		)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.Nil(t, NormalizeType(nil))
+	})
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/turbolent/prettier"
 )
 
 func TestTypeAnnotation_MarshalJSON(t *testing.T) {
@@ -67,6 +68,28 @@ func TestTypeAnnotation_MarshalJSON(t *testing.T) {
 	)
 }
 
+func TestTypeAnnotation_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	ty := &TypeAnnotation{
+		IsResource: true,
+		Type: &NominalType{
+			Identifier: Identifier{
+				Identifier: "R",
+			},
+		},
+	}
+
+	assert.Equal(t,
+		prettier.Concat{
+			prettier.Text("@"),
+			prettier.Text("R"),
+		},
+		ty.Doc(),
+	)
+}
+
 func TestNominalType_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -111,6 +134,211 @@ func TestNominalType_MarshalJSON(t *testing.T) {
 	)
 }
 
+func TestNominalType_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	ty := &NominalType{
+		Identifier: Identifier{
+			Identifier: "foo",
+		},
+		NestedIdentifiers: []Identifier{
+			{
+				Identifier: "bar",
+			},
+		},
+	}
+
+	assert.Equal(t,
+		prettier.Text("foo.bar"),
+		ty.Doc(),
+	)
+}
+
+func TestContainsResourceType(t *testing.T) {
+
+	t.Parallel()
+
+	isResource := func(ty *NominalType) bool {
+		return ty.Identifier.Identifier == "NFT"
+	}
+
+	nft := func() *NominalType {
+		return &NominalType{Identifier: Identifier{Identifier: "NFT"}}
+	}
+
+	intType := func() *NominalType {
+		return &NominalType{Identifier: Identifier{Identifier: "Int"}}
+	}
+
+	type testCase struct {
+		name     string
+		ty       Type
+		expected bool
+	}
+
+	testCases := []testCase{
+		{
+			name:     "NFT",
+			ty:       nft(),
+			expected: true,
+		},
+		{
+			name:     "Int",
+			ty:       intType(),
+			expected: false,
+		},
+		{
+			name:     "[NFT]",
+			ty:       &VariableSizedType{Type: nft()},
+			expected: true,
+		},
+		{
+			name: "[[NFT; 2]?]",
+			ty: &VariableSizedType{
+				Type: &OptionalType{
+					Type: &ConstantSizedType{
+						Type: nft(),
+						Size: NewIntegerExpression(big.NewInt(2), Position{}),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			name:     "[Int]?",
+			ty:       &OptionalType{Type: &VariableSizedType{Type: intType()}},
+			expected: false,
+		},
+		{
+			name: "{Int: [NFT]}",
+			ty: &DictionaryType{
+				KeyType:   intType(),
+				ValueType: &VariableSizedType{Type: nft()},
+			},
+			expected: true,
+		},
+		{
+			name: "{Int: Int}",
+			ty: &DictionaryType{
+				KeyType:   intType(),
+				ValueType: intType(),
+			},
+			expected: false,
+		},
+		{
+			name:     "&NFT",
+			ty:       &ReferenceType{Type: nft()},
+			expected: false,
+		},
+		{
+			name: "((): NFT)",
+			ty: &FunctionType{
+				ReturnTypeAnnotation: &TypeAnnotation{
+					IsResource: true,
+					Type:       nft(),
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "NFT{I}",
+			ty: &RestrictedType{
+				Type: nft(),
+				Restrictions: []*NominalType{
+					{Identifier: Identifier{Identifier: "I"}},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "{NFT}",
+			ty: &RestrictedType{
+				Restrictions: []*NominalType{nft()},
+			},
+			expected: true,
+		},
+		{
+			name: "Capability<&NFT>",
+			ty: &InstantiationType{
+				Type: &NominalType{Identifier: Identifier{Identifier: "Capability"}},
+				TypeArguments: []*TypeAnnotation{
+					{Type: &ReferenceType{Type: nft()}},
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			assert.Equal(t,
+				testCase.expected,
+				ContainsResourceType(testCase.ty, isResource),
+			)
+		})
+	}
+}
+
+func TestNewNominalTypeFromQualifiedName(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("single", func(t *testing.T) {
+
+		t.Parallel()
+
+		ty := NewNominalTypeFromQualifiedName("Int", Position{Offset: 4, Line: 2, Column: 3})
+
+		assert.Equal(t,
+			&NominalType{
+				Identifier: Identifier{
+					Identifier: "Int",
+					Pos:        Position{Offset: 4, Line: 2, Column: 3},
+				},
+			},
+			ty,
+		)
+		assert.False(t, ty.IsQualifiedName())
+		assert.Equal(t, Position{Offset: 6, Line: 2, Column: 5}, ty.EndPosition())
+	})
+
+	t.Run("qualified", func(t *testing.T) {
+
+		t.Parallel()
+
+		ty := NewNominalTypeFromQualifiedName("Foo.Bar.Baz", Position{Offset: 4, Line: 2, Column: 3})
+
+		assert.Equal(t,
+			&NominalType{
+				Identifier: Identifier{
+					Identifier: "Foo",
+					Pos:        Position{Offset: 4, Line: 2, Column: 3},
+				},
+				NestedIdentifiers: []Identifier{
+					{
+						Identifier: "Bar",
+						Pos:        Position{Offset: 8, Line: 2, Column: 7},
+					},
+					{
+						Identifier: "Baz",
+						Pos:        Position{Offset: 12, Line: 2, Column: 11},
+					},
+				},
+			},
+			ty,
+		)
+		assert.True(t, ty.IsQualifiedName())
+		assert.Equal(t, "Foo.Bar.Baz", ty.String())
+		assert.Equal(t, Position{Offset: 14, Line: 2, Column: 13}, ty.EndPosition())
+	})
+}
+
 func TestOptionalType_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -150,6 +378,27 @@ func TestOptionalType_MarshalJSON(t *testing.T) {
 	)
 }
 
+func TestOptionalType_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	ty := &OptionalType{
+		Type: &NominalType{
+			Identifier: Identifier{
+				Identifier: "T",
+			},
+		},
+	}
+
+	assert.Equal(t,
+		prettier.Concat{
+			prettier.Text("T"),
+			prettier.Text("?"),
+		},
+		ty.Doc(),
+	)
+}
+
 func TestVariableSizedType_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -192,6 +441,36 @@ func TestVariableSizedType_MarshalJSON(t *testing.T) {
 	)
 }
 
+func TestVariableSizedType_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	ty := &VariableSizedType{
+		Type: &NominalType{
+			Identifier: Identifier{
+				Identifier: "T",
+			},
+		},
+	}
+
+	assert.Equal(t,
+		prettier.Group{
+			Doc: prettier.Concat{
+				prettier.Text("["),
+				prettier.Indent{
+					Doc: prettier.Concat{
+						prettier.SoftLine{},
+						prettier.Text("T"),
+					},
+				},
+				prettier.SoftLine{},
+				prettier.Text("]"),
+			},
+		},
+		ty.Doc(),
+	)
+}
+
 func TestConstantSizedType_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -251,6 +530,141 @@ func TestConstantSizedType_MarshalJSON(t *testing.T) {
 	)
 }
 
+func TestConstantSizedType_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	ty := &ConstantSizedType{
+		Type: &NominalType{
+			Identifier: Identifier{
+				Identifier: "T",
+			},
+		},
+		Size: &IntegerExpression{
+			PositiveLiteral: "42",
+			Value:           big.NewInt(42),
+			Base:            10,
+		},
+	}
+
+	assert.Equal(t,
+		prettier.Group{
+			Doc: prettier.Concat{
+				prettier.Text("["),
+				prettier.Indent{
+					Doc: prettier.Concat{
+						prettier.SoftLine{},
+						prettier.Concat{
+							prettier.Text("T"),
+							prettier.Concat{
+								prettier.Text(";"),
+								prettier.Line{},
+							},
+							prettier.Text("42"),
+						},
+					},
+				},
+				prettier.SoftLine{},
+				prettier.Text("]"),
+			},
+		},
+		ty.Doc(),
+	)
+}
+
+func TestConstantSizedType_Length(t *testing.T) {
+
+	t.Parallel()
+
+	newConstantSizedType := func(size *IntegerExpression) *ConstantSizedType {
+		return &ConstantSizedType{
+			Type: &NominalType{
+				Identifier: Identifier{
+					Identifier: "T",
+				},
+			},
+			Size: size,
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+
+		t.Parallel()
+
+		length, ok := newConstantSizedType(&IntegerExpression{
+			PositiveLiteral: "42",
+			Value:           big.NewInt(42),
+			Base:            10,
+		}).Length()
+
+		assert.True(t, ok)
+		assert.Equal(t, int64(42), length)
+	})
+
+	t.Run("zero", func(t *testing.T) {
+
+		t.Parallel()
+
+		length, ok := newConstantSizedType(&IntegerExpression{
+			PositiveLiteral: "0",
+			Value:           big.NewInt(0),
+			Base:            10,
+		}).Length()
+
+		assert.True(t, ok)
+		assert.Equal(t, int64(0), length)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+
+		t.Parallel()
+
+		value := new(big.Int).Lsh(big.NewInt(1), 63)
+
+		_, ok := newConstantSizedType(&IntegerExpression{
+			PositiveLiteral: value.String(),
+			Value:           value,
+			Base:            10,
+		}).Length()
+
+		assert.False(t, ok)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, ok := newConstantSizedType(&IntegerExpression{
+			PositiveLiteral: "1",
+			Value:           big.NewInt(-1),
+			Base:            10,
+		}).Length()
+
+		assert.False(t, ok)
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, ok := newConstantSizedType(&IntegerExpression{
+			PositiveLiteral: "1",
+			Base:            10,
+		}).Length()
+
+		assert.False(t, ok)
+	})
+
+	t.Run("missing size", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, ok := newConstantSizedType(nil).Length()
+
+		assert.False(t, ok)
+	})
+}
+
 func TestDictionaryType_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -309,6 +723,48 @@ func TestDictionaryType_MarshalJSON(t *testing.T) {
 	)
 }
 
+func TestDictionaryType_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	ty := &DictionaryType{
+		KeyType: &NominalType{
+			Identifier: Identifier{
+				Identifier: "K",
+			},
+		},
+		ValueType: &NominalType{
+			Identifier: Identifier{
+				Identifier: "V",
+			},
+		},
+	}
+
+	assert.Equal(t,
+		prettier.Group{
+			Doc: prettier.Concat{
+				prettier.Text("{"),
+				prettier.Indent{
+					Doc: prettier.Concat{
+						prettier.SoftLine{},
+						prettier.Concat{
+							prettier.Text("K"),
+							prettier.Concat{
+								prettier.Text(":"),
+								prettier.Line{},
+							},
+							prettier.Text("V"),
+						},
+					},
+				},
+				prettier.SoftLine{},
+				prettier.Text("}"),
+			},
+		},
+		ty.Doc(),
+	)
+}
+
 func TestFunctionType_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -389,6 +845,210 @@ func TestFunctionType_MarshalJSON(t *testing.T) {
 	)
 }
 
+func TestFunctionType_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	ty := &FunctionType{
+		ParameterTypeAnnotations: []*TypeAnnotation{
+			{
+				Type: &NominalType{
+					Identifier: Identifier{
+						Identifier: "A",
+					},
+				},
+			},
+		},
+		ReturnTypeAnnotation: &TypeAnnotation{
+			Type: &NominalType{
+				Identifier: Identifier{
+					Identifier: "B",
+				},
+			},
+		},
+	}
+
+	assert.Equal(t,
+		prettier.Group{
+			Doc: prettier.Concat{
+				prettier.Text("("),
+				prettier.Indent{
+					Doc: prettier.Concat{
+						prettier.SoftLine{},
+						prettier.Concat{
+							prettier.Group{
+								Doc: prettier.Concat{
+									prettier.Text("("),
+									prettier.Indent{
+										Doc: prettier.Concat{
+											prettier.SoftLine{},
+											prettier.Text("A"),
+										},
+									},
+									prettier.SoftLine{},
+									prettier.Text(")"),
+								},
+							},
+							prettier.Text(": "),
+							prettier.Text("B"),
+						},
+					},
+				},
+				prettier.SoftLine{},
+				prettier.Text(")"),
+			},
+		},
+		ty.Doc(),
+	)
+}
+
+func TestFunctionType_Parameters(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("no parameters", func(t *testing.T) {
+
+		t.Parallel()
+
+		ty := &FunctionType{
+			ReturnTypeAnnotation: &TypeAnnotation{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "Int"},
+				},
+			},
+		}
+
+		assert.Equal(t, 0, ty.Arity())
+
+		parameterType, ok := ty.ParameterType(0)
+		assert.False(t, ok)
+		assert.Nil(t, parameterType)
+
+		assert.False(t, ty.IsVoidReturn())
+	})
+
+	t.Run("multiple parameters", func(t *testing.T) {
+
+		t.Parallel()
+
+		firstParameterType := &TypeAnnotation{
+			IsResource: true,
+			Type: &NominalType{
+				Identifier: Identifier{Identifier: "R"},
+			},
+		}
+
+		secondParameterType := &TypeAnnotation{
+			Type: &OptionalType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "Int"},
+				},
+			},
+		}
+
+		ty := &FunctionType{
+			ParameterTypeAnnotations: []*TypeAnnotation{
+				firstParameterType,
+				secondParameterType,
+			},
+			ReturnTypeAnnotation: &TypeAnnotation{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "Void"},
+				},
+			},
+		}
+
+		assert.Equal(t, 2, ty.Arity())
+
+		parameterType, ok := ty.ParameterType(0)
+		assert.True(t, ok)
+		assert.Same(t, firstParameterType, parameterType)
+
+		parameterType, ok = ty.ParameterType(1)
+		assert.True(t, ok)
+		assert.Same(t, secondParameterType, parameterType)
+
+		for _, index := range []int{-1, 2} {
+			parameterType, ok = ty.ParameterType(index)
+			assert.False(t, ok)
+			assert.Nil(t, parameterType)
+		}
+
+		assert.True(t, ty.IsVoidReturn())
+	})
+
+	t.Run("void return", func(t *testing.T) {
+
+		t.Parallel()
+
+		type testCase struct {
+			returnTypeAnnotation *TypeAnnotation
+			expected             bool
+		}
+
+		testCases := map[string]testCase{
+			"missing annotation": {
+				returnTypeAnnotation: nil,
+				expected:             true,
+			},
+			"missing type": {
+				returnTypeAnnotation: &TypeAnnotation{},
+				expected:             true,
+			},
+			"empty type": {
+				returnTypeAnnotation: &TypeAnnotation{
+					Type: &NominalType{},
+				},
+				expected: true,
+			},
+			"Void": {
+				returnTypeAnnotation: &TypeAnnotation{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "Void"},
+					},
+				},
+				expected: true,
+			},
+			"nested Void": {
+				returnTypeAnnotation: &TypeAnnotation{
+					Type: &NominalType{
+						Identifier: Identifier{Identifier: "C"},
+						NestedIdentifiers: []Identifier{
+							{Identifier: "Void"},
+						},
+					},
+				},
+				expected: false,
+			},
+			"optional Void": {
+				returnTypeAnnotation: &TypeAnnotation{
+					Type: &OptionalType{
+						Type: &NominalType{
+							Identifier: Identifier{Identifier: "Void"},
+						},
+					},
+				},
+				expected: false,
+			},
+		}
+
+		for name, testCase := range testCases {
+			testCase := testCase
+
+			t.Run(name, func(t *testing.T) {
+
+				t.Parallel()
+
+				ty := &FunctionType{
+					ReturnTypeAnnotation: testCase.returnTypeAnnotation,
+				}
+
+				assert.Equal(t, testCase.expected, ty.IsVoidReturn())
+			})
+		}
+	})
+}
+
 func TestReferenceType_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -430,6 +1090,78 @@ func TestReferenceType_MarshalJSON(t *testing.T) {
 	)
 }
 
+func TestReferenceType_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("auth", func(t *testing.T) {
+
+		t.Parallel()
+
+		ty := &ReferenceType{
+			Authorized: true,
+			Type: &RestrictedType{
+				Restrictions: []*NominalType{
+					{
+						Identifier: Identifier{
+							Identifier: "Foo",
+						},
+					},
+				},
+			},
+		}
+
+		assert.Equal(t,
+			prettier.Concat{
+				prettier.Text("auth "),
+				prettier.Text("&"),
+				prettier.Concat{
+					prettier.Group{
+						Doc: prettier.Concat{
+							prettier.Text("{"),
+							prettier.Indent{
+								Doc: prettier.Concat{
+									prettier.SoftLine{},
+									prettier.Text("Foo"),
+								},
+							},
+							prettier.SoftLine{},
+							prettier.Text("}"),
+						},
+					},
+				},
+			},
+			ty.Doc(),
+		)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+
+		t.Parallel()
+
+		ty := &ReferenceType{
+			Type: &ReferenceType{
+				Type: &NominalType{
+					Identifier: Identifier{
+						Identifier: "T",
+					},
+				},
+			},
+		}
+
+		assert.Equal(t,
+			prettier.Concat{
+				prettier.Text("&"),
+				prettier.Concat{
+					prettier.Text("&"),
+					prettier.Text("T"),
+				},
+			},
+			ty.Doc(),
+		)
+	})
+}
+
 func TestRestrictedType_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -508,6 +1240,118 @@ func TestRestrictedType_MarshalJSON(t *testing.T) {
 	)
 }
 
+func TestRestrictedType_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("with restricted type", func(t *testing.T) {
+
+		t.Parallel()
+
+		ty := &RestrictedType{
+			Type: &NominalType{
+				Identifier: Identifier{
+					Identifier: "AnyResource",
+				},
+			},
+			Restrictions: []*NominalType{
+				{
+					Identifier: Identifier{
+						Identifier: "Provider",
+					},
+				},
+				{
+					Identifier: Identifier{
+						Identifier: "Receiver",
+					},
+				},
+			},
+		}
+
+		assert.Equal(t,
+			prettier.Concat{
+				prettier.Text("AnyResource"),
+				prettier.Group{
+					Doc: prettier.Concat{
+						prettier.Text("{"),
+						prettier.Indent{
+							Doc: prettier.Concat{
+								prettier.SoftLine{},
+								prettier.Concat{
+									prettier.Text("Provider"),
+									prettier.Concat{
+										prettier.Text(","),
+										prettier.Line{},
+									},
+									prettier.Text("Receiver"),
+								},
+							},
+						},
+						prettier.SoftLine{},
+						prettier.Text("}"),
+					},
+				},
+			},
+			ty.Doc(),
+		)
+	})
+
+	t.Run("without restricted type", func(t *testing.T) {
+
+		t.Parallel()
+
+		ty := &RestrictedType{
+			Restrictions: []*NominalType{
+				{
+					Identifier: Identifier{
+						Identifier: "Foo",
+					},
+				},
+			},
+		}
+
+		assert.Equal(t,
+			prettier.Concat{
+				prettier.Group{
+					Doc: prettier.Concat{
+						prettier.Text("{"),
+						prettier.Indent{
+							Doc: prettier.Concat{
+								prettier.SoftLine{},
+								prettier.Text("Foo"),
+							},
+						},
+						prettier.SoftLine{},
+						prettier.Text("}"),
+					},
+				},
+			},
+			ty.Doc(),
+		)
+	})
+
+	t.Run("without restrictions", func(t *testing.T) {
+
+		t.Parallel()
+
+		ty := &RestrictedType{
+			Type: &NominalType{
+				Identifier: Identifier{
+					Identifier: "T",
+				},
+			},
+		}
+
+		assert.Equal(t,
+			prettier.Concat{
+				prettier.Text("T"),
+				prettier.Text("{}"),
+			},
+			ty.Doc(),
+		)
+	})
+}
+
 func TestInstantiationType_MarshalJSON(t *testing.T) {
 
 	t.Parallel()
@@ -602,3 +1446,158 @@ func TestInstantiationType_MarshalJSON(t *testing.T) {
 		string(actual),
 	)
 }
+
+func TestInstantiationType_Doc(t *testing.T) {
+
+	t.Parallel()
+
+	ty := &InstantiationType{
+		Type: &NominalType{
+			Identifier: Identifier{
+				Identifier: "T",
+			},
+		},
+		TypeArguments: []*TypeAnnotation{
+			{
+				Type: &NominalType{
+					Identifier: Identifier{
+						Identifier: "U",
+					},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t,
+		prettier.Concat{
+			prettier.Text("T"),
+			prettier.Group{
+				Doc: prettier.Concat{
+					prettier.Text("<"),
+					prettier.Indent{
+						Doc: prettier.Concat{
+							prettier.SoftLine{},
+							prettier.Text("U"),
+						},
+					},
+					prettier.SoftLine{},
+					prettier.Text(">"),
+				},
+			},
+		},
+		ty.Doc(),
+	)
+}
+
+func TestType_Walk(t *testing.T) {
+
+	t.Parallel()
+
+	newNominalType := func(identifier string) *NominalType {
+		return &NominalType{
+			Identifier: Identifier{
+				Identifier: identifier,
+			},
+		}
+	}
+
+	collectNominalTypes := func(ty Type) []string {
+		var identifiers []string
+
+		var walk func(Type)
+		walk = func(ty Type) {
+			if nominalType, ok := ty.(*NominalType); ok {
+				identifiers = append(identifiers, nominalType.String())
+			}
+			ty.Walk(walk)
+		}
+		walk(ty)
+
+		return identifiers
+	}
+
+	t.Run("dictionary", func(t *testing.T) {
+
+		t.Parallel()
+
+		// {String: [&Foo]}
+
+		ty := &DictionaryType{
+			KeyType: newNominalType("String"),
+			ValueType: &VariableSizedType{
+				Type: &ReferenceType{
+					Type: newNominalType("Foo"),
+				},
+			},
+		}
+
+		assert.Equal(t,
+			[]string{"String", "Foo"},
+			collectNominalTypes(ty),
+		)
+	})
+
+	t.Run("function", func(t *testing.T) {
+
+		t.Parallel()
+
+		// ((A, [B; 2]): C?)
+
+		ty := &FunctionType{
+			ParameterTypeAnnotations: []*TypeAnnotation{
+				{
+					Type: newNominalType("A"),
+				},
+				{
+					Type: &ConstantSizedType{
+						Type: newNominalType("B"),
+						Size: &IntegerExpression{
+							PositiveLiteral: "2",
+							Value:           big.NewInt(2),
+							Base:            10,
+						},
+					},
+				},
+			},
+			ReturnTypeAnnotation: &TypeAnnotation{
+				Type: &OptionalType{
+					Type: newNominalType("C"),
+				},
+			},
+		}
+
+		assert.Equal(t,
+			[]string{"A", "B", "C"},
+			collectNominalTypes(ty),
+		)
+	})
+
+	t.Run("restricted and instantiation", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Capability<&R{I1, I2}>
+
+		ty := &InstantiationType{
+			Type: newNominalType("Capability"),
+			TypeArguments: []*TypeAnnotation{
+				{
+					Type: &ReferenceType{
+						Type: &RestrictedType{
+							Type: newNominalType("R"),
+							Restrictions: []*NominalType{
+								newNominalType("I1"),
+								newNominalType("I2"),
+							},
+						},
+					},
+				},
+			},
+		}
+
+		assert.Equal(t,
+			[]string{"Capability", "R", "I1", "I2"},
+			collectNominalTypes(ty),
+		)
+	})
+}
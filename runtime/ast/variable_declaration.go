@@ -96,6 +96,10 @@ var varKeywordDoc prettier.Doc = prettier.Text("var")
 var letKeywordDoc prettier.Doc = prettier.Text("let")
 
 func (d *VariableDeclaration) Doc() prettier.Doc {
+	return d.doc(docContext{})
+}
+
+func (d *VariableDeclaration) doc(context docContext) prettier.Doc {
 	keywordDoc := varKeywordDoc
 	if d.IsConstant {
 		keywordDoc = letKeywordDoc
@@ -104,7 +108,7 @@ func (d *VariableDeclaration) Doc() prettier.Doc {
 	// TODO: second transfer and value (if any)
 
 	// TODO: potentially parenthesize
-	valueDoc := d.Value.Doc()
+	valueDoc := expressionDoc(d.Value, context)
 
 	return prettier.Group{
 		Doc: prettier.Concat{
@@ -63,6 +63,7 @@ type StatementVisitor interface {
 type ExpressionVisitor interface {
 	VisitBoolExpression(*BoolExpression) Repr
 	VisitNilExpression(*NilExpression) Repr
+	VisitVoidExpression(*VoidExpression) Repr
 	VisitIntegerExpression(*IntegerExpression) Repr
 	VisitFixedPointExpression(*FixedPointExpression) Repr
 	VisitArrayExpression(*ArrayExpression) Repr
@@ -76,14 +77,119 @@ type ExpressionVisitor interface {
 	VisitBinaryExpression(*BinaryExpression) Repr
 	VisitFunctionExpression(*FunctionExpression) Repr
 	VisitStringExpression(*StringExpression) Repr
+	VisitStringTemplateExpression(*StringTemplateExpression) Repr
 	VisitCastingExpression(*CastingExpression) Repr
 	VisitCreateExpression(*CreateExpression) Repr
 	VisitDestroyExpression(*DestroyExpression) Repr
+	VisitAttachmentExpression(*AttachmentExpression) Repr
 	VisitReferenceExpression(*ReferenceExpression) Repr
 	VisitForceExpression(*ForceExpression) Repr
 	VisitPathExpression(*PathExpression) Repr
 }
 
+// BaseExpressionVisitor is an ExpressionVisitor which returns nil for all expressions.
+// It can be embedded into a visitor which only needs to handle some kinds of expressions:
+// Only the Visit methods for those need to be implemented.
+type BaseExpressionVisitor struct{}
+
+var _ ExpressionVisitor = BaseExpressionVisitor{}
+
+func (BaseExpressionVisitor) VisitBoolExpression(_ *BoolExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitNilExpression(_ *NilExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitVoidExpression(_ *VoidExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitIntegerExpression(_ *IntegerExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitFixedPointExpression(_ *FixedPointExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitArrayExpression(_ *ArrayExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitDictionaryExpression(_ *DictionaryExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitIdentifierExpression(_ *IdentifierExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitInvocationExpression(_ *InvocationExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitMemberExpression(_ *MemberExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitIndexExpression(_ *IndexExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitConditionalExpression(_ *ConditionalExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitUnaryExpression(_ *UnaryExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitBinaryExpression(_ *BinaryExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitFunctionExpression(_ *FunctionExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitStringExpression(_ *StringExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitStringTemplateExpression(_ *StringTemplateExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitCastingExpression(_ *CastingExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitCreateExpression(_ *CreateExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitDestroyExpression(_ *DestroyExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitAttachmentExpression(_ *AttachmentExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitReferenceExpression(_ *ReferenceExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitForceExpression(_ *ForceExpression) Repr {
+	return nil
+}
+
+func (BaseExpressionVisitor) VisitPathExpression(_ *PathExpression) Repr {
+	return nil
+}
+
 type Visitor interface {
 	StatementVisitor
 	ExpressionVisitor
@@ -0,0 +1,57 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testIdentifierVisitor struct {
+	BaseExpressionVisitor
+}
+
+var _ ExpressionVisitor = testIdentifierVisitor{}
+
+func (testIdentifierVisitor) VisitIdentifierExpression(expression *IdentifierExpression) Repr {
+	return expression.Identifier.Identifier
+}
+
+func TestBaseExpressionVisitor(t *testing.T) {
+
+	t.Parallel()
+
+	visitor := testIdentifierVisitor{}
+
+	assert.Equal(t,
+		"x",
+		newTestIdentifierExpression("x").AcceptExp(visitor),
+	)
+
+	assert.Nil(t, (&BoolExpression{Value: true}).AcceptExp(visitor))
+	assert.Nil(t, (&NilExpression{}).AcceptExp(visitor))
+	assert.Nil(t,
+		(&BinaryExpression{
+			Operation: OperationPlus,
+			Left:      newTestIdentifierExpression("a"),
+			Right:     newTestIdentifierExpression("b"),
+		}).AcceptExp(visitor),
+	)
+}
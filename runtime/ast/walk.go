@@ -22,6 +22,19 @@ type Walker interface {
 	Walk(element Element) Walker
 }
 
+// TypeWalker is a walker which is also interested in
+// the types that elements refer to, e.g. the type of a casting expression
+type TypeWalker interface {
+	Walker
+	WalkType(ty Type)
+}
+
+// TypedElement is an element which refers to types
+type TypedElement interface {
+	Element
+	WalkTypes(walkType func(Type))
+}
+
 // Walk traverses an AST in depth-first order:
 // It starts by calling walker.Walk(element);
 // If the returned walker is nil,
@@ -33,11 +46,21 @@ type Walker interface {
 //
 // The initial walker may not be nil.
 //
+// If the returned walker is a TypeWalker and the element is a TypedElement,
+// then WalkType is invoked on the returned walker for each of the types
+// the element refers to, before the child elements are walked.
+//
 func Walk(walker Walker, element Element) {
 	if walker = walker.Walk(element); walker == nil {
 		return
 	}
 
+	if typeWalker, ok := walker.(TypeWalker); ok {
+		if typedElement, ok := element.(TypedElement); ok {
+			typedElement.WalkTypes(typeWalker.WalkType)
+		}
+	}
+
 	element.Walk(func(child Element) {
 		Walk(walker, child)
 	})
@@ -45,6 +68,80 @@ func Walk(walker Walker, element Element) {
 	walker.Walk(nil)
 }
 
+// WalkDepth traverses an AST in depth-first order, like Walk,
+// and calls visit for each element and its depth, starting with the root at depth 0.
+// Elements deeper than maxDepth are not visited.
+//
+// The traversal is recursive, but the recursion depth is bounded by maxDepth,
+// so WalkDepth may be used on untrusted, arbitrarily deeply nested trees.
+func WalkDepth(root Element, maxDepth int, visit func(element Element, depth int)) {
+	if maxDepth < 0 {
+		return
+	}
+
+	walkDepth(root, 0, maxDepth, visit)
+}
+
+// NOTE: Walk is not used, as it calls the walker with nil both for absent children,
+// e.g. a missing operand, and to signal that all children of an element have been walked,
+// so the depth cannot be tracked reliably
+func walkDepth(element Element, depth int, maxDepth int, visit func(element Element, depth int)) {
+	if element == nil {
+		return
+	}
+
+	visit(element, depth)
+
+	if depth >= maxDepth {
+		return
+	}
+
+	element.Walk(func(child Element) {
+		walkDepth(child, depth+1, maxDepth, visit)
+	})
+}
+
+// WalkIter traverses an AST in depth-first order, like Walk,
+// and calls visit for each element.
+//
+// Unlike Walk, the traversal is not recursive, but uses an explicit stack,
+// so WalkIter may be used on arbitrarily deeply nested trees
+// without overflowing the goroutine stack.
+func WalkIter(root Element, visit func(Element)) {
+	if root == nil {
+		return
+	}
+
+	stack := []Element{root}
+
+	var children []Element
+	collectChild := func(child Element) {
+		if child == nil {
+			return
+		}
+		children = append(children, child)
+	}
+
+	for len(stack) > 0 {
+		lastIndex := len(stack) - 1
+		element := stack[lastIndex]
+		stack[lastIndex] = nil
+		stack = stack[:lastIndex]
+
+		visit(element)
+
+		children = children[:0]
+		element.Walk(collectChild)
+
+		// Push the children in reverse order,
+		// so they are visited in order
+
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
+		}
+	}
+}
+
 func walkExpressions(walkChild func(Element), expressions []Expression) {
 	for _, expression := range expressions {
 		walkChild(expression)
@@ -0,0 +1,368 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTypeWalker struct {
+	types []Type
+}
+
+func (w *testTypeWalker) Walk(element Element) Walker {
+	if element == nil {
+		return nil
+	}
+	return w
+}
+
+func (w *testTypeWalker) WalkType(ty Type) {
+	w.types = append(w.types, ty)
+}
+
+func TestWalk_Types(t *testing.T) {
+
+	t.Parallel()
+
+	fooType := &NominalType{
+		Identifier: Identifier{
+			Identifier: "Foo",
+		},
+	}
+
+	barType := &ReferenceType{
+		Type: &NominalType{
+			Identifier: Identifier{
+				Identifier: "Bar",
+			},
+		},
+	}
+
+	expression := &BinaryExpression{
+		Operation: OperationNilCoalesce,
+		Left: &CastingExpression{
+			Operation:  OperationFailableCast,
+			Expression: newTestIdentifierExpression("a"),
+			TypeAnnotation: &TypeAnnotation{
+				Type: fooType,
+			},
+		},
+		Right: &ReferenceExpression{
+			Expression: newTestIdentifierExpression("b"),
+			Type:       barType,
+		},
+	}
+
+	walker := &testTypeWalker{}
+	Walk(walker, expression)
+
+	assert.Equal(t,
+		[]Type{
+			fooType,
+			barType,
+		},
+		walker.types,
+	)
+}
+
+type testOrderWalker struct {
+	visited []interface{}
+}
+
+func (w *testOrderWalker) Walk(element Element) Walker {
+	if element == nil {
+		return nil
+	}
+	w.visited = append(w.visited, element)
+	return w
+}
+
+func (w *testOrderWalker) WalkType(ty Type) {
+	w.visited = append(w.visited, ty)
+}
+
+func TestWalk_FunctionExpression(t *testing.T) {
+
+	t.Parallel()
+
+	aType := &NominalType{
+		Identifier: Identifier{
+			Identifier: "A",
+		},
+	}
+
+	bType := &OptionalType{
+		Type: &NominalType{
+			Identifier: Identifier{
+				Identifier: "B",
+			},
+		},
+	}
+
+	returnType := &NominalType{
+		Identifier: Identifier{
+			Identifier: "R",
+		},
+	}
+
+	block := &Block{}
+
+	functionBlock := &FunctionBlock{
+		Block: block,
+	}
+
+	expression := &FunctionExpression{
+		ParameterList: &ParameterList{
+			Parameters: []*Parameter{
+				{
+					Identifier: Identifier{
+						Identifier: "a",
+					},
+					TypeAnnotation: &TypeAnnotation{
+						Type: aType,
+					},
+				},
+				{
+					Identifier: Identifier{
+						Identifier: "b",
+					},
+					TypeAnnotation: &TypeAnnotation{
+						Type: bType,
+					},
+				},
+			},
+		},
+		ReturnTypeAnnotation: &TypeAnnotation{
+			Type: returnType,
+		},
+		FunctionBlock: functionBlock,
+	}
+
+	walker := &testOrderWalker{}
+	Walk(walker, expression)
+
+	assert.Equal(t,
+		[]interface{}{
+			expression,
+			aType,
+			bType,
+			returnType,
+			functionBlock,
+			block,
+		},
+		walker.visited,
+	)
+}
+
+func newTestNestedUnaryExpression(depth int) Expression {
+	var expression Expression = newTestIdentifierExpression("x")
+	for i := 0; i < depth; i++ {
+		expression = &UnaryExpression{
+			Operation:  OperationMinus,
+			Expression: expression,
+		}
+	}
+	return expression
+}
+
+func TestWalkDepth(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("limited", func(t *testing.T) {
+
+		t.Parallel()
+
+		const maxDepth = 10
+
+		expression := newTestNestedUnaryExpression(2000)
+
+		var depths []int
+		WalkDepth(expression, maxDepth, func(element Element, depth int) {
+			assert.IsType(t, &UnaryExpression{}, element)
+			depths = append(depths, depth)
+		})
+
+		assert.Equal(t,
+			[]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			depths,
+		)
+	})
+
+	t.Run("siblings", func(t *testing.T) {
+
+		t.Parallel()
+
+		// (a + -b)
+
+		expression := &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      newTestIdentifierExpression("a"),
+			Right: &UnaryExpression{
+				Operation:  OperationMinus,
+				Expression: newTestIdentifierExpression("b"),
+			},
+		}
+
+		type visit struct {
+			expression string
+			depth      int
+		}
+
+		var visits []visit
+		WalkDepth(expression, 1, func(element Element, depth int) {
+			visits = append(visits, visit{
+				expression: element.(Expression).String(),
+				depth:      depth,
+			})
+		})
+
+		assert.Equal(t,
+			[]visit{
+				{"a + -b", 0},
+				{"a", 1},
+				{"-b", 1},
+			},
+			visits,
+		)
+	})
+
+	t.Run("nil child", func(t *testing.T) {
+
+		t.Parallel()
+
+		// [a + _, -b], with a missing right operand
+
+		expression := &ArrayExpression{
+			Values: []Expression{
+				&BinaryExpression{
+					Operation: OperationPlus,
+					Left:      newTestIdentifierExpression("a"),
+				},
+				&UnaryExpression{
+					Operation:  OperationMinus,
+					Expression: newTestIdentifierExpression("b"),
+				},
+			},
+		}
+
+		var depths []int
+		WalkDepth(expression, 2, func(_ Element, depth int) {
+			depths = append(depths, depth)
+		})
+
+		assert.Equal(t,
+			[]int{0, 1, 2, 1, 2},
+			depths,
+		)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+
+		t.Parallel()
+
+		WalkDepth(newTestIdentifierExpression("x"), -1, func(_ Element, _ int) {
+			assert.Fail(t, "unexpected visit")
+		})
+	})
+}
+
+func TestWalkIter(t *testing.T) {
+
+	t.Parallel()
+
+	expression := newTestJSONExpression()
+
+	var expected []Element
+	Inspect(expression, func(element Element) bool {
+		if element != nil {
+			expected = append(expected, element)
+		}
+		return true
+	})
+
+	var actual []Element
+	WalkIter(expression, func(element Element) {
+		actual = append(actual, element)
+	})
+
+	assert.Equal(t, expected, actual)
+}
+
+func newTestDeepBinaryExpression(terms int) Expression {
+	var expression Expression = newTestIdentifierExpression("a")
+	for i := 1; i < terms; i++ {
+		expression = &BinaryExpression{
+			Operation: OperationPlus,
+			Left:      expression,
+			Right:     newTestIdentifierExpression("a"),
+		}
+	}
+	return expression
+}
+
+// NOTE: not parallel, as the maximum stack size is global
+func TestWalkIter_Deep(t *testing.T) {
+
+	// a + a + ... + a, with 100,000 terms.
+	// The recursive Walk overflows a stack of this size for such a tree
+
+	const terms = 100_000
+
+	expression := newTestDeepBinaryExpression(terms)
+
+	previousMaxStack := debug.SetMaxStack(1 << 20)
+	defer debug.SetMaxStack(previousMaxStack)
+
+	identifiers := 0
+	WalkIter(expression, func(element Element) {
+		if _, ok := element.(*IdentifierExpression); ok {
+			identifiers++
+		}
+	})
+
+	assert.Equal(t, terms, identifiers)
+}
+
+func BenchmarkWalk(b *testing.B) {
+
+	expression := newTestDeepBinaryExpression(1000)
+
+	b.Run("recursive", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			Inspect(expression, func(_ Element) bool {
+				return true
+			})
+		}
+	})
+
+	b.Run("iterative", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			WalkIter(expression, func(_ Element) {})
+		}
+	})
+}
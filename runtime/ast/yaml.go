@@ -0,0 +1,249 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// marshalYAML returns the YAML representation of the given value,
+// which has the same layout as its JSON representation:
+// Objects are mappings with the fields in the same order,
+// including the type discriminators, and arrays are sequences.
+//
+// Strings, e.g. the decimal strings of big integers, remain strings.
+func marshalYAML(value json.Marshaler) (interface{}, error) {
+	decoded, err := decodeJSONValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return newYAMLNode(decoded)
+}
+
+// newYAMLNode returns the YAML node equivalent to the given decoded JSON value,
+// see decodeJSONValue
+func newYAMLNode(value interface{}) (*yaml.Node, error) {
+	switch value := value.(type) {
+	case jsonObject:
+		node := &yaml.Node{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+		}
+		for _, field := range value {
+			fieldNode, err := newYAMLNode(field.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(
+				node.Content,
+				newYAMLScalarNode("!!str", field.Key),
+				fieldNode,
+			)
+		}
+		return node, nil
+
+	case []interface{}:
+		node := &yaml.Node{
+			Kind: yaml.SequenceNode,
+			Tag:  "!!seq",
+		}
+		for _, element := range value {
+			elementNode, err := newYAMLNode(element)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, elementNode)
+		}
+		return node, nil
+
+	case string:
+		return newYAMLScalarNode("!!str", value), nil
+
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return newYAMLScalarNode("!!int", value.String()), nil
+		}
+		return newYAMLScalarNode("!!float", value.String()), nil
+
+	case bool:
+		if value {
+			return newYAMLScalarNode("!!bool", "true"), nil
+		}
+		return newYAMLScalarNode("!!bool", "false"), nil
+
+	case nil:
+		return newYAMLScalarNode("!!null", "null"), nil
+
+	default:
+		return nil, fmt.Errorf("cannot marshal YAML: unexpected value %v", value)
+	}
+}
+
+func newYAMLScalarNode(tag string, value string) *yaml.Node {
+	return &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   tag,
+		Value: value,
+	}
+}
+
+// Expressions
+
+func (e *BoolExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *NilExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *VoidExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *StringExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *StringTemplateExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *IntegerExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *FixedPointExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *ArrayExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *DictionaryExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *IdentifierExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *InvocationExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *MemberExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *IndexExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *ConditionalExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *UnaryExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *BinaryExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *FunctionExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *CastingExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *CreateExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *DestroyExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *AttachmentExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *ReferenceExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *ForceExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+func (e *PathExpression) MarshalYAML() (interface{}, error) {
+	return marshalYAML(e)
+}
+
+// Types
+
+func (t *TypeAnnotation) MarshalYAML() (interface{}, error) {
+	return marshalYAML(t)
+}
+
+func (t *NominalType) MarshalYAML() (interface{}, error) {
+	return marshalYAML(t)
+}
+
+func (t *OptionalType) MarshalYAML() (interface{}, error) {
+	return marshalYAML(t)
+}
+
+func (t *VariableSizedType) MarshalYAML() (interface{}, error) {
+	return marshalYAML(t)
+}
+
+func (t *ConstantSizedType) MarshalYAML() (interface{}, error) {
+	return marshalYAML(t)
+}
+
+func (t *DictionaryType) MarshalYAML() (interface{}, error) {
+	return marshalYAML(t)
+}
+
+func (t *FunctionType) MarshalYAML() (interface{}, error) {
+	return marshalYAML(t)
+}
+
+func (t *ReferenceType) MarshalYAML() (interface{}, error) {
+	return marshalYAML(t)
+}
+
+func (t *RestrictedType) MarshalYAML() (interface{}, error) {
+	return marshalYAML(t)
+}
+
+func (t *InstantiationType) MarshalYAML() (interface{}, error) {
+	return marshalYAML(t)
+}
@@ -0,0 +1,235 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMarshalYAML(t *testing.T) {
+
+	t.Parallel()
+
+	position := func(offset int) Position {
+		return Position{Offset: offset, Line: 1, Column: offset}
+	}
+
+	// -0x2A < x as? [Int]
+
+	expression := &CastingExpression{
+		Operation: OperationFailableCast,
+		Expression: &BinaryExpression{
+			Operation: OperationLess,
+			Left: &UnaryExpression{
+				Operation: OperationMinus,
+				Expression: &IntegerExpression{
+					PositiveLiteral: "0x2A",
+					Value:           big.NewInt(42),
+					Base:            16,
+					Range:           Range{StartPos: position(1), EndPos: position(4)},
+				},
+				StartPos: position(0),
+			},
+			Right: &IdentifierExpression{
+				Identifier: Identifier{Identifier: "x", Pos: position(8)},
+			},
+		},
+		TypeAnnotation: &TypeAnnotation{
+			Type: &VariableSizedType{
+				Type: &NominalType{
+					Identifier: Identifier{Identifier: "Int", Pos: position(15)},
+				},
+				Range: Range{StartPos: position(14), EndPos: position(18)},
+			},
+			StartPos: position(14),
+		},
+	}
+
+	actual, err := yaml.Marshal(expression)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`Type: CastingExpression
+StartPos:
+    Offset: 0
+    Line: 1
+    Column: 0
+EndPos:
+    Offset: 18
+    Line: 1
+    Column: 18
+Expression:
+    Type: BinaryExpression
+    StartPos:
+        Offset: 0
+        Line: 1
+        Column: 0
+    EndPos:
+        Offset: 8
+        Line: 1
+        Column: 8
+    Operation: Less
+    Left:
+        Type: UnaryExpression
+        StartPos:
+            Offset: 0
+            Line: 1
+            Column: 0
+        EndPos:
+            Offset: 4
+            Line: 1
+            Column: 4
+        Operation: Minus
+        Expression:
+            Type: IntegerExpression
+            Value: "42"
+            PositiveLiteral: "0x2A"
+            Base: 16
+            StartPos:
+                Offset: 1
+                Line: 1
+                Column: 1
+            EndPos:
+                Offset: 4
+                Line: 1
+                Column: 4
+    Right:
+        Type: IdentifierExpression
+        Identifier:
+            Identifier: x
+            StartPos:
+                Offset: 8
+                Line: 1
+                Column: 8
+            EndPos:
+                Offset: 8
+                Line: 1
+                Column: 8
+        StartPos:
+            Offset: 8
+            Line: 1
+            Column: 8
+        EndPos:
+            Offset: 8
+            Line: 1
+            Column: 8
+Operation: FailableCast
+TypeAnnotation:
+    StartPos:
+        Offset: 14
+        Line: 1
+        Column: 14
+    EndPos:
+        Offset: 18
+        Line: 1
+        Column: 18
+    IsResource: false
+    AnnotatedType:
+        Type: VariableSizedType
+        ElementType:
+            Type: NominalType
+            StartPos:
+                Offset: 15
+                Line: 1
+                Column: 15
+            EndPos:
+                Offset: 17
+                Line: 1
+                Column: 17
+            Identifier:
+                Identifier: Int
+                StartPos:
+                    Offset: 15
+                    Line: 1
+                    Column: 15
+                EndPos:
+                    Offset: 17
+                    Line: 1
+                    Column: 17
+        StartPos:
+            Offset: 14
+            Line: 1
+            Column: 14
+        EndPos:
+            Offset: 18
+            Line: 1
+            Column: 18
+`,
+		string(actual),
+	)
+}
+
+func TestMarshalYAML_Nested(t *testing.T) {
+
+	t.Parallel()
+
+	// Nodes are also marshaled when they are nested in other values,
+	// e.g. when the static type of the value is the interface
+
+	value := map[string]interface{}{
+		"expression": Expression(&BoolExpression{Value: true}),
+		"type": Type(&NominalType{
+			Identifier: Identifier{Identifier: "Int"},
+		}),
+	}
+
+	actual, err := yaml.Marshal(value)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`expression:
+    Type: BoolExpression
+    Value: true
+    StartPos:
+        Offset: 0
+        Line: 0
+        Column: 0
+    EndPos:
+        Offset: 0
+        Line: 0
+        Column: 0
+type:
+    Type: NominalType
+    StartPos:
+        Offset: 0
+        Line: 0
+        Column: 0
+    EndPos:
+        Offset: 2
+        Line: 0
+        Column: 2
+    Identifier:
+        Identifier: Int
+        StartPos:
+            Offset: 0
+            Line: 0
+            Column: 0
+        EndPos:
+            Offset: 2
+            Line: 0
+            Column: 2
+`,
+		string(actual),
+	)
+}
@@ -141,6 +141,11 @@ func (compiler *Compiler) VisitNilExpression(_ *ast.NilExpression) ast.Repr {
 	panic(errors.NewUnreachableError())
 }
 
+func (compiler *Compiler) VisitVoidExpression(_ *ast.VoidExpression) ast.Repr {
+	// TODO
+	panic(errors.NewUnreachableError())
+}
+
 func (compiler *Compiler) VisitIntegerExpression(expression *ast.IntegerExpression) ast.Repr {
 	var value []byte
 
@@ -235,6 +240,11 @@ func (compiler *Compiler) VisitStringExpression(e *ast.StringExpression) ast.Rep
 	}
 }
 
+func (compiler *Compiler) VisitStringTemplateExpression(_ *ast.StringTemplateExpression) ast.Repr {
+	// TODO
+	panic(errors.NewUnreachableError())
+}
+
 func (compiler *Compiler) VisitCastingExpression(_ *ast.CastingExpression) ast.Repr {
 	// TODO
 	panic(errors.NewUnreachableError())
@@ -250,6 +260,11 @@ func (compiler *Compiler) VisitDestroyExpression(_ *ast.DestroyExpression) ast.R
 	panic(errors.NewUnreachableError())
 }
 
+func (compiler *Compiler) VisitAttachmentExpression(_ *ast.AttachmentExpression) ast.Repr {
+	// TODO
+	panic(errors.NewUnreachableError())
+}
+
 func (compiler *Compiler) VisitReferenceExpression(_ *ast.ReferenceExpression) ast.Repr {
 	// TODO
 	panic(errors.NewUnreachableError())
@@ -339,6 +339,10 @@ func (interpreter *Interpreter) VisitNilExpression(_ *ast.NilExpression) ast.Rep
 	return NilValue{}
 }
 
+func (interpreter *Interpreter) VisitVoidExpression(_ *ast.VoidExpression) ast.Repr {
+	return VoidValue{}
+}
+
 func (interpreter *Interpreter) VisitIntegerExpression(expression *ast.IntegerExpression) ast.Repr {
 	typ := interpreter.Program.Elaboration.IntegerExpressionType[expression]
 
@@ -439,6 +443,11 @@ func (interpreter *Interpreter) VisitStringExpression(expression *ast.StringExpr
 	return NewStringValue(expression.Value)
 }
 
+func (interpreter *Interpreter) VisitStringTemplateExpression(_ *ast.StringTemplateExpression) ast.Repr {
+	// NOTE: the checker rejects string template expressions
+	panic(errors.NewUnreachableError())
+}
+
 func (interpreter *Interpreter) VisitArrayExpression(expression *ast.ArrayExpression) ast.Repr {
 	values := interpreter.visitExpressionsNonCopying(expression.Values)
 
@@ -728,6 +737,11 @@ func (interpreter *Interpreter) VisitDestroyExpression(expression *ast.DestroyEx
 	return VoidValue{}
 }
 
+func (interpreter *Interpreter) VisitAttachmentExpression(_ *ast.AttachmentExpression) ast.Repr {
+	// NOTE: the checker rejects attachment expressions
+	panic(errors.NewUnreachableError())
+}
+
 func (interpreter *Interpreter) VisitReferenceExpression(referenceExpression *ast.ReferenceExpression) ast.Repr {
 
 	borrowType := interpreter.Program.Elaboration.ReferenceExpressionBorrowTypes[referenceExpression]
@@ -51,6 +51,10 @@ func (d *CheckCastVisitor) VisitNilExpression(_ *ast.NilExpression) ast.Repr {
 	return d.isTypeRedundant(NilType, d.targetType)
 }
 
+func (d *CheckCastVisitor) VisitVoidExpression(_ *ast.VoidExpression) ast.Repr {
+	return d.isTypeRedundant(VoidType, d.targetType)
+}
+
 func (d *CheckCastVisitor) VisitIntegerExpression(_ *ast.IntegerExpression) ast.Repr {
 	// For integer expressions, default inferred type is `Int`.
 	// So, if the target type is not `Int`, then the cast is not redundant.
@@ -169,6 +173,10 @@ func (d *CheckCastVisitor) VisitStringExpression(_ *ast.StringExpression) ast.Re
 	return d.isTypeRedundant(StringType, d.targetType)
 }
 
+func (d *CheckCastVisitor) VisitStringTemplateExpression(_ *ast.StringTemplateExpression) ast.Repr {
+	return d.isTypeRedundant(StringType, d.targetType)
+}
+
 func (d *CheckCastVisitor) VisitCastingExpression(_ *ast.CastingExpression) ast.Repr {
 	// This is already covered under Case-I: where expected type is same as casted type.
 	// So skip checking it here to avid duplicate errors.
@@ -183,6 +191,10 @@ func (d *CheckCastVisitor) VisitDestroyExpression(_ *ast.DestroyExpression) ast.
 	return d.isTypeRedundant(d.exprInferredType, d.targetType)
 }
 
+func (d *CheckCastVisitor) VisitAttachmentExpression(_ *ast.AttachmentExpression) ast.Repr {
+	return d.isTypeRedundant(d.exprInferredType, d.targetType)
+}
+
 func (d *CheckCastVisitor) VisitReferenceExpression(_ *ast.ReferenceExpression) ast.Repr {
 	return d.isTypeRedundant(d.exprInferredType, d.targetType)
 }
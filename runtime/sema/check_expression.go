@@ -163,6 +163,10 @@ func (checker *Checker) VisitNilExpression(_ *ast.NilExpression) ast.Repr {
 	return NilType
 }
 
+func (checker *Checker) VisitVoidExpression(_ *ast.VoidExpression) ast.Repr {
+	return VoidType
+}
+
 func (checker *Checker) VisitIntegerExpression(expression *ast.IntegerExpression) ast.Repr {
 	expectedType := UnwrapOptionalType(checker.expectedType)
 
@@ -226,6 +230,21 @@ func (checker *Checker) VisitStringExpression(expression *ast.StringExpression)
 	return StringType
 }
 
+func (checker *Checker) VisitStringTemplateExpression(expression *ast.StringTemplateExpression) ast.Repr {
+
+	// String templates are not supported yet.
+	// The expression can be represented, e.g. for tooling,
+	// but it cannot be checked, so report an error
+
+	checker.report(
+		&UnsupportedStringTemplateExpressionError{
+			Range: ast.NewRangeFromPositioned(expression),
+		},
+	)
+
+	return InvalidType
+}
+
 func (checker *Checker) VisitIndexExpression(expression *ast.IndexExpression) ast.Repr {
 	return checker.visitIndexExpression(expression, false)
 }
@@ -1293,6 +1293,30 @@ func (e *UnsupportedOptionalChainingAssignmentError) Error() string {
 
 func (*UnsupportedOptionalChainingAssignmentError) isSemanticError() {}
 
+// UnsupportedStringTemplateExpressionError
+
+type UnsupportedStringTemplateExpressionError struct {
+	ast.Range
+}
+
+func (e *UnsupportedStringTemplateExpressionError) Error() string {
+	return "string templates are not supported"
+}
+
+func (*UnsupportedStringTemplateExpressionError) isSemanticError() {}
+
+// UnsupportedAttachmentExpressionError
+
+type UnsupportedAttachmentExpressionError struct {
+	ast.Range
+}
+
+func (e *UnsupportedAttachmentExpressionError) Error() string {
+	return "attachments are not supported"
+}
+
+func (*UnsupportedAttachmentExpressionError) isSemanticError() {}
+
 // MissingResourceAnnotationError
 
 type MissingResourceAnnotationError struct {
@@ -37,10 +37,15 @@ type ReplacementHint struct {
 	ast.Range
 }
 
+// Hint returns the description of the hint.
+//
+// The replacement is inserted in place of the replaced expression,
+// so its canonical string representation is used, which is fully parenthesized,
+// e.g. `(1 as UInt8)`, and can be inserted in any surrounding expression.
 func (h *ReplacementHint) Hint() string {
 	return fmt.Sprintf(
 		"consider replacing with: `%s`",
-		h.Expression,
+		h.Expression.CanonicalString(),
 	)
 }
 
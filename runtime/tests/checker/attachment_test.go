@@ -0,0 +1,73 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package checker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+func TestCheckAttachment(t *testing.T) {
+
+	t.Parallel()
+
+	// Attachment expressions are not produced by the parser,
+	// and are rejected by the checker
+
+	attachmentExpression := &ast.AttachmentExpression{
+		Base: &ast.IdentifierExpression{
+			Identifier: ast.Identifier{
+				Identifier: "y",
+				Pos:        ast.Position{Offset: 40, Line: 3, Column: 20},
+			},
+		},
+		Attachment: &ast.InvocationExpression{
+			InvokedExpression: &ast.IdentifierExpression{
+				Identifier: ast.Identifier{
+					Identifier: "A",
+					Pos:        ast.Position{Offset: 30, Line: 3, Column: 10},
+				},
+			},
+			EndPos: ast.Position{Offset: 32, Line: 3, Column: 12},
+		},
+		StartPos: ast.Position{Offset: 23, Line: 3, Column: 3},
+	}
+
+	_, err := checkWithReplacedValue(t,
+		`
+          let y = 1
+          let x = 2
+        `,
+		attachmentExpression,
+	)
+
+	errs := ExpectCheckerErrors(t, err, 1)
+
+	require.IsType(t, &sema.UnsupportedAttachmentExpressionError{}, errs[0])
+
+	assert.Equal(t,
+		ast.NewRangeFromPositioned(attachmentExpression),
+		errs[0].(*sema.UnsupportedAttachmentExpressionError).Range,
+	)
+}
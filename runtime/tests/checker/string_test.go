@@ -24,7 +24,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/parser2"
 	"github.com/onflow/cadence/runtime/sema"
+	. "github.com/onflow/cadence/runtime/tests/utils"
 )
 
 func TestCheckCharacter(t *testing.T) {
@@ -324,3 +327,58 @@ func TestCheckStringToLower(t *testing.T) {
 		RequireGlobalValue(t, checker.Elaboration, "x"),
 	)
 }
+
+// checkWithReplacedValue parses the given program, which must declare a global variable `x`,
+// replaces the value of the variable with the given expression, and checks the program.
+//
+// This allows checking expressions which the parser does not produce.
+func checkWithReplacedValue(t *testing.T, code string, value ast.Expression) (*sema.Checker, error) {
+	program, err := parser2.ParseProgram(code)
+	require.NoError(t, err)
+
+	var replaced bool
+	for _, declaration := range program.VariableDeclarations() {
+		if declaration.Identifier.Identifier == "x" {
+			declaration.Value = value
+			replaced = true
+		}
+	}
+	require.True(t, replaced)
+
+	checker, err := sema.NewChecker(
+		program,
+		TestLocation,
+		sema.WithAccessCheckMode(sema.AccessCheckModeNotSpecifiedUnrestricted),
+	)
+	require.NoError(t, err)
+
+	err = checker.Check()
+	return checker, err
+}
+
+func TestCheckStringTemplate(t *testing.T) {
+
+	t.Parallel()
+
+	// String templates are not produced by the parser,
+	// and are rejected by the checker
+
+	_, err := checkWithReplacedValue(t,
+		`
+          let y = "world"
+          let x = ""
+        `,
+		&ast.StringTemplateExpression{
+			Segments: []string{"hello ", ""},
+			Values: []ast.Expression{
+				&ast.IdentifierExpression{
+					Identifier: ast.Identifier{Identifier: "y"},
+				},
+			},
+		},
+	)
+
+	errs := ExpectCheckerErrors(t, err, 1)
+
+	require.IsType(t, &sema.UnsupportedStringTemplateExpressionError{}, errs[0])
+}